@@ -30,13 +30,14 @@ const (
 //	       gin.Context
 //	       fiber.Ctx.Context()
 //	       ...
+//
+// Deprecated: the output is not stored in ctx (ctx can't be returned) so
+// DisableOutput, GetOutput and PrintOutput don't find it, and output enabled on
+// *sql.DB may be read from another session of the pool. use NewOutput of
+// github.com/sijms/go-ora/v3/dbms that bind the output to *sql.Conn
 func EnableOutput(ctx context.Context, conn *sql.DB) error {
-	out, err := NewOutput(conn, MaxBufferSize)
-	if err != nil {
-		return err
-	}
-	context.WithValue(ctx, KeyInContext, out)
-	return nil
+	_, err := NewOutput(conn, MaxBufferSize)
+	return err
 }
 
 // disable oracle output for current session
@@ -1,12 +1,14 @@
 package TestIssues
 
 import (
-	"github.com/sijms/go-ora/dbms"
+	"context"
+	"strings"
 	"testing"
+
+	"github.com/sijms/go-ora/v3/dbms"
 )
 
 func TestDBMS_OUTPUT(t *testing.T) {
-
 	db, err := getDB()
 	if err != nil {
 		t.Error(err)
@@ -18,46 +20,110 @@ func TestDBMS_OUTPUT(t *testing.T) {
 			t.Error(err)
 		}
 	}()
-	output, err := dbms.NewOutput(db, 0x7FFF)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 	defer func() {
-		err = output.Close()
+		err = conn.Close()
 		if err != nil {
 			t.Error(err)
 		}
 	}()
-	err = execCmd(db, `BEGIN
-DBMS_OUTPUT.PUT_LINE('this is a test');
-END;`)
+	var sink strings.Builder
+	output, err := dbms.NewOutput(ctx, conn, dbms.WithWriter(&sink))
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	line, err := output.GetOutput()
+	defer func() {
+		err = output.Close(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	_, err = output.ExecContext(ctx, `BEGIN
+DBMS_OUTPUT.PUT_LINE('this is a test');
+END;`)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	if line != "this is a test\n" {
-		t.Errorf("expected: %s and got: %s", "this is a test", line)
+	if sink.String() != "this is a test\n" {
+		t.Errorf("expected: %s and got: %s", "this is a test", sink.String())
 	}
 
-	err = execCmd(db, `BEGIN
+	// lines produced outside ExecContext are read by the iterator
+	_, err = conn.ExecContext(ctx, `BEGIN
 DBMS_OUTPUT.PUT_LINE('this is a test2');
+DBMS_OUTPUT.PUT_LINE('this is a test3');
 END;`)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	line, err = output.GetOutput()
+	var lines []string
+	for line, err := range output.Lines(ctx) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		lines = append(lines, line)
+	}
+	if strings.Join(lines, ",") != "this is a test2,this is a test3" {
+		t.Errorf("unexpected lines: %v", lines)
+	}
+}
+
+func TestDBMS_OUTPUTPiggyback(t *testing.T) {
+	db, err := getDB()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer func() {
+		err = db.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer func() {
+		err = conn.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	ctx, err = dbms.EnableOutput(ctx, conn, dbms.WithPiggyback())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer func() {
+		err = dbms.DisableOutput(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	output, _ := dbms.FromContext(ctx)
+	_, err = output.ExecContext(ctx, `BEGIN DBMS_OUTPUT.PUT_LINE('value: ' || :1); END;`, 5)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	text, err := dbms.GetOutput(ctx)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	if line != "this is a test2\n" {
-		t.Errorf("expected: %s and got: %s", "this is a test2", line)
+	if text != "value: 5\n" {
+		t.Errorf("expected: %s and got: %s", "value: 5", text)
 	}
 }
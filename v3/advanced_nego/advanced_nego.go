@@ -28,6 +28,8 @@ type AdvNego struct {
 	version      uint32
 	authKerberos bool
 	authNTS      bool
+	authRadius   bool
+	tcps         bool
 	comm         *AdvancedNegoComm
 	clientInfo   *configurations.ClientInfo
 	negoInfo     *configurations.AdvNegoServiceInfo
@@ -49,6 +51,7 @@ func NewAdvNego(session *network.Session, tracer trace.Tracer, config *configura
 		negoInfo:    &config.AdvNegoServiceInfo,
 		tracer:      tracer,
 		serviceList: make([]AdvNegoService, 5),
+		tcps:        config.SSL,
 	}
 	var err error
 	output.serviceList[1], err = newAuthService(output, output.negoInfo)
@@ -141,6 +144,26 @@ func (nego *AdvNego) IsKerberosAuth() bool {
 	return nego.authKerberos
 }
 
+// EncryptionAlgorithm return name of negotiated encryption algorithm or empty string if not active
+func (nego *AdvNego) EncryptionAlgorithm() string {
+	if serv, ok := nego.serviceList[2].(*encryptService); ok && serv.algoID != 0 {
+		return serv.algorithmName(serv.algoID)
+	}
+	return ""
+}
+
+// ChecksumAlgorithm return name of negotiated data integrity algorithm or empty string if not active
+func (nego *AdvNego) ChecksumAlgorithm() string {
+	if serv, ok := nego.serviceList[3].(*dataIntegrityService); ok && serv.algoID != 0 {
+		return serv.algorithmName(serv.algoID)
+	}
+	return ""
+}
+
+func (nego *AdvNego) IsRadiusAuth() bool {
+	return nego.authRadius
+}
+
 func (nego *AdvNego) Read() error {
 	_, version, count, err := nego.readHeader()
 	if err != nil {
@@ -163,6 +186,7 @@ func (nego *AdvNego) Read() error {
 	}
 	nego.authKerberos = false
 	nego.authNTS = false
+	nego.authRadius = false
 	if authServ, ok := nego.serviceList[1].(*authService); ok {
 		if authServ.active {
 			if authServ.serviceName == "KERBEROS5" {
@@ -170,6 +194,8 @@ func (nego *AdvNego) Read() error {
 				nego.authKerberos = true
 			} else if authServ.serviceName == "NTS" {
 				nego.authNTS = true
+			} else if authServ.serviceName == "RADIUS" {
+				nego.authRadius = true
 			}
 		}
 	}
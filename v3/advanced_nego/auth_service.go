@@ -24,8 +24,8 @@ func newAuthService(ano *AdvNego, negoInfo *configurations.AdvNegoServiceInfo) (
 		status: 0xFCFF,
 	}
 	// var avaAuth []string
-	output.availableServiceNames = []string{"", "NTS", "KERBEROS5", "TCPS"}
-	output.availableServiceIDs = []int{0, 1, 1, 2}
+	output.availableServiceNames = []string{"", "NTS", "KERBEROS5", "TCPS", "RADIUS"}
+	output.availableServiceIDs = []int{0, 1, 1, 2, 1}
 	//if runtime.GOOS == "windows" {
 	//
 	//} else {
@@ -35,7 +35,7 @@ func newDataIntegrityService(ano *AdvNego, negoInfo *configurations.AdvNegoServi
 		},
 		tracer: tracer,
 	}
-	err := output.buildServiceList([]string{}, true, true)
+	err := output.buildServiceList(append([]string{}, negoInfo.ChecksumTypes...), true, true)
 	// output.selectedServ, err = output.validate(strings.Split(str,","), true)
 	if err != nil {
 		return nil, err
@@ -150,6 +150,9 @@ func (serv *dataIntegrityService) activateAlgorithm() error {
 	// return errors.New(fmt.Sprintf("advanced negotiation error: data integrity service algorithm: %d still not supported", serv.algoID))
 	var algo security.OracleNetworkDataIntegrity = nil
 	var err error
+	if serv.algoID != 0 && len(serv.ano.negoInfo.ChecksumTypes) > 0 && !serv.isOffered(serv.algoID) {
+		return fmt.Errorf("advanced negotiation error: server selected checksum algorithm %s which is not in CRYPTO CHECKSUM TYPES", serv.algorithmName(serv.algoID))
+	}
 	switch serv.algoID {
 	case 0:
 		algo = nil
@@ -40,6 +40,26 @@ func (serv *defaultService) activateAlgorithm() error {
 	return nil
 }
 
+// algorithmName return name of the algorithm id or empty string for id 0
+func (serv *defaultService) algorithmName(algoID int) string {
+	for i, id := range serv.availableServiceIDs {
+		if id == algoID {
+			return serv.availableServiceNames[i]
+		}
+	}
+	return fmt.Sprintf("%d", algoID)
+}
+
+// isOffered return true if the algorithm id is one of algorithms sent to the server
+func (serv *defaultService) isOffered(algoID int) bool {
+	for _, index := range serv.selectedIndices {
+		if serv.availableServiceIDs[index] == algoID {
+			return true
+		}
+	}
+	return false
+}
+
 //	func (serv *defaultService) writePacketHeader(session *network.Session, length, _type int) {
 //		// the driver call Anocommunication.ValidateType(length, type);
 //		session.PutInt(length, 2, true, false)
@@ -27,7 +27,12 @@ func newEncryptService(ano *AdvNego, negoInfo *configurations.AdvNegoServiceInfo
 			availableServiceIDs: []int{0, 1, 8, 10, 6, 3, 2, 11, 12, 15, 16, 17},
 		},
 	}
-	err := output.buildServiceList([]string{"RC4_40", "RC4_56", "RC4_128", "RC4_256", "DES56C", "AES128", "AES192", "AES256"}, true, true)
+	// 3DES is offered only when listed in ENCRYPTION TYPES
+	algorithms := []string{"RC4_40", "RC4_56", "RC4_128", "RC4_256", "DES56C", "AES128", "AES192", "AES256"}
+	if len(negoInfo.EncryptionTypes) > 0 {
+		algorithms = append([]string{}, negoInfo.EncryptionTypes...)
+	}
+	err := output.buildServiceList(algorithms, true, true)
 	// output.selectedServ, err = output.validate(strings.Split(str,","), true)
 	if err != nil {
 		return nil, err
@@ -36,7 +41,7 @@ func newEncryptService(ano *AdvNego, negoInfo *configurations.AdvNegoServiceInfo
 }
 func (serv *encryptService) isNewVersion() bool {
 	switch serv.algoID {
-	case 1, 2, 3, 6, 8, 10:
+	case 1, 2, 3, 6, 8, 10, 11, 12:
 		return false
 	default:
 		return serv.defaultService.isNewVersion()
@@ -87,6 +92,9 @@ func (serv *encryptService) activateAlgorithm() error {
 	// iv := make([]byte, 16)
 	var algo security.OracleNetworkEncryption = nil
 	var err error
+	if serv.algoID != 0 && !serv.isOffered(serv.algoID) {
+		return fmt.Errorf("advanced negotiation error: server selected encryption algorithm %s which is not offered", serv.algorithmName(serv.algoID))
+	}
 	switch serv.algoID {
 	case 0:
 		return nil
@@ -100,6 +108,10 @@ func (serv *encryptService) activateAlgorithm() error {
 		algo, err = security.NewOracleNetworkRC4Cryptor(key, iv, 56)
 	case 10:
 		algo, err = security.NewOracleNetworkRC4Cryptor(key, iv, 128)
+	case 11:
+		algo, err = security.NewOracleNetwork3DESCryptor(key[:16], iv)
+	case 12:
+		algo, err = security.NewOracleNetwork3DESCryptor(key[:24], iv)
 	case 15:
 		algo, err = security.NewOracleNetworkCBCEncrypter(key[:16], iv)
 	case 16:
@@ -0,0 +1,106 @@
+package advanced_nego
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/sijms/go-ora/v3/configurations"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/trace"
+)
+
+func newTestNego(t *testing.T, info configurations.AdvNegoServiceInfo) *AdvNego {
+	config := &configurations.ConnectionConfig{AdvNegoServiceInfo: info}
+	nego, err := NewAdvNego(network.NewSession(config, trace.NilTracer()), trace.NilTracer(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nego.sessionKey = make([]byte, 64)
+	_, _ = rand.Read(nego.sessionKey)
+	nego.iv = make([]byte, 0x20)
+	copy(nego.iv, nego.sessionKey[0x20:])
+	nego.oldIV = make([]byte, 16)
+	_, _ = rand.Read(nego.oldIV)
+	return nego
+}
+
+func TestTripleDES(t *testing.T) {
+	for _, algoID := range []int{11, 12} {
+		nego := newTestNego(t, configurations.AdvNegoServiceInfo{})
+		serv := nego.serviceList[2].(*encryptService)
+		if serv.isOffered(algoID) {
+			t.Errorf("algorithm %s should not be offered by default", serv.algorithmName(algoID))
+		}
+		serv.algoID = algoID
+		if err := serv.activateAlgorithm(); err == nil {
+			t.Errorf("expected error for algorithm %s which is not offered", serv.algorithmName(algoID))
+		}
+		nego = newTestNego(t, configurations.AdvNegoServiceInfo{EncryptionTypes: []string{"3DES112", "3DES168"}})
+		serv = nego.serviceList[2].(*encryptService)
+		if !serv.isOffered(algoID) {
+			t.Errorf("algorithm %s should be offered when listed", serv.algorithmName(algoID))
+		}
+		serv.algoID = algoID
+		if err := serv.activateAlgorithm(); err != nil {
+			t.Fatal(err)
+		}
+		if nego.EncryptionAlgorithm() != serv.availableServiceNames[7+algoID-11] {
+			t.Errorf("unexpected algorithm name: %s", nego.EncryptionAlgorithm())
+		}
+		for _, size := range []int{0, 1, 8, 13, 100} {
+			plain := make([]byte, size)
+			_, _ = rand.Read(plain)
+			data, err := nego.cryptAlgo.Encrypt(append([]byte{}, plain...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (len(data)-1)%8 != 0 {
+				t.Errorf("%s: cipher text isn't multiple of 8 bytes: %d", nego.EncryptionAlgorithm(), len(data))
+			}
+			output, err := nego.cryptAlgo.Decrypt(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(output, plain) {
+				t.Errorf("%s: got %x, want %x", nego.EncryptionAlgorithm(), output, plain)
+			}
+		}
+	}
+}
+
+func TestAlgorithmPolicy(t *testing.T) {
+	nego := newTestNego(t, configurations.AdvNegoServiceInfo{
+		EncryptionTypes: []string{"AES256", "AES192"},
+		ChecksumTypes:   []string{"SHA256"},
+	})
+	encServ := nego.serviceList[2].(*encryptService)
+	for _, algoID := range []int{1, 2, 10, 11, 15} {
+		if encServ.isOffered(algoID) {
+			t.Errorf("algorithm %s should not be offered", encServ.algorithmName(algoID))
+		}
+	}
+	encServ.algoID = 10
+	err := encServ.activateAlgorithm()
+	if err == nil || !strings.Contains(err.Error(), "RC4_128") {
+		t.Errorf("expected policy error for RC4_128, got: %v", err)
+	}
+	encServ.algoID = 17
+	if err = encServ.activateAlgorithm(); err != nil {
+		t.Fatal(err)
+	}
+	intServ := nego.serviceList[3].(*dataIntegrityService)
+	intServ.algoID = 1
+	err = intServ.activateAlgorithm()
+	if err == nil || !strings.Contains(err.Error(), "MD5") {
+		t.Errorf("expected policy error for MD5, got: %v", err)
+	}
+	intServ.algoID = 5
+	if err = intServ.activateAlgorithm(); err != nil {
+		t.Fatal(err)
+	}
+	if nego.EncryptionAlgorithm() != "AES256" || nego.ChecksumAlgorithm() != "SHA256" {
+		t.Errorf("unexpected negotiated algorithms: %s, %s", nego.EncryptionAlgorithm(), nego.ChecksumAlgorithm())
+	}
+}
@@ -0,0 +1,109 @@
+package advanced_nego
+
+import (
+	"errors"
+	"fmt"
+)
+
+// status values of RADIUS server reply
+const (
+	radiusAccept    = 0xFAFF
+	radiusReject    = 0xFBFF
+	radiusChallenge = 0xFCFF
+)
+
+// maxRadiusChallenges limit number of access challenges in one login
+const maxRadiusChallenges = 10
+
+// RadiusHandshake send RADIUS credentials to the server and answer access
+// challenges with RadiusChallenge handler. it should be called after
+// StartServices and activation of data packet encryption because the password
+// is sent inside ANO packet.
+// experimental: the packet layout is not verified against a captured login
+func (nego *AdvNego) RadiusHandshake() error {
+	if nego.cryptAlgo == nil && !nego.tcps {
+		return errors.New("advanced negotiation error: RADIUS authentication requires network encryption or TCPS to protect the password")
+	}
+	authServ, ok := nego.serviceList[1].(*authService)
+	if !ok {
+		return errors.New("advanced negotiation error: authentication service is not available")
+	}
+	mode := 0
+	if nego.negoInfo.RadiusChallenge != nil {
+		mode = 1
+	}
+	user := nego.negoInfo.RadiusUser
+	password := []byte(nego.negoInfo.RadiusPassword)
+	nego.tracer.Print("RADIUS Authentication (experimental)")
+	nego.comm.session.ResetBuffer()
+	nego.writeHeader(13+8+8+8+4+len(user)+4+len(password), 1)
+	authServ.writeHeader(4)
+	nego.comm.writeVersion(authServ.getVersion())
+	nego.comm.writeUB4(mode)
+	nego.comm.writeString(user)
+	nego.comm.writeBytes(password)
+	err := nego.comm.session.Write()
+	if err != nil {
+		return err
+	}
+	for round := 0; ; round++ {
+		status, prompt, state, err := nego.readRadiusReply()
+		if err != nil {
+			return err
+		}
+		switch status {
+		case radiusAccept:
+			return nil
+		case radiusReject:
+			return errors.New("advanced negotiation error: RADIUS authentication rejected")
+		case radiusChallenge:
+			if nego.negoInfo.RadiusChallenge == nil {
+				return errors.New("advanced negotiation error: RADIUS challenge received but challenge handler not set; call WithRadiusChallenge to set it")
+			}
+			if round >= maxRadiusChallenges {
+				return fmt.Errorf("advanced negotiation error: RADIUS challenge exceeds %d rounds", maxRadiusChallenges)
+			}
+			response, err := nego.negoInfo.RadiusChallenge.Respond(prompt)
+			if err != nil {
+				return err
+			}
+			nego.comm.session.ResetBuffer()
+			nego.writeHeader(13+8+6+4+len(response)+4+len(state), 1)
+			authServ.writeHeader(3)
+			nego.comm.writeStatus(radiusChallenge)
+			nego.comm.writeString(response)
+			nego.comm.writeBytes(state)
+			err = nego.comm.session.Write()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("advanced negotiation error: unexpected RADIUS status: %X", status)
+		}
+	}
+}
+
+// readRadiusReply read server status. challenge status is followed by prompt
+// message and state that should be returned with the response
+func (nego *AdvNego) readRadiusReply() (status int, prompt string, state []byte, err error) {
+	_, _, count, err := nego.readHeader()
+	if err != nil {
+		return
+	}
+	for i := 0; i < count; i++ {
+		_, _, err = nego.readServiceHeader()
+		if err != nil {
+			return
+		}
+	}
+	status, err = nego.comm.readStatus()
+	if err != nil || status != radiusChallenge {
+		return
+	}
+	prompt, err = nego.comm.readString()
+	if err != nil {
+		return
+	}
+	state, err = nego.comm.readBytes()
+	return
+}
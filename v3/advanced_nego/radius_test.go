@@ -0,0 +1,207 @@
+package advanced_nego
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sijms/go-ora/v3/configurations"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/trace"
+)
+
+// radiusResponder is a local RADIUS peer that speaks ANO over TNS data packets.
+// when otp is set it reply with access challenge and accept only the otp response
+type radiusResponder struct {
+	user     string
+	password string
+	otp      string
+}
+
+type anoPacket struct {
+	_type int
+	data  []byte
+}
+
+func (resp *radiusResponder) readPackets(conn net.Conn) ([]anoPacket, error) {
+	head := make([]byte, 10)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return nil, err
+	}
+	if head[4] != 6 {
+		return nil, errors.New("not data packet")
+	}
+	body := make([]byte, int(binary.BigEndian.Uint16(head))-10)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, err
+	}
+	if len(body) < 21 || binary.BigEndian.Uint32(body) != 0xDEADBEEF {
+		return nil, errors.New("invalid ano header")
+	}
+	count := int(binary.BigEndian.Uint16(body[15:]))
+	body = body[21:]
+	output := make([]anoPacket, 0, count)
+	for i := 0; i < count; i++ {
+		length := int(binary.BigEndian.Uint16(body))
+		output = append(output, anoPacket{_type: int(binary.BigEndian.Uint16(body[2:])), data: body[4 : 4+length]})
+		body = body[4+length:]
+	}
+	return output, nil
+}
+
+func (resp *radiusResponder) writePackets(conn net.Conn, packets ...anoPacket) error {
+	payload := bytes.Buffer{}
+	for _, pck := range packets {
+		_ = binary.Write(&payload, binary.BigEndian, uint16(len(pck.data)))
+		_ = binary.Write(&payload, binary.BigEndian, uint16(pck._type))
+		payload.Write(pck.data)
+	}
+	ano := bytes.Buffer{}
+	_ = binary.Write(&ano, binary.BigEndian, uint32(0xDEADBEEF))
+	_ = binary.Write(&ano, binary.BigEndian, uint16(21+payload.Len()))
+	_ = binary.Write(&ano, binary.BigEndian, version)
+	_ = binary.Write(&ano, binary.BigEndian, uint16(1))
+	ano.WriteByte(0)
+	_ = binary.Write(&ano, binary.BigEndian, uint16(1))
+	_ = binary.Write(&ano, binary.BigEndian, uint16(len(packets)))
+	_ = binary.Write(&ano, binary.BigEndian, uint32(0))
+	ano.Write(payload.Bytes())
+	tns := make([]byte, 10, 10+ano.Len())
+	binary.BigEndian.PutUint16(tns, uint16(10+ano.Len()))
+	tns[4] = 6
+	tns = append(tns, ano.Bytes()...)
+	_, err := conn.Write(tns)
+	return err
+}
+
+func status(value int) anoPacket {
+	return anoPacket{_type: 6, data: binary.BigEndian.AppendUint16(nil, uint16(value))}
+}
+
+func (resp *radiusResponder) serve(conn net.Conn) error {
+	defer conn.Close()
+	packets, err := resp.readPackets(conn)
+	if err != nil {
+		return err
+	}
+	if len(packets) != 4 {
+		return errors.New("unexpected number of packets in access request")
+	}
+	mode := binary.BigEndian.Uint32(packets[1].data)
+	if string(packets[2].data) != resp.user || string(packets[3].data) != resp.password {
+		return resp.writePackets(conn, status(radiusReject))
+	}
+	if len(resp.otp) == 0 {
+		return resp.writePackets(conn, status(radiusAccept))
+	}
+	if mode != 1 {
+		// client can't answer challenge
+		return resp.writePackets(conn, status(radiusReject))
+	}
+	err = resp.writePackets(conn, status(radiusChallenge),
+		anoPacket{_type: 0, data: []byte("Enter one-time code")},
+		anoPacket{_type: 1, data: []byte("state-1")})
+	if err != nil {
+		return err
+	}
+	packets, err = resp.readPackets(conn)
+	if err != nil {
+		return err
+	}
+	if len(packets) != 3 || string(packets[2].data) != "state-1" || string(packets[1].data) != resp.otp {
+		return resp.writePackets(conn, status(radiusReject))
+	}
+	return resp.writePackets(conn, status(radiusAccept))
+}
+
+func runRadiusHandshake(t *testing.T, responder *radiusResponder, info configurations.AdvNegoServiceInfo, ssl bool) error {
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan error, 1)
+	go func() {
+		done <- responder.serve(server)
+	}()
+	config := &configurations.ConnectionConfig{
+		AdvNegoServiceInfo: info,
+		SessionInfo: configurations.SessionInfo{
+			SessionDataUnitSize:   0xFFFF,
+			TransportDataUnitSize: 0xFFFF,
+		},
+	}
+	config.SSL = ssl
+	session := network.NewSessionWithConn(client, config, trace.NilTracer())
+	nego, err := NewAdvNego(session, trace.NilTracer(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nego.authRadius = true
+	err = nego.RadiusHandshake()
+	_ = client.Close()
+	if serveErr := <-done; serveErr != nil && err == nil {
+		t.Fatalf("responder: %v", serveErr)
+	}
+	return err
+}
+
+func TestRadiusHandshake(t *testing.T) {
+	responder := &radiusResponder{user: "scott", password: "tiger"}
+	err := runRadiusHandshake(t, responder, configurations.AdvNegoServiceInfo{
+		RadiusUser: "scott", RadiusPassword: "tiger",
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runRadiusHandshake(t, responder, configurations.AdvNegoServiceInfo{
+		RadiusUser: "scott", RadiusPassword: "wrong",
+	}, true)
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("expected reject error, got: %v", err)
+	}
+}
+
+func TestRadiusChallenge(t *testing.T) {
+	responder := &radiusResponder{user: "scott", password: "tiger", otp: "123456"}
+	var prompts []string
+	handler := func(otp string) configurations.RadiusChallengeFunc {
+		return func(prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			return otp, nil
+		}
+	}
+	err := runRadiusHandshake(t, responder, configurations.AdvNegoServiceInfo{
+		RadiusUser: "scott", RadiusPassword: "tiger", RadiusChallenge: handler("123456"),
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 || prompts[0] != "Enter one-time code" {
+		t.Errorf("unexpected challenge prompts: %v", prompts)
+	}
+	err = runRadiusHandshake(t, responder, configurations.AdvNegoServiceInfo{
+		RadiusUser: "scott", RadiusPassword: "tiger", RadiusChallenge: handler("000000"),
+	}, true)
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("expected reject error for wrong code, got: %v", err)
+	}
+	err = runRadiusHandshake(t, responder, configurations.AdvNegoServiceInfo{
+		RadiusUser: "scott", RadiusPassword: "tiger",
+	}, true)
+	if err == nil {
+		t.Error("expected error when challenge handler isn't set")
+	}
+}
+
+func TestRadiusRequireEncryption(t *testing.T) {
+	config := &configurations.ConnectionConfig{}
+	nego, err := NewAdvNego(network.NewSession(config, trace.NilTracer()), trace.NilTracer(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = nego.RadiusHandshake(); err == nil {
+		t.Error("RADIUS password should not be sent without encryption or TCPS")
+	}
+}
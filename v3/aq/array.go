@@ -73,14 +73,7 @@ func (qu *queue) writeArrayData(messages []*Message) error {
 
 		message.write(qu.conn)
 
-		if len(message.recipients) > 0 {
-			session.PutInt(len(message.recipients)*3, 2, true, true)
-			for _, recipient := range message.recipients {
-				session.PutKeyValString(recipient.Key, recipient.Value, recipient.Num)
-			}
-		} else {
-			session.PutBytes(0)
-		}
+		session.PutBytes(0)
 		switch qu.messageType {
 		case RAW:
 			session.PutUint(len(message.bValue), 4, true, true)
@@ -107,11 +100,31 @@ func (qu *queue) writeArrayData(messages []*Message) error {
 	return nil
 }
 
+// hasRecipients return true if any message has recipient list. these messages
+// are enqueued one by one
+func hasRecipients(messages []*Message) bool {
+	for _, message := range messages {
+		if len(message.Recipients) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (qu *queue) EnqueueMessages(messages []*Message) error {
 	var err error
 	if len(messages) == 0 {
 		return errors.New("no messages to enqueue")
 	}
+	if qu.messageType.usePLSQL() || hasRecipients(messages) {
+		for _, message := range messages {
+			err = qu.Enqueue(message)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	session := qu.conn.GetSession()
 	session.ResetBuffer()
 
@@ -171,6 +184,9 @@ func (qu *queue) DequeueMessages(options *DequeueOptions, count int) ([]*Message
 	if count <= 0 {
 		return nil, errors.New("count must be positive")
 	}
+	if qu.messageType.usePLSQL() {
+		return qu.dequeueMessagesPLSQL(options, count)
+	}
 
 	session := qu.conn.GetSession()
 	session.ResetBuffer()
@@ -212,14 +228,7 @@ func (qu *queue) DequeueMessages(options *DequeueOptions, count int) ([]*Message
 		queueNameBytes := qu.conn.GetServerStringCoder().Encode(qu.Name)
 		session.PutDlc(queueNameBytes)
 		message.write(qu.conn)
-		if len(message.recipients) > 0 {
-			session.PutInt(len(message.recipients)*3, 2, true, true)
-			for _, recipient := range message.recipients {
-				session.PutKeyValString(recipient.Key, recipient.Value, recipient.Num)
-			}
-		} else {
-			session.PutBytes(0)
-		}
+		session.PutBytes(0)
 		// consumer name bytes
 		consumer := qu.conn.GetServerStringCoder().Encode(options.Consumer)
 		session.PutDlc(consumer)
@@ -227,7 +236,7 @@ func (qu *queue) DequeueMessages(options *DequeueOptions, count int) ([]*Message
 		session.PutInt(int(options.Navigation), 4, true, true)
 		session.PutInt(int(options.Visibility), 4, true, true)
 		session.PutInt(options.Wait, 4, true, true)
-		session.PutDlc(message.messageID)
+		session.PutDlc(options.MessageID)
 		correlation := qu.conn.GetServerStringCoder().Encode(options.Correlation)
 		session.PutDlc(correlation)
 		condition := qu.conn.GetServerStringCoder().Encode(options.Condition)
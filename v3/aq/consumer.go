@@ -0,0 +1,200 @@
+package aq
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+// Handler process dequeued message. returning nil commit the dequeue while
+// returning error roll it back so the message return to the queue (and its
+// attempts are incremented)
+type Handler func(ctx context.Context, message *Message) error
+
+// ConsumerHooks are optional callbacks used to collect consumer metrics
+type ConsumerHooks struct {
+	// OnReceive called after message is dequeued and before handler
+	OnReceive func(message *Message)
+	// OnCommit called after successful handling of the message
+	OnCommit func(message *Message, elapsed time.Duration)
+	// OnRollback called when handler fail (or panic) and dequeue is rolled back
+	OnRollback func(message *Message, elapsed time.Duration, err error)
+	// OnIdle called when dequeue wait expire without messages
+	OnIdle func()
+}
+
+type ConsumeOptions struct {
+	// Dequeue options used for each dequeue. Mode Remove is used if not set.
+	// Wait is the time in seconds a single dequeue wait for message before
+	// starting new wait (-1 wait forever)
+	Dequeue *DequeueOptions
+	Hooks   ConsumerHooks
+}
+
+// ORA-25228: timeout or end-of-fetch during message dequeue
+const errDequeueTimeout = 25228
+
+// Consume dequeue messages in a loop and deliver them to handler. the loop
+// run on a dedicated session taken from the pool and each message is dequeued
+// in its own transaction (*sql.Tx) that is committed or rolled back according
+// to handler result. the loop run until ctx is done (waiting dequeue is broken
+// and the session is discarded) or dequeue fail and return the cause.
+// the queue should be created with *sql.DB
+func (qu *queue) Consume(ctx context.Context, options *ConsumeOptions, handler Handler) error {
+	if handler == nil {
+		return errors.New("aq: consume require a handler")
+	}
+	db, ok := qu.db.(*sql.DB)
+	if !ok {
+		return errors.New("aq: consume require queue created with *sql.DB")
+	}
+	if options == nil {
+		options = &ConsumeOptions{}
+	}
+	deqOptions := DefaultDequeueOptions()
+	if options.Dequeue != nil {
+		temp := *options.Dequeue
+		deqOptions = &temp
+		if deqOptions.Mode == 0 {
+			deqOptions.Mode = Remove
+		}
+	}
+	hooks := options.Hooks
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// copy of the queue bound to the pinned session, shared queue is not modified
+	session := *qu
+	session.AutoCommit = false
+	_, err = conn.ExecContext(ctx, "--GET-CONNECTION-REF--", &session.conn)
+	if err != nil {
+		return err
+	}
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		// transaction is not bound to ctx so cancel doesn't roll it back
+		// while dequeue is still running on the session
+		tx, err := conn.BeginTx(context.Background(), nil)
+		if err != nil {
+			return err
+		}
+		session.db = tx
+		message, err := session.DequeueContext(ctx, deqOptions)
+		if err != nil {
+			_ = tx.Rollback()
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				// session state is unknown after break so it is not returned to the pool
+				_ = conn.Raw(func(interface{}) error {
+					return driver.ErrBadConn
+				})
+				return ctxErr
+			}
+			var oraErr *network.OracleError
+			if errors.As(err, &oraErr) && oraErr.ErrCode == errDequeueTimeout {
+				if hooks.OnIdle != nil {
+					hooks.OnIdle()
+				}
+				continue
+			}
+			return err
+		}
+		if hooks.OnReceive != nil {
+			hooks.OnReceive(message)
+		}
+		start := time.Now()
+		err = callHandler(ctx, handler, message)
+		if err != nil {
+			rollbackErr := tx.Rollback()
+			if hooks.OnRollback != nil {
+				hooks.OnRollback(message, time.Since(start), err)
+			}
+			if rollbackErr != nil {
+				return rollbackErr
+			}
+			continue
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+		if hooks.OnCommit != nil {
+			hooks.OnCommit(message, time.Since(start))
+		}
+	}
+}
+
+// callHandler convert handler panic into error so the dequeue is rolled back
+func callHandler(ctx context.Context, handler Handler, message *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("aq: handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, message)
+}
+
+// Consumer run Consume on several dedicated sessions taken from DB so messages
+// are processed concurrently
+//
+//	consumer := &aq.Consumer{DB: db, QueueName: "ORDERS_Q", MessageType: aq.JSON, Sessions: 4}
+//	err := consumer.Run(ctx, &aq.ConsumeOptions{}, handler)
+type Consumer struct {
+	DB          *sql.DB
+	QueueName   string
+	MessageType MessageType
+	UdtName     string
+	// Sessions number of concurrent sessions (default 1)
+	Sessions int
+}
+
+// Run start consumer sessions and block until ctx is done or one of them fail.
+// failure of one session stop the others
+func (consumer *Consumer) Run(ctx context.Context, options *ConsumeOptions, handler Handler) error {
+	sessions := consumer.Sessions
+	if sessions <= 0 {
+		sessions = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := consumer.runSession(ctx, options, handler)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (consumer *Consumer) runSession(ctx context.Context, options *ConsumeOptions, handler Handler) error {
+	qu, err := CreateQueue(consumer.DB, consumer.QueueName, consumer.MessageType, consumer.UdtName)
+	if err != nil {
+		return err
+	}
+	return qu.Consume(ctx, options, handler)
+}
@@ -0,0 +1,210 @@
+package aq_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/aq"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func newServer(t *testing.T) *oratest.Server {
+	t.Helper()
+	server, err := oratest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+func openDB(t *testing.T, url string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("oracle", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+var outAssignRegexp = regexp.MustCompile(`:(\d+) := ([^;]+);`)
+
+// dequeueResult answer jms text dequeue by setting output parameters assigned
+// from the expressions
+func dequeueResult(sql string, text string) *oratest.Result {
+	outputs := map[string]interface{}{
+		"l_msgid":                            []byte{1, 2, 3},
+		"l_props.priority":                   1,
+		"NVL(DBMS_LOB.GETLENGTH(l_clob), 0)": len(text),
+		"DBMS_LOB.SUBSTR(l_clob, 32767)":     text,
+		"l_json.to_string":                   "{}",
+	}
+	result := &oratest.Result{Out: make(map[int]interface{})}
+	for _, match := range outAssignRegexp.FindAllStringSubmatch(sql, -1) {
+		if value, ok := outputs[match[2]]; ok {
+			index, _ := strconv.Atoi(match[1])
+			result.Out[index] = value
+		}
+	}
+	return result
+}
+
+// statementsAfterDequeue return statements sent after the first dequeue
+func statementsAfterDequeue(server *oratest.Server) []string {
+	statements := server.Statements()
+	for i, stmt := range statements {
+		if strings.Contains(stmt, "DBMS_AQ.DEQUEUE") {
+			return statements[i+1:]
+		}
+	}
+	return nil
+}
+
+func TestConsume(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	var testScenarios = []struct {
+		name      string
+		handler   func() error
+		statement string
+		err       string
+	}{
+		{"commit", func() error { return nil }, "COMMIT", ""},
+		{"handler error", func() error { return handlerErr }, "ROLLBACK", "handler error"},
+		{"handler panic", func() error { panic("boom") }, "ROLLBACK", "aq: handler panic: boom"},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(t)
+			server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+				if !strings.Contains(req.SQL, "DBMS_AQ.DEQUEUE") {
+					return nil
+				}
+				return dequeueResult(req.SQL, "hello")
+			})
+			db := openDB(t, server.URL(nil))
+			queue, err := aq.CreateQueue(db, "TEXT_Q", aq.JMSText, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var (
+				text        string
+				committed   int
+				rollbackErr error
+			)
+			options := &aq.ConsumeOptions{Hooks: aq.ConsumerHooks{
+				OnCommit: func(*aq.Message, time.Duration) { committed++ },
+				OnRollback: func(_ *aq.Message, _ time.Duration, err error) {
+					rollbackErr = err
+				},
+			}}
+			err = queue.Consume(ctx, options, func(ctx context.Context, message *aq.Message) error {
+				// stop the loop after the first message
+				cancel()
+				text = message.Payload.(*aq.JMSTextMessage).Text
+				return tt.handler()
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context canceled got: %v", err)
+			}
+			if text != "hello" {
+				t.Errorf("expected message text hello got: %s", text)
+			}
+			statements := statementsAfterDequeue(server)
+			if len(statements) != 1 || statements[0] != tt.statement {
+				t.Errorf("expected %s after dequeue got: %v", tt.statement, statements)
+			}
+			if len(tt.err) == 0 {
+				if committed != 1 || rollbackErr != nil {
+					t.Errorf("expected single commit got: %d, rollback: %v", committed, rollbackErr)
+				}
+			} else if committed != 0 || rollbackErr == nil || rollbackErr.Error() != tt.err {
+				t.Errorf("expected rollback with %q got: %v, commits: %d", tt.err, rollbackErr, committed)
+			}
+			// session is returned to the pool
+			if open := db.Stats().OpenConnections; open != 1 {
+				t.Errorf("expected 1 open connection got: %d", open)
+			}
+		})
+	}
+}
+
+func TestConsumeIdle(t *testing.T) {
+	server := newServer(t)
+	dequeues := 0
+	server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+		if !strings.Contains(req.SQL, "DBMS_AQ.DEQUEUE") {
+			return nil
+		}
+		dequeues++
+		return oratest.ErrorResult(25228, "timeout or end-of-fetch during message dequeue")
+	})
+	db := openDB(t, server.URL(nil))
+	queue, err := aq.CreateQueue(db, "TEXT_Q", aq.JMSText, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idle := 0
+	options := &aq.ConsumeOptions{Hooks: aq.ConsumerHooks{OnIdle: func() {
+		idle++
+		if idle == 2 {
+			cancel()
+		}
+	}}}
+	err = queue.Consume(ctx, options, func(context.Context, *aq.Message) error {
+		t.Error("unexpected message")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled got: %v", err)
+	}
+	if idle != 2 || dequeues != 2 {
+		t.Errorf("expected 2 idle dequeues got: %d, %d", idle, dequeues)
+	}
+	if statements := statementsAfterDequeue(server); len(statements) != 3 || statements[0] != "ROLLBACK" {
+		t.Errorf("expected rollback after dequeue timeout got: %v", statements)
+	}
+}
+
+func TestConsumeCancel(t *testing.T) {
+	server := newServer(t)
+	server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+		if !strings.Contains(req.SQL, "DBMS_AQ.DEQUEUE") {
+			return nil
+		}
+		return &oratest.Result{Delay: 5 * time.Second}
+	})
+	db := openDB(t, server.URL(nil))
+	queue, err := aq.CreateQueue(db, "TEXT_Q", aq.JMSText, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = queue.Consume(ctx, nil, func(context.Context, *aq.Message) error {
+		t.Error("unexpected message")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("waiting dequeue is not broken: %v", elapsed)
+	}
+	// interrupted session is discarded instead of returning to the pool
+	if open := db.Stats().OpenConnections; open != 0 {
+		t.Errorf("expected interrupted session to be closed got: %d open connections", open)
+	}
+}
@@ -20,15 +20,22 @@ const (
 )
 
 type DequeueOptions struct {
-	Mode           DequeMode
-	Navigation     NavigationMode
-	Visibility     VisibilityMode
-	Delivery       DeliveryMode
-	Wait           int
-	Consumer       string
-	Correlation    string
-	Condition      string
+	Mode        DequeMode
+	Navigation  NavigationMode
+	Visibility  VisibilityMode
+	Delivery    DeliveryMode
+	Wait        int
+	Consumer    string
+	Correlation string
+	Condition   string
+	// Transformation applied to payload on dequeue (JMS and ANYDATA queues only).
+	// the transformation should return the payload type of the queue
 	Transformation string
+	// ObjectDest receive user defined type wrapped in ANYDATA payload. it should
+	// be pointer to struct registered for the type
+	ObjectDest interface{}
+	// MessageID dequeue specific message by id returned from Message.ServerMessageID
+	MessageID []byte
 }
 
 func DefaultDequeueOptions() *DequeueOptions {
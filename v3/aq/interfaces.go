@@ -1,6 +1,7 @@
 package aq
 
 import (
+	"context"
 	"time"
 
 	"github.com/sijms/go-ora/v3/converters"
@@ -24,5 +25,7 @@ type (
 		GetDBServerTimeZone() *time.Location
 		TTCVersion() uint8
 		GetMaxRawLength() int64
+		StartContext(ctx context.Context) chan struct{}
+		EndContext(done chan struct{})
 	}
 )
@@ -0,0 +1,387 @@
+package aq
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sijms/go-ora/v3/types"
+)
+
+// JMSHeader is the header of SYS.AQ$_JMS_*_MESSAGE payloads. Properties values
+// are string, bool, integers or floats. on dequeue numeric and boolean
+// properties are returned as int64 or float64
+type JMSHeader struct {
+	Type       string
+	UserID     string
+	AppID      string
+	GroupID    string
+	GroupSeq   int
+	ReplyTo    *Agent
+	Properties map[string]interface{}
+}
+
+// JMSTextMessage is payload of SYS.AQ$_JMS_TEXT_MESSAGE queues
+type JMSTextMessage struct {
+	JMSHeader
+	Text string
+}
+
+// JMSBytesMessage is payload of SYS.AQ$_JMS_BYTES_MESSAGE queues
+type JMSBytesMessage struct {
+	JMSHeader
+	Bytes []byte
+}
+
+// JMSMapMessage is payload of SYS.AQ$_JMS_MAP_MESSAGE queues. on dequeue map
+// values are returned as strings following JMS conversion rules
+type JMSMapMessage struct {
+	JMSHeader
+	Map map[string]interface{}
+}
+
+// AnyData is payload of SYS.ANYDATA queues. Value is string, []byte, number,
+// time.Time or registered UDT. TypeName is filled on dequeue (e.g. SYS.VARCHAR2
+// or SCHEMA.TYPE_NAME)
+type AnyData struct {
+	TypeName string
+	Value    interface{}
+}
+
+// maximum size of VARCHAR2 and RAW values returned from pl/sql
+const plsqlMaxSize = 32767
+
+// plsqlArgs collect arguments of pl/sql block and return their placeholders
+type plsqlArgs []interface{}
+
+func (args *plsqlArgs) add(value interface{}) string {
+	*args = append(*args, value)
+	return ":" + strconv.Itoa(len(*args))
+}
+
+// outString return output placeholder for string of maximum size. output
+// strings are sized by their initial value
+func (args *plsqlArgs) outString(dest *string, size int) string {
+	*dest = strings.Repeat(" ", size)
+	return args.add(sqlOut(dest))
+}
+
+func (args *plsqlArgs) outBytes(dest *[]byte, size int) string {
+	*dest = make([]byte, size)
+	return args.add(sqlOut(dest))
+}
+
+// agentExpression return SYS.AQ$_AGENT constructor for agent
+func (args *plsqlArgs) agentExpression(agent Agent) string {
+	return fmt.Sprintf("SYS.AQ$_AGENT(%s, %s, %s)", args.add(agent.Name),
+		args.add(agentAddress(agent)), args.add(int(agent.Protocol)))
+}
+
+// payloadTypeName return oracle type of pl/sql payload variable
+func (messageType MessageType) payloadTypeName() string {
+	switch messageType {
+	case JMSText:
+		return "SYS.AQ$_JMS_TEXT_MESSAGE"
+	case JMSBytes:
+		return "SYS.AQ$_JMS_BYTES_MESSAGE"
+	case JMSMap:
+		return "SYS.AQ$_JMS_MAP_MESSAGE"
+	case AnyDataType:
+		return "SYS.ANYDATA"
+	}
+	return ""
+}
+
+// usePLSQL return true for message types enqueued and dequeued with DBMS_AQ
+func (messageType MessageType) usePLSQL() bool {
+	return len(messageType.payloadTypeName()) > 0
+}
+
+// validatePayload check that payload match message type
+func (messageType MessageType) validatePayload(payload interface{}) error {
+	ok := true
+	switch messageType {
+	case JMSText:
+		switch payload.(type) {
+		case JMSTextMessage, *JMSTextMessage, string:
+		default:
+			ok = false
+		}
+	case JMSBytes:
+		switch payload.(type) {
+		case JMSBytesMessage, *JMSBytesMessage, []byte:
+		default:
+			ok = false
+		}
+	case JMSMap:
+		switch payload.(type) {
+		case JMSMapMessage, *JMSMapMessage, map[string]interface{}:
+		default:
+			ok = false
+		}
+	case AnyDataType:
+		ok = payload != nil
+	}
+	if !ok {
+		return fmt.Errorf("aq: invalid payload of type %T for %s queue", payload, messageType.payloadTypeName())
+	}
+	return nil
+}
+
+// writeHeader write statements that set jms header of l_payload
+func (args *plsqlArgs) writeHeader(builder *strings.Builder, header *JMSHeader) error {
+	setters := []struct {
+		name  string
+		value string
+	}{
+		{"set_type", header.Type},
+		{"set_userid", header.UserID},
+		{"set_appid", header.AppID},
+		{"set_groupid", header.GroupID},
+	}
+	for _, setter := range setters {
+		if len(setter.value) > 0 {
+			builder.WriteString(fmt.Sprintf("\tl_payload.%s(%s);\n", setter.name, args.add(setter.value)))
+		}
+	}
+	if header.GroupSeq != 0 {
+		builder.WriteString(fmt.Sprintf("\tl_payload.set_groupseq(%s);\n", args.add(header.GroupSeq)))
+	}
+	if header.ReplyTo != nil {
+		builder.WriteString(fmt.Sprintf("\tl_payload.set_replyto(%s);\n", args.agentExpression(*header.ReplyTo)))
+	}
+	for _, name := range sortedKeys(header.Properties) {
+		value := header.Properties[name]
+		var setter string
+		switch kind := reflect.ValueOf(value).Kind(); {
+		case kind == reflect.String:
+			setter = "set_string_property(%s, %s)"
+		case kind == reflect.Bool:
+			setter = "set_boolean_property(%s, %s = 1)"
+			value = boolInt(value.(bool))
+		case isInt(kind):
+			setter = "set_long_property(%s, %s)"
+		case kind == reflect.Float32 || kind == reflect.Float64:
+			setter = "set_double_property(%s, %s)"
+		default:
+			return fmt.Errorf("aq: unsupported type %T of jms property %s", value, name)
+		}
+		builder.WriteString("\tl_payload." + fmt.Sprintf(setter, args.add(name), args.add(value)) + ";\n")
+	}
+	return nil
+}
+
+// writePayload write statements that construct l_payload from message payload
+func (args *plsqlArgs) writePayload(builder *strings.Builder, messageType MessageType, payload interface{}) error {
+	switch messageType {
+	case JMSText:
+		msg := &JMSTextMessage{}
+		switch value := payload.(type) {
+		case string:
+			msg.Text = value
+		case JMSTextMessage:
+			msg = &value
+		case *JMSTextMessage:
+			msg = value
+		}
+		builder.WriteString("\tl_payload := SYS.AQ$_JMS_TEXT_MESSAGE.construct;\n")
+		builder.WriteString(fmt.Sprintf("\tl_payload.set_text(%s);\n", args.add(msg.Text)))
+		return args.writeHeader(builder, &msg.JMSHeader)
+	case JMSBytes:
+		msg := &JMSBytesMessage{}
+		switch value := payload.(type) {
+		case []byte:
+			msg.Bytes = value
+		case JMSBytesMessage:
+			msg = &value
+		case *JMSBytesMessage:
+			msg = value
+		}
+		builder.WriteString("\tl_payload := SYS.AQ$_JMS_BYTES_MESSAGE.construct;\n")
+		builder.WriteString(fmt.Sprintf("\tl_payload.set_bytes(%s);\n", args.add(msg.Bytes)))
+		return args.writeHeader(builder, &msg.JMSHeader)
+	case JMSMap:
+		msg := &JMSMapMessage{}
+		switch value := payload.(type) {
+		case map[string]interface{}:
+			msg.Map = value
+		case JMSMapMessage:
+			msg = &value
+		case *JMSMapMessage:
+			msg = value
+		}
+		builder.WriteString("\tl_payload := SYS.AQ$_JMS_MAP_MESSAGE.construct;\n")
+		builder.WriteString("\tl_id := l_payload.prepare(-1);\n")
+		for _, name := range sortedKeys(msg.Map) {
+			value := msg.Map[name]
+			var setter string
+			switch kind := reflect.ValueOf(value).Kind(); {
+			case kind == reflect.String:
+				setter = "set_string(l_id, %s, %s)"
+			case kind == reflect.Bool:
+				setter = "set_boolean(l_id, %s, %s = 1)"
+				value = boolInt(value.(bool))
+			case isInt(kind):
+				setter = "set_long(l_id, %s, %s)"
+			case kind == reflect.Float32 || kind == reflect.Float64:
+				setter = "set_double(l_id, %s, %s)"
+			case kind == reflect.Slice && reflect.TypeOf(value).Elem().Kind() == reflect.Uint8:
+				setter = "set_bytes(l_id, %s, %s)"
+			default:
+				return fmt.Errorf("aq: unsupported type %T of jms map entry %s", value, name)
+			}
+			builder.WriteString("\tl_payload." + fmt.Sprintf(setter, args.add(name), args.add(value)) + ";\n")
+		}
+		builder.WriteString("\tl_payload.flush(l_id);\n\tl_payload.clean(l_id);\n")
+		return args.writeHeader(builder, &msg.JMSHeader)
+	case AnyDataType:
+		value := payload
+		switch temp := payload.(type) {
+		case AnyData:
+			value = temp.Value
+		case *AnyData:
+			value = temp.Value
+		}
+		convert, err := anyDataConverter(value)
+		if err != nil {
+			return err
+		}
+		builder.WriteString(fmt.Sprintf("\tl_payload := SYS.ANYDATA.%s(%s);\n", convert, args.add(value)))
+		return nil
+	case RAW:
+		value, ok := payload.([]byte)
+		if !ok {
+			return fmt.Errorf("aq: invalid payload of type %T for RAW queue", payload)
+		}
+		builder.WriteString("\tl_payload := " + args.add(value) + ";\n")
+		return nil
+	}
+	return fmt.Errorf("unsupported message type: %v", messageType)
+}
+
+// anyDataConverter return SYS.ANYDATA static function used to wrap value
+func anyDataConverter(value interface{}) (string, error) {
+	switch value.(type) {
+	case nil:
+		return "", fmt.Errorf("aq: anydata payload can't be nil")
+	case string, *string:
+		return "ConvertVarchar2", nil
+	case []byte:
+		return "ConvertRaw", nil
+	case time.Time, *time.Time:
+		return "ConvertTimestamp", nil
+	case types.Number, *types.Number:
+		return "ConvertNumber", nil
+	}
+	kind := reflect.Indirect(reflect.ValueOf(value)).Kind()
+	switch {
+	case isInt(kind), kind == reflect.Float32, kind == reflect.Float64:
+		return "ConvertNumber", nil
+	case kind == reflect.Struct:
+		// registered user defined type
+		return "ConvertObject", nil
+	}
+	return "", fmt.Errorf("aq: unsupported anydata payload of type %T", value)
+}
+
+// readHeader write statements that read header of l_payload into outputs.
+// properties are returned as json object of [str_value, num_value] pairs
+func (args *plsqlArgs) readHeader(builder *strings.Builder, out *jmsHeaderOut) {
+	builder.WriteString(fmt.Sprintf("\t%s := l_payload.get_type;\n", args.outString(&out.msgType, 100)))
+	builder.WriteString(fmt.Sprintf("\t%s := l_payload.get_userid;\n", args.outString(&out.userID, 100)))
+	builder.WriteString(fmt.Sprintf("\t%s := l_payload.get_appid;\n", args.outString(&out.appID, 100)))
+	builder.WriteString(fmt.Sprintf("\t%s := l_payload.get_groupid;\n", args.outString(&out.groupID, 100)))
+	builder.WriteString(fmt.Sprintf("\t%s := l_payload.get_groupseq;\n", args.add(sqlOut(&out.groupSeq))))
+	builder.WriteString(fmt.Sprintf(`	l_agent := l_payload.get_replyto;
+	IF l_agent IS NOT NULL THEN
+		%s := l_agent.name;
+		%s := l_agent.address;
+		%s := l_agent.protocol;
+	END IF;
+	l_json := JSON_OBJECT_T();
+	IF l_payload.header.properties IS NOT NULL THEN
+		FOR i IN 1 .. l_payload.header.properties.COUNT LOOP
+			l_array := JSON_ARRAY_T();
+			l_array.append(l_payload.header.properties(i).str_value);
+			l_array.append(l_payload.header.properties(i).num_value);
+			l_json.put(l_payload.header.properties(i).name, l_array);
+		END LOOP;
+	END IF;
+	%s := l_json.to_string;
+`, args.outString(&out.replyName, 128), args.outString(&out.replyAddress, 1024),
+		args.add(sqlOut(&out.replyProtocol)), args.outString(&out.properties, plsqlMaxSize)))
+}
+
+type jmsHeaderOut struct {
+	msgType, userID, appID, groupID string
+	groupSeq                        int64
+	replyName, replyAddress         string
+	replyProtocol                   int64
+	properties                      string
+}
+
+func (out *jmsHeaderOut) header() (JMSHeader, error) {
+	ret := JMSHeader{
+		Type:     out.msgType,
+		UserID:   out.userID,
+		AppID:    out.appID,
+		GroupID:  out.groupID,
+		GroupSeq: int(out.groupSeq),
+	}
+	if len(out.replyName) > 0 || len(out.replyAddress) > 0 {
+		ret.ReplyTo = &Agent{Name: out.replyName, Address: out.replyAddress, Protocol: uint8(out.replyProtocol)}
+	}
+	var properties map[string][2]interface{}
+	decoder := json.NewDecoder(strings.NewReader(out.properties))
+	decoder.UseNumber()
+	err := decoder.Decode(&properties)
+	if err != nil {
+		return ret, fmt.Errorf("aq: invalid jms properties: %w", err)
+	}
+	if len(properties) > 0 {
+		ret.Properties = make(map[string]interface{}, len(properties))
+	}
+	for name, value := range properties {
+		if value[0] != nil {
+			ret.Properties[name] = value[0]
+			continue
+		}
+		if num, ok := value[1].(json.Number); ok {
+			if temp, err := num.Int64(); err == nil {
+				ret.Properties[name] = temp
+			} else if temp, err := num.Float64(); err == nil {
+				ret.Properties[name] = temp
+			}
+		}
+	}
+	return ret, nil
+}
+
+func sortedKeys(input map[string]interface{}) []string {
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isInt(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func boolInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
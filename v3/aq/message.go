@@ -21,10 +21,15 @@ var RAW_TOID = []byte{
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 23}
 
-type OracleAgent struct {
-	Key   string
-	Value string
-	Num   uint8
+// Deprecated: use Agent
+type OracleAgent = Agent
+
+// Agent identify message sender, recipient or queue subscriber (SYS.AQ$_AGENT).
+// Address is remote queue name for propagation and Protocol is 0 for local agents
+type Agent struct {
+	Name     string
+	Address  string
+	Protocol uint8
 }
 type DeliveryMode int
 
@@ -53,30 +58,35 @@ const (
 )
 
 type Message struct {
-	ID             string
-	messageID      []byte
-	Delay          int
-	Expiration     int
-	Priority       int
-	correlation    string
-	exceptionQueue string
+	ID          string
+	messageID   []byte
+	Delay       int
+	Expiration  int
+	Priority    int
+	Correlation string
+	// ExceptionQueue receive the message when it expire or exceed max retries.
+	// empty use exception queue of the queue table
+	ExceptionQueue string
 	deqAttempts    int
 	State          MessageState
 	DeliveryMode   DeliveryMode
 	VisibilityMode VisibilityMode
-	recipients     []OracleAgent
-	sender         OracleAgent
-	EnqTime        time.Time
+	// Recipients override queue subscribers for this message (multi-consumer queues only).
+	// they are sent by DBMS_AQ so they are supported for RAW, JMS and ANYDATA queues
+	Recipients []Agent
+	Sender     Agent
+	EnqTime    time.Time
+	// Transformation applied to payload on enqueue (JMS and ANYDATA queues only).
+	// the transformation should return the payload type of the queue
+	Transformation string
 
 	propModified bool
 	Payload      interface{}
 	bValue       []byte
 	shareNum     int
 
-	senderName       string
-	senderAddress    string
-	senderProtocol   uint8
-	transactionGroup string
+	originalMessageID []byte
+	transactionGroup  string
 
 	extensions []QAExtension
 }
@@ -104,9 +114,9 @@ func (message *Message) read(conn IConnection) error {
 		if err != nil {
 			return err
 		}
-		message.correlation = conn.GetServerStringCoder().Decode(temp)
+		message.Correlation = conn.GetServerStringCoder().Decode(temp)
 	} else {
-		message.correlation = ""
+		message.Correlation = ""
 	}
 	message.deqAttempts, err = session.GetInt(4, true, true)
 	if err != nil {
@@ -122,9 +132,9 @@ func (message *Message) read(conn IConnection) error {
 		if err != nil {
 			return err
 		}
-		message.exceptionQueue = conn.GetServerStringCoder().Decode(temp)
+		message.ExceptionQueue = conn.GetServerStringCoder().Decode(temp)
 	} else {
-		message.exceptionQueue = ""
+		message.ExceptionQueue = ""
 	}
 	num, err = session.GetInt(4, true, true)
 	if err != nil {
@@ -196,18 +206,19 @@ func (message *Message) read(conn IConnection) error {
 		switch num {
 		case 64:
 			if len(key) > 0 {
-				message.senderName = conn.GetServerStringCoder().Decode(key)
+				message.Sender.Name = conn.GetServerStringCoder().Decode(key)
 			}
 		case 65:
 			if len(key) > 0 {
-				message.senderAddress = conn.GetServerStringCoder().Decode(key)
+				message.Sender.Address = conn.GetServerStringCoder().Decode(key)
 			}
 		case 66:
 			if len(val) > 0 {
-				message.senderProtocol = val[0]
+				message.Sender.Protocol = val[0]
 			}
 		case 69:
 			if len(val) > 0 {
+				message.originalMessageID = val
 				message.ID = conn.GetServerStringCoder().Decode(val)
 			}
 		}
@@ -250,17 +261,17 @@ func (message *Message) write(conn IConnection) {
 	session.PutInt(message.Priority, 4, true, true)
 	session.PutInt(message.Delay, 4, true, true)
 	session.PutInt(message.Expiration, 4, true, true)
-	if len(message.correlation) > 0 {
-		temp := conn.GetServerStringCoder().Encode(message.correlation)
+	if len(message.Correlation) > 0 {
+		temp := conn.GetServerStringCoder().Encode(message.Correlation)
 		session.PutInt(len(temp), 2, true, true)
 		session.PutClr(temp)
 	} else {
 		session.PutBytes(0)
 	}
 	session.PutBytes(0)
-	if len(message.exceptionQueue) > 0 {
-		temp := conn.GetServerStringCoder().Encode(message.exceptionQueue)
-		session.PutInt(len(message.exceptionQueue), 2, true, true)
+	if len(message.ExceptionQueue) > 0 {
+		temp := conn.GetServerStringCoder().Encode(message.ExceptionQueue)
+		session.PutInt(len(temp), 2, true, true)
 		session.PutClr(temp)
 	} else {
 		session.PutBytes(0)
@@ -278,9 +289,9 @@ func (message *Message) write(conn IConnection) {
 	}
 	session.PutInt(4, 2, true, true)
 	session.PutBytes(14)
-	session.PutKeyValString(message.senderName, "", 64)
-	session.PutKeyValString(message.senderAddress, "", 65)
-	session.PutKeyVal(nil, []byte{message.senderProtocol}, 66)
+	session.PutKeyValString(message.Sender.Name, "", 64)
+	session.PutKeyValString(message.Sender.Address, "", 65)
+	session.PutKeyVal(nil, []byte{message.Sender.Protocol}, 66)
 	session.PutKeyValString("", message.ID, 69)
 	if conn.TTCVersion() >= 3 {
 		session.PutBytes(0, 0, 0)
@@ -297,6 +308,22 @@ func (message *Message) ServerMessageID() []byte {
 	return message.messageID
 }
 
+// Attempts return number of dequeue attempts of the message
+func (message *Message) Attempts() int {
+	return message.deqAttempts
+}
+
+// OriginalMessageID return id of the message in the source queue when the
+// message is propagated from another queue
+func (message *Message) OriginalMessageID() []byte {
+	return message.originalMessageID
+}
+
+// TransactionGroup return transaction group of the message
+func (message *Message) TransactionGroup() string {
+	return message.transactionGroup
+}
+
 func (message *Message) readData(conn IConnection, messageType MessageType, udtName string) error {
 	var err error
 	session := conn.GetSession()
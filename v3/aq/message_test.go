@@ -0,0 +1,167 @@
+package aq
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sijms/go-ora/v3/converters"
+	"github.com/sijms/go-ora/v3/network"
+)
+
+var testProp = network.SessionProperties{ClrChunkSize: 0x40}
+
+// testConn is aq connection backed by memory session
+type testConn struct {
+	IConnection
+	session    *network.MemorySession
+	ttcVersion uint8
+}
+
+func (conn *testConn) GetSession() network.SessionReadWriter {
+	return conn.session
+}
+
+func (conn *testConn) GetServerStringCoder() converters.IStringConverter {
+	return converters.NewStringConverter(0x369)
+}
+
+func (conn *testConn) TTCVersion() uint8 {
+	return conn.ttcVersion
+}
+
+func TestMessageWrite(t *testing.T) {
+	conn := &testConn{session: network.NewMemorySession(nil, nil, testProp), ttcVersion: 16}
+	message := &Message{
+		ID:             "ID1",
+		Priority:       3,
+		Delay:          5,
+		Expiration:     60,
+		Correlation:    "ORDER",
+		ExceptionQueue: "EXCEPTION_Q",
+		State:          MessageStateWaiting,
+		Sender:         Agent{Name: "SENDER", Address: "REMOTE_Q", Protocol: 1},
+		shareNum:       0xFFFF,
+	}
+	message.write(conn)
+	r := network.NewMemorySession(conn.session.GetWriteBuffer(), nil, testProp)
+	for _, expected := range []int{3, 5, 60} {
+		if value, err := r.GetInt(4, true, true); err != nil || value != expected {
+			t.Fatalf("expected %d got: %d, %v", expected, value, err)
+		}
+	}
+	readString := func(name, expected string) {
+		length, err := r.GetInt(2, true, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := r.GetClr()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if length != len(expected) || string(value) != expected {
+			t.Errorf("expected %s: %s got: %s (length: %d)", name, expected, value, length)
+		}
+	}
+	readString("correlation", "ORDER")
+	if _, err := r.GetByte(); err != nil {
+		t.Fatal(err)
+	}
+	readString("exception queue", "EXCEPTION_Q")
+	if state, _ := r.GetInt(4, true, true); state != int(MessageStateWaiting) {
+		t.Errorf("expected state %d got: %d", MessageStateWaiting, state)
+	}
+	// enqueue time and empty transaction group
+	if temp, _ := r.GetBytes(2); !bytes.Equal(temp, []byte{0, 0}) {
+		t.Errorf("unexpected enqueue time and transaction group: %v", temp)
+	}
+	if count, _ := r.GetInt(2, true, true); count != 4 {
+		t.Errorf("expected 4 properties got: %d", count)
+	}
+	if _, err := r.GetByte(); err != nil {
+		t.Fatal(err)
+	}
+	var testScenarios = []struct {
+		num   int
+		key   string
+		value []byte
+	}{
+		{64, "SENDER", nil},
+		{65, "REMOTE_Q", nil},
+		{66, "", []byte{1}},
+		{69, "", []byte("ID1")},
+	}
+	for _, tt := range testScenarios {
+		key, value, num, err := r.GetKeyVal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if num != tt.num || string(key) != tt.key || !bytes.Equal(value, tt.value) {
+			t.Errorf("expected property %d: %s, %v got %d: %s, %v", tt.num, tt.key, tt.value, num, key, value)
+		}
+	}
+	if temp, _ := r.GetBytes(4); !bytes.Equal(temp, []byte{0, 0, 0, 0}) {
+		t.Errorf("unexpected message tail: %v", temp)
+	}
+	if shareNum, _ := r.GetInt(2, true, true); shareNum != 0xFFFF {
+		t.Errorf("expected share number 0xFFFF got: %d", shareNum)
+	}
+}
+
+func TestMessageRead(t *testing.T) {
+	w := network.NewMemorySession(nil, nil, testProp)
+	putString := func(value string) {
+		w.PutInt(len(value), 4, true, true)
+		w.PutClr([]byte(value))
+	}
+	w.PutInt(2, 4, true, true)  // priority
+	w.PutInt(10, 4, true, true) // delay
+	w.PutBytes(0x81, 1)         // expiration -1 (negative flag in length byte)
+	putString("ORDER")
+	w.PutInt(3, 4, true, true) // attempts
+	putString("EXCEPTION_Q")
+	w.PutInt(int(MessageStateProcessed), 4, true, true)
+	enqTime := []byte{120, 124, 5, 6, 8, 9, 10}
+	w.PutInt(len(enqTime), 4, true, true)
+	w.PutClr(enqTime)
+	w.PutInt(len("TX1"), 2, true, true)
+	w.PutClr([]byte("TX1"))
+	w.PutInt(4, 4, true, true)
+	w.PutBytes(14)
+	w.PutKeyValString("SENDER", "", 64)
+	w.PutKeyValString("REMOTE_Q", "", 65)
+	w.PutKeyVal(nil, []byte{1}, 66)
+	w.PutKeyVal(nil, []byte("ORIGINAL"), 69)
+	w.PutInt(0, 2, true, true)
+	w.PutInt(0, 4, true, true) // csn
+	w.PutInt(0, 4, true, true) // dsn
+	w.PutInt(0, 4, true, true) // delivery mode 0 mean persistent
+	w.PutInt(7, 4, true, true) // share number
+	conn := &testConn{session: network.NewMemorySession(w.GetWriteBuffer(), nil, testProp), ttcVersion: 16}
+	message := &Message{}
+	err := message.read(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Priority != 2 || message.Delay != 10 || message.Expiration != -1 || message.Attempts() != 3 {
+		t.Errorf("unexpected message properties: %+v", message)
+	}
+	if message.Correlation != "ORDER" || message.ExceptionQueue != "EXCEPTION_Q" || message.State != MessageStateProcessed {
+		t.Errorf("unexpected message properties: %+v", message)
+	}
+	if message.EnqTime.Format(time.DateTime) != "2024-05-06 07:08:09" {
+		t.Errorf("expected enqueue time 2024-05-06 07:08:09 got: %v", message.EnqTime)
+	}
+	if message.TransactionGroup() != "TX1" {
+		t.Errorf("expected transaction group TX1 got: %s", message.TransactionGroup())
+	}
+	if message.Sender != (Agent{Name: "SENDER", Address: "REMOTE_Q", Protocol: 1}) {
+		t.Errorf("unexpected sender: %+v", message.Sender)
+	}
+	if string(message.OriginalMessageID()) != "ORIGINAL" {
+		t.Errorf("expected original message id ORIGINAL got: %s", message.OriginalMessageID())
+	}
+	if message.DeliveryMode != DeliveryModePersistent || message.shareNum != 7 {
+		t.Errorf("unexpected delivery mode: %d, share number: %d", message.DeliveryMode, message.shareNum)
+	}
+}
@@ -0,0 +1,348 @@
+package aq
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+// message types with payload converted by DBMS_AQ pl/sql calls. unlike RAW,
+// UDT, XML and JSON they are not sent as native aq messages so enqueue and
+// dequeue transformations are supported
+const (
+	JMSText     MessageType = 5
+	JMSBytes    MessageType = 6
+	JMSMap      MessageType = 7
+	AnyDataType MessageType = 8
+)
+
+func sqlOut(dest interface{}) sql.Out {
+	return sql.Out{Dest: dest}
+}
+
+// exec run pl/sql block using ExecContext when supported by the queue db.
+// *sql.DB run each call on any pooled session and commit it so it is accepted
+// only when the block commit itself (AutoCommit)
+func (qu *queue) exec(ctx context.Context, query string, args ...interface{}) error {
+	var err error
+	if _, ok := qu.db.(*sql.DB); ok && !qu.AutoCommit {
+		return errors.New("aq: queue created with *sql.DB require auto commit. use *sql.Conn or *sql.Tx to control the transaction")
+	}
+	if execer, ok := qu.db.(interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	}); ok {
+		_, err = execer.ExecContext(ctx, query, args...)
+	} else {
+		_, err = qu.db.Exec(query, args...)
+	}
+	return err
+}
+
+// plsqlPayloadType return type of l_payload. RAW queues use pl/sql only to
+// enqueue messages with recipient list
+func (qu *queue) plsqlPayloadType() string {
+	if qu.messageType == RAW {
+		return "RAW(32767)"
+	}
+	return qu.messageType.payloadTypeName()
+}
+
+func (qu *queue) enqueuePLSQL(ctx context.Context, message *Message) error {
+	var args plsqlArgs
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf(`DECLARE
+	l_options DBMS_AQ.ENQUEUE_OPTIONS_T;
+	l_props DBMS_AQ.MESSAGE_PROPERTIES_T;
+	l_payload %s;
+	l_msgid RAW(16);
+	l_id PLS_INTEGER;
+BEGIN
+`, qu.plsqlPayloadType()))
+	if message.VisibilityMode != 0 {
+		builder.WriteString("\tl_options.visibility := " + args.add(int(message.VisibilityMode)) + ";\n")
+	}
+	if message.DeliveryMode == DeliveryModeBuffered {
+		builder.WriteString("\tl_options.delivery_mode := " + args.add(int(message.DeliveryMode)) + ";\n")
+	}
+	if len(message.Transformation) > 0 {
+		builder.WriteString("\tl_options.transformation := " + args.add(message.Transformation) + ";\n")
+	}
+	builder.WriteString(fmt.Sprintf("\tl_props.priority := %s;\n\tl_props.delay := %s;\n\tl_props.expiration := %s;\n",
+		args.add(message.Priority), args.add(message.Delay), args.add(message.Expiration)))
+	if len(message.Correlation) > 0 {
+		builder.WriteString("\tl_props.correlation := " + args.add(message.Correlation) + ";\n")
+	}
+	if len(message.ExceptionQueue) > 0 {
+		builder.WriteString("\tl_props.exception_queue := " + args.add(message.ExceptionQueue) + ";\n")
+	}
+	if len(message.Sender.Name) > 0 || len(message.Sender.Address) > 0 {
+		builder.WriteString("\tl_props.sender_id := " + args.agentExpression(message.Sender) + ";\n")
+	}
+	for i, recipient := range message.Recipients {
+		builder.WriteString(fmt.Sprintf("\tl_props.recipient_list(%d) := %s;\n", i+1, args.agentExpression(recipient)))
+	}
+	err := args.writePayload(&builder, qu.messageType, message.Payload)
+	if err != nil {
+		return err
+	}
+	var msgID []byte
+	builder.WriteString(fmt.Sprintf(`	DBMS_AQ.ENQUEUE(queue_name => %s, enqueue_options => l_options,
+		message_properties => l_props, payload => l_payload, msgid => l_msgid);
+	%s := l_msgid;
+`, args.add(qu.Name), args.outBytes(&msgID, 16)))
+	if qu.AutoCommit {
+		builder.WriteString("\tCOMMIT;\n")
+	}
+	builder.WriteString("END;")
+	err = qu.exec(ctx, builder.String(), args...)
+	if err != nil {
+		return err
+	}
+	message.messageID = msgID
+	return nil
+}
+
+func (qu *queue) dequeuePLSQL(ctx context.Context, options *DequeueOptions) (*Message, error) {
+	var args plsqlArgs
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf(`DECLARE
+	l_options DBMS_AQ.DEQUEUE_OPTIONS_T;
+	l_props DBMS_AQ.MESSAGE_PROPERTIES_T;
+	l_payload %s;
+	l_msgid RAW(16);
+	l_id PLS_INTEGER;
+	l_agent SYS.AQ$_AGENT;
+	l_json JSON_OBJECT_T;
+	l_array JSON_ARRAY_T;
+	l_names SYS.AQ$_JMS_NAMEARRAY;
+	l_clob CLOB;
+	l_type_name VARCHAR2(261);
+	l_status PLS_INTEGER;
+BEGIN
+`, qu.messageType.payloadTypeName()))
+	if len(options.Consumer) > 0 {
+		builder.WriteString("\tl_options.consumer_name := " + args.add(options.Consumer) + ";\n")
+	}
+	if options.Mode != 0 {
+		builder.WriteString("\tl_options.dequeue_mode := " + args.add(int(options.Mode)) + ";\n")
+	}
+	if options.Navigation != 0 {
+		builder.WriteString("\tl_options.navigation := " + args.add(int(options.Navigation)) + ";\n")
+	}
+	if options.Visibility != 0 {
+		builder.WriteString("\tl_options.visibility := " + args.add(int(options.Visibility)) + ";\n")
+	}
+	if options.Delivery != 0 {
+		builder.WriteString("\tl_options.delivery_mode := " + args.add(int(options.Delivery)) + ";\n")
+	}
+	builder.WriteString("\tl_options.wait := " + args.add(options.Wait) + ";\n")
+	if len(options.MessageID) > 0 {
+		builder.WriteString("\tl_options.msgid := " + args.add(options.MessageID) + ";\n")
+	}
+	if len(options.Correlation) > 0 {
+		builder.WriteString("\tl_options.correlation := " + args.add(options.Correlation) + ";\n")
+	}
+	if len(options.Condition) > 0 {
+		builder.WriteString("\tl_options.deq_condition := " + args.add(options.Condition) + ";\n")
+	}
+	if len(options.Transformation) > 0 {
+		builder.WriteString("\tl_options.transformation := " + args.add(options.Transformation) + ";\n")
+	}
+	var (
+		message                               = &Message{}
+		msgID, originalMsgID                  []byte
+		priority, delay, expiration, attempts int64
+		state, senderProtocol                 int64
+		senderName, senderAddress             string
+		correlation, exceptionQueue           string
+		enqueueTime                           time.Time
+		out                                   payloadOut
+	)
+	builder.WriteString(fmt.Sprintf(`	DBMS_AQ.DEQUEUE(queue_name => %s, dequeue_options => l_options,
+		message_properties => l_props, payload => l_payload, msgid => l_msgid);
+	%s := l_msgid;
+	%s := l_props.priority;
+	%s := l_props.delay;
+	%s := l_props.expiration;
+	%s := l_props.attempts;
+	%s := l_props.state;
+	%s := l_props.correlation;
+	%s := l_props.exception_queue;
+	%s := l_props.enqueue_time;
+	%s := l_props.original_msgid;
+	IF l_props.sender_id IS NOT NULL THEN
+		%s := l_props.sender_id.name;
+		%s := l_props.sender_id.address;
+		%s := l_props.sender_id.protocol;
+	END IF;
+`, args.add(qu.Name), args.outBytes(&msgID, 16), args.add(sqlOut(&priority)), args.add(sqlOut(&delay)),
+		args.add(sqlOut(&expiration)), args.add(sqlOut(&attempts)), args.add(sqlOut(&state)),
+		args.outString(&correlation, 128), args.outString(&exceptionQueue, 261), args.add(sqlOut(&enqueueTime)),
+		args.outBytes(&originalMsgID, 16), args.outString(&senderName, 128), args.outString(&senderAddress, 1024),
+		args.add(sqlOut(&senderProtocol))))
+	args.readPayload(&builder, qu.messageType, options, &out)
+	if qu.AutoCommit {
+		builder.WriteString("\tCOMMIT;\n")
+	}
+	builder.WriteString("END;")
+	err := qu.exec(ctx, builder.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	message.messageID = msgID
+	message.Priority = int(priority)
+	message.Delay = int(delay)
+	message.Expiration = int(expiration)
+	message.deqAttempts = int(attempts)
+	message.State = MessageState(state)
+	message.Correlation = correlation
+	message.ExceptionQueue = exceptionQueue
+	message.EnqTime = enqueueTime
+	message.originalMessageID = originalMsgID
+	message.Sender = Agent{Name: senderName, Address: senderAddress, Protocol: uint8(senderProtocol)}
+	message.DeliveryMode = options.Delivery
+	message.Payload, err = out.payload(qu.messageType, options)
+	return message, err
+}
+
+// dequeueMessagesPLSQL dequeue up to count messages. only first dequeue wait
+// for messages and the rest return what is available in the queue
+func (qu *queue) dequeueMessagesPLSQL(options *DequeueOptions, count int) ([]*Message, error) {
+	temp := *options
+	messages := make([]*Message, 0, count)
+	for len(messages) < count {
+		message, err := qu.dequeuePLSQL(context.Background(), &temp)
+		if err != nil {
+			var oraErr *network.OracleError
+			if len(messages) > 0 && errors.As(err, &oraErr) && oraErr.ErrCode == errDequeueTimeout {
+				break
+			}
+			return nil, err
+		}
+		messages = append(messages, message)
+		temp.Wait = 0
+	}
+	return messages, nil
+}
+
+type payloadOut struct {
+	header     jmsHeaderOut
+	text       string
+	textLength int64
+	bytes      []byte
+	byteLength int64
+	mapValue   string
+	typeName   string
+	number     string
+	date       time.Time
+}
+
+// readPayload write statements that read l_payload into outputs
+func (args *plsqlArgs) readPayload(builder *strings.Builder, messageType MessageType, options *DequeueOptions, out *payloadOut) {
+	switch messageType {
+	case JMSText:
+		builder.WriteString(fmt.Sprintf(`	l_payload.get_text(l_clob);
+	%s := NVL(DBMS_LOB.GETLENGTH(l_clob), 0);
+	%s := DBMS_LOB.SUBSTR(l_clob, %d);
+`, args.add(sqlOut(&out.textLength)), args.outString(&out.text, plsqlMaxSize), plsqlMaxSize))
+		args.readHeader(builder, &out.header)
+	case JMSBytes:
+		builder.WriteString(fmt.Sprintf(`	%s := NVL(l_payload.bytes_len, 0);
+	%s := NVL(l_payload.bytes_raw, DBMS_LOB.SUBSTR(l_payload.bytes_lob, %d));
+`, args.add(sqlOut(&out.byteLength)), args.outBytes(&out.bytes, plsqlMaxSize), plsqlMaxSize))
+		args.readHeader(builder, &out.header)
+	case JMSMap:
+		builder.WriteString(fmt.Sprintf(`	l_id := l_payload.prepare(-1);
+	l_names := l_payload.get_names(l_id);
+	l_json := JSON_OBJECT_T();
+	FOR i IN 1 .. l_names.COUNT LOOP
+		l_json.put(l_names(i), l_payload.get_string(l_id, l_names(i)));
+	END LOOP;
+	l_payload.clean(l_id);
+	%s := l_json.to_string;
+`, args.outString(&out.mapValue, plsqlMaxSize)))
+		args.readHeader(builder, &out.header)
+	case AnyDataType:
+		builder.WriteString(fmt.Sprintf(`	l_type_name := l_payload.GetTypeName;
+	%s := l_type_name;
+	CASE l_type_name
+		WHEN 'SYS.VARCHAR2' THEN %s := l_payload.AccessVarchar2;
+		WHEN 'SYS.NVARCHAR2' THEN %s := l_payload.AccessNVarchar2;
+		WHEN 'SYS.CHAR' THEN %s := l_payload.AccessChar;
+		WHEN 'SYS.NUMBER' THEN %s := TO_CHAR(l_payload.AccessNumber, 'TM9');
+		WHEN 'SYS.RAW' THEN %s := l_payload.AccessRaw;
+		WHEN 'SYS.DATE' THEN %s := l_payload.AccessDate;
+		WHEN 'SYS.TIMESTAMP' THEN %s := l_payload.AccessTimestamp;
+`, args.outString(&out.typeName, 261), args.outString(&out.text, plsqlMaxSize), args.add(sqlOut(&out.text)),
+			args.add(sqlOut(&out.text)), args.outString(&out.number, 100), args.outBytes(&out.bytes, plsqlMaxSize),
+			args.add(sqlOut(&out.date)), args.add(sqlOut(&out.date))))
+		if options.ObjectDest != nil {
+			// object of user defined type is extracted with dynamic pl/sql as its type
+			// is known only after dequeue
+			builder.WriteString(fmt.Sprintf(`		ELSE EXECUTE IMMEDIATE 'DECLARE o ' || l_type_name || '; r PLS_INTEGER; BEGIN r := :1.GetObject(o); :2 := o; END;'
+			USING IN l_payload, OUT %s;
+`, args.add(sqlOut(options.ObjectDest))))
+		} else {
+			builder.WriteString("\t\tELSE NULL;\n")
+		}
+		builder.WriteString("\tEND CASE;\n")
+	}
+}
+
+func (out *payloadOut) payload(messageType MessageType, options *DequeueOptions) (interface{}, error) {
+	switch messageType {
+	case JMSText:
+		if out.textLength > int64(len([]rune(out.text))) {
+			return nil, fmt.Errorf("aq: jms text of length %d exceed %d", out.textLength, plsqlMaxSize)
+		}
+		header, err := out.header.header()
+		return &JMSTextMessage{JMSHeader: header, Text: out.text}, err
+	case JMSBytes:
+		if out.byteLength > int64(len(out.bytes)) {
+			return nil, fmt.Errorf("aq: jms bytes of length %d exceed %d", out.byteLength, plsqlMaxSize)
+		}
+		header, err := out.header.header()
+		return &JMSBytesMessage{JMSHeader: header, Bytes: out.bytes}, err
+	case JMSMap:
+		header, err := out.header.header()
+		if err != nil {
+			return nil, err
+		}
+		msg := &JMSMapMessage{JMSHeader: header}
+		var temp map[string]interface{}
+		err = json.Unmarshal([]byte(out.mapValue), &temp)
+		msg.Map = temp
+		return msg, err
+	case AnyDataType:
+		ret := &AnyData{TypeName: out.typeName}
+		switch out.typeName {
+		case "SYS.VARCHAR2", "SYS.NVARCHAR2", "SYS.CHAR":
+			ret.Value = out.text
+		case "SYS.NUMBER":
+			if temp, err := strconv.ParseInt(out.number, 10, 64); err == nil {
+				ret.Value = temp
+			} else {
+				temp, err := strconv.ParseFloat(out.number, 64)
+				if err != nil {
+					return nil, err
+				}
+				ret.Value = temp
+			}
+		case "SYS.RAW":
+			ret.Value = out.bytes
+		case "SYS.DATE", "SYS.TIMESTAMP":
+			ret.Value = out.date
+		default:
+			ret.Value = options.ObjectDest
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("unsupported message type: %v", messageType)
+}
@@ -0,0 +1,314 @@
+package aq
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// plsqlExecer record executed pl/sql and fill output parameters by the
+// expression assigned to them (e.g. l_props.priority)
+type plsqlExecer struct {
+	query   string
+	args    []interface{}
+	outputs map[string]interface{}
+}
+
+var outAssignRegexp = regexp.MustCompile(`:(\d+) := ([^;]+);`)
+
+func (exec *plsqlExecer) Exec(query string, args ...any) (sql.Result, error) {
+	exec.query = query
+	exec.args = args
+	for _, match := range outAssignRegexp.FindAllStringSubmatch(query, -1) {
+		value, ok := exec.outputs[match[2]]
+		if !ok {
+			continue
+		}
+		index, _ := strconv.Atoi(match[1])
+		out, ok := args[index-1].(sql.Out)
+		if !ok {
+			return nil, errors.New("parameter :" + match[1] + " is not output")
+		}
+		reflect.ValueOf(out.Dest).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil, nil
+}
+
+func (exec *plsqlExecer) Prepare(query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestPlsqlArgs(t *testing.T) {
+	var args plsqlArgs
+	if name := args.add("a"); name != ":1" {
+		t.Errorf("expected :1 got: %s", name)
+	}
+	var text string
+	if name := args.outString(&text, 10); name != ":2" || len(text) != 10 {
+		t.Errorf("expected :2 with string of size 10 got: %s, %d", name, len(text))
+	}
+	var data []byte
+	if name := args.outBytes(&data, 16); name != ":3" || len(data) != 16 {
+		t.Errorf("expected :3 with bytes of size 16 got: %s, %d", name, len(data))
+	}
+	if out, ok := args[1].(sql.Out); !ok || out.Dest != &text {
+		t.Errorf("expected output parameter got: %#v", args[1])
+	}
+	expression := args.agentExpression(Agent{Name: "AGENT"})
+	if expression != "SYS.AQ$_AGENT(:4, :5, :6)" {
+		t.Errorf("unexpected agent expression: %s", expression)
+	}
+	// empty address is bound as null
+	if !reflect.DeepEqual(args[3:], plsqlArgs{"AGENT", nil, 0}) {
+		t.Errorf("unexpected agent arguments: %v", args[3:])
+	}
+}
+
+func TestEnqueuePLSQL(t *testing.T) {
+	exec := &plsqlExecer{outputs: map[string]interface{}{"l_msgid": []byte{1, 2, 3}}}
+	qu := &queue{Name: "TEXT_Q", messageType: JMSText, db: exec, AutoCommit: true}
+	message := &Message{
+		Priority:       2,
+		Expiration:     -1,
+		Correlation:    "ORDER",
+		VisibilityMode: VisibilityImmediate,
+		Transformation: "APP.TO_TEXT",
+		Sender:         Agent{Name: "SENDER"},
+		Recipients:     []Agent{{Name: "R1"}},
+		Payload: &JMSTextMessage{
+			JMSHeader: JMSHeader{Type: "order", Properties: map[string]interface{}{"count": 5, "urgent": true}},
+			Text:      "hello",
+		},
+	}
+	err := qu.enqueuePLSQL(t.Context(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"l_payload SYS.AQ$_JMS_TEXT_MESSAGE;",
+		"l_options.visibility := :1;",
+		"l_options.transformation := :2;",
+		"l_props.priority := :3;\n\tl_props.delay := :4;\n\tl_props.expiration := :5;",
+		"l_props.correlation := :6;",
+		"l_props.sender_id := SYS.AQ$_AGENT(:7, :8, :9);",
+		"l_props.recipient_list(1) := SYS.AQ$_AGENT(:10, :11, :12);",
+		"l_payload := SYS.AQ$_JMS_TEXT_MESSAGE.construct;\n\tl_payload.set_text(:13);",
+		"l_payload.set_type(:14);",
+		"l_payload.set_long_property(:15, :16);",
+		"l_payload.set_boolean_property(:17, :18 = 1);",
+		"DBMS_AQ.ENQUEUE(queue_name => :19,",
+		":20 := l_msgid;",
+		"COMMIT;\nEND;",
+	}
+	for _, item := range expected {
+		if !strings.Contains(exec.query, item) {
+			t.Errorf("expected %q in:\n%s", item, exec.query)
+		}
+	}
+	args := exec.args[:len(exec.args)-1]
+	expectedArgs := []interface{}{int(VisibilityImmediate), "APP.TO_TEXT", 2, 0, -1, "ORDER", "SENDER", nil, 0,
+		"R1", nil, 0, "hello", "order", "count", 5, "urgent", 1, "TEXT_Q"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected arguments: %v got: %v", expectedArgs, args)
+	}
+	if !reflect.DeepEqual(message.ServerMessageID(), []byte{1, 2, 3}) {
+		t.Errorf("expected message id [1 2 3] got: %v", message.ServerMessageID())
+	}
+	// map entry of unsupported type
+	qu.messageType = JMSMap
+	err = qu.enqueuePLSQL(t.Context(), &Message{Payload: map[string]interface{}{"key": struct{}{}}})
+	if err == nil {
+		t.Error("expected error for unsupported map entry")
+	}
+}
+
+func TestEnqueueRecipients(t *testing.T) {
+	exec := &plsqlExecer{outputs: map[string]interface{}{"l_msgid": []byte{1, 2, 3}}}
+	qu := &queue{Name: "RAW_Q", messageType: RAW, db: exec}
+	message := &Message{
+		Expiration: -1,
+		Recipients: []Agent{{Name: "R1"}, {Name: "R2", Address: "REMOTE_Q", Protocol: 2}},
+		Payload:    []byte{0xA, 0xB},
+	}
+	err := qu.EnqueueMessages([]*Message{message})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"l_payload RAW(32767);",
+		"l_props.recipient_list(1) := SYS.AQ$_AGENT(:4, :5, :6);",
+		"l_props.recipient_list(2) := SYS.AQ$_AGENT(:7, :8, :9);",
+		"l_payload := :10;",
+		"DBMS_AQ.ENQUEUE(queue_name => :11,",
+	}
+	for _, item := range expected {
+		if !strings.Contains(exec.query, item) {
+			t.Errorf("expected %q in:\n%s", item, exec.query)
+		}
+	}
+	if strings.Contains(exec.query, "COMMIT;") {
+		t.Error("unexpected commit for queue without auto commit")
+	}
+	expectedArgs := []interface{}{"R1", nil, 0, "R2", "REMOTE_Q", 2, []byte{0xA, 0xB}, "RAW_Q"}
+	if !reflect.DeepEqual(exec.args[3:len(exec.args)-1], expectedArgs) {
+		t.Errorf("expected arguments: %v got: %v", expectedArgs, exec.args[3:len(exec.args)-1])
+	}
+	if !reflect.DeepEqual(message.ServerMessageID(), []byte{1, 2, 3}) {
+		t.Errorf("expected message id [1 2 3] got: %v", message.ServerMessageID())
+	}
+	// recipient list of native queues other than RAW is refused
+	qu.messageType = UDT
+	if err = qu.Enqueue(message); err == nil {
+		t.Error("expected error for recipient list of UDT queue")
+	}
+}
+
+func TestDequeuePLSQL(t *testing.T) {
+	enqTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	exec := &plsqlExecer{outputs: map[string]interface{}{
+		"l_msgid":                            []byte{1, 2, 3},
+		"l_props.priority":                   int64(4),
+		"l_props.attempts":                   int64(1),
+		"l_props.correlation":                "ORDER",
+		"l_props.enqueue_time":               enqTime,
+		"l_props.sender_id.name":             "SENDER",
+		"NVL(DBMS_LOB.GETLENGTH(l_clob), 0)": int64(5),
+		"DBMS_LOB.SUBSTR(l_clob, 32767)":     "hello",
+		"l_payload.get_type":                 "order",
+		"l_payload.get_groupseq":             int64(2),
+		"l_agent.name":                       "REPLY",
+		"l_json.to_string":                   `{"count":[null,5],"name":["x",null],"rate":[null,1.5]}`,
+		"l_payload.get_userid":               "",
+		"l_payload.get_appid":                "",
+		"l_payload.get_groupid":              "",
+		"l_agent.address":                    "",
+		"l_props.exception_queue":            "",
+		"l_props.sender_id.address":          "",
+		"l_props.original_msgid":             []byte(nil),
+		"l_props.delay":                      int64(0),
+		"l_props.expiration":                 int64(-1),
+		"l_props.state":                      int64(0),
+		"l_props.sender_id.protocol":         int64(0),
+		"l_agent.protocol":                   int64(0),
+	}}
+	qu := &queue{Name: "TEXT_Q", messageType: JMSText, db: exec}
+	options := &DequeueOptions{Consumer: "C1", Wait: 5, MessageID: []byte{1, 2, 3}, Transformation: "APP.FROM_TEXT"}
+	message, err := qu.dequeuePLSQL(t.Context(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"l_options.consumer_name := :1;",
+		"l_options.wait := :2;",
+		"l_options.msgid := :3;",
+		"l_options.transformation := :4;",
+		"DBMS_AQ.DEQUEUE(queue_name => :5,",
+		"l_payload.get_text(l_clob);",
+	}
+	for _, item := range expected {
+		if !strings.Contains(exec.query, item) {
+			t.Errorf("expected %q in:\n%s", item, exec.query)
+		}
+	}
+	if strings.Contains(exec.query, "COMMIT") {
+		t.Error("unexpected commit without auto commit")
+	}
+	if !reflect.DeepEqual(exec.args[:5], []interface{}{"C1", 5, []byte{1, 2, 3}, "APP.FROM_TEXT", "TEXT_Q"}) {
+		t.Errorf("unexpected arguments: %v", exec.args[:5])
+	}
+	if message.Priority != 4 || message.Attempts() != 1 || message.Correlation != "ORDER" || message.Expiration != -1 {
+		t.Errorf("unexpected message properties: %+v", message)
+	}
+	if !message.EnqTime.Equal(enqTime) || message.Sender.Name != "SENDER" {
+		t.Errorf("unexpected message properties: %+v", message)
+	}
+	payload, ok := message.Payload.(*JMSTextMessage)
+	if !ok {
+		t.Fatalf("expected *JMSTextMessage got: %T", message.Payload)
+	}
+	if payload.Text != "hello" || payload.Type != "order" || payload.GroupSeq != 2 || payload.ReplyTo == nil || payload.ReplyTo.Name != "REPLY" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	expectedProperties := map[string]interface{}{"count": int64(5), "name": "x", "rate": 1.5}
+	if !reflect.DeepEqual(payload.Properties, expectedProperties) {
+		t.Errorf("expected properties: %v got: %v", expectedProperties, payload.Properties)
+	}
+	// text longer than the output buffer
+	exec.outputs["NVL(DBMS_LOB.GETLENGTH(l_clob), 0)"] = int64(plsqlMaxSize + 1)
+	_, err = qu.dequeuePLSQL(t.Context(), options)
+	if err == nil {
+		t.Error("expected error for jms text longer than output buffer")
+	}
+}
+
+func TestDequeueAnyDataPLSQL(t *testing.T) {
+	var testScenarios = []struct {
+		name     string
+		typeName string
+		outputs  map[string]interface{}
+		expected interface{}
+	}{
+		{"varchar", "SYS.VARCHAR2", map[string]interface{}{"l_payload.AccessVarchar2": "text"}, "text"},
+		{"integer", "SYS.NUMBER", map[string]interface{}{"TO_CHAR(l_payload.AccessNumber, 'TM9')": "42"}, int64(42)},
+		{"float", "SYS.NUMBER", map[string]interface{}{"TO_CHAR(l_payload.AccessNumber, 'TM9')": "4.5"}, 4.5},
+		{"raw", "SYS.RAW", map[string]interface{}{"l_payload.AccessRaw": []byte{1, 2}}, []byte{1, 2}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.outputs["l_type_name"] = tt.typeName
+			exec := &plsqlExecer{outputs: tt.outputs}
+			qu := &queue{Name: "ANY_Q", messageType: AnyDataType, db: exec}
+			message, err := qu.dequeuePLSQL(t.Context(), DefaultDequeueOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(exec.query, "\t\tELSE NULL;\n") {
+				t.Errorf("expected no object extraction without ObjectDest in:\n%s", exec.query)
+			}
+			payload := message.Payload.(*AnyData)
+			if payload.TypeName != tt.typeName || !reflect.DeepEqual(payload.Value, tt.expected) {
+				t.Errorf("expected %s: %v got: %+v", tt.typeName, tt.expected, payload)
+			}
+		})
+	}
+}
+
+func TestWriteAnyDataPayload(t *testing.T) {
+	var testScenarios = []struct {
+		value   interface{}
+		convert string
+	}{
+		{"text", "ConvertVarchar2"},
+		{[]byte{1}, "ConvertRaw"},
+		{time.Now(), "ConvertTimestamp"},
+		{int32(5), "ConvertNumber"},
+		{1.5, "ConvertNumber"},
+		{&struct{ ID int }{1}, "ConvertObject"},
+	}
+	for _, tt := range testScenarios {
+		var (
+			args    plsqlArgs
+			builder strings.Builder
+		)
+		err := args.writePayload(&builder, AnyDataType, AnyData{Value: tt.value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "\tl_payload := SYS.ANYDATA." + tt.convert + "(:1);\n"
+		if builder.String() != expected || len(args) != 1 {
+			t.Errorf("expected %q got: %q with arguments: %v", expected, builder.String(), args)
+		}
+	}
+	var (
+		args    plsqlArgs
+		builder strings.Builder
+	)
+	if err := args.writePayload(&builder, AnyDataType, AnyData{}); err == nil {
+		t.Error("expected error for nil anydata payload")
+	}
+}
@@ -1,6 +1,7 @@
 package aq
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -16,8 +17,12 @@ type Queue interface {
 	Enqueue(message *Message) error
 	EnqueueMessages(messages []*Message) error
 	Dequeue(options *DequeueOptions) (*Message, error)
+	DequeueContext(ctx context.Context, options *DequeueOptions) (*Message, error)
 	DequeueMessages(options *DequeueOptions, count int) ([]*Message, error)
 	SetAutoCommit(autoCommit bool)
+	AddSubscriber(subscriber Agent, rule string) error
+	RemoveSubscriber(subscriber Agent) error
+	Consume(ctx context.Context, options *ConsumeOptions, handler Handler) error
 }
 type queue struct {
 	Name        string
@@ -27,6 +32,7 @@ type queue struct {
 	udtName     string
 	toid        []byte
 	AutoCommit  bool
+	db          utils.Execuer
 }
 
 func CreateQueue(db utils.Execuer, name string, messageType MessageType, udtName string) (Queue, error) {
@@ -35,6 +41,7 @@ func CreateQueue(db utils.Execuer, name string, messageType MessageType, udtName
 		messageType: messageType,
 		udtName:     udtName,
 		version:     1,
+		db:          db,
 	}
 	_, err := db.Exec("--GET-CONNECTION-REF--", &ret.conn)
 	if err != nil {
@@ -48,6 +55,9 @@ func CreateQueue(db utils.Execuer, name string, messageType MessageType, udtName
 		ret.toid = JSON_TOID
 	case XML:
 		ret.toid = XMLTYPE_TOID
+	case AnyDataType:
+		ret.toid = ANYDATA_TOID
+	case JMSText, JMSBytes, JMSMap:
 	default:
 		if len(udtName) > 0 {
 			coder, err := ret.conn.GetParameterCoder(udtName)
@@ -66,32 +76,61 @@ func (qu *queue) SetAutoCommit(autoCommit bool) {
 	qu.AutoCommit = autoCommit
 }
 
+// AddSubscriber add subscriber to multi-consumer queue. rule is optional condition
+// on message properties (e.g. "priority < 5") or payload (tab.user_data) that
+// filter messages delivered to the subscriber
+func (qu *queue) AddSubscriber(subscriber Agent, rule string) error {
+	var ruleValue interface{}
+	if len(rule) > 0 {
+		ruleValue = rule
+	}
+	_, err := qu.db.Exec(`BEGIN
+	DBMS_AQADM.ADD_SUBSCRIBER(queue_name => :1, subscriber => SYS.AQ$_AGENT(:2, :3, :4), rule => :5);
+END;`, qu.Name, subscriber.Name, agentAddress(subscriber), int(subscriber.Protocol), ruleValue)
+	return err
+}
+
+func (qu *queue) RemoveSubscriber(subscriber Agent) error {
+	_, err := qu.db.Exec(`BEGIN
+	DBMS_AQADM.REMOVE_SUBSCRIBER(queue_name => :1, subscriber => SYS.AQ$_AGENT(:2, :3, :4));
+END;`, qu.Name, subscriber.Name, agentAddress(subscriber), int(subscriber.Protocol))
+	return err
+}
+
+func agentAddress(agent Agent) interface{} {
+	if len(agent.Address) == 0 {
+		return nil
+	}
+	return agent.Address
+}
+
 func (qu *queue) NewMessage(data interface{}) (*Message, error) {
 	var err error
 	message := &Message{
 		messageID:        nil,
 		Delay:            0,
 		Expiration:       -1,
-		correlation:      "",
-		exceptionQueue:   "",
+		Correlation:      "",
+		ExceptionQueue:   "",
 		deqAttempts:      0,
 		DeliveryMode:     DeliveryModePersistent,
 		VisibilityMode:   VisibilityOnCommit,
-		recipients:       nil,
-		sender:           OracleAgent{},
+		Recipients:       nil,
+		Sender:           Agent{},
 		EnqTime:          time.Time{},
 		State:            0,
 		Priority:         0,
 		ID:               "",
 		propModified:     false,
 		shareNum:         0xFFFF,
-		senderName:       "",
-		senderAddress:    "",
-		senderProtocol:   0,
 		transactionGroup: "",
 		Payload:          data,
 		extensions:       nil,
 	}
+	if qu.messageType.usePLSQL() {
+		// payload is constructed by DBMS_AQ on enqueue
+		return message, qu.messageType.validatePayload(data)
+	}
 	var encoder parameter_coder.OracleParameterCoder
 	switch qu.messageType {
 	case RAW:
@@ -120,6 +159,16 @@ func (qu *queue) NewMessage(data interface{}) (*Message, error) {
 	return message, err
 }
 func (qu *queue) Enqueue(message *Message) error {
+	if qu.messageType.usePLSQL() {
+		return qu.enqueuePLSQL(context.Background(), message)
+	}
+	if len(message.Recipients) > 0 {
+		// native recipient list layout is not verified
+		if qu.messageType != RAW {
+			return errors.New("aq: recipient list is supported only for RAW, JMS and ANYDATA queues")
+		}
+		return qu.enqueuePLSQL(context.Background(), message)
+	}
 	session := qu.conn.GetSession()
 	session.ResetBuffer()
 	session.PutTTCFunc(0x3, 0x79)
@@ -133,12 +182,7 @@ func (qu *queue) Enqueue(message *Message) error {
 	}
 	// message.marshal()
 	message.write(qu.conn)
-	if len(message.recipients) > 0 {
-		session.PutBytes(1)
-		session.PutInt(len(message.recipients)*3, 2, true, true)
-	} else {
-		session.PutBytes(0, 0)
-	}
+	session.PutBytes(0, 0)
 	session.PutInt(int(message.VisibilityMode), 4, true, true)
 	if len(message.messageID) > 0 {
 		session.PutBytes(1)
@@ -199,9 +243,6 @@ func (qu *queue) Enqueue(message *Message) error {
 	if len(queueNameBytes) > 0 {
 		session.PutCHR(queueNameBytes)
 	}
-	for _, recipient := range message.recipients {
-		session.PutKeyValString(recipient.Key, recipient.Value, recipient.Num)
-	}
 	if len(message.messageID) > 0 {
 		session.PutBytes(message.messageID...)
 	}
@@ -239,6 +280,9 @@ func (qu *queue) Enqueue(message *Message) error {
 }
 
 func (qu *queue) Dequeue(options *DequeueOptions) (*Message, error) {
+	if qu.messageType.usePLSQL() {
+		return qu.dequeuePLSQL(context.Background(), options)
+	}
 	outMsg := &Message{}
 	session := qu.conn.GetSession()
 	session.ResetBuffer()
@@ -262,9 +306,9 @@ func (qu *queue) Dequeue(options *DequeueOptions) (*Message, error) {
 	session.PutInt(int(options.Navigation), 4, true, true)
 	session.PutInt(int(options.Visibility), 4, true, true)
 	session.PutInt(options.Wait, 4, true, true)
-	if len(outMsg.messageID) > 0 {
+	if len(options.MessageID) > 0 {
 		session.PutBytes(1)
-		session.PutInt(len(outMsg.messageID), 2, true, true)
+		session.PutInt(len(options.MessageID), 2, true, true)
 	} else {
 		session.PutBytes(0, 0)
 	}
@@ -322,8 +366,8 @@ func (qu *queue) Dequeue(options *DequeueOptions) (*Message, error) {
 	if len(consumer) > 0 {
 		session.PutClr(consumer)
 	}
-	if len(outMsg.messageID) > 0 {
-		session.PutBytes(outMsg.messageID...)
+	if len(options.MessageID) > 0 {
+		session.PutBytes(options.MessageID...)
 	}
 	if len(correlation) > 0 {
 		session.PutClr(correlation)
@@ -343,6 +387,16 @@ func (qu *queue) Dequeue(options *DequeueOptions) (*Message, error) {
 	return outMsg, err
 }
 
+// DequeueContext dequeue message and break the wait when ctx is done
+func (qu *queue) DequeueContext(ctx context.Context, options *DequeueOptions) (*Message, error) {
+	if qu.messageType.usePLSQL() {
+		return qu.dequeuePLSQL(ctx, options)
+	}
+	done := qu.conn.StartContext(ctx)
+	defer qu.conn.EndContext(done)
+	return qu.Dequeue(options)
+}
+
 func (qu *queue) readEnqueueResponse(message *Message) error {
 	session := qu.conn.GetSession()
 	loop := true
@@ -0,0 +1,96 @@
+package aq_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sijms/go-ora/v3/aq"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+// committedEnqueues return number of enqueue calls followed by commit
+func committedEnqueues(server *oratest.Server) int {
+	committed, pending := 0, 0
+	for _, stmt := range server.Statements() {
+		switch {
+		case strings.Contains(stmt, "DBMS_AQ.ENQUEUE"):
+			pending++
+			if strings.Contains(stmt, "COMMIT;") {
+				committed += pending
+				pending = 0
+			}
+		case stmt == "COMMIT":
+			committed += pending
+			pending = 0
+		case stmt == "ROLLBACK":
+			pending = 0
+		}
+	}
+	return committed
+}
+
+func newEnqueueServer(t *testing.T) *oratest.Server {
+	server := newServer(t)
+	server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+		if !strings.Contains(req.SQL, "DBMS_AQ.ENQUEUE") {
+			return nil
+		}
+		return dequeueResult(req.SQL, "")
+	})
+	return server
+}
+
+func TestEnqueueRollback(t *testing.T) {
+	server := newEnqueueServer(t)
+	db := openDB(t, server.URL(nil))
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue, err := aq.CreateQueue(tx, "TEXT_Q", aq.JMSText, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := queue.NewMessage("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = queue.Enqueue(message); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	statements := server.Statements()
+	if len(statements) == 0 || statements[len(statements)-1] != "ROLLBACK" {
+		t.Errorf("expected enqueue to be rolled back got: %v", statements)
+	}
+	if committed := committedEnqueues(server); committed != 0 {
+		t.Errorf("expected no committed enqueue after rollback got: %d", committed)
+	}
+}
+
+func TestEnqueueDB(t *testing.T) {
+	server := newEnqueueServer(t)
+	db := openDB(t, server.URL(nil))
+	queue, err := aq.CreateQueue(db, "TEXT_Q", aq.JMSText, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := queue.NewMessage("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pooled session can't hold the transaction
+	if err = queue.Enqueue(message); err == nil {
+		t.Fatal("expected error for *sql.DB queue without auto commit")
+	}
+	queue.SetAutoCommit(true)
+	if err = queue.Enqueue(message); err != nil {
+		t.Fatal(err)
+	}
+	if committed := committedEnqueues(server); committed != 1 {
+		t.Errorf("expected 1 committed enqueue got: %d", committed)
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/sijms/go-ora/v3/network"
 	"github.com/sijms/go-ora/v3/network/security"
+	"github.com/sijms/go-ora/v3/ttc"
 )
 
 // E infront of the variable means encrypted
@@ -56,22 +57,21 @@ type AuthObject struct {
 func (obj *AuthObject) read() error {
 	loop := true
 	session := obj.conn.session
+	decoder := ttc.NewDecoder(session, session.Capabilities())
+	decoder.Call = ttc.CallAuthPhaseOne
 	for loop {
 		messageCode, err := session.GetByte()
 		if err != nil {
 			return err
 		}
 		switch messageCode {
-		case 8:
-			dictLen, err := session.GetInt(4, true, true)
+		case ttc.MsgReturnParameters:
+			msg, err := decoder.Decode(messageCode)
 			if err != nil {
 				return err
 			}
-			for x := 0; x < dictLen; x++ {
-				key, val, num, err := session.GetKeyVal()
-				if err != nil {
-					return err
-				}
+			for _, kv := range msg.(*ttc.ReturnParameters).KeyValues {
+				key, val, num := kv.Key, kv.Value, kv.Num
 				if bytes.Equal(key, []byte("AUTH_SESSKEY")) {
 					if len(obj.EServerSessKey) == 0 {
 						obj.EServerSessKey = string(val)
@@ -307,21 +307,51 @@ func (obj *AuthObject) Write() error {
 		fmt.Sprintf("ALTER SESSION SET NLS_LANGUAGE='%s' NLS_TERRITORY='%s'  TIME_ZONE='%s'\x00",
 			connOption.Language, connOption.Territory, tz), 1)
 	index++
+	if len(connOption.ApplicationEdition) > 0 {
+		appendKeyVal("AUTH_ORA_EDITION", connOption.ApplicationEdition, 0)
+		index++
+	}
 	if len(connOption.ProxyClientName) > 0 {
 		appendKeyVal("PROXY_CLIENT_NAME", connOption.ProxyClientName, 0)
 		index++
 	}
+	if len(connOption.ProxyClientDN) > 0 {
+		appendKeyVal("PROXY_CLIENT_DN", connOption.ProxyClientDN, 0)
+		index++
+	}
+	if len(connOption.ProxyClientCertificate) > 0 {
+		appendKeyVal("PROXY_CLIENT_CERT", strings.ToUpper(hex.EncodeToString(connOption.ProxyClientCertificate)), 0)
+		index++
+	}
+	if connOption.IsProxy() && len(connOption.ProxyClientPassword) > 0 {
+		// client password is encrypted with the same key as proxy user password
+		if len(obj.conn.newKey) == 0 {
+			return errors.New("proxy client password require proxy user authenticated by password")
+		}
+		ePassword, err := encryptPassword([]byte(connOption.ProxyClientPassword), obj.conn.newKey, true)
+		if err != nil {
+			return err
+		}
+		appendKeyVal("PROXY_CLIENT_PASSWORD", ePassword, 0)
+		index++
+	}
 	if len(obj.conn.token) > 0 {
-		addr := obj.conn.connOption.GetActiveServer(false)
-		if addr != nil {
+		mode |= IAMToken
+		appendKeyVal("AUTH_TOKEN", string(obj.conn.token), 0)
+		index++
+		// IAM database token require proof of possession: sign header with private key
+		// OAuth2 bearer token is sent alone
+		if len(obj.conn.tokenPrivateKey) > 0 {
+			addr := obj.conn.connOption.GetActiveServer(false)
+			if addr == nil {
+				return errors.New("no active server to generate token header")
+			}
 			serviceName := obj.conn.connOption.ServiceName
 			header := generateTokenHeader(addr.Addr, serviceName, addr.Port)
 			signature, err := signTokenHeader(header, obj.conn.tokenPrivateKey)
 			if err != nil {
 				return err
 			}
-			appendKeyVal("AUTH_TOKEN", string(obj.conn.token), 0)
-			index++
 			appendKeyVal("AUTH_HEADER", header, 0)
 			index++
 			appendKeyVal("AUTH_SIGNATURE", signature, 0)
@@ -0,0 +1,262 @@
+// Package capture read and write binary captures of the TNS packets exchanged
+// between the driver and the server.
+//
+// a capture is written when the connection string contains CAPTURE FILE or
+// CAPTURE DIR option. unlike the trace file it keep the exact bytes of each
+// packet with its direction, time and network connection id so it can be
+// replayed (see ReplayDialer and ServerSession) or decoded with oracap tool.
+//
+// packets are captured above TLS and below native network encryption so
+// sessions that use native encryption can't be decoded or replayed.
+//
+// file format (all integers are big endian):
+//
+//	header: "OCAP" magic, uint16 version, uint16 reserved
+//	record: uint64 unix time in nano seconds, uint32 connection id,
+//	        uint8 direction, uint32 packet length, packet bytes
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+const (
+	magic   = "OCAP"
+	version = 1
+	// maxPacketSize is the largest packet accepted by the reader
+	maxPacketSize = 0x10000000
+)
+
+type Direction uint8
+
+const (
+	// Sent is a packet written by the client
+	Sent Direction = 1
+	// Received is a packet read from the server
+	Received Direction = 2
+)
+
+func (dir Direction) String() string {
+	switch dir {
+	case Sent:
+		return "sent"
+	case Received:
+		return "received"
+	default:
+		return fmt.Sprintf("direction(%d)", uint8(dir))
+	}
+}
+
+// Record is a captured packet
+type Record struct {
+	Time      time.Time
+	ConnID    uint32
+	Direction Direction
+	Packet    []byte
+}
+
+// PacketType return TNS packet type
+func (rec *Record) PacketType() network.PacketType {
+	if len(rec.Packet) < 5 {
+		return 0
+	}
+	return network.PacketType(rec.Packet[4])
+}
+
+// DataFlag return data flag of DATA packet
+func (rec *Record) DataFlag() uint16 {
+	if rec.PacketType() != network.DATA || len(rec.Packet) < 10 {
+		return 0
+	}
+	return binary.BigEndian.Uint16(rec.Packet[8:])
+}
+
+// Payload return TTC data carried by DATA packet and nil for other packets
+func (rec *Record) Payload() []byte {
+	if rec.PacketType() != network.DATA || len(rec.Packet) < 10 {
+		return nil
+	}
+	return rec.Packet[10:]
+}
+
+// Writer write capture records. it is safe for concurrent use and implement
+// network.PacketRecorder
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewWriter write capture header into w and return writer of the records
+func NewWriter(w io.Writer) (*Writer, error) {
+	header := make([]byte, 8)
+	copy(header, magic)
+	binary.BigEndian.PutUint16(header[4:], version)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// Create create capture file in the path
+func Create(path string) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewWriter(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+// WriteRecord write the record. each record is written with single call to the
+// underlying writer
+func (w *Writer) WriteRecord(rec Record) error {
+	buffer := make([]byte, 17, 17+len(rec.Packet))
+	binary.BigEndian.PutUint64(buffer, uint64(rec.Time.UnixNano()))
+	binary.BigEndian.PutUint32(buffer[8:], rec.ConnID)
+	buffer[12] = uint8(rec.Direction)
+	binary.BigEndian.PutUint32(buffer[13:], uint32(len(rec.Packet)))
+	buffer = append(buffer, rec.Packet...)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	_, w.err = w.w.Write(buffer)
+	return w.err
+}
+
+// RecordPacket write the packet with current time. write errors are kept and
+// returned by Err and Close
+func (w *Writer) RecordPacket(connID uint32, sent bool, packet []byte) {
+	rec := Record{Time: time.Now(), ConnID: connID, Direction: Received, Packet: packet}
+	if sent {
+		rec.Direction = Sent
+	}
+	_ = w.WriteRecord(rec)
+}
+
+// Err return the first write error
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close close the underlying writer if it is io.Closer and return the first
+// write error
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if closer, ok := w.w.(io.Closer); ok {
+		if err := closer.Close(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	err := w.err
+	if w.err == nil {
+		w.err = errors.New("capture: writer is closed")
+	}
+	return err
+}
+
+// Reader read capture records
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader read and check capture header from r
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := bufio.NewReader(r)
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("capture: can't read header: %w", err)
+	}
+	if string(header[:4]) != magic {
+		return nil, errors.New("capture: invalid file format")
+	}
+	if ver := binary.BigEndian.Uint16(header[4:]); ver != version {
+		return nil, fmt.Errorf("capture: unsupported version: %d", ver)
+	}
+	return &Reader{r: reader}, nil
+}
+
+// Next return next record. io.EOF is returned at the end of capture
+func (r *Reader) Next() (*Record, error) {
+	head := make([]byte, 17)
+	if _, err := io.ReadFull(r.r, head); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("capture: truncated record: %w", err)
+		}
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(head[13:])
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("capture: invalid packet length: %d", length)
+	}
+	rec := &Record{
+		Time:      time.Unix(0, int64(binary.BigEndian.Uint64(head))),
+		ConnID:    binary.BigEndian.Uint32(head[8:]),
+		Direction: Direction(head[12]),
+		Packet:    make([]byte, length),
+	}
+	if _, err := io.ReadFull(r.r, rec.Packet); err != nil {
+		return nil, fmt.Errorf("capture: truncated record: %w", err)
+	}
+	return rec, nil
+}
+
+// ReadAll return all records of the capture
+func (r *Reader) ReadAll() ([]Record, error) {
+	var output []Record
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return output, nil
+		}
+		if err != nil {
+			return output, err
+		}
+		output = append(output, *rec)
+	}
+}
+
+// ReadFile return all records of capture file
+func ReadFile(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return reader.ReadAll()
+}
+
+// Connections return ids of network connections in the order of their first packet
+func Connections(records []Record) []uint32 {
+	var output []uint32
+	seen := make(map[uint32]bool)
+	for _, rec := range records {
+		if !seen[rec.ConnID] {
+			seen[rec.ConnID] = true
+			output = append(output, rec.ConnID)
+		}
+	}
+	return output
+}
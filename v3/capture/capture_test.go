@@ -0,0 +1,133 @@
+package capture_test
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/capture"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func TestWriterReader(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := capture.NewWriter(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []capture.Record{
+		{Time: time.Unix(0, 1000), ConnID: 1, Direction: capture.Sent, Packet: []byte{0, 10, 0, 0, 1, 0, 0, 0, 1, 2}},
+		{Time: time.Unix(0, 2000), ConnID: 1, Direction: capture.Received, Packet: []byte{0, 0, 0, 12, 6, 0, 0, 0, 0, 0, 8, 9}},
+		{Time: time.Unix(0, 3000), ConnID: 2, Direction: capture.Received, Packet: []byte{}},
+	}
+	for _, rec := range records {
+		if err = writer.WriteRecord(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reader, err := capture.NewReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("expected %d records got: %d", len(records), len(got))
+	}
+	for i := range records {
+		if !got[i].Time.Equal(records[i].Time) || got[i].ConnID != records[i].ConnID ||
+			got[i].Direction != records[i].Direction || !bytes.Equal(got[i].Packet, records[i].Packet) {
+			t.Errorf("record %d: expected %+v got: %+v", i, records[i], got[i])
+		}
+	}
+	if payload := got[1].Payload(); !bytes.Equal(payload, []byte{8, 9}) {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+	if ids := capture.Connections(got); !reflect.DeepEqual(ids, []uint32{1, 2}) {
+		t.Errorf("unexpected connections: %v", ids)
+	}
+	if _, err = reader.Next(); err != io.EOF {
+		t.Errorf("expected EOF got: %v", err)
+	}
+	if _, err = capture.NewReader(bytes.NewReader([]byte("NOTACAPTURE"))); err == nil {
+		t.Error("expected error for invalid header")
+	}
+}
+
+func queryNames(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query("SELECT NAME FROM EMP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestCaptureReplay(t *testing.T) {
+	server, err := oratest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.Handle("SELECT NAME FROM EMP", oratest.RowsResult([]string{"NAME"},
+		[]interface{}{"KING"}, []interface{}{"BLAKE"}))
+	path := filepath.Join(t.TempDir(), "session.ocap")
+	db, err := sql.Open("oracle", server.URL(map[string]string{"CAPTURE FILE": path}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := queryNames(t, db)
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := capture.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) == 0 || records[0].PacketType() != network.CONNECT || records[0].Direction != capture.Sent {
+		t.Fatalf("capture doesn't start with connect packet: %d records", len(records))
+	}
+	conns := capture.Connections(records)
+	if len(conns) != 1 {
+		t.Fatalf("expected one connection got: %v", conns)
+	}
+	// first message received after accept is protocol negotiation
+	session := capture.ServerSession(records, conns[0], network.SessionProperties{ClrChunkSize: 0x40})
+	if code, err := session.GetByte(); err != nil || code != 1 {
+		t.Errorf("expected protocol negotiation message got: %d, %v", code, err)
+	}
+
+	// replay without the server
+	_ = server.Close()
+	dialer := capture.NewReplayDialer(records)
+	connector := go_ora.NewConnector(server.URL(nil)).(*go_ora.OracleConnector)
+	connector.Dialer(dialer)
+	db = sql.OpenDB(connector)
+	defer db.Close()
+	if got := queryNames(t, db); !reflect.DeepEqual(got, expected) {
+		t.Errorf("replay expected %v got: %v", expected, got)
+	}
+	if dialer.Remaining() != 0 {
+		t.Errorf("expected all connections replayed, remaining: %d", dialer.Remaining())
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+/*  oracap print packets of go-ora capture file (CAPTURE FILE / CAPTURE DIR options)
+
+usage: oracap [-conn id] [-hex] [-decode] capture.ocap
+
+-decode print the messages of each server packet decoded with ttc package. the
+session capabilities are taken from the captured negotiation
+
+*/
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sijms/go-ora/v3/capture"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/ttc"
+)
+
+var packetNames = map[network.PacketType]string{
+	network.CONNECT:  "CONNECT",
+	network.ACCEPT:   "ACCEPT",
+	network.ACK:      "ACK",
+	network.REFUSE:   "REFUSE",
+	network.REDIRECT: "REDIRECT",
+	network.DATA:     "DATA",
+	network.NULL:     "NULL",
+	network.ABORT:    "ABORT",
+	network.RESEND:   "RESEND",
+	network.MARKER:   "MARKER",
+	network.ATTN:     "ATTN",
+	network.CTRL:     "CTRL",
+}
+
+var markerNames = map[uint8]string{
+	1: "break",
+	2: "reset",
+	3: "interrupt",
+}
+
+func main() {
+	connID := flag.Uint("conn", 0, "print only packets of this connection id")
+	dump := flag.Bool("hex", false, "hex dump packet bytes")
+	decode := flag.Bool("decode", false, "decode messages of server packets")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintln(os.Stderr, "usage: oracap [-conn id] [-hex] [-decode] capture.ocap")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	records, err := capture.ReadFile(flag.Arg(0))
+	decoders := make(map[uint32]*connDecoder)
+	for index, rec := range records {
+		if *connID != 0 && rec.ConnID != uint32(*connID) {
+			continue
+		}
+		fmt.Printf("#%d %s conn=%d %-8s %s\n", index+1, rec.Time.Format("15:04:05.000000"),
+			rec.ConnID, rec.Direction, describe(&rec))
+		if *dump {
+			fmt.Print(hex.Dump(rec.Packet))
+		}
+		if *decode {
+			decoder, ok := decoders[rec.ConnID]
+			if !ok {
+				decoder = &connDecoder{}
+				decoders[rec.ConnID] = decoder
+			}
+			for _, line := range decoder.process(&rec) {
+				fmt.Println("    " + line)
+			}
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// describe return packet type, length and the first TTC message of data packets
+func describe(rec *capture.Record) string {
+	pckType := rec.PacketType()
+	name, ok := packetNames[pckType]
+	if !ok {
+		name = fmt.Sprintf("TYPE(%d)", pckType)
+	}
+	output := fmt.Sprintf("%s len=%d", name, len(rec.Packet))
+	switch pckType {
+	case network.DATA:
+		if dataFlag := rec.DataFlag(); dataFlag != 0 {
+			output += fmt.Sprintf(" flag=0x%X", dataFlag)
+		}
+		if payload := rec.Payload(); len(payload) > 0 {
+			output += " " + describeMessage(rec.Direction, payload)
+		}
+	case network.MARKER:
+		if len(rec.Packet) > 10 {
+			output += " " + lookup(markerNames, rec.Packet[10])
+		}
+	case network.REDIRECT, network.REFUSE:
+		if len(rec.Packet) > 10 {
+			output += " " + strings.TrimRight(string(rec.Packet[10:]), "\x00")
+		}
+	}
+	return output
+}
+
+func describeMessage(dir capture.Direction, payload []byte) string {
+	code := payload[0]
+	output := fmt.Sprintf("msg=%d (%s)", code, ttc.MessageName(code))
+	if dir == capture.Sent && (code == ttc.MsgFunction || code == ttc.MsgPiggyback) && len(payload) > 1 {
+		output += fmt.Sprintf(" func=0x%X (%s)", payload[1], ttc.FunctionName(payload[1]))
+	}
+	return output
+}
+
+func lookup(names map[uint8]string, code uint8) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// connDecoder decode server packets of one connection. the capabilities are
+// collected from the negotiation packets and the call from the function sent
+// before the response
+type connDecoder struct {
+	clientTTCVersion uint8
+	protocol         *ttc.Protocol
+	decoder          *ttc.Decoder
+	call             ttc.Call
+}
+
+func (cd *connDecoder) process(rec *capture.Record) []string {
+	payload := rec.Payload()
+	if len(payload) == 0 {
+		return nil
+	}
+	if rec.Direction == capture.Sent {
+		switch payload[0] {
+		case ttc.MsgDataTypes:
+			// compile time caps start at index 7 and TTC version is the 8th
+			if len(payload) > 14 {
+				cd.clientTTCVersion = payload[14]
+			}
+		case ttc.MsgFunction:
+			if len(payload) > 1 {
+				cd.call = ttc.CallOf(payload[1])
+			}
+		}
+		return nil
+	}
+	var caps network.TTCCapabilities
+	if cd.protocol != nil {
+		caps = cd.protocol.Capabilities(cd.clientTTCVersion)
+	}
+	prop := network.SessionProperties{ClrChunkSize: 0x40}
+	if caps.UseBigClrChunks {
+		prop.UseBigClrChunks = true
+		prop.ClrChunkSize = 0x7FFF
+	}
+	session := network.NewMemorySession(payload, nil, prop)
+	switch payload[0] {
+	case ttc.MsgProtocol:
+		msg, err := ttc.NewDecoder(session, caps).Next()
+		if err != nil {
+			return []string{"error: " + err.Error()}
+		}
+		cd.protocol = msg.(*ttc.Protocol)
+		return []string{describeDecoded(msg)}
+	case ttc.MsgDataTypes:
+		// data type negotiation answer end the negotiation
+		cd.decoder = ttc.NewDecoder(session, caps)
+		return nil
+	}
+	if cd.decoder == nil {
+		cd.decoder = ttc.NewDecoder(session, caps)
+	}
+	cd.decoder.Reset(session)
+	cd.decoder.Call = cd.call
+	var output []string
+	for {
+		if _, err := session.Peek(); err != nil {
+			return output
+		}
+		msg, err := cd.decoder.Next()
+		if err != nil {
+			// the rest of the packet can't be decoded
+			return append(output, "error: "+err.Error())
+		}
+		output = append(output, describeDecoded(msg))
+	}
+}
+
+func describeDecoded(msg ttc.Message) string {
+	output := ttc.MessageName(msg.Code())
+	switch msg := msg.(type) {
+	case *ttc.Protocol:
+		output += fmt.Sprintf(": %s charset=%d ncharset=%d", msg.Banner, msg.Charset, msg.NCharset)
+	case *ttc.Error:
+		output += fmt.Sprintf(": code=%d cursor=%d", msg.Summary.RetCode, msg.Summary.CursorID)
+		if len(msg.Summary.ErrorMessage) > 0 {
+			output += fmt.Sprintf(" %q", strings.TrimSpace(string(msg.Summary.ErrorMessage)))
+		}
+	case *ttc.RowHeader:
+		output += fmt.Sprintf(": columns=%d rows=%d", msg.ColumnCount, msg.RowCount)
+	case *ttc.RowData:
+		output += fmt.Sprintf(": %x", msg.Values)
+	case *ttc.ReturnParameters:
+		if len(msg.Banner) > 0 {
+			output += fmt.Sprintf(": %q number=0x%X", msg.Banner, msg.Number)
+		} else {
+			output += fmt.Sprintf(": values=%v keys=%d", msg.Values, len(msg.KeyValues))
+		}
+	case *ttc.Status:
+		output += fmt.Sprintf(": end of call=%d", msg.EndOfCallStatus)
+	case *ttc.IOVector:
+		output += fmt.Sprintf(": directions=%v", msg.Directions)
+	case *ttc.Warning:
+		output += ": " + msg.String()
+	case *ttc.Describe:
+		names := make([]string, len(msg.Columns))
+		for x, col := range msg.Columns {
+			names[x] = fmt.Sprintf("%s(%d)", col.Name, col.DataType)
+		}
+		output += ": " + strings.Join(names, ", ")
+	case *ttc.BitVector:
+		output += fmt.Sprintf(": sent=%d % X", msg.ColumnsSent, msg.BitVector)
+	case *ttc.ServerPiggyback:
+		output += fmt.Sprintf(": opcode=%d", msg.OpCode)
+	}
+	return output
+}
@@ -0,0 +1,127 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+// Stream return TTC data of DATA packets sent in the direction on the connection
+// joined in order. it is the input the driver parse for received direction
+func Stream(records []Record, connID uint32, dir Direction) []byte {
+	var buffer bytes.Buffer
+	for i := range records {
+		if records[i].ConnID == connID && records[i].Direction == dir {
+			buffer.Write(records[i].Payload())
+		}
+	}
+	return buffer.Bytes()
+}
+
+// ServerSession return memory session that read TTC data received from the
+// server on the connection. it is used to re-run message decoding of captured
+// responses without network
+func ServerSession(records []Record, connID uint32, prop network.SessionProperties) *network.MemorySession {
+	return network.NewMemorySession(Stream(records, connID, Received), nil, prop)
+}
+
+// ReplayDialer replay the server side of captured connections. each dial return
+// the next captured connection that answer the client with the captured server
+// packets whatever the client send. use it with OracleConnector.Dialer to re-run
+// a driver session deterministically:
+//
+//	connector.Dialer(capture.NewReplayDialer(records))
+//
+// the client must run the same calls of the captured session
+type ReplayDialer struct {
+	mu    sync.Mutex
+	conns [][]byte
+	index int
+}
+
+// NewReplayDialer create dialer for the connections of the records in the order
+// of their first packet
+func NewReplayDialer(records []Record) *ReplayDialer {
+	dialer := &ReplayDialer{}
+	for _, connID := range Connections(records) {
+		var buffer bytes.Buffer
+		for i := range records {
+			if records[i].ConnID == connID && records[i].Direction == Received {
+				buffer.Write(records[i].Packet)
+			}
+		}
+		dialer.conns = append(dialer.conns, buffer.Bytes())
+	}
+	return dialer
+}
+
+// DialContext return next captured connection. error is returned when all
+// captured connections are used
+func (dialer *ReplayDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+	if dialer.index >= len(dialer.conns) {
+		return nil, errors.New("capture: no more captured connections to replay")
+	}
+	conn := &replayConn{input: bytes.NewReader(dialer.conns[dialer.index])}
+	dialer.index++
+	return conn, nil
+}
+
+// Remaining return number of captured connections that are not dialed yet
+func (dialer *ReplayDialer) Remaining() int {
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+	return len(dialer.conns) - dialer.index
+}
+
+// replayConn is net.Conn that read captured server packets and discard writes
+type replayConn struct {
+	mu     sync.Mutex
+	input  *bytes.Reader
+	closed bool
+}
+
+func (conn *replayConn) Read(b []byte) (int, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.closed {
+		return 0, net.ErrClosed
+	}
+	return conn.input.Read(b)
+}
+
+func (conn *replayConn) Write(b []byte) (int, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.closed {
+		return 0, net.ErrClosed
+	}
+	return len(b), nil
+}
+
+func (conn *replayConn) Close() error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.closed = true
+	return nil
+}
+
+func (conn *replayConn) LocalAddr() net.Addr                { return replayAddr{} }
+func (conn *replayConn) RemoteAddr() net.Addr               { return replayAddr{} }
+func (conn *replayConn) SetDeadline(t time.Time) error      { return nil }
+func (conn *replayConn) SetReadDeadline(t time.Time) error  { return nil }
+func (conn *replayConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
@@ -5,7 +5,6 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"reflect"
@@ -19,6 +18,7 @@ import (
 
 	"github.com/sijms/go-ora/v3/configurations"
 	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/ttc"
 )
 
 type StmtType int
@@ -743,6 +743,8 @@ func (stmt *defaultStmt) read(resultSet *ResultSet) (err error) {
 	resultSet.parent = stmt
 	resultSet.cols = &stmt.columns
 	session := stmt.connection.session
+	decoder := ttc.NewDecoder(session, session.Capabilities())
+	decoder.StrConv = session.StrConv
 	defer func() {
 		if session.Summary != nil {
 			stmt.cursorID = session.Summary.CursorID
@@ -856,69 +858,19 @@ func (stmt *defaultStmt) read(resultSet *ResultSet) (err error) {
 				}
 			}
 		case 8:
-			size, err := session.GetInt(2, true, true)
+			decoder.ArrayRowCounts = stmt.stmtType == DML && stmt.arrayBindCount > 0
+			msg, err := decoder.Decode(msg)
 			if err != nil {
 				return err
 			}
-			for x := 0; x < 2; x++ {
-				stmt.scnForSnapshot[x], err = session.GetInt(4, true, true)
-				if err != nil {
-					return err
-				}
-			}
-			for x := 2; x < size; x++ {
-				_, err = session.GetInt(4, true, true)
-				if err != nil {
-					return err
-				}
+			rpa := msg.(*ttc.ReturnParameters)
+			for x := 0; x < 2 && x < len(rpa.Values); x++ {
+				stmt.scnForSnapshot[x] = rpa.Values[x]
 			}
-			_, err = session.GetInt(2, true, true)
-			if err != nil {
-				return err
-			}
-			size, err = session.GetInt(2, true, true)
-			for x := 0; x < size; x++ {
-				_, val, num, err := session.GetKeyVal()
-				if err != nil {
-					return err
-				}
-				// fmt.Println(key, val, num)
-				if num == 163 {
-					session.TimeZone = val
-					// fmt.Println("session time zone", session.TimeZone)
-				}
-			}
-			if session.TTCVersion >= 4 {
-				// get queryID
-				size, err = session.GetInt(4, true, true)
-				if err != nil {
-					return err
-				}
-				if size > 0 {
-					bty, err := session.GetBytes(size)
-					if err != nil {
-						return err
-					}
-					if len(bty) >= 8 {
-						stmt.queryID = binary.LittleEndian.Uint64(bty[size-8:])
-						fmt.Println("query ID: ", stmt.queryID)
-					}
-				}
-			}
-			if session.TTCVersion >= 7 && stmt.stmtType == DML && stmt.arrayBindCount > 0 {
-				length, err := session.GetInt(4, true, true)
-				if err != nil {
-					return err
-				}
-				// for (int index = 0; index < length3; ++index)
-				//	rowsAffectedByArrayBind[index] = this.m_marshallingEngine.UnmarshalSB8();
-				for i := 0; i < length; i++ {
-					_, err = session.GetInt(8, true, true)
-					if err != nil {
-						return err
-					}
-				}
+			if rpa.QueryID != 0 {
+				stmt.queryID = rpa.QueryID
 			}
+			stmt.connection.applyReturnParameters(rpa)
 		case 11:
 			err = resultSet.load(session)
 			if err != nil {
@@ -942,31 +894,16 @@ func (stmt *defaultStmt) read(resultSet *ResultSet) (err error) {
 				}
 			}
 		case 16:
-			size, err := session.GetByte()
-			if err != nil {
-				return err
-			}
-			_, err = session.GetBytes(int(size))
+			msg, err := decoder.Decode(msg)
 			if err != nil {
 				return err
 			}
-			resultSet.maxRowSize, err = session.GetInt(4, true, true)
-			if err != nil {
-				return err
-			}
-			resultSet.columnCount, err = session.GetInt(4, true, true)
-			if err != nil {
-				return err
-			}
-			if resultSet.columnCount > 0 {
-				_, err = session.GetByte() // session.GetInt(1, false, false)
-			}
+			describe := msg.(*ttc.Describe)
+			resultSet.maxRowSize = describe.MaxRowSize
+			resultSet.columnCount = len(describe.Columns)
 			stmt.columns = make([]ParameterInfo, resultSet.columnCount)
-			for x := 0; x < resultSet.columnCount; x++ {
-				err = stmt.columns[x].load(stmt.connection)
-				if err != nil {
-					return err
-				}
+			for x := range describe.Columns {
+				stmt.columns[x].fromColumn(stmt.connection, &describe.Columns[x])
 				if stmt.columns[x].isLongType() {
 					stmt._hasLONG = true
 				}
@@ -974,18 +911,6 @@ func (stmt *defaultStmt) read(resultSet *ResultSet) (err error) {
 					stmt._hasBLOB = true
 				}
 			}
-			_, err = session.GetDlc()
-			if session.TTCVersion >= 3 {
-				_, err = session.GetInt(4, true, true)
-				_, err = session.GetInt(4, true, true)
-			}
-			if session.TTCVersion >= 4 {
-				_, err = session.GetInt(4, true, true)
-				_, err = session.GetInt(4, true, true)
-			}
-			if session.TTCVersion >= 5 {
-				_, err = session.GetDlc()
-			}
 		case 19:
 			session.ResetBuffer()
 			session.PutBytes(19)
@@ -2213,6 +2138,18 @@ func (stmt *defaultStmt) decodePrim(resultSet *ResultSet) error {
 						}
 					}
 				}
+			case *oraTypes.XmlType:
+				// inline documents are always returned as string
+				if !val.IsLob() || val.GetReadMode() == configurations.LobReadMode_AUTO {
+					err = val.Read(context.Background())
+					if err != nil {
+						return err
+					}
+					resultSet.rows[rowIndex][colIndex], err = val.Value()
+					if err != nil {
+						return err
+					}
+				}
 			case *oraTypes.Clob:
 				if val.GetReadMode() == configurations.LobReadMode_AUTO {
 					err = val.Read(context.Background())
@@ -32,12 +32,36 @@ type KerberosAuthInterface interface {
 	Authenticate(server, service string) ([]byte, error)
 }
 
+// RadiusChallengeHandler is called when RADIUS server reply with access challenge
+// (e.g. one-time code). prompt is the message sent by the server and the returned
+// value is the user response
+type RadiusChallengeHandler interface {
+	Respond(prompt string) (string, error)
+}
+
+// RadiusChallengeFunc is an adapter to use ordinary function as RadiusChallengeHandler
+type RadiusChallengeFunc func(prompt string) (string, error)
+
+func (f RadiusChallengeFunc) Respond(prompt string) (string, error) {
+	return f(prompt)
+}
+
 type AdvNegoServiceInfo struct {
 	AuthService     []string
 	EncServiceLevel int
 	IntServiceLevel int
 	// Kerberos is an optional session-specific auth, which will be preferred over the global interface if present.
 	Kerberos KerberosAuthInterface
+	// RadiusUser and RadiusPassword are credentials sent to RADIUS server (AUTH TYPE=RADIUS)
+	RadiusUser     string
+	RadiusPassword string
+	// RadiusChallenge if present is called for each RADIUS access challenge
+	RadiusChallenge RadiusChallengeHandler
+	// EncryptionTypes and ChecksumTypes are allow-lists of native network encryption
+	// and data integrity algorithms. when set only these algorithms are offered and
+	// accepted from the server
+	EncryptionTypes []string
+	ChecksumTypes   []string
 }
 type ConnectionConfig struct {
 	ClientInfo
@@ -46,17 +70,21 @@ type ConnectionConfig struct {
 	AdvNegoServiceInfo
 	TraceFilePath       string
 	TraceDir            string
+	CaptureFilePath     string // binary capture of network packets
+	CaptureDir          string
 	PrefetchRows        int
 	Lob                 LobFetch
 	LobReadMode         LobReadMode
 	FastLogin           bool
 	TokenFile           string
 	TokenPrivateKeyFile string
+	// TokenProvider if present is called for each new connection to get access token
+	TokenProvider TokenProvider
 }
 
 func (config *ConnectionConfig) ConnectionData() string {
 	if len(config.connStr) != 0 {
-		return config.connStr
+		return config.addShardingData(config.connStr)
 	}
 	host := config.GetActiveServer(false)
 	protocol := config.Protocol
@@ -83,13 +111,16 @@ func (config *ConnectionConfig) ConnectionData() string {
 	if config.InstanceName != "" {
 		connectData += "(INSTANCE_NAME=" + config.InstanceName + ")"
 	}
+	connectData += config.shardingData()
 	// should add connection id also here
 	//(CONNECTION_ID=/lKthdECvk6r+ivbdaDGJQ==)
 	connectData += FulCid
 
 	// (security=(ssl_server_dn_match=yes))
 	security := ""
-	if config.SSLVerify {
+	if len(config.SSLServerCertDN) > 0 {
+		security = "(security=(ssl_server_dn_match=yes)(ssl_server_cert_dn=\"" + config.SSLServerCertDN + "\"))"
+	} else if config.SSLVerify {
 		security = "(security=(ssl_server_dn_match=yes))"
 	}
 	return "(DESCRIPTION=" + address + connectData + security + "))"
@@ -165,6 +196,11 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 			if err != nil {
 				return nil, err
 			}
+			err = config.updateFailoverOptions(q.Get("connStr"))
+			if err != nil {
+				return nil, err
+			}
+			config.updateSecurityOptions(q.Get("connStr"))
 		case "SERVER":
 			for _, srv := range val {
 				srv = strings.TrimSpace(srv)
@@ -200,6 +236,8 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 				config.AuthType = Kerberos
 			} else if strings.ToUpper(val[0]) == "TCPS" {
 				config.AuthType = TCPS
+			} else if strings.ToUpper(val[0]) == "RADIUS" {
+				config.AuthType = Radius
 			} else {
 				config.AuthType = Normal
 			}
@@ -248,6 +286,10 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 			default:
 				return nil, fmt.Errorf("unknown data integrity service level: %s use one of the following [ACCEPTED, REJECTED, REQUESTED, REQUIRED]", val[0])
 			}
+		case "ENCRYPTION TYPES":
+			config.EncryptionTypes = appendAlgorithms(config.EncryptionTypes, val)
+		case "CRYPTO CHECKSUM TYPES":
+			config.ChecksumTypes = appendAlgorithms(config.ChecksumTypes, val)
 		case "SSL":
 			config.SSL = strings.ToUpper(val[0]) == "TRUE" ||
 				strings.ToUpper(val[0]) == "ENABLE" ||
@@ -256,6 +298,30 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 			config.SSLVerify = strings.ToUpper(val[0]) == "TRUE" ||
 				strings.ToUpper(val[0]) == "ENABLE" ||
 				strings.ToUpper(val[0]) == "ENABLED"
+		case "SSL SERVER DN MATCH":
+			config.SSLServerDNMatch = isOptionOn(val[0])
+		case "SSL SERVER CERT DN":
+			config.SSLServerCertDN = val[0]
+		case "SSL SERVER NAME":
+			fallthrough
+		case "SNI":
+			config.SSLServerName = val[0]
+		case "SSL CERT":
+			fallthrough
+		case "SSL CERT FILE":
+			config.SSLCertFile = val[0]
+		case "SSL KEY":
+			fallthrough
+		case "SSL KEY FILE":
+			config.SSLKeyFile = val[0]
+		case "SSL CA":
+			fallthrough
+		case "SSL CA FILE":
+			config.SSLCAFile = val[0]
+		case "SSL CRL":
+			fallthrough
+		case "SSL CRL FILE":
+			config.SSLCRLFile = val[0]
 		case "DBA PRIVILEGE":
 			config.DBAPrivilege = DBAPrivilegeFromString(val[0])
 		case "TIMEOUT":
@@ -296,6 +362,12 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 			fallthrough
 		case "TRACE DIRECTORY":
 			config.TraceDir = val[0]
+		case "CAPTURE FILE":
+			config.CaptureFilePath = val[0]
+		case "CAPTURE DIR":
+			fallthrough
+		case "CAPTURE DIRECTORY":
+			config.CaptureDir = val[0]
 		case "USE_OOB":
 			fallthrough
 		case "ENABLE_OOB":
@@ -319,22 +391,54 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 			config.TokenPrivateKeyFile = val[0]
 		case "PROXY CLIENT NAME":
 			config.DatabaseInfo.ProxyClientName = val[0]
-		//case "FAILOVER":
-		//	return nil, errors.New("starting from v2.7.0 this feature (FAILOVER) is not supported and the driver use database/sql package fail over")
-		// config.Failover, err = strconv.Atoi(val[0])
-		// if err != nil {
-		//	config.Failover = 0
-		// }
-		//case "RETRYTIME":
-		//	fallthrough
-		//case "RE-TRY TIME":
-		//	fallthrough
-		//case "RETRY TIME":
-		//	return nil, errors.New("starting from v2.7.0 this feature (RETRY TIME) is not supported and the driver use database/sql package fail over")
-		// config.RetryTime, err = strconv.Atoi(val[0])
-		// if err != nil {
-		//	config.RetryTime = 0
-		// }
+		case "PROXY CLIENT PASSWORD":
+			config.DatabaseInfo.ProxyClientPassword = val[0]
+		case "PROXY CLIENT DN":
+			fallthrough
+		case "PROXY DISTINGUISHED NAME":
+			config.DatabaseInfo.ProxyClientDN = val[0]
+		case "PROXY USER ID":
+			config.DatabaseInfo.ProxyUserID = val[0]
+		case "PROXY PASSWORD":
+			config.DatabaseInfo.ProxyPassword = val[0]
+		case "PROXY ROLES":
+			for _, tempVal := range val {
+				for _, role := range strings.Split(tempVal, ",") {
+					role = strings.TrimSpace(role)
+					if len(role) > 0 {
+						config.DatabaseInfo.ProxyRoles = append(config.DatabaseInfo.ProxyRoles, role)
+					}
+				}
+			}
+		case "EDITION":
+			fallthrough
+		case "APPLICATION EDITION":
+			config.DatabaseInfo.ApplicationEdition = val[0]
+		case "FAILOVER":
+			config.DisableFailover = !isOptionOn(val[0])
+		case "LOAD BALANCE":
+			fallthrough
+		case "LOAD_BALANCE":
+			config.LoadBalance = isOptionOn(val[0])
+		case "RETRY COUNT":
+			fallthrough
+		case "RETRY_COUNT":
+			config.RetryCount, err = strconv.Atoi(val[0])
+			if err != nil {
+				return nil, errors.New("RETRY COUNT value must be an integer")
+			}
+		case "RETRY DELAY":
+			fallthrough
+		case "RETRY_DELAY":
+			config.RetryDelay, err = parseSeconds(val[0])
+			if err != nil {
+				return nil, errors.New("RETRY DELAY value must be a number of seconds")
+			}
+		case "TRANSPORT CONNECT TIMEOUT":
+			config.TransportConnectTimeout, err = parseSeconds(val[0])
+			if err != nil {
+				return nil, errors.New("TRANSPORT CONNECT TIMEOUT value must be a number of seconds")
+			}
 		case "LOB FETCH":
 			tempVal := strings.ToUpper(val[0])
 			if tempVal == "PRE" || tempVal == "INLINE" {
@@ -443,12 +547,6 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 			//	} else {
 			//		ret.PromotableTransaction = Local
 			//	}
-			// case "APPLICATION EDITION":
-			//	ret.ApplicationEdition = val[0]
-			// case "PROXY USER ID":
-			//	ret.ProxyUserID = val[0]
-			// case "PROXY PASSWORD":
-			//	ret.ProxyPassword = val[0]
 		}
 	}
 	if len(config.Servers) == 0 {
@@ -458,21 +556,12 @@ func ParseConfig(dsn string) (*ConnectionConfig, error) {
 		if len(config.ServiceName) == 0 {
 			return nil, errors.New("you should specify server/service if you will use wallet")
 		}
-		if _, err = os.Stat(path.Join(walletPath, "ewallet.p12")); err == nil && len(walletPass) > 0 {
-			fileData, err := os.ReadFile(path.Join(walletPath, "ewallet.p12"))
-			if err != nil {
-				return nil, err
-			}
-			config.Wallet = &Wallet{password: []byte(walletPass)}
-			err = config.Wallet.readPKCS12(fileData)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			config.Wallet, err = NewWallet(path.Join(walletPath, "cwallet.sso"))
-			if err != nil {
-				return nil, err
-			}
+		if _, err = os.Stat(path.Join(walletPath, "ewallet.p12")); err != nil {
+			walletPass = ""
+		}
+		config.Wallet, err = OpenWallet(walletPath, walletPass)
+		if err != nil {
+			return nil, err
 		}
 
 		if len(config.UserID) > 0 {
@@ -500,12 +589,44 @@ func (config *ConnectionConfig) validate() error {
 	if len(config.SID) == 0 && len(config.ServiceName) == 0 {
 		return errors.New("empty SID and service name")
 	}
+	if len(config.ProxyUserID) > 0 {
+		// USER and PASSWORD identify the client, the session is authenticated as proxy user
+		if len(config.ProxyClientName) > 0 {
+			return errors.New("PROXY USER ID can't be used with PROXY CLIENT NAME")
+		}
+		config.ProxyClientName = config.UserID
+		config.ProxyClientPassword = config.Password
+		config.UserID = config.ProxyUserID
+		config.Password = config.ProxyPassword
+	}
 	if config.AuthType == Kerberos {
 		config.AuthService = append(config.AuthService, "KERBEROS5")
 	}
 	if config.AuthType == TCPS {
 		config.AuthService = append(config.AuthService, "TCPS")
 	}
+	for _, algo := range config.EncryptionTypes {
+		if isWeakAlgorithm(algo) {
+			return fmt.Errorf("weak encryption algorithm %s is not allowed in ENCRYPTION TYPES", algo)
+		}
+	}
+	for _, algo := range config.ChecksumTypes {
+		if isWeakAlgorithm(algo) {
+			return fmt.Errorf("weak checksum algorithm %s is not allowed in CRYPTO CHECKSUM TYPES", algo)
+		}
+	}
+	if config.AuthType == Radius {
+		// the password is sent to RADIUS server during advanced negotiation
+		// and the session logon proceeds as external authentication.
+		// experimental: logon without password is not verified by a capture
+		if len(config.UserID) == 0 || len(config.Password) == 0 {
+			return errors.New("RADIUS authentication requires user id and password")
+		}
+		config.AuthService = append(config.AuthService, "RADIUS")
+		config.RadiusUser = config.UserID
+		config.RadiusPassword = config.Password
+		config.Password = ""
+	}
 	//if len(config.TokenFile) > 0 {
 	//	config.tokenBytes, err = os.ReadFile(config.TokenFile)
 	//	if err != nil {
@@ -518,9 +639,11 @@ func (config *ConnectionConfig) validate() error {
 	//		return err
 	//	}
 	//}
-	if len(config.TokenFile) > 0 && len(config.TokenPrivateKeyFile) > 0 {
-
-	} else {
+	if len(config.TokenFile) > 0 {
+		if config.TokenProvider == nil {
+			config.TokenProvider = NewFileTokenProvider(config.TokenFile, config.TokenPrivateKeyFile)
+		}
+	} else if config.TokenProvider == nil {
 		if len(config.UserID) == 0 || len(config.Password) == 0 && config.AuthType == Normal {
 			config.AuthType = OS
 		}
@@ -535,6 +658,10 @@ func (config *ConnectionConfig) validate() error {
 	if config.SSL {
 		config.Protocol = "tcps"
 	}
+	err := config.loadPEMFiles()
+	if err != nil {
+		return err
+	}
 
 	// get client info
 	var idx int
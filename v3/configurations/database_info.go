@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const defaultPort int = 1521
@@ -30,6 +31,7 @@ const (
 	OS       AuthType = 1
 	Kerberos AuthType = 2
 	TCPS     AuthType = 3
+	Radius   AuthType = 4
 )
 
 type ServerAddr struct {
@@ -39,20 +41,72 @@ type ServerAddr struct {
 }
 
 type DatabaseInfo struct {
-	UserID          string
-	Password        string
-	Servers         []ServerAddr
-	serverIndex     int
-	SID             string
+	UserID      string
+	Password    string
+	Servers     []ServerAddr
+	serverIndex int
+	SID         string
+	// ProxyClientName is the client user of proxy authentication. UserID and
+	// Password are credentials of the proxy user
 	ProxyClientName string
-	ServiceName     string
-	InstanceName    string
-	DBName          string
-	DBAPrivilege    DBAPrivilege
-	AuthType        AuthType
-	Wallet          *Wallet
-	connStr         string
-	Location        string
+	// ProxyClientPassword is required when the client is granted connect through
+	// proxy AUTHENTICATED USING PASSWORD
+	ProxyClientPassword string
+	// ProxyClientDN and ProxyClientCertificate identify the client by distinguished
+	// name or X.509 certificate (DER) instead of user name
+	ProxyClientDN          string
+	ProxyClientCertificate []byte
+	// ProxyRoles are roles enabled for the client after proxy authentication
+	ProxyRoles []string
+	// ProxyUserID and ProxyPassword are credentials of the proxy user when UserID
+	// and Password are used for the client (PROXY USER ID url option)
+	ProxyUserID   string
+	ProxyPassword string
+	ServiceName   string
+	InstanceName  string
+	DBName        string
+	DBAPrivilege  DBAPrivilege
+	AuthType      AuthType
+	Wallet        *Wallet
+	connStr       string
+	Location      string
+	// ApplicationEdition is the edition used by sessions (edition-based redefinition)
+	ApplicationEdition string
+	// LoadBalance randomize the order of Servers for each new connection
+	LoadBalance bool
+	// DisableFailover stop after the first failed address instead of trying the next one
+	DisableFailover bool
+	// RetryCount is the number of extra rounds over the address list when all addresses fail
+	RetryCount int
+	// RetryDelay is the base delay between retry rounds
+	RetryDelay time.Duration
+	// ShardingKey and SuperShardingKey route the connection to a shard of sharded database
+	ShardingKey      *ShardingKey
+	SuperShardingKey *ShardingKey
+}
+
+// ProxyClient identify the client user of proxy session by Name,
+// DistinguishedName or Certificate
+type ProxyClient struct {
+	Name              string
+	Password          string
+	DistinguishedName string
+	Certificate       []byte
+	Roles             []string
+}
+
+// SetProxyClient replace client of proxy authentication
+func (info *DatabaseInfo) SetProxyClient(client ProxyClient) {
+	info.ProxyClientName = client.Name
+	info.ProxyClientPassword = client.Password
+	info.ProxyClientDN = client.DistinguishedName
+	info.ProxyClientCertificate = client.Certificate
+	info.ProxyRoles = client.Roles
+}
+
+// IsProxy return true if the session is authenticated through proxy user
+func (info *DatabaseInfo) IsProxy() bool {
+	return len(info.ProxyClientName) > 0 || len(info.ProxyClientDN) > 0 || len(info.ProxyClientCertificate) > 0
 }
 
 func ExtractServers(connStr string) (addresses []ServerAddr, err error) {
@@ -0,0 +1,104 @@
+package configurations
+
+import (
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxRetryDelay = 30 * time.Second
+
+// ConnectAttempt describe a single try to connect to one server address
+type ConnectAttempt struct {
+	Server   ServerAddr
+	Retry    int // 0 for the first round over the address list
+	Duration time.Duration
+	Err      error
+}
+
+// ConnectAttemptCallback is called after each connect attempt (successful or failed)
+type ConnectAttemptCallback func(attempt ConnectAttempt)
+
+// ShuffleServers randomize server list order (LOAD_BALANCE=ON).
+// the list is copied first so shared configurations are not affected
+func (info *DatabaseInfo) ShuffleServers() {
+	if len(info.Servers) < 2 {
+		return
+	}
+	servers := make([]ServerAddr, len(info.Servers))
+	copy(servers, info.Servers)
+	rand.Shuffle(len(servers), func(i, j int) {
+		servers[i], servers[j] = servers[j], servers[i]
+	})
+	info.Servers = servers
+	info.serverIndex = 0
+}
+
+// RetryBackoff return the time to wait before retry number n (starting from 1).
+// the delay is doubled each retry (up to 30 seconds) and jittered to avoid
+// all clients reconnecting at the same moment
+func (info *DatabaseInfo) RetryBackoff(n int) time.Duration {
+	if info.RetryDelay <= 0 || n <= 0 {
+		return 0
+	}
+	delay := info.RetryDelay
+	for x := 1; x < n && delay < maxRetryDelay; x++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+var descriptorOptionRegexp = regexp.MustCompile(`(?i)\(\s*(LOAD_BALANCE|FAILOVER|RETRY_COUNT|RETRY_DELAY|TRANSPORT_CONNECT_TIMEOUT)\s*=\s*([\w.]+)\s*\)`)
+
+// isOptionOn read boolean option. numeric values are on when not zero
+func isOptionOn(val string) bool {
+	val = strings.ToUpper(strings.TrimSpace(val))
+	switch val {
+	case "ON", "YES", "TRUE", "ENABLE", "ENABLED":
+		return true
+	}
+	if num, err := strconv.Atoi(val); err == nil {
+		return num != 0
+	}
+	return false
+}
+
+// parseSeconds read duration in seconds. ms suffix is accepted for milliseconds
+func parseSeconds(val string) (time.Duration, error) {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if strings.HasSuffix(val, "ms") {
+		num, err := strconv.Atoi(strings.TrimSuffix(val, "ms"))
+		return time.Duration(num) * time.Millisecond, err
+	}
+	num, err := strconv.ParseFloat(val, 64)
+	return time.Duration(num * float64(time.Second)), err
+}
+
+// updateFailoverOptions read failover options from connect descriptor
+func (config *ConnectionConfig) updateFailoverOptions(connStr string) error {
+	var err error
+	for _, match := range descriptorOptionRegexp.FindAllStringSubmatch(connStr, -1) {
+		switch strings.ToUpper(match[1]) {
+		case "LOAD_BALANCE":
+			config.LoadBalance = isOptionOn(match[2])
+		case "FAILOVER":
+			config.DisableFailover = !isOptionOn(match[2])
+		case "RETRY_COUNT":
+			config.RetryCount, err = strconv.Atoi(match[2])
+		case "RETRY_DELAY":
+			config.RetryDelay, err = parseSeconds(match[2])
+		case "TRANSPORT_CONNECT_TIMEOUT":
+			config.TransportConnectTimeout, err = parseSeconds(match[2])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
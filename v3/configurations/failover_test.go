@@ -0,0 +1,84 @@
+package configurations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateFailoverOptions(t *testing.T) {
+	text := `(DESCRIPTION=(LOAD_BALANCE=on)(FAILOVER=off)(RETRY_COUNT=3)(RETRY_DELAY=2)(TRANSPORT_CONNECT_TIMEOUT=500ms)
+(ADDRESS_LIST=(ADDRESS=(PROTOCOL=tcp)(HOST=host1)(PORT=1521))(ADDRESS=(PROTOCOL=tcp)(HOST=host2)(PORT=1522)))
+(CONNECT_DATA=(SERVICE_NAME=service)))`
+	config := &ConnectionConfig{}
+	err := config.UpdateDatabaseInfo(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.updateFailoverOptions(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.LoadBalance || !config.DisableFailover || config.RetryCount != 3 {
+		t.Errorf("unexpected failover options: %#v", config.DatabaseInfo)
+	}
+	if config.RetryDelay != 2*time.Second {
+		t.Errorf("expected retry delay 2s, got: %v", config.RetryDelay)
+	}
+	if config.TransportConnectTimeout != 500*time.Millisecond {
+		t.Errorf("expected transport connect timeout 500ms, got: %v", config.TransportConnectTimeout)
+	}
+}
+
+func TestIsOptionOn(t *testing.T) {
+	for val, expected := range map[string]bool{
+		"on": true, "YES": true, " true ": true, "3": true, "1": true,
+		"off": false, "no": false, "0": false, "": false,
+	} {
+		if got := isOptionOn(val); got != expected {
+			t.Errorf("isOptionOn(%q): expected %v got: %v", val, expected, got)
+		}
+	}
+	config := &ConnectionConfig{}
+	if err := config.updateFailoverOptions("(DESCRIPTION=(FAILOVER=3))"); err != nil {
+		t.Fatal(err)
+	}
+	if config.DisableFailover {
+		t.Error("expected numeric FAILOVER value to keep failover on")
+	}
+}
+
+func TestShuffleServers(t *testing.T) {
+	servers := []ServerAddr{{Addr: "host1", Port: 1521}, {Addr: "host2", Port: 1521}, {Addr: "host3", Port: 1521}}
+	info := &DatabaseInfo{Servers: servers}
+	info.ShuffleServers()
+	if len(info.Servers) != len(servers) {
+		t.Fatalf("expected %d servers, got: %d", len(servers), len(info.Servers))
+	}
+	if &info.Servers[0] == &servers[0] {
+		t.Error("shuffle should not modify the original server list")
+	}
+	for _, serv := range servers {
+		found := false
+		for _, temp := range info.Servers {
+			if temp.IsEqual(&serv) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("server %s missing after shuffle", serv.Addr)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	info := &DatabaseInfo{RetryDelay: time.Second}
+	for retry, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		delay := info.RetryBackoff(retry + 1)
+		if delay < max/2 || delay > max {
+			t.Errorf("retry #%d delay %v out of range [%v, %v]", retry+1, delay, max/2, max)
+		}
+	}
+	if delay := info.RetryBackoff(20); delay > maxRetryDelay {
+		t.Errorf("delay %v exceed maximum retry delay", delay)
+	}
+}
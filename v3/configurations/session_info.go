@@ -3,6 +3,7 @@ package configurations
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"strings"
@@ -26,6 +27,27 @@ type SessionInfo struct {
 	SSLVerify             bool
 	TLSConfig             *tls.Config
 	Dialer                DialerContext
+	// TransportConnectTimeout limit the time of connecting to each address
+	TransportConnectTimeout time.Duration
+	// OnConnectAttempt is called after each try to connect to a server address
+	OnConnectAttempt ConnectAttemptCallback
+	// SSLServerDNMatch verify server certificate DN (SSLServerCertDN or host/service
+	// name) instead of host name
+	SSLServerDNMatch bool
+	SSLServerCertDN  string
+	// SSLServerName is sent as SNI instead of host name
+	SSLServerName string
+	// PEM files of client certificate, private key, CA bundle and revocation list
+	// used without wallet
+	SSLCertFile string
+	SSLKeyFile  string
+	SSLCAFile   string
+	SSLCRLFile  string
+	// RevocationCheck if present is called with verified server certificate chains
+	RevocationCheck RevocationChecker
+
+	clientCertificates []tls.Certificate
+	rootCAs            *x509.CertPool
 }
 
 func (si *SessionInfo) RegisterDial(dialer func(ctx context.Context, network, address string) (net.Conn, error)) {
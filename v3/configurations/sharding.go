@@ -0,0 +1,102 @@
+package configurations
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShardingKey is value of sharding key (or super sharding key) used by the
+// shard director to route the connection. compound keys hold one value per
+// key column in the order of the columns
+type ShardingKey struct {
+	values []interface{}
+	text   string
+}
+
+// NewShardingKey create sharding key from values. supported values are
+// integers and floats (NUMBER) and string (VARCHAR2). values are sent as text
+// without type code so DATE and RAW key columns are not supported
+func NewShardingKey(values ...interface{}) (*ShardingKey, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("sharding key require at least one value")
+	}
+	texts := make([]string, 0, len(values))
+	for _, value := range values {
+		text, err := encodeShardingValue(value)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+	return &ShardingKey{values: values, text: strings.Join(texts, ",")}, nil
+}
+
+// Values return values of the key columns
+func (key *ShardingKey) Values() []interface{} {
+	if key == nil {
+		return nil
+	}
+	return key.values
+}
+
+// String return key as written in CONNECT_DATA. it is also used to compare keys
+func (key *ShardingKey) String() string {
+	if key == nil {
+		return ""
+	}
+	return key.text
+}
+
+func encodeShardingValue(value interface{}) (string, error) {
+	switch temp := value.(type) {
+	case string:
+		return quoteShardingText(temp), nil
+	}
+	rValue := reflect.ValueOf(value)
+	switch rValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rValue.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rValue.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rValue.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported sharding key value of type %T", value)
+}
+
+// quoteShardingText quote empty text and text that contains characters
+// reserved by connect descriptor syntax
+func quoteShardingText(text string) string {
+	if len(text) == 0 || strings.ContainsAny(text, "()=,\"' \t") {
+		return `"` + strings.ReplaceAll(text, `"`, `\"`) + `"`
+	}
+	return text
+}
+
+// shardingData return SHARDING_KEY and SUPER_SHARDING_KEY parameters of CONNECT_DATA
+func (info *DatabaseInfo) shardingData() string {
+	ret := ""
+	if info.ShardingKey != nil {
+		ret += "(SHARDING_KEY=" + info.ShardingKey.String() + ")"
+	}
+	if info.SuperShardingKey != nil {
+		ret += "(SUPER_SHARDING_KEY=" + info.SuperShardingKey.String() + ")"
+	}
+	return ret
+}
+
+var connectDataRegexp = regexp.MustCompile(`(?i)\(\s*CONNECT_DATA\s*=`)
+
+// addShardingData insert sharding keys into each CONNECT_DATA of connect descriptor
+func (info *DatabaseInfo) addShardingData(connStr string) string {
+	data := info.shardingData()
+	if len(data) == 0 {
+		return connStr
+	}
+	return connectDataRegexp.ReplaceAllStringFunc(connStr, func(match string) string {
+		return match + data
+	})
+}
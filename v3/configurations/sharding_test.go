@@ -0,0 +1,49 @@
+package configurations
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShardingKey(t *testing.T) {
+	key, err := NewShardingKey(42, "gold customer", "", 1.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `42,"gold customer","",1.5`
+	if key.String() != expected {
+		t.Errorf("expected: %s got: %s", expected, key.String())
+	}
+	// DATE and RAW are refused as they can't be told apart from VARCHAR2
+	for _, value := range []interface{}{struct{}{}, []byte{0xAB}, time.Now()} {
+		if _, err = NewShardingKey(value); err == nil {
+			t.Errorf("expected error for unsupported value of type %T", value)
+		}
+	}
+	_, err = NewShardingKey()
+	if err == nil {
+		t.Error("expected error for empty key")
+	}
+}
+
+func TestShardingData(t *testing.T) {
+	config := &ConnectionConfig{}
+	config.ServiceName = "oltp_rw_srvc"
+	config.Servers = []ServerAddr{{Addr: "director", Port: 1522}}
+	config.ShardingKey, _ = NewShardingKey(40)
+	config.SuperShardingKey, _ = NewShardingKey("gold")
+	data := config.ConnectionData()
+	if !strings.Contains(data, "(SERVICE_NAME=oltp_rw_srvc)(SHARDING_KEY=40)(SUPER_SHARDING_KEY=gold)") {
+		t.Errorf("sharding keys not found in: %s", data)
+	}
+	err := config.UpdateDatabaseInfo(`(DESCRIPTION_LIST=(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=host1)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=s)))
+(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=host2)(PORT=1521))(connect_data = (SERVICE_NAME=s))))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = config.ConnectionData()
+	if strings.Count(data, "(SHARDING_KEY=40)(SUPER_SHARDING_KEY=gold)") != 2 {
+		t.Errorf("sharding keys not added to each CONNECT_DATA: %s", data)
+	}
+}
@@ -0,0 +1,286 @@
+package configurations
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RevocationChecker is called with verified certificate chains of the server.
+// returning error abort the connection. use it for CRL or OCSP checks
+type RevocationChecker func(chains [][]*x509.Certificate) error
+
+var sslServerDNMatchRegexp = regexp.MustCompile(`(?i)\(\s*SSL_SERVER_DN_MATCH\s*=\s*(\w+)\s*\)`)
+var sslServerCertDNRegexp = regexp.MustCompile(`(?i)\(\s*SSL_SERVER_CERT_DN\s*=\s*(?:"([^"]*)"|([^)]*))\s*\)`)
+
+// updateSecurityOptions read SSL_SERVER_DN_MATCH and SSL_SERVER_CERT_DN from
+// SECURITY section of connect descriptor
+func (config *ConnectionConfig) updateSecurityOptions(connStr string) {
+	match := sslServerDNMatchRegexp.FindStringSubmatch(connStr)
+	if len(match) > 1 {
+		config.SSLServerDNMatch = isOptionOn(match[1])
+	}
+	match = sslServerCertDNRegexp.FindStringSubmatch(connStr)
+	if len(match) > 2 {
+		config.SSLServerCertDN = strings.TrimSpace(match[1] + match[2])
+	}
+}
+
+// loadPEMFiles read client certificate, key and CA bundle from PEM files
+func (info *SessionInfo) loadPEMFiles() error {
+	if len(info.SSLCertFile) > 0 || len(info.SSLKeyFile) > 0 {
+		if len(info.SSLCertFile) == 0 || len(info.SSLKeyFile) == 0 {
+			return errors.New("SSL CERT FILE and SSL KEY FILE should be used together")
+		}
+		cert, err := tls.LoadX509KeyPair(info.SSLCertFile, info.SSLKeyFile)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		info.clientCertificates = []tls.Certificate{cert}
+	}
+	if len(info.SSLCAFile) > 0 {
+		data, err := os.ReadFile(info.SSLCAFile)
+		if err != nil {
+			return err
+		}
+		info.rootCAs = x509.NewCertPool()
+		if !info.rootCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates found in: %s", info.SSLCAFile)
+		}
+	}
+	if len(info.SSLCRLFile) > 0 && info.RevocationCheck == nil {
+		data, err := os.ReadFile(info.SSLCRLFile)
+		if err != nil {
+			return err
+		}
+		info.RevocationCheck, err = NewCRLChecker(data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigureTLS complete config with server name (SNI), client certificates and CA
+// bundle (if not already set) and server verification options. host and
+// serviceName are used for server DN matching
+func (info *SessionInfo) ConfigureTLS(config *tls.Config, host, serviceName string) {
+	config.ServerName = host
+	if len(info.SSLServerName) > 0 {
+		config.ServerName = info.SSLServerName
+	}
+	if len(config.Certificates) == 0 && len(info.clientCertificates) > 0 {
+		config.Certificates = info.clientCertificates
+	}
+	if config.RootCAs == nil && info.rootCAs != nil {
+		config.RootCAs = info.rootCAs
+	}
+	if !info.SSLVerify {
+		config.InsecureSkipVerify = true
+		return
+	}
+	// verification set by user tls config is kept and called last
+	userVerify := config.VerifyConnection
+	revocationCheck := func(state tls.ConnectionState, chains [][]*x509.Certificate) error {
+		if info.RevocationCheck != nil {
+			if err := info.RevocationCheck(chains); err != nil {
+				return err
+			}
+		}
+		if userVerify != nil {
+			return userVerify(state)
+		}
+		return nil
+	}
+	if !info.SSLServerDNMatch && len(info.SSLServerCertDN) == 0 {
+		if info.RevocationCheck != nil {
+			config.VerifyConnection = func(state tls.ConnectionState) error {
+				return revocationCheck(state, state.VerifiedChains)
+			}
+		}
+		return
+	}
+	// server certificate is matched by DN instead of host name so the chain is
+	// verified here
+	roots := config.RootCAs
+	expectedDN := info.SSLServerCertDN
+	config.InsecureSkipVerify = true
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server doesn't present certificate")
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		chains, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		if err != nil {
+			return err
+		}
+		err = MatchServerDN(state.PeerCertificates[0], expectedDN, host, serviceName)
+		if err != nil {
+			return err
+		}
+		return revocationCheck(state, chains)
+	}
+}
+
+// MatchServerDN check server certificate DN. if expectedDN is empty the common
+// name should match host name (or certificate SAN) or service name
+func MatchServerDN(cert *x509.Certificate, expectedDN, host, serviceName string) error {
+	if len(expectedDN) > 0 {
+		expected, err := parseDN(expectedDN)
+		if err != nil {
+			return err
+		}
+		actual := map[string]string{}
+		for _, attr := range cert.Subject.Names {
+			actual[attributeName(attr.Type)+"="+strings.ToUpper(fmt.Sprint(attr.Value))] = ""
+		}
+		if len(expected) != len(actual) {
+			return fmt.Errorf("server certificate DN %q doesn't match %q", cert.Subject.String(), expectedDN)
+		}
+		for key := range expected {
+			if _, ok := actual[key]; !ok {
+				return fmt.Errorf("server certificate DN %q doesn't match %q", cert.Subject.String(), expectedDN)
+			}
+		}
+		return nil
+	}
+	cn := cert.Subject.CommonName
+	if strings.EqualFold(cn, host) || (len(serviceName) > 0 && strings.EqualFold(cn, serviceName)) {
+		return nil
+	}
+	if cert.VerifyHostname(host) == nil {
+		return nil
+	}
+	return fmt.Errorf("server certificate CN %q doesn't match host %q or service %q", cn, host, serviceName)
+}
+
+var dnAttributeNames = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"2.5.4.9":                    "STREET",
+	"2.5.4.17":                   "POSTALCODE",
+	"2.5.4.5":                    "SERIALNUMBER",
+	"0.9.2342.19200300.100.1.25": "DC",
+	"0.9.2342.19200300.100.1.1":  "UID",
+	"1.2.840.113549.1.9.1":       "EMAILADDRESS",
+}
+
+func attributeName(oid asn1.ObjectIdentifier) string {
+	if name, ok := dnAttributeNames[oid.String()]; ok {
+		return name
+	}
+	return oid.String()
+}
+
+// parseDN split distinguished name (e.g. "CN=server,O=org,L=city") into set of
+// upper case attribute=value pairs. commas escaped with \ are part of value
+func parseDN(dn string) (map[string]string, error) {
+	ret := map[string]string{}
+	var parts []string
+	current := strings.Builder{}
+	escaped := false
+	for _, r := range dn {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',' || r == ';' || r == '+':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	parts = append(parts, current.String())
+	for _, part := range parts {
+		index := strings.Index(part, "=")
+		if index <= 0 {
+			return nil, fmt.Errorf("invalid distinguished name: %q", dn)
+		}
+		name := strings.ToUpper(strings.TrimSpace(part[:index]))
+		switch name {
+		case "E", "EMAIL":
+			name = "EMAILADDRESS"
+		case "S":
+			name = "ST"
+		}
+		ret[name+"="+strings.ToUpper(strings.TrimSpace(part[index+1:]))] = ""
+	}
+	return ret, nil
+}
+
+// NewCRLChecker return revocation checker that reject certificates listed in
+// certificate revocation list (PEM or DER). the list should be issued by one of
+// the chain certificates
+func NewCRLChecker(data []byte) (RevocationChecker, error) {
+	var lists []*x509.RevocationList
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type == "X509 CRL" {
+			list, err := x509.ParseRevocationList(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			lists = append(lists, list)
+		}
+		data = rest
+	}
+	if len(lists) == 0 {
+		list, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate revocation list: %w", err)
+		}
+		lists = append(lists, list)
+	}
+	return func(chains [][]*x509.Certificate) error {
+		for _, chain := range chains {
+			for i, cert := range chain {
+				if i+1 >= len(chain) {
+					break
+				}
+				issuer := chain[i+1]
+				for _, list := range lists {
+					if !issuerMatch(list.Issuer, issuer.Subject) || list.CheckSignatureFrom(issuer) != nil {
+						continue
+					}
+					if !list.NextUpdate.IsZero() && time.Now().After(list.NextUpdate) {
+						return fmt.Errorf("certificate revocation list of %q expired at %v", issuer.Subject.String(), list.NextUpdate)
+					}
+					for _, entry := range list.RevokedCertificateEntries {
+						if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+							return fmt.Errorf("certificate %q is revoked", cert.Subject.String())
+						}
+					}
+				}
+			}
+		}
+		return nil
+	}, nil
+}
+
+func issuerMatch(a, b pkix.Name) bool {
+	return a.String() == b.String()
+}
@@ -0,0 +1,107 @@
+package configurations
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func createCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestUpdateSecurityOptions(t *testing.T) {
+	config := &ConnectionConfig{}
+	config.updateSecurityOptions(`(DESCRIPTION=(ADDRESS=(PROTOCOL=TCPS)(HOST=host.com)(PORT=1522))(CONNECT_DATA=(SERVICE_NAME=service))
+(SECURITY=(SSL_SERVER_DN_MATCH=yes)(SSL_SERVER_CERT_DN="CN=adb.example.com,O=Oracle Corporation,L=Redwood City,ST=California,C=US")))`)
+	if !config.SSLServerDNMatch {
+		t.Error("expected SSL_SERVER_DN_MATCH on")
+	}
+	if config.SSLServerCertDN != "CN=adb.example.com,O=Oracle Corporation,L=Redwood City,ST=California,C=US" {
+		t.Errorf("unexpected server cert DN: %s", config.SSLServerCertDN)
+	}
+}
+
+func TestMatchServerDN(t *testing.T) {
+	cert, _ := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "orclpdb", Organization: []string{"Org, Inc"}, Country: []string{"US"}},
+		DNSNames:     []string{"db.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, nil, nil)
+	tests := []struct {
+		dn, host, service string
+		ok                bool
+	}{
+		{`c=us, o=Org\, Inc, CN=orclpdb`, "", "", true},
+		{`CN=orclpdb,O=Org\, Inc`, "", "", false},
+		{`CN=other,O=Org\, Inc,C=US`, "", "", false},
+		{"", "other.host", "ORCLPDB", true},
+		{"", "db.example.com", "", true},
+		{"", "other.host", "other", false},
+	}
+	for _, test := range tests {
+		err := MatchServerDN(cert, test.dn, test.host, test.service)
+		if (err == nil) != test.ok {
+			t.Errorf("dn: %q host: %q service: %q expected ok=%v got: %v", test.dn, test.host, test.service, test.ok, err)
+		}
+	}
+}
+
+func TestCRLChecker(t *testing.T) {
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	ca, caKey := createCertificate(t, caTemplate, nil, nil)
+	revoked, _ := createCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "revoked"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}, ca, caKey)
+	valid, _ := createCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: "valid"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}, ca, caKey)
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: big.NewInt(2), RevocationTime: time.Now()}},
+	}, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, err := NewCRLChecker(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checker([][]*x509.Certificate{{revoked, ca}}); err == nil {
+		t.Error("expected revoked certificate error")
+	}
+	if err = checker([][]*x509.Certificate{{valid, ca}}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,146 @@
+package configurations
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessToken is a database access token used for token based authentication.
+//
+// OAuth2 bearer tokens are passed without PrivateKey. IAM database tokens
+// require proof-of-possession so PrivateKey should hold the PEM encoded key
+// used to sign the authentication header.
+//
+// if Expiry is zero it is read from the JWT exp claim of the token (if present)
+type AccessToken struct {
+	Token      string
+	PrivateKey []byte
+	Expiry     time.Time
+}
+
+// TokenProvider is called for each new connection to obtain an access token
+type TokenProvider interface {
+	GetToken(ctx context.Context) (*AccessToken, error)
+}
+
+// TokenProviderFunc is an adapter to use ordinary function as TokenProvider
+type TokenProviderFunc func(ctx context.Context) (*AccessToken, error)
+
+func (f TokenProviderFunc) GetToken(ctx context.Context) (*AccessToken, error) {
+	return f(ctx)
+}
+
+// ExpiresAt return token expiry time. zero time means the token never expire
+func (token *AccessToken) ExpiresAt() time.Time {
+	if !token.Expiry.IsZero() {
+		return token.Expiry
+	}
+	return jwtExpiry(token.Token)
+}
+
+// IsExpired return true if the token will expire within the margin duration
+func (token *AccessToken) IsExpired(margin time.Duration) bool {
+	expiry := token.ExpiresAt()
+	if expiry.IsZero() {
+		return false
+	}
+	return !time.Now().Add(margin).Before(expiry)
+}
+
+// jwtExpiry read exp claim from JWT payload. return zero time if the token
+// is not a JWT or exp claim is missing
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(claims.Exp), 0)
+}
+
+type fileTokenProvider struct {
+	tokenFile      string
+	privateKeyFile string
+}
+
+// NewFileTokenProvider return a TokenProvider that read token file (and optional
+// private key file) each time a token is requested so rotated tokens are picked
+// by new connections
+func NewFileTokenProvider(tokenFile, privateKeyFile string) TokenProvider {
+	return &fileTokenProvider{tokenFile: tokenFile, privateKeyFile: privateKeyFile}
+}
+
+func (provider *fileTokenProvider) GetToken(_ context.Context) (*AccessToken, error) {
+	token, err := os.ReadFile(provider.tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	ret := &AccessToken{Token: strings.TrimSpace(string(token))}
+	if len(provider.privateKeyFile) > 0 {
+		ret.PrivateKey, err = os.ReadFile(provider.privateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+type cachedTokenProvider struct {
+	mu            sync.Mutex
+	provider      TokenProvider
+	refreshBefore time.Duration
+	token         *AccessToken
+}
+
+// NewCachedTokenProvider wrap a TokenProvider and reuse the returned token until
+// it is about to expire (within refreshBefore) then a new token is requested
+func NewCachedTokenProvider(provider TokenProvider, refreshBefore time.Duration) TokenProvider {
+	return &cachedTokenProvider{provider: provider, refreshBefore: refreshBefore}
+}
+
+func (provider *cachedTokenProvider) GetToken(ctx context.Context) (*AccessToken, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.token != nil && !provider.token.IsExpired(provider.refreshBefore) {
+		return provider.token, nil
+	}
+	token, err := provider.provider.GetToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || len(token.Token) == 0 {
+		return nil, errors.New("token provider returned empty token")
+	}
+	provider.token = token
+	return token, nil
+}
+
+// SetTokenProvider use token authentication for the connection configuration
+func (config *ConnectionConfig) SetTokenProvider(provider TokenProvider) {
+	config.TokenProvider = provider
+	if provider != nil && config.AuthType == OS && len(config.UserID) == 0 {
+		config.AuthType = Normal
+		services := make([]string, 0, len(config.AuthService))
+		for _, serv := range config.AuthService {
+			if serv != "NTS" {
+				services = append(services, serv)
+			}
+		}
+		config.AuthService = services
+	}
+}
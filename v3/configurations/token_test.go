@@ -0,0 +1,92 @@
+package configurations
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func createTestJWT(exp time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"user","exp":%d}`, exp.Unix())))
+	return header + "." + payload + ".signature"
+}
+
+func TestAccessTokenExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	token := &AccessToken{Token: createTestJWT(exp)}
+	if !token.ExpiresAt().Equal(exp) {
+		t.Errorf("expected expiry: %v, got: %v", exp, token.ExpiresAt())
+	}
+	if token.IsExpired(0) {
+		t.Error("token should not be expired")
+	}
+	if !token.IsExpired(2 * time.Hour) {
+		t.Error("token should be expired within 2 hours margin")
+	}
+	token = &AccessToken{Token: "opaque-token"}
+	if !token.ExpiresAt().IsZero() || token.IsExpired(0) {
+		t.Error("opaque token without expiry should never expire")
+	}
+}
+
+func TestCachedTokenProvider(t *testing.T) {
+	calls := 0
+	expiry := time.Now().Add(time.Hour)
+	provider := NewCachedTokenProvider(TokenProviderFunc(func(ctx context.Context) (*AccessToken, error) {
+		calls++
+		return &AccessToken{Token: fmt.Sprintf("token_%d", calls), Expiry: expiry}, nil
+	}), time.Minute)
+	for i := 0; i < 3; i++ {
+		token, err := provider.GetToken(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Token != "token_1" {
+			t.Errorf("expected cached token, got: %s", token.Token)
+		}
+	}
+	// token will expire within refresh period
+	expiry = time.Now().Add(30 * time.Second)
+	provider.(*cachedTokenProvider).token.Expiry = expiry
+	token, err := provider.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "token_2" {
+		t.Errorf("expected refreshed token, got: %s", token.Token)
+	}
+}
+
+func TestFileTokenProvider(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	err := os.WriteFile(tokenFile, []byte("first\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := NewFileTokenProvider(tokenFile, "")
+	token, err := provider.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "first" || token.PrivateKey != nil {
+		t.Errorf("unexpected token: %#v", token)
+	}
+	// rotated token should be read by next call
+	err = os.WriteFile(tokenFile, []byte("second"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err = provider.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "second" {
+		t.Errorf("expected rotated token, got: %s", token.Token)
+	}
+}
@@ -26,6 +26,25 @@ func uniqueAppendString(list []string, newItem string, ignoreCase bool) ([]strin
 	return list, !found
 }
 
+// appendAlgorithms add comma separated algorithm names to the list
+func appendAlgorithms(list []string, values []string) []string {
+	for _, value := range values {
+		for _, algo := range strings.Split(value, ",") {
+			algo = strings.ToUpper(strings.TrimSpace(algo))
+			if len(algo) > 0 {
+				list, _ = uniqueAppendString(list, algo, false)
+			}
+		}
+	}
+	return list
+}
+
+// isWeakAlgorithm return true for RC4, single DES and MD5 algorithms of native network encryption
+func isWeakAlgorithm(algo string) bool {
+	algo = strings.ToUpper(algo)
+	return strings.HasPrefix(algo, "RC4") || strings.HasPrefix(algo, "DES") || algo == "MD5"
+}
+
 func getCharsetID(charset string) (int, error) {
 	charsetMap := map[string]int{
 		"US7ASCII":          0x1,
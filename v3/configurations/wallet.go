@@ -30,9 +30,13 @@ type Wallet struct {
 	sha1Iteration       int
 	algType             int
 	credentials         []WalletCredential
+	pkcs12Password      []byte // user password of ewallet.p12
 	Certificates        [][]byte
 	PrivateKeys         [][]byte
 	CertificateRequests [][]byte
+	unknownBags         [][]byte          // safe bags that are not modeled, written back as is
+	bagAttributes       map[string][]byte // attributes (friendlyName, localKeyId) of modeled bags
+	unmodeled           []string          // content that can't be written back
 }
 type WalletCredential struct {
 	dsn      string
@@ -40,6 +44,9 @@ type WalletCredential struct {
 	password string
 }
 
+// autoLoginIV is used to encrypt PKCS12 password stored in cwallet.sso
+var autoLoginIV = []byte{192, 52, 216, 49, 28, 2, 206, 248, 81, 240, 20, 75, 129, 237, 75, 242}
+
 // NewWallet create new Wallet object from file path
 func NewWallet(filePath string) (*Wallet, error) {
 	ret := new(Wallet)
@@ -111,25 +118,13 @@ func (w *Wallet) readFromBytes(fileData []byte) error {
 		if err != nil {
 			return err
 		}
-		dec := cipher.NewCBCDecrypter(blk, []byte{192, 52, 216, 49, 28, 2, 206, 248, 81, 240, 20, 75, 129, 237, 75, 242})
+		dec := cipher.NewCBCDecrypter(blk, autoLoginIV)
 		passwordLen := int(size) - 1 - 16
 		w.password = make([]byte, passwordLen)
 		dec.CryptBlocks(w.password, fileData[index:index+passwordLen])
 		index += passwordLen
 		if autoLoginLocal {
-			hostname, _ := os.Hostname()
-			currentUser := getCurrentUser()
-			if idx := strings.Index(hostname, "."); idx != -1 {
-				hostname = hostname[:idx]
-			}
-			key := []byte(hostname + currentUser.Name)
-			mac := hmac.New(sha1.New, key)
-			mac.Write(w.password)
-			tempPassword := mac.Sum(nil)
-			for x := 0; x < len(tempPassword); x++ {
-				tempPassword[x] = (tempPassword[x]+128)%128%127 + 1
-			}
-			w.password = tempPassword[:16]
+			w.password = localWalletPassword(w.password)
 		}
 	} else if num3 == 0x35 {
 		index++
@@ -200,25 +195,32 @@ func (w *Wallet) readCredentials(input []byte) error {
 		}
 	}
 	type struct1 struct {
-		Id   asn1.ObjectIdentifier
-		Data asn1.RawValue
+		Id         asn1.ObjectIdentifier
+		Data       asn1.RawValue
+		Attributes asn1.RawValue `asn1:"optional"`
 	}
 	type WalletCredentialData struct {
 		Id    string
 		Value string
 	}
 	var (
-		temp1 []struct1
+		temp1 []asn1.RawValue
 		temp2 struct1
 		temp3 WalletCredentialData
 	)
+	w.unknownBags = nil
+	w.bagAttributes = nil
 	// objectType := 0
 	_, err := asn1.Unmarshal(input, &temp1)
 	if err != nil {
 		return err
 	}
-	// var a []asn1.RawValue
-	for _, tmp := range temp1 {
+	for _, bag := range temp1 {
+		var tmp struct1
+		_, err = asn1.Unmarshal(bag.FullBytes, &tmp)
+		if err != nil {
+			return err
+		}
 		// check the ContentType of the tmp first
 		switch tmp.Id.String() {
 		case "1.2.840.113549.1.12.10.1.5":
@@ -235,9 +237,12 @@ func (w *Wallet) readCredentials(input []byte) error {
 					return err
 				}
 				w.CertificateRequests = append(w.CertificateRequests, a)
+				w.keepAttributes(a, tmp.Attributes)
+				continue
 			}
 
 			if temp2.Id.String() != "1.2.840.113549.1.16.12.12" {
+				w.unknownBags = append(w.unknownBags, bag.FullBytes)
 				continue
 			}
 
@@ -247,22 +252,27 @@ func (w *Wallet) readCredentials(input []byte) error {
 			}
 
 			var walletCredentialsRegexp *regexp.Regexp
-			walletCredentialsRegexp, err = regexp.Compile("(^.+)([0-9]+)")
+			walletCredentialsRegexp, err = regexp.Compile("(^.+?)([0-9]+)$")
 			if err != nil {
 				return err
 			}
 
 			matches := walletCredentialsRegexp.FindStringSubmatch(temp3.Id)
 			if len(matches) != 3 {
+				w.unknownBags = append(w.unknownBags, bag.FullBytes)
 				continue
 			}
 
 			var length int
 			length, err = strconv.Atoi(matches[2])
 			if err != nil {
+				w.unknownBags = append(w.unknownBags, bag.FullBytes)
 				continue
 			}
 
+			if len(tmp.Attributes.FullBytes) > 0 {
+				w.unmodeled = append(w.unmodeled, "attributes of secret store entry: "+temp3.Id)
+			}
 			for len(w.credentials) < length {
 				w.credentials = append(w.credentials, WalletCredential{})
 			}
@@ -291,6 +301,7 @@ func (w *Wallet) readCredentials(input []byte) error {
 				return err
 			}
 			w.PrivateKeys = append(w.PrivateKeys, a.PrivateKeyData)
+			w.keepAttributes(a.PrivateKeyData, tmp.Attributes)
 		case "1.2.840.113549.1.12.10.1.3":
 			var a struct {
 				Id asn1.ObjectIdentifier
@@ -311,14 +322,27 @@ func (w *Wallet) readCredentials(input []byte) error {
 			}
 			if !found {
 				w.Certificates = append(w.Certificates, a.F1.Data)
+				w.keepAttributes(a.F1.Data, tmp.Attributes)
 			}
 		default:
-			continue
+			w.unknownBags = append(w.unknownBags, bag.FullBytes)
 		}
 	}
 	return nil
 }
 
+// keepAttributes save bag attributes of modeled content so they are written
+// back with it
+func (w *Wallet) keepAttributes(content []byte, attributes asn1.RawValue) {
+	if len(attributes.FullBytes) == 0 {
+		return
+	}
+	if w.bagAttributes == nil {
+		w.bagAttributes = make(map[string][]byte)
+	}
+	w.bagAttributes[string(content)] = attributes.FullBytes
+}
+
 func (w *Wallet) decodeASN1(buffer []byte) (data []byte, err error) {
 	type contentInfo struct {
 		ContentType asn1.ObjectIdentifier
@@ -391,11 +415,13 @@ func (w *Wallet) decodeASN1(buffer []byte) (data []byte, err error) {
 		return
 	}
 	index := -1
+	w.unmodeled = nil
 	for idx, obj := range authenticatedSafe {
-		if obj.ContentType.Equal(oidEncryptedDataContentType) {
+		if index == -1 && obj.ContentType.Equal(oidEncryptedDataContentType) {
 			index = idx
-			break
+			continue
 		}
+		w.unmodeled = append(w.unmodeled, "content of type "+obj.ContentType.String())
 	}
 	if index == -1 {
 		err = errors.New(fmt.Sprintf("error in reading Wallet: object ID: %s is not present",
@@ -560,3 +586,21 @@ func (w *Wallet) getCredential(server string, port int, service, username string
 	}
 	return nil, nil
 }
+
+// localWalletPassword derive PKCS12 password of auto login local wallet from
+// stored password. the result depends on host name and current user
+func localWalletPassword(password []byte) []byte {
+	hostname, _ := os.Hostname()
+	currentUser := getCurrentUser()
+	if idx := strings.Index(hostname, "."); idx != -1 {
+		hostname = hostname[:idx]
+	}
+	key := []byte(hostname + currentUser.Name)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(password)
+	tempPassword := mac.Sum(nil)
+	for x := 0; x < len(tempPassword); x++ {
+		tempPassword[x] = (tempPassword[x]+128)%128%127 + 1
+	}
+	return tempPassword[:16]
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
 	"encoding/asn1"
 	"encoding/binary"
 	"errors"
@@ -28,6 +30,14 @@ var (
 	oidAES128CBC                     = asn1.ObjectIdentifier([]int{2, 16, 840, 1, 101, 3, 4, 1, 2})
 	oidAES192CBC                     = asn1.ObjectIdentifier([]int{2, 16, 840, 1, 101, 3, 4, 1, 22})
 	oidAES256CBC                     = asn1.ObjectIdentifier([]int{2, 16, 840, 1, 101, 3, 4, 1, 42})
+	oidSHA1                          = asn1.ObjectIdentifier([]int{1, 3, 14, 3, 2, 26})
+
+	oidKeyBag             = asn1.ObjectIdentifier([]int{1, 2, 840, 113549, 1, 12, 10, 1, 1})
+	oidCertBag            = asn1.ObjectIdentifier([]int{1, 2, 840, 113549, 1, 12, 10, 1, 3})
+	oidSecretBag          = asn1.ObjectIdentifier([]int{1, 2, 840, 113549, 1, 12, 10, 1, 5})
+	oidX509Certificate    = asn1.ObjectIdentifier([]int{1, 2, 840, 113549, 1, 9, 22, 1})
+	oidSecretStoreEntry   = asn1.ObjectIdentifier([]int{1, 2, 840, 113549, 1, 16, 12, 12})
+	oidCertificateRequest = asn1.ObjectIdentifier([]int{0, 22, 72, 134, 247, 13, 1, 10})
 )
 
 func fillWithRepeats(input []byte, v int) []byte {
@@ -88,6 +98,25 @@ func decrypt(algo walletAlgorithm, input []byte) ([]byte, error) {
 	return output, nil
 }
 
+func encrypt(algo walletAlgorithm, input []byte) []byte {
+	blk := algo.getBlock()
+	num := blk.BlockSize() - len(input)%blk.BlockSize()
+	output := append(append(make([]byte, 0, len(input)+num), input...), bytes.Repeat([]byte{uint8(num)}, num)...)
+	cbc := cipher.NewCBCEncrypter(blk, algo.getIV())
+	cbc.CryptBlocks(output, output)
+	return output
+}
+
+// pkcs12MAC return HMAC-SHA1 of data with key derived from password as
+// described in RFC 7292 appendix B
+func pkcs12MAC(password, salt []byte, iteration int, data []byte) []byte {
+	key := produceHash(bytes.Repeat([]byte{3}, 64), fillWithRepeats(salt, 64),
+		fillWithRepeats(convertToBigEndianUtf16(password), 64), iteration)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
 func decodeBMPString(bmpString []byte) (string, error) {
 	if len(bmpString)%2 != 0 {
 		return "", errors.New("pkcs12: odd-length BMP string")
@@ -0,0 +1,502 @@
+package configurations
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const walletIterations = 10000
+
+// DSN return connect string (alias or connect descriptor) of the credential
+func (cred WalletCredential) DSN() string {
+	return cred.dsn
+}
+
+// Username return user name of the credential
+func (cred WalletCredential) Username() string {
+	return cred.username
+}
+
+// Password return password of the credential
+func (cred WalletCredential) Password() string {
+	return cred.password
+}
+
+// CreateWallet create empty wallet protected by password. password should be
+// at least 8 characters and contain letters and numbers or special characters
+func CreateWallet(password string) (*Wallet, error) {
+	ret := new(Wallet)
+	return ret, ret.SetPassword(password)
+}
+
+// OpenWallet read wallet from directory. ewallet.p12 is used when password is
+// set otherwise the wallet is read from auto login cwallet.sso
+func OpenWallet(dir, password string) (*Wallet, error) {
+	if len(password) == 0 {
+		return NewWallet(path.Join(dir, "cwallet.sso"))
+	}
+	fileData, err := os.ReadFile(path.Join(dir, "ewallet.p12"))
+	if err != nil {
+		return nil, err
+	}
+	ret := &Wallet{password: []byte(password), pkcs12Password: []byte(password)}
+	return ret, ret.readPKCS12(fileData)
+}
+
+// SetPassword change password used to write ewallet.p12
+func (w *Wallet) SetPassword(password string) error {
+	if len(password) < 8 {
+		return errors.New("wallet password should be at least 8 characters")
+	}
+	hasLetter, hasOther := false, false
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		} else {
+			hasOther = true
+		}
+	}
+	if !hasLetter || !hasOther {
+		return errors.New("wallet password should contain letters and numbers or special characters")
+	}
+	w.pkcs12Password = []byte(password)
+	return nil
+}
+
+// Credentials return secret store credentials (like mkstore -listCredential)
+func (w *Wallet) Credentials() []WalletCredential {
+	return append([]WalletCredential(nil), w.credentials...)
+}
+
+func (w *Wallet) credentialIndex(dsn string) int {
+	for i, cred := range w.credentials {
+		if strings.EqualFold(cred.dsn, dsn) {
+			return i
+		}
+	}
+	return -1
+}
+
+// CreateCredential add credential of dsn to secret store (like mkstore
+// -createCredential). dsn should not be already in the wallet
+func (w *Wallet) CreateCredential(dsn, username, password string) error {
+	if len(dsn) == 0 || len(username) == 0 {
+		return errors.New("credential require dsn and username")
+	}
+	if w.credentialIndex(dsn) >= 0 {
+		return fmt.Errorf("credential already exists for: %s", dsn)
+	}
+	w.credentials = append(w.credentials, WalletCredential{dsn: dsn, username: username, password: password})
+	return nil
+}
+
+// ModifyCredential change username and password of dsn credential
+func (w *Wallet) ModifyCredential(dsn, username, password string) error {
+	index := w.credentialIndex(dsn)
+	if index < 0 {
+		return fmt.Errorf("credential not found for: %s", dsn)
+	}
+	w.credentials[index].username = username
+	w.credentials[index].password = password
+	return nil
+}
+
+// DeleteCredential remove dsn credential from secret store
+func (w *Wallet) DeleteCredential(dsn string) error {
+	index := w.credentialIndex(dsn)
+	if index < 0 {
+		return fmt.Errorf("credential not found for: %s", dsn)
+	}
+	w.credentials = append(w.credentials[:index], w.credentials[index+1:]...)
+	return nil
+}
+
+// ImportPEM add certificates, RSA private keys (PKCS#1 or PKCS#8) and
+// certificate requests from PEM data
+func (w *Wallet) ImportPEM(data []byte) error {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			_, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return err
+			}
+			found := false
+			for _, cert := range w.Certificates {
+				if bytes.Equal(cert, block.Bytes) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				w.Certificates = append(w.Certificates, block.Bytes)
+			}
+		case "RSA PRIVATE KEY":
+			_, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			w.PrivateKeys = append(w.PrivateKeys, block.Bytes)
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			rsaKey, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				return errors.New("wallet support only RSA private keys")
+			}
+			w.PrivateKeys = append(w.PrivateKeys, x509.MarshalPKCS1PrivateKey(rsaKey))
+		case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+			_, err := x509.ParseCertificateRequest(block.Bytes)
+			if err != nil {
+				return err
+			}
+			w.CertificateRequests = append(w.CertificateRequests, block.Bytes)
+		}
+	}
+}
+
+// ExportPEM write certificates, private keys (unencrypted) and certificate
+// requests of the wallet as PEM blocks
+func (w *Wallet) ExportPEM(out io.Writer) error {
+	for _, cert := range w.Certificates {
+		err := pem.Encode(out, &pem.Block{Type: "CERTIFICATE", Bytes: cert})
+		if err != nil {
+			return err
+		}
+	}
+	for _, key := range w.PrivateKeys {
+		err := pem.Encode(out, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: key})
+		if err != nil {
+			return err
+		}
+	}
+	for _, request := range w.CertificateRequests {
+		err := pem.Encode(out, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: request})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save write ewallet.p12 into dir and cwallet.sso if autoLogin is true.
+// unknown safe bags and bag attributes of a read wallet are written back;
+// wallets that contain content outside the encrypted safe contents or
+// attributes on secret store credentials can't be saved
+func (w *Wallet) Save(dir string, autoLogin bool) error {
+	var buffer bytes.Buffer
+	err := w.WritePKCS12(&buffer)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path.Join(dir, "ewallet.p12"), buffer.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+	if !autoLogin {
+		return nil
+	}
+	buffer.Reset()
+	err = w.WriteAutoLogin(&buffer, false)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(dir, "cwallet.sso"), buffer.Bytes(), 0600)
+}
+
+// WritePKCS12 write wallet as ewallet.p12 encrypted with wallet password
+func (w *Wallet) WritePKCS12(out io.Writer) error {
+	if len(w.pkcs12Password) == 0 {
+		return errors.New("wallet password is required to write ewallet.p12")
+	}
+	data, err := w.encodePKCS12(w.pkcs12Password)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// WriteAutoLogin write wallet as auto login cwallet.sso. local auto login
+// wallet can be opened only by the current user on this host
+func (w *Wallet) WriteAutoLogin(out io.Writer, local bool) error {
+	secret := make([]byte, 16)
+	key := make([]byte, 16)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return err
+	}
+	_, err = rand.Read(key)
+	if err != nil {
+		return err
+	}
+	// stored password is 32 hex characters so it doesn't need padding
+	storedPassword := []byte(hex.EncodeToString(secret))
+	password := storedPassword
+	magic := byte(54)
+	if local {
+		password = localWalletPassword(storedPassword)
+		magic = 56
+	}
+	data, err := w.encodePKCS12(password)
+	if err != nil {
+		return err
+	}
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	encrypted := make([]byte, len(storedPassword))
+	cipher.NewCBCEncrypter(blk, autoLoginIV).CryptBlocks(encrypted, storedPassword)
+	header := []byte{161, 248, 78, magic}
+	header = binary.BigEndian.AppendUint32(header, 6)
+	header = binary.BigEndian.AppendUint32(header, uint32(1+len(key)+len(encrypted)))
+	header = append(header, 6)
+	header = append(header, key...)
+	header = append(header, encrypted...)
+	_, err = out.Write(append(header, data...))
+	return err
+}
+
+// encodePKCS12 encode wallet content as PKCS12 encrypted with PBES2
+// (PBKDF2 HMAC-SHA256, AES-256-CBC) and protected by HMAC-SHA1
+func (w *Wallet) encodePKCS12(password []byte) ([]byte, error) {
+	type algorithmIdentifier struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.RawValue `asn1:"optional"`
+	}
+	type contentInfo struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}
+	type encryptedContentInfo struct {
+		ContentType                asn1.ObjectIdentifier
+		ContentEncryptionAlgorithm algorithmIdentifier
+		EncryptedContent           asn1.RawValue
+	}
+	type encryptedData struct {
+		Version              int
+		EncryptedContentInfo encryptedContentInfo
+	}
+	type pbkdf2Params struct {
+		Salt       []byte
+		Iterations int
+		Prf        algorithmIdentifier
+	}
+	type pbes2Params struct {
+		Kdf              algorithmIdentifier
+		EncryptionScheme algorithmIdentifier
+	}
+	type macData struct {
+		Mac struct {
+			Algorithm algorithmIdentifier
+			Digest    []byte
+		}
+		MacSalt    []byte
+		Iterations int
+	}
+	type pfxPdu struct {
+		Version  int
+		AuthSafe contentInfo
+		MacData  macData
+	}
+	if len(w.unmodeled) > 0 {
+		return nil, fmt.Errorf("wallet can't be written because it contains %s", strings.Join(w.unmodeled, ", "))
+	}
+	contents, err := w.encodeSafeContents()
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	iv := make([]byte, 16)
+	macSalt := make([]byte, 16)
+	for _, buffer := range [][]byte{salt, iv, macSalt} {
+		_, err = rand.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	algo := &pbkdf2{
+		defaultAlgorithm: defaultAlgorithm{
+			password:  password,
+			salt:      salt,
+			iv:        iv,
+			iteration: walletIterations,
+		},
+		hash:   sha256.New,
+		keyLen: 32,
+	}
+	err = algo.create()
+	if err != nil {
+		return nil, err
+	}
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: walletIterations,
+		Prf:        algorithmIdentifier{Algorithm: oidHmacWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		Kdf:              algorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme: algorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := asn1.Marshal(encryptedData{
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType: oidDataContentType,
+			ContentEncryptionAlgorithm: algorithmIdentifier{
+				Algorithm:  oidPBES2,
+				Parameters: asn1.RawValue{FullBytes: params},
+			},
+			EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: encrypt(algo, contents)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	authSafe, err := asn1.Marshal([]contentInfo{{ContentType: oidEncryptedDataContentType, Content: explicitTag(encrypted)}})
+	if err != nil {
+		return nil, err
+	}
+	authSafeData, err := asn1.Marshal(authSafe)
+	if err != nil {
+		return nil, err
+	}
+	pfx := pfxPdu{
+		Version:  3,
+		AuthSafe: contentInfo{ContentType: oidDataContentType, Content: explicitTag(authSafeData)},
+	}
+	pfx.MacData.Mac.Algorithm = algorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue}
+	pfx.MacData.Mac.Digest = pkcs12MAC(password, macSalt, walletIterations, authSafe)
+	pfx.MacData.MacSalt = macSalt
+	pfx.MacData.Iterations = walletIterations
+	return asn1.Marshal(pfx)
+}
+
+// encodeSafeContents encode private keys, certificates, certificate requests
+// and secret store credentials as PKCS12 safe bags. bags that are read but not
+// modeled are written back as is
+func (w *Wallet) encodeSafeContents() ([]byte, error) {
+	type safeBag struct {
+		Id         asn1.ObjectIdentifier
+		Data       asn1.RawValue
+		Attributes asn1.RawValue `asn1:"optional"`
+	}
+	type secretEntry struct {
+		Id    string `asn1:"utf8"`
+		Value string `asn1:"utf8"`
+	}
+	var bags []asn1.RawValue
+	appendBag := func(id asn1.ObjectIdentifier, data, content []byte) error {
+		bag := safeBag{Id: id, Data: explicitTag(data)}
+		if attributes, ok := w.bagAttributes[string(content)]; ok {
+			bag.Attributes = asn1.RawValue{FullBytes: attributes}
+		}
+		temp, err := asn1.Marshal(bag)
+		if err != nil {
+			return err
+		}
+		bags = append(bags, asn1.RawValue{FullBytes: temp})
+		return nil
+	}
+	addBag := func(id asn1.ObjectIdentifier, value interface{}, content []byte) error {
+		data, err := asn1.Marshal(value)
+		if err != nil {
+			return err
+		}
+		return appendBag(id, data, content)
+	}
+	for _, key := range w.PrivateKeys {
+		rsaKey, err := x509.ParsePKCS1PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		data, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+		if err != nil {
+			return nil, err
+		}
+		if err = appendBag(oidKeyBag, data, key); err != nil {
+			return nil, err
+		}
+	}
+	typedValue := func(id asn1.ObjectIdentifier, value interface{}) (interface{}, error) {
+		data, err := asn1.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return safeBag{Id: id, Data: explicitTag(data)}, nil
+	}
+	for _, cert := range w.Certificates {
+		value, err := typedValue(oidX509Certificate, cert)
+		if err != nil {
+			return nil, err
+		}
+		if err = addBag(oidCertBag, value, cert); err != nil {
+			return nil, err
+		}
+	}
+	for _, request := range w.CertificateRequests {
+		value, err := typedValue(oidCertificateRequest, request)
+		if err != nil {
+			return nil, err
+		}
+		if err = addBag(oidSecretBag, value, request); err != nil {
+			return nil, err
+		}
+	}
+	for i, cred := range w.credentials {
+		entries := []secretEntry{
+			{fmt.Sprintf("oracle.security.client.connect_string%d", i+1), cred.dsn},
+			{fmt.Sprintf("oracle.security.client.username%d", i+1), cred.username},
+			{fmt.Sprintf("oracle.security.client.password%d", i+1), cred.password},
+		}
+		for _, entry := range entries {
+			value, err := typedValue(oidSecretStoreEntry, entry)
+			if err != nil {
+				return nil, err
+			}
+			if err = addBag(oidSecretBag, value, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, bag := range w.unknownBags {
+		bags = append(bags, asn1.RawValue{FullBytes: bag})
+	}
+	return asn1.Marshal(bags)
+}
+
+func explicitTag(data []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: data}
+}
@@ -0,0 +1,265 @@
+package configurations
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createTestWallet(t *testing.T) *Wallet {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: template.Subject}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := CreateWallet("Welcome#123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyData, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyData})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: request})...)
+	err = wallet.ImportPEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 11; i++ {
+		err = wallet.CreateCredential(fmt.Sprintf("(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=host%d)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=svc)))", i),
+			fmt.Sprintf("user%d", i), fmt.Sprintf("p@ss%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return wallet
+}
+
+func compareWallets(t *testing.T, expected, actual *Wallet) {
+	if len(actual.Certificates) != 1 || !bytes.Equal(actual.Certificates[0], expected.Certificates[0]) {
+		t.Error("certificates don't match")
+	}
+	if len(actual.PrivateKeys) != 1 || !bytes.Equal(actual.PrivateKeys[0], expected.PrivateKeys[0]) {
+		t.Error("private keys don't match")
+	}
+	if len(actual.CertificateRequests) != 1 || !bytes.Equal(actual.CertificateRequests[0], expected.CertificateRequests[0]) {
+		t.Error("certificate requests don't match")
+	}
+	creds := actual.Credentials()
+	if len(creds) != len(expected.credentials) {
+		t.Fatalf("expected %d credentials got %d", len(expected.credentials), len(creds))
+	}
+	for i, cred := range creds {
+		if cred != expected.credentials[i] {
+			t.Errorf("credential %d: expected %v got %v", i, expected.credentials[i], cred)
+		}
+	}
+}
+
+func TestWalletSave(t *testing.T) {
+	wallet := createTestWallet(t)
+	dir := t.TempDir()
+	err := wallet.Save(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	autoLogin, err := NewWallet(path.Join(dir, "cwallet.sso"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareWallets(t, wallet, autoLogin)
+	cred, err := autoLogin.getCredential("host11", 1521, "svc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred == nil || cred.Username() != "user11" || cred.Password() != "p@ss11" {
+		t.Errorf("unexpected credential: %v", cred)
+	}
+	p12, err := OpenWallet(dir, "Welcome#123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareWallets(t, wallet, p12)
+	// wallet loaded from p12 keep its password so it can be saved again
+	err = p12.DeleteCredential(wallet.credentials[0].dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p12.Save(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p12, err = OpenWallet(dir, "Welcome#123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p12.Credentials()) != 10 || p12.Credentials()[0].Username() != "user2" {
+		t.Errorf("unexpected credentials after delete: %v", p12.Credentials())
+	}
+}
+
+func TestWalletAutoLoginLocal(t *testing.T) {
+	wallet := createTestWallet(t)
+	var buffer bytes.Buffer
+	err := wallet.WriteAutoLogin(&buffer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := NewWalletFromReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareWallets(t, wallet, local)
+}
+
+func TestWalletCredentials(t *testing.T) {
+	_, err := CreateWallet("password")
+	if err == nil {
+		t.Error("expected error for password without numbers or special characters")
+	}
+	_, err = CreateWallet("pass#1")
+	if err == nil {
+		t.Error("expected error for short password")
+	}
+	wallet := new(Wallet)
+	if err = wallet.CreateCredential("db", "scott", "tiger"); err != nil {
+		t.Fatal(err)
+	}
+	if err = wallet.CreateCredential("DB", "scott", "tiger"); err == nil {
+		t.Error("expected error for duplicate credential")
+	}
+	if err = wallet.ModifyCredential("db", "adams", "wood"); err != nil {
+		t.Fatal(err)
+	}
+	if cred := wallet.Credentials()[0]; cred.DSN() != "db" || cred.Username() != "adams" || cred.Password() != "wood" {
+		t.Errorf("unexpected credential: %v", cred)
+	}
+	if err = wallet.DeleteCredential("other"); err == nil {
+		t.Error("expected error for missing credential")
+	}
+	if err = wallet.WritePKCS12(&bytes.Buffer{}); err == nil {
+		t.Error("expected error for wallet without password")
+	}
+}
+
+func TestWalletExportPEM(t *testing.T) {
+	wallet := createTestWallet(t)
+	var buffer bytes.Buffer
+	err := wallet.ExportPEM(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tls.X509KeyPair(buffer.Bytes(), buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := new(Wallet)
+	err = imported.ImportPEM(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported.credentials = wallet.credentials
+	compareWallets(t, wallet, imported)
+}
+
+func TestWalletUnknownBags(t *testing.T) {
+	type typedValue struct {
+		Id   asn1.ObjectIdentifier
+		Data asn1.RawValue
+	}
+	type attribute struct {
+		Id     asn1.ObjectIdentifier
+		Values []string `asn1:"set,omitempty"`
+	}
+	type secretEntry struct {
+		Id    string `asn1:"utf8"`
+		Value string `asn1:"utf8"`
+	}
+	marshal := func(value interface{}) []byte {
+		data, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	secretBag := func(id asn1.ObjectIdentifier, value interface{}, attributes []byte) []byte {
+		bag := struct {
+			Id         asn1.ObjectIdentifier
+			Data       asn1.RawValue
+			Attributes asn1.RawValue `asn1:"optional"`
+		}{Id: oidSecretBag, Data: explicitTag(marshal(typedValue{Id: id, Data: explicitTag(marshal(value))}))}
+		if len(attributes) > 0 {
+			bag.Attributes = asn1.RawValue{FullBytes: attributes}
+		}
+		return marshal(bag)
+	}
+	attributes := marshal([]attribute{{Id: oidFriendlyName, Values: []string{"client"}}})
+	// asn1 encode []attribute as SEQUENCE so change the tag into SET
+	attributes[0] = 0x31
+	wallet := createTestWallet(t)
+	wallet.bagAttributes = map[string][]byte{string(wallet.Certificates[0]): attributes}
+	wallet.unknownBags = [][]byte{
+		secretBag(asn1.ObjectIdentifier{1, 2, 3, 4}, []byte{1, 2, 3}, nil),
+		secretBag(oidSecretStoreEntry, secretEntry{"oracle.security.client.default_user", "scott"}, nil),
+	}
+	readBack := func(input *Wallet) *Wallet {
+		var buffer bytes.Buffer
+		err := input.WriteAutoLogin(&buffer, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := NewWalletFromReader(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+	for i, output := range []*Wallet{readBack(wallet), readBack(readBack(wallet))} {
+		compareWallets(t, wallet, output)
+		if len(output.unknownBags) != len(wallet.unknownBags) {
+			t.Fatalf("round %d: expected %d unknown bags got %d", i, len(wallet.unknownBags), len(output.unknownBags))
+		}
+		for x, bag := range wallet.unknownBags {
+			if !bytes.Equal(bag, output.unknownBags[x]) {
+				t.Errorf("round %d: unknown bag %d doesn't match", i, x)
+			}
+		}
+		if !bytes.Equal(attributes, output.bagAttributes[string(wallet.Certificates[0])]) {
+			t.Errorf("round %d: certificate attributes don't match", i)
+		}
+	}
+	// attributes of credential entry can't be written back
+	wallet.unknownBags = [][]byte{secretBag(oidSecretStoreEntry,
+		secretEntry{"oracle.security.client.username12", "scott"}, attributes)}
+	var buffer bytes.Buffer
+	err := readBack(wallet).WriteAutoLogin(&buffer, false)
+	if err == nil || !strings.Contains(err.Error(), "username12") {
+		t.Errorf("expected error for wallet with unmodeled content and got: %v", err)
+	}
+}
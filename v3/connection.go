@@ -6,7 +6,6 @@ import (
 	"crypto/tls"
 	"database/sql"
 	"database/sql/driver"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -18,10 +17,12 @@ import (
 	"time"
 
 	"github.com/sijms/go-ora/v3/aq"
+	"github.com/sijms/go-ora/v3/capture"
 	"github.com/sijms/go-ora/v3/configurations"
 	"github.com/sijms/go-ora/v3/lazy_init"
 	"github.com/sijms/go-ora/v3/parameter_coder"
 	"github.com/sijms/go-ora/v3/trace"
+	"github.com/sijms/go-ora/v3/ttc"
 	"github.com/sijms/go-ora/v3/types"
 
 	"github.com/sijms/go-ora/v3/advanced_nego"
@@ -50,6 +51,7 @@ const (
 	UserAndPass LogonMode = 0x100
 	WithNewPass LogonMode = 0x2
 	PROXY       LogonMode = 0x400
+	IAMToken    LogonMode = 0x20000000
 )
 
 // from GODROR
@@ -59,6 +61,9 @@ const wrapResultset = "--WRAP-RESULTSET--"
 const createLob = "--CREATE-LOB-STREAM--"
 const connRef = "--GET-CONNECTION-REF--"
 
+// ttcFieldVersion23 ttc field version of 23.1 servers
+const ttcFieldVersion23 = 17
+
 // Querier is the QueryContext of sql.Conn.
 type Querier interface {
 	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
@@ -98,6 +103,7 @@ type Connection struct {
 	LogonMode         LogonMode
 	autoCommit        bool
 	tracer            trace.Tracer
+	capture           *capture.Writer
 	connOption        *configurations.ConnectionConfig
 	session           *network.Session
 	tcpNego           *TCPNego
@@ -107,7 +113,7 @@ type Connection struct {
 	dBVersion         *DBVersion
 	sessionID         int
 	serialID          int
-	transactionID     []byte
+	ltxid             []byte
 	sStrConv          converters.IStringConverter
 	nStrConv          converters.IStringConverter
 	cStrConv          converters.IStringConverter
@@ -116,8 +122,9 @@ type Connection struct {
 	oracleTypeCoder   map[uint16]parameter_coder.OracleParameterCoder
 	nameTypeCoder     map[string]parameter_coder.OracleParameterCoder
 	//typeDecoder       map[uint16]type_coder.OracleTypeDecoder
-	cusTyp map[string]types.Object
-	maxLen struct {
+	cusTyp      map[string]types.Object
+	xmlTypeTOID []byte
+	maxLen      struct {
 		varchar   int64
 		nvarchar  int64
 		raw       int64
@@ -126,6 +133,7 @@ type Connection struct {
 		timestamp int64
 	}
 	bad                      bool
+	clientSwitched           bool           // proxy client is switched so the session is not returned to the pool
 	dbTimeZone               *time.Location // equivalent to database timezone used for timestamp with local timezone
 	dbServerTimeZone         *time.Location // equivalent to timezone of the server carry the database
 	dbServerTimeZoneExplicit *time.Location
@@ -134,6 +142,8 @@ type Connection struct {
 	tokenPrivateKey          []byte
 	connectionCookie         *ConnectionCookie
 	newKey                   []byte
+	encryptionAlgo           string
+	checksumAlgo             string
 }
 
 type ConnectionProperties struct {
@@ -156,7 +166,14 @@ type OracleConnector struct {
 	dialer        configurations.DialerContext
 	tlsConfig     *tls.Config
 	kerberos      configurations.KerberosAuthInterface
+	radius        configurations.RadiusChallengeHandler
 	wallet        *configurations.Wallet
+	tokenProvider configurations.TokenProvider
+	onAttempt     configurations.ConnectAttemptCallback
+
+	shardingKey      *configurations.ShardingKey
+	superShardingKey *configurations.ShardingKey
+	revocationCheck  configurations.RevocationChecker
 }
 
 func NewConnector(connString string) driver.Connector {
@@ -194,11 +211,28 @@ func (connector *OracleConnector) Connect(ctx context.Context) (driver.Conn, err
 	if conn.connOption.Kerberos == nil {
 		conn.connOption.Kerberos = connector.kerberos
 	}
+	if conn.connOption.RadiusChallenge == nil {
+		conn.connOption.RadiusChallenge = connector.radius
+	}
 	if conn.connOption.Wallet == nil && connector.wallet != nil {
 		conn.connOption.Wallet = connector.wallet
 	}
+	if connector.tokenProvider != nil {
+		conn.connOption.SetTokenProvider(connector.tokenProvider)
+	}
+	if conn.connOption.OnConnectAttempt == nil {
+		conn.connOption.OnConnectAttempt = connector.onAttempt
+	}
+	if conn.connOption.RevocationCheck == nil {
+		conn.connOption.RevocationCheck = connector.revocationCheck
+	}
+	if connector.shardingKey != nil || connector.superShardingKey != nil {
+		conn.connOption.ShardingKey = connector.shardingKey
+		conn.connOption.SuperShardingKey = connector.superShardingKey
+	}
 	err = conn.OpenWithContext(ctx)
 	if err != nil {
+		conn.closeCapture()
 		return nil, err
 	}
 	err = connector.drv.initFromConn(conn)
@@ -234,6 +268,30 @@ func (connector *OracleConnector) WithKerberosAuth(auth configurations.KerberosA
 	connector.kerberos = auth
 }
 
+// WithRadiusChallenge sets the handler called when RADIUS server request a challenge response
+// (e.g. one-time code). It does not enable RADIUS; set AUTH TYPE to RADIUS to do so.
+func (connector *OracleConnector) WithRadiusChallenge(handler configurations.RadiusChallengeHandler) {
+	connector.radius = handler
+}
+
+// WithTokenProvider sets the provider called for each new connection to get database access token.
+// use it for OAuth2 bearer tokens or IAM database tokens (with private key). token authentication requires TCPS
+func (connector *OracleConnector) WithTokenProvider(provider configurations.TokenProvider) {
+	connector.tokenProvider = provider
+}
+
+// WithConnectAttemptCallback sets a callback that report each try to connect to a server address
+// during connect-time failover
+func (connector *OracleConnector) WithConnectAttemptCallback(callback configurations.ConnectAttemptCallback) {
+	connector.onAttempt = callback
+}
+
+// WithRevocationCheck sets a hook called with verified server certificate chains
+// of TLS connections (e.g. CRL or OCSP check)
+func (connector *OracleConnector) WithRevocationCheck(check configurations.RevocationChecker) {
+	connector.revocationCheck = check
+}
+
 // Open return a new open connection
 func (driver *OracleDriver) Open(name string) (driver.Conn, error) {
 	conn, err := NewConnection(name, driver.connOption)
@@ -516,27 +574,46 @@ func (conn *Connection) read() error {
 	return nil
 }
 
+// openCapture start writing network packets into capture file when CAPTURE FILE
+// or CAPTURE DIR option is present
+func (conn *Connection) openCapture() error {
+	path := conn.connOption.CaptureFilePath
+	if len(conn.connOption.CaptureDir) > 0 {
+		if err := os.MkdirAll(conn.connOption.CaptureDir, os.ModePerm); err != nil {
+			return fmt.Errorf("can't create capture directory: %w", err)
+		}
+		now := time.Now()
+		path = fmt.Sprintf("%s/capture_%d_%02d_%02d_%02d_%02d_%02d_%d.ocap", conn.connOption.CaptureDir,
+			now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(),
+			now.Nanosecond())
+	}
+	if len(path) == 0 {
+		return nil
+	}
+	writer, err := capture.Create(path)
+	if err != nil {
+		return fmt.Errorf("can't open capture file: %w", err)
+	}
+	conn.capture = writer
+	conn.session.SetPacketRecorder(writer)
+	conn.tracer.Print("Capture packets into: ", path)
+	return nil
+}
+
+func (conn *Connection) closeCapture() {
+	if conn.capture != nil {
+		if err := conn.capture.Close(); err != nil {
+			conn.tracer.Print("Capture closed with error: ", err)
+		}
+		conn.capture = nil
+	}
+}
+
 // Open the connection = bring it online
 func (conn *Connection) Open() error {
 	return conn.OpenWithContext(context.Background())
 }
 
-// func (conn *Connection) restore() error {
-//	tracer := conn.tracer
-//	failOver := conn.connOption.Failover
-//	var err error
-//	for trial := 0; trial < failOver; trial++ {
-//		tracer.Print("reconnect trial #", trial+1)
-//		err = conn.Open()
-//		if err != nil {
-//			tracer.Print("Error: ", err)
-//			continue
-//		}
-//		break
-//	}
-//	return err
-// }
-
 // OpenWithContext open the connection with timeout context
 func (conn *Connection) OpenWithContext(ctx context.Context) error {
 	if len(conn.connOption.TraceDir) > 0 {
@@ -582,6 +659,9 @@ func (conn *Connection) OpenWithContext(ctx context.Context) error {
 	}
 	conn.connOption.ResetServerIndex()
 	conn.session = network.NewSession(conn.connOption, conn.tracer)
+	if err := conn.openCapture(); err != nil {
+		return err
+	}
 	W := conn.connOption.Wallet
 	if conn.connOption.SSL && W != nil {
 		err := conn.session.LoadSSLData(W.Certificates, W.PrivateKeys, W.CertificateRequests)
@@ -597,8 +677,13 @@ func (conn *Connection) OpenWithContext(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	err = conn.loadAccessToken(ctx)
+	if err != nil {
+		return err
+	}
 	// advanced negotiation
 	var ano *advanced_nego.AdvNego = nil
+	conn.encryptionAlgo, conn.checksumAlgo = "", ""
 	if session.Context.ACFL0&1 != 0 && session.Context.ACFL0&4 == 0 && session.Context.ACFL1&8 == 0 {
 		tracer.Print("Advance Negotiation")
 		ano, err = advanced_nego.NewAdvNego(session, conn.tracer, conn.connOption)
@@ -617,8 +702,19 @@ func (conn *Connection) OpenWithContext(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		conn.encryptionAlgo, conn.checksumAlgo = ano.EncryptionAlgorithm(), ano.ChecksumAlgorithm()
+		tracer.Printf("Network Encryption: %q, Data Integrity: %q", conn.encryptionAlgo, conn.checksumAlgo)
+	}
+	if ano != nil {
+		// nil *AdvNego stored in the interface is not nil
+		conn.session.Context.SetAdvancedNegotiator(ano)
+	}
+	if ano != nil && ano.IsRadiusAuth() {
+		err = ano.RadiusHandshake()
+		if err != nil {
+			return err
+		}
 	}
-	conn.session.Context.SetAdvancedNegotiator(ano)
 	// if fast login enabled this means new features are available
 	if conn.session.Context.FastAuthEnabled {
 		session.UseBigClrChunks = true
@@ -752,6 +848,32 @@ func (conn *Connection) OpenWithContext(ctx context.Context) error {
 		}
 	}
 	conn.getDBServerTimeZone()
+	return conn.setRoles(ctx)
+}
+
+// loadAccessToken get access token from token provider (if present) before authentication
+func (conn *Connection) loadAccessToken(ctx context.Context) error {
+	conn.token, conn.tokenPrivateKey = nil, nil
+	provider := conn.connOption.TokenProvider
+	if provider == nil {
+		return nil
+	}
+	if !conn.connOption.SSL {
+		return errors.New("token authentication requires TCPS protocol")
+	}
+	token, err := provider.GetToken(ctx)
+	if err != nil {
+		return fmt.Errorf("token provider: %w", err)
+	}
+	if token == nil || len(token.Token) == 0 {
+		return errors.New("token provider returned empty token")
+	}
+	if token.IsExpired(0) {
+		return fmt.Errorf("access token expired at: %v", token.ExpiresAt())
+	}
+	conn.tracer.Print("Using token authentication")
+	conn.token = []byte(token.Token)
+	conn.tokenPrivateKey = token.PrivateKey
 	return nil
 }
 
@@ -797,6 +919,22 @@ func (conn *Connection) getDBTimeZone() error {
 	return nil
 }
 
+// getXMLTypeTOID return type object id of SYS.XMLTYPE which is required for
+// output XMLTYPE parameters
+func (conn *Connection) getXMLTypeTOID() ([]byte, error) {
+	if len(conn.xmlTypeTOID) > 0 {
+		return conn.xmlTypeTOID, nil
+	}
+	var toid []byte
+	err := conn.QueryRowContext(context.Background(),
+		"SELECT TYPE_OID FROM ALL_TYPES WHERE OWNER='SYS' AND TYPE_NAME='XMLTYPE'", nil).Scan(&toid)
+	if err != nil {
+		return nil, err
+	}
+	conn.xmlTypeTOID = toid
+	return toid, nil
+}
+
 // Begin a transaction
 func (conn *Connection) Begin() (driver.Tx, error) {
 	conn.tracer.Print("Begin transaction")
@@ -829,22 +967,6 @@ func NewConnection(databaseUrl string, config *configurations.ConnectionConfig)
 			return nil, errors.New("database url or configuration is required")
 		}
 	}
-	var token, tokenPrivateKey []byte
-	if len(config.TokenFile) > 0 {
-		token, err = os.ReadFile(config.TokenFile)
-		if err != nil {
-			return nil, err
-		}
-	}
-	if len(config.TokenPrivateKeyFile) > 0 {
-		tokenPrivateKey, err = os.ReadFile(config.TokenPrivateKeyFile)
-		if err != nil {
-			return nil, err
-		}
-	}
-	if len(token) > 0 && len(tokenPrivateKey) == 0 {
-		return nil, errors.New("token private key file is required")
-	}
 	// conStr, err := newConnectionStringFromUrl(databaseUrl)
 	temp := new(configurations.ConnectionConfig)
 	*temp = *config
@@ -863,8 +985,6 @@ func NewConnection(databaseUrl string, config *configurations.ConnectionConfig)
 			date      int64
 			timestamp int64
 		}{varchar: 0x7FFF, nvarchar: 0x7FFF, raw: 0x7FFF, number: 0x16, date: 0xB, timestamp: 0xB},
-		token:           token,
-		tokenPrivateKey: tokenPrivateKey,
 	}, nil
 }
 
@@ -933,6 +1053,7 @@ func (conn *Connection) Close() (err error) {
 		conn.session.Disconnect()
 		conn.session = nil
 	}
+	conn.closeCapture()
 	conn.State = Closed
 	conn.tracer.Print("Connection Closed")
 	_ = conn.tracer.Close()
@@ -1015,24 +1136,23 @@ func (conn *Connection) doAuth() error {
 		}
 	}
 	stop := false
+	decoder := ttc.NewDecoder(conn.session, conn.session.Capabilities())
+	decoder.Call = ttc.CallAuthPhaseTwo
 	for !stop {
 		msg, err := conn.session.GetByte()
 		if err != nil {
 			return err
 		}
 		switch msg {
-		case 8:
-			dictLen, err := conn.session.GetInt(2, true, true)
+		case ttc.MsgReturnParameters:
+			message, err := decoder.Decode(msg)
 			if err != nil {
 				return err
 			}
-			conn.SessionProperties = make(map[string]string, dictLen)
-			for x := 0; x < dictLen; x++ {
-				key, val, _, err := conn.session.GetKeyVal()
-				if err != nil {
-					return err
-				}
-				conn.SessionProperties[string(key)] = string(val)
+			rpa := message.(*ttc.ReturnParameters)
+			conn.SessionProperties = make(map[string]string, len(rpa.KeyValues))
+			for _, kv := range rpa.KeyValues {
+				conn.SessionProperties[string(kv.Key)] = string(kv.Value)
 			}
 		default:
 			err = conn.ProcessTCCResponse(msg)
@@ -1051,145 +1171,27 @@ func (conn *Connection) doAuth() error {
 	return nil
 }
 
-// loadNLSData get nls data for v2
-func (conn *Connection) loadNLSData() error {
-	_, err := conn.session.GetInt(2, true, true)
-	if err != nil {
-		return err
-	}
-	_, err = conn.session.GetByte()
-	if err != nil {
-		return err
-	}
-	length, err := conn.session.GetInt(4, true, true)
-	if err != nil {
-		return err
-	}
-	_, err = conn.session.GetByte()
-	if err != nil {
-		return err
-	}
-	for i := 0; i < length; i++ {
-		nlsKey, nlsVal, nlsCode, err := conn.session.GetKeyVal()
-		if err != nil {
-			return err
-		}
-		conn.NLSData.SaveNLSValue(string(nlsKey), string(nlsVal), nlsCode)
-	}
-	_, err = conn.session.GetInt(4, true, true)
-	return err
-}
-
-func (conn *Connection) getServerNetworkInformation(code uint8) error {
-	session := conn.session
-	if code == 0 {
-		_, err := session.GetByte()
-		return err
-	}
-	switch code - 1 {
-	case 1:
-		// receive OCOSPID
-		length, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetByte()
-		if err != nil {
-			return err
-		}
-		_, err = session.GetBytes(length)
-		if err != nil {
-			return err
-		}
-	case 3:
-		// receive OCSESSRET session return values
-		_, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetByte()
-		if err != nil {
-			return err
-		}
-		length, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-		// get nls data
-		for i := 0; i < length; i++ {
-			nlsKey, nlsVal, nlsCode, err := session.GetKeyVal()
-			if err != nil {
-				return err
-			}
-			conn.NLSData.SaveNLSValue(string(nlsKey), string(nlsVal), nlsCode)
-		}
-		flag, err := session.GetInt(4, true, true)
-		if err != nil {
-			return err
+// applyServerPiggyback save session information sent by server side piggyback
+func (conn *Connection) applyServerPiggyback(msg *ttc.ServerPiggyback) {
+	switch msg.OpCode {
+	case ttc.PiggybackSessionReturn:
+		for _, kv := range msg.KeyValues {
+			conn.NLSData.SaveNLSValue(string(kv.Key), string(kv.Value), kv.Num)
 		}
-		sessionID, err := session.GetInt(4, true, true)
-		if err != nil {
-			return err
-		}
-		serialID, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-		if flag&4 == 4 {
-			conn.sessionID = sessionID
-			conn.serialID = serialID
+		if msg.Flag&4 == 4 {
 			// save session id and serial number to connection
+			conn.sessionID = msg.SessionID
+			conn.serialID = msg.SerialID
 		}
-	case 4:
-		err := conn.loadNLSData()
-		if err != nil {
-			return err
-		}
-	case 6:
-		length, err := session.GetInt(4, true, true)
-		if err != nil {
-			return err
-		}
-		conn.transactionID, err = session.GetClr()
-		if len(conn.transactionID) > length {
-			conn.transactionID = conn.transactionID[:length]
-		}
-	case 7:
-		_, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetByte()
-		if err != nil {
-			return err
-		}
-		_, err = session.GetInt(4, true, true)
-		if err != nil {
-			return err
+	case ttc.PiggybackNLS:
+		for _, kv := range msg.KeyValues {
+			conn.NLSData.SaveNLSValue(string(kv.Key), string(kv.Value), kv.Num)
 		}
-		_, err = session.GetInt(4, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetByte()
-		if err != nil {
-			return err
-		}
-		_, err = session.GetDlc()
-		if err != nil {
-			return err
-		}
-	case 8:
-		_, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetByte()
-		if err != nil {
-			return err
+	case ttc.PiggybackLTXID:
+		if msg.Data != nil {
+			conn.ltxid = msg.Data
 		}
 	}
-	return nil
 }
 
 // SaveNLSValue a helper function that convert between nls key and code
@@ -1567,128 +1569,43 @@ func (conn *Connection) PrepareContext(ctx context.Context, query string) (drive
 func (conn *Connection) ProcessTCCResponse(msgCode uint8) error {
 	session := conn.session
 	tracer := conn.tracer
-	var err error
 	switch msgCode {
-	case 4:
-		// if conn.session.IsBreak() {
-		//	if conn.session.RestoreIndex() {
-		//		_, _ = conn.session.GetByte()
-		//	}
-		//	conn.session.ResetBreak()
-		// }
-		conn.session.Summary, err = network.NewSummary(session)
-		if err != nil {
-			return err
-		}
+	case ttc.MsgError, ttc.MsgReturnParameters, ttc.MsgStatus, ttc.MsgWarning, ttc.MsgServerPiggyback, ttc.MsgRenegotiate:
+	default:
+		return &ttc.UnknownMessageError{MsgCode: msgCode}
+	}
+	decoder := ttc.NewDecoder(session, session.Capabilities())
+	decoder.ArrayRowCounts = true
+	msg, err := decoder.Decode(msgCode)
+	if err != nil {
+		return err
+	}
+	switch msg := msg.(type) {
+	case *ttc.Error:
+		session.Summary = msg.Summary
 		tracer.Printf("Summary: RetCode:%d, Error Message:%q", session.Summary.RetCode, string(session.Summary.ErrorMessage))
-		if conn.session.HasError() {
-			return conn.session.GetError()
-		}
-	case 8:
-		size, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
+		if session.HasError() {
+			return session.GetError()
 		}
-		for x := 0; x < size; x++ {
-			_, err = session.GetInt(4, true, true)
-			if err != nil {
-				return err
-			}
-		}
-		// for x := 0; x < 2; x++ {
-		//	_, err = session.GetInt(4, true, true)
-		//	if err != nil {
-		//		return err
-		//	}
-		// }
-		// for x := 2; x < size; x++ {
-		//	_, err = session.GetInt(4, true, true)
-		//	if err != nil {
-		//		return err
-		//	}
-		// }
-		_, err = session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-		size, err = session.GetInt(2, true, true)
-		for x := 0; x < size; x++ {
-			_, val, num, err := session.GetKeyVal()
-			if err != nil {
-				return err
-			}
-			// fmt.Println(key, val, num)
-			if num == 163 {
-				session.TimeZone = val
-				// fmt.Println("session time zone", session.TimeZone)
-			}
-		}
-		if session.TTCVersion >= 4 {
-			// get queryID
-			size, err = session.GetInt(4, true, true)
-			if err != nil {
-				return err
-			}
-			if size > 0 {
-				bty, err := session.GetBytes(size)
-				if err != nil {
-					return err
-				}
-				if len(bty) >= 8 {
-					queryID := binary.LittleEndian.Uint64(bty[size-8:])
-					_, _ = os.Stderr.WriteString(fmt.Sprintln("query ID: ", queryID))
-				}
-			}
-		}
-		if session.TTCVersion >= 7 {
-			length, err := session.GetInt(4, true, true)
-			if err != nil {
-				return err
-			}
-			for i := 0; i < length; i++ {
-				_, err = session.GetInt(8, true, true)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	case 9:
-		if session.HasEOSCapability {
-			temp, err := session.GetInt(4, true, true)
-			if err != nil {
-				return err
-			}
-			if session.Summary != nil {
-				session.Summary.EndOfCallStatus = temp
-			}
+	case *ttc.ReturnParameters:
+		conn.applyReturnParameters(msg)
+	case *ttc.Status:
+		if session.HasEOSCapability && session.Summary != nil {
+			session.Summary.EndOfCallStatus = msg.EndOfCallStatus
 		}
 		if session.HasFSAPCapability {
 			if session.Summary == nil {
 				session.Summary = new(network.SummaryObject)
 			}
-			session.Summary.EndToEndECIDSequence, err = session.GetInt(2, true, true)
-			if err != nil {
-				return err
-			}
-		}
-	case 15:
-		warning, err := network.NewWarningObject(session)
-		if err != nil {
-			return err
+			session.Summary.EndToEndECIDSequence = msg.ECIDSequence
 		}
-		if warning != nil {
-			_, _ = os.Stderr.WriteString(fmt.Sprintln(warning))
+	case *ttc.Warning:
+		if len(msg.Message) > 0 {
+			_, _ = os.Stderr.WriteString(fmt.Sprintln(msg))
 		}
-	case 23:
-		opCode, err := session.GetByte()
-		if err != nil {
-			return err
-		}
-		err = conn.getServerNetworkInformation(opCode)
-		if err != nil {
-			return err
-		}
-	case 28:
+	case *ttc.ServerPiggyback:
+		conn.applyServerPiggyback(msg)
+	case *ttc.Renegotiate:
 		tracer.Print("Fast Negotiation REJECTED by Server")
 		err = conn.protocolNegotiation()
 		if err != nil {
@@ -1698,20 +1615,31 @@ func (conn *Connection) ProcessTCCResponse(msgCode uint8) error {
 		if err != nil {
 			return err
 		}
-	default:
-		return errors.New(fmt.Sprintf("TTC error: received code %d during response reading", msgCode))
 	}
 	return nil
 	// cancel loop if = 4 or 9
 }
 
+// applyReturnParameters save session time zone and trace query id of general
+// return parameters
+func (conn *Connection) applyReturnParameters(msg *ttc.ReturnParameters) {
+	for _, kv := range msg.KeyValues {
+		if kv.Num == 163 {
+			conn.session.TimeZone = kv.Value
+		}
+	}
+	if msg.QueryID != 0 {
+		conn.tracer.Printf("Query ID: %d", msg.QueryID)
+	}
+}
+
 func (conn *Connection) setBad() {
 	conn.bad = true
 }
 
 // ResetSession decides responsible for resetting a connection. Part of a keepConnOnRollback condition to decide if to keep a transaction after rollback.
 func (conn *Connection) ResetSession(_ context.Context) error {
-	if conn.bad {
+	if conn.bad || conn.clientSwitched {
 		return driver.ErrBadConn
 	}
 	return nil
@@ -1719,8 +1647,9 @@ func (conn *Connection) ResetSession(_ context.Context) error {
 
 // IsValid validates if a connection has to be discarded. Part of a keepConnOnRollback condition to decide if to keep a transaction after rollback.
 func (conn *Connection) IsValid() bool {
-	// Connection is valid if it's not marked as bad and is in opened state
-	return !conn.bad && conn.State == Opened
+	// Connection is valid if it's not marked as bad, its proxy client is not
+	// switched and is in opened state
+	return !conn.bad && !conn.clientSwitched && conn.State == Opened
 }
 
 func (conn *Connection) dataTypeNegotiation() error {
@@ -1768,6 +1697,16 @@ func (conn *Connection) GetSession() network.SessionReadWriter {
 	return conn.session
 }
 
+// StartContext break current network operation when ctx is done. used by
+// packages that drive the session directly (e.g. aq)
+func (conn *Connection) StartContext(ctx context.Context) chan struct{} {
+	return conn.session.StartContext(ctx)
+}
+
+func (conn *Connection) EndContext(done chan struct{}) {
+	conn.session.EndContext(done)
+}
+
 func (conn *Connection) NewLobStreamer() types.LobStreamer {
 	return &LobStream{conn: conn}
 }
@@ -1804,6 +1743,15 @@ func (conn *Connection) GetParameterCoder(input interface{}) (parameter_coder.Or
 	}
 }
 
+// SupportNativeBoolean return true if the server support SQL BOOLEAN type (23ai and later).
+// older servers only support boolean inside PL/SQL
+func (conn *Connection) SupportNativeBoolean() bool {
+	if conn.session != nil && conn.session.TTCVersion >= ttcFieldVersion23 {
+		return true
+	}
+	return conn.dBVersion != nil && conn.dBVersion.MajorVersion >= 23
+}
+
 func (conn *Connection) SendTimeAsUTC() bool {
 	return conn.dataNego.serverTZVersion > 0 && conn.dataNego.clientTZVersion == conn.dataNego.serverTZVersion
 	//return !(conn.dataNego.serverTZVersion > 0 && conn.dataNego.clientTZVersion != conn.dataNego.serverTZVersion)
@@ -1820,3 +1768,9 @@ func (conn *Connection) GetDBServerTimeZone() *time.Location {
 func (conn *Connection) TTCVersion() uint8 {
 	return conn.session.TTCVersion
 }
+
+// NetworkSecurity return names of encryption and data integrity algorithms negotiated
+// for native network encryption. empty name means the service isn't active
+func (conn *Connection) NetworkSecurity() (encryption, checksum string) {
+	return conn.encryptionAlgo, conn.checksumAlgo
+}
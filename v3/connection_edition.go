@@ -0,0 +1,64 @@
+package go_ora
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var editionNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
+
+// GetEdition return the edition name of the current session
+func (conn *Connection) GetEdition(ctx context.Context) (string, error) {
+	var edition string
+	err := conn.QueryRowContext(ctx, "SELECT SYS_CONTEXT('USERENV', 'CURRENT_EDITION_NAME') FROM DUAL", nil).Scan(&edition)
+	if err != nil {
+		return "", err
+	}
+	return edition, nil
+}
+
+// SetEdition change the edition of the current session.
+// the new edition is also used when the connection is re-opened
+func (conn *Connection) SetEdition(ctx context.Context, edition string) error {
+	edition = strings.TrimSpace(edition)
+	if !editionNameRegexp.MatchString(edition) {
+		return fmt.Errorf("invalid edition name: %q", edition)
+	}
+	_, err := conn.ExecContext(ctx, "ALTER SESSION SET EDITION = "+edition, nil)
+	if err != nil {
+		return err
+	}
+	conn.connOption.ApplicationEdition = edition
+	return nil
+}
+
+// GetConnectionEdition return the edition of the session behind sql.Conn
+func GetConnectionEdition(ctx context.Context, conn *sql.Conn) (edition string, err error) {
+	rawErr := conn.Raw(func(driverConn interface{}) error {
+		oraConn, ok := driverConn.(*Connection)
+		if !ok {
+			return errors.New("the driver used is not a go-ora driver type")
+		}
+		edition, err = oraConn.GetEdition(ctx)
+		return nil
+	})
+	if rawErr != nil {
+		return "", rawErr
+	}
+	return
+}
+
+// SetConnectionEdition change the edition of the session behind sql.Conn
+func SetConnectionEdition(ctx context.Context, conn *sql.Conn, edition string) error {
+	return conn.Raw(func(driverConn interface{}) error {
+		oraConn, ok := driverConn.(*Connection)
+		if !ok {
+			return errors.New("the driver used is not a go-ora driver type")
+		}
+		return oraConn.SetEdition(ctx, edition)
+	})
+}
@@ -0,0 +1,98 @@
+package go_ora
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sijms/go-ora/v3/configurations"
+)
+
+var roleNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
+
+// setRoles enable proxy roles of the session
+func (conn *Connection) setRoles(ctx context.Context) error {
+	roles := conn.connOption.ProxyRoles
+	if len(roles) == 0 {
+		return nil
+	}
+	for _, role := range roles {
+		if !roleNameRegexp.MatchString(role) {
+			return fmt.Errorf("invalid role name: %q", role)
+		}
+	}
+	_, err := conn.ExecContext(ctx, "SET ROLE "+strings.Join(roles, ", "), nil)
+	return err
+}
+
+// SwitchProxyClient end the current session and authenticate a new session for
+// client through the proxy user on the same network connection. TLS, network
+// encryption and protocol negotiation are kept so it is cheaper than reconnect.
+// the switch is refused inside transaction. if authentication fail the
+// connection is marked bad. after the switch the connection is not valid for
+// database/sql pool so it is closed when released instead of being handed to
+// another caller with the identity of the client
+func (conn *Connection) SwitchProxyClient(ctx context.Context, client configurations.ProxyClient) error {
+	if conn.State != Opened {
+		return errors.New("connection is not open")
+	}
+	if !conn.autoCommit {
+		return errors.New("can't switch proxy client inside transaction")
+	}
+	if len(client.Name) == 0 && len(client.DistinguishedName) == 0 && len(client.Certificate) == 0 {
+		return errors.New("proxy client require name, distinguished name or certificate")
+	}
+	done := conn.session.StartContext(ctx)
+	defer conn.session.EndContext(done)
+	err := conn.freeTemporaryLobs()
+	if err != nil {
+		conn.tracer.Printf("Error free temporary lobs: %v", err)
+	}
+	err = conn.Logoff()
+	if err != nil {
+		conn.setBad()
+		return err
+	}
+	conn.clientSwitched = true
+	conn.connOption.SetProxyClient(client)
+	// fast login piggyback protocol negotiation on authentication which is
+	// already done for this connection
+	fastLogin := conn.connOption.FastLogin
+	conn.connOption.FastLogin = false
+	err = conn.doAuth()
+	conn.connOption.FastLogin = fastLogin
+	if err != nil {
+		conn.setBad()
+		return err
+	}
+	sessionID, err := strconv.ParseUint(conn.SessionProperties["AUTH_SESSION_ID"], 10, 32)
+	if err != nil {
+		conn.setBad()
+		return err
+	}
+	conn.sessionID = int(sessionID)
+	serialNum, err := strconv.ParseUint(conn.SessionProperties["AUTH_SERIAL_NUM"], 10, 32)
+	if err != nil {
+		conn.setBad()
+		return err
+	}
+	conn.serialID = int(serialNum)
+	return conn.setRoles(ctx)
+}
+
+// SwitchConnectionProxyClient switch proxy client of the session behind sql.Conn.
+// conn keep the client until it is closed. then the session is closed instead
+// of returning to the pool
+func SwitchConnectionProxyClient(ctx context.Context, conn *sql.Conn, client configurations.ProxyClient) error {
+	return conn.Raw(func(driverConn interface{}) error {
+		oraConn, ok := driverConn.(*Connection)
+		if !ok {
+			return errors.New("the driver used is not a go-ora driver type")
+		}
+		return oraConn.SwitchProxyClient(ctx, client)
+	})
+}
@@ -0,0 +1,105 @@
+package go_ora_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/configurations"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func TestSwitchProxyClient(t *testing.T) {
+	server := newServer(t)
+	server.Handle("SET ROLE HR_READ, HR_WRITE", oratest.ExecResult(0))
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = go_ora.SwitchConnectionProxyClient(ctx, conn, configurations.ProxyClient{
+		Name: "adams", Password: "secret", Roles: []string{"HR_READ", "HR_WRITE"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logins := server.Logins()
+	if len(logins) != 2 {
+		t.Fatalf("expected 2 logins got: %d", len(logins))
+	}
+	if logins[1]["PROXY_CLIENT_NAME"] != "adams" {
+		t.Errorf("expected PROXY_CLIENT_NAME adams got: %q", logins[1]["PROXY_CLIENT_NAME"])
+	}
+	// client password is encrypted by the session key
+	if password := logins[1]["PROXY_CLIENT_PASSWORD"]; len(password) == 0 || password == "secret" {
+		t.Errorf("unexpected PROXY_CLIENT_PASSWORD: %q", password)
+	}
+	statements := server.Statements()
+	if len(statements) == 0 || statements[len(statements)-1] != "SET ROLE HR_READ, HR_WRITE" {
+		t.Errorf("expected roles to be set got: %v", statements)
+	}
+	// distinguished name and certificate replace the client name
+	err = go_ora.SwitchConnectionProxyClient(ctx, conn, configurations.ProxyClient{DistinguishedName: "CN=adams"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = go_ora.SwitchConnectionProxyClient(ctx, conn, configurations.ProxyClient{Certificate: []byte{0xAB, 0x01}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logins = server.Logins()
+	if logins[2]["PROXY_CLIENT_DN"] != "CN=adams" || len(logins[2]["PROXY_CLIENT_NAME"]) > 0 {
+		t.Errorf("unexpected distinguished name login: %v", logins[2])
+	}
+	if logins[3]["PROXY_CLIENT_CERT"] != "AB01" || len(logins[3]["PROXY_CLIENT_DN"]) > 0 {
+		t.Errorf("unexpected certificate login: %v", logins[3])
+	}
+	// the session still serve the caller
+	if err = conn.PingContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// switched session is closed instead of returning to the pool
+	if err = conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if open := db.Stats().OpenConnections; open != 0 {
+		t.Errorf("expected switched session to be closed got %d open connections", open)
+	}
+}
+
+func TestSwitchProxyClientError(t *testing.T) {
+	server := newServer(t)
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// invalid role is refused after authentication
+	err = go_ora.SwitchConnectionProxyClient(ctx, conn, configurations.ProxyClient{Name: "adams", Roles: []string{"HR; DROP"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid role name") {
+		t.Errorf("expected invalid role error got: %v", err)
+	}
+	// authentication failure mark the connection bad
+	server.AddUser(oratest.DefaultUser, "changed")
+	err = go_ora.SwitchConnectionProxyClient(ctx, conn, configurations.ProxyClient{Name: "adams"})
+	var oraErr *network.OracleError
+	if !errors.As(err, &oraErr) || oraErr.ErrCode != 1017 {
+		t.Fatalf("expected ORA-01017 got: %v", err)
+	}
+	err = conn.Raw(func(driverConn interface{}) error {
+		if driverConn.(driver.Validator).IsValid() {
+			t.Error("expected connection to be invalid after failed switch")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
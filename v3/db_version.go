@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/ttc"
 )
 
 type DBVersion struct {
@@ -33,25 +34,17 @@ func GetDBVersion(session *network.Session) (*DBVersion, error) {
 	if err != nil {
 		return nil, err
 	}
-	msg, err := session.GetByte()
+	decoder := ttc.NewDecoder(session, session.Capabilities())
+	decoder.Call = ttc.CallVersion
+	msg, err := decoder.Next()
 	if err != nil {
 		return nil, err
 	}
-	if msg != 8 {
-		return nil, errors.New(fmt.Sprintf("message code error: received code %d and expected code is 8", msg))
-	}
-	length, err := session.GetInt(2, true, true)
-	if err != nil {
-		return nil, err
-	}
-	info, err := session.GetString(int(length))
-	if err != nil {
-		return nil, err
-	}
-	number, err := session.GetInt(4, true, true)
-	if err != nil {
-		return nil, err
+	rpa, ok := msg.(*ttc.ReturnParameters)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("message code error: received code %d and expected code is 8", msg.Code()))
 	}
+	info, number := rpa.Banner, rpa.Number
 	version := (number>>24&0xFF)*1000 + (number>>20&0xF)*100 + (number>>12&0xF)*10 + (number >> 8 & 0xF)
 	text := fmt.Sprintf("%d.%d.%d.%d.%d", number>>24&0xFF, number>>20&0xF,
 		number>>12&0xF, number>>8&0xF, number&0xFF)
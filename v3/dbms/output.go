@@ -0,0 +1,376 @@
+// Package dbms read DBMS_OUTPUT of oracle session.
+//
+// DBMS_OUTPUT buffer belongs to the database session so the output must be
+// enabled and read on the same session that produce it. Output is bound to a
+// pinned *sql.Conn (or *go_ora.Connection) instead of *sql.DB whose pool may
+// use different session for each call:
+//
+//	conn, _ := db.Conn(ctx)
+//	defer conn.Close()
+//	output, _ := dbms.NewOutput(ctx, conn, dbms.WithWriter(os.Stdout))
+//	defer output.Close(ctx)
+//	_, _ = output.ExecContext(ctx, "BEGIN DBMS_OUTPUT.PUT_LINE('hello'); END;")
+package dbms
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+
+	go_ora "github.com/sijms/go-ora/v3"
+)
+
+const (
+	// MaxBufferSize is the largest chunk of lines read in one round trip
+	MaxBufferSize = 0x7FFF
+	// MinBufferSize is the smallest chunk of lines read in one round trip
+	MinBufferSize = 2000
+)
+
+// fetchBlock read lines from DBMS_OUTPUT until the buffer is full. the line
+// that doesn't fit in the buffer is returned alone in %line%. parameters appear
+// in the order of bind arguments: max, done, buffer, pending, line
+const fetchBlock = `DECLARE
+	l_line VARCHAR2(32767);
+	l_done NUMBER := 0;
+	l_buffer VARCHAR2(32767);
+	l_pending NUMBER := 0;
+BEGIN
+	LOOP
+		DBMS_OUTPUT.GET_LINE(l_line, l_done);
+		EXIT WHEN l_done = 1;
+		IF NVL(LENGTHB(l_buffer), 0) + NVL(LENGTHB(l_line), 0) + 1 > %max% THEN
+			l_pending := 1;
+			EXIT;
+		END IF;
+		l_buffer := l_buffer || l_line || CHR(10);
+	END LOOP;
+	%done% := l_done;
+	%buffer% := l_buffer;
+	%pending% := l_pending;
+	%line% := CASE WHEN l_pending = 1 THEN l_line END;
+END;`
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// connectionExecer call ExecContext of go-ora connection
+type connectionExecer struct {
+	conn *go_ora.Connection
+}
+
+func (exec connectionExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	namedArgs := make([]driver.NamedValue, len(args))
+	for x, arg := range args {
+		namedArgs[x] = driver.NamedValue{Ordinal: x + 1, Value: arg}
+		if named, ok := arg.(sql.NamedArg); ok {
+			namedArgs[x].Name = named.Name
+			namedArgs[x].Value = named.Value
+		}
+	}
+	return exec.conn.ExecContext(ctx, query, namedArgs)
+}
+
+// Option configure Output
+type Option func(output *Output)
+
+// WithBufferSize set size of DBMS_OUTPUT buffer in the server. 0 (default) is
+// unlimited buffer
+func WithBufferSize(size int) Option {
+	return func(output *Output) {
+		output.bufferSize = size
+	}
+}
+
+// WithFetchSize set max bytes of lines read in one round trip. the value is
+// limited between MinBufferSize and MaxBufferSize
+func WithFetchSize(size int) Option {
+	return func(output *Output) {
+		output.fetchSize = size
+	}
+}
+
+// WithWriter write each line that is read by ExecContext or Flush into w
+// instead of keeping it for Lines
+func WithWriter(w io.Writer) Option {
+	return func(output *Output) {
+		output.sink = w
+	}
+}
+
+// WithPiggyback read the output of PL/SQL blocks executed with ExecContext in
+// the same round trip by appending the fetch to the block. other statements
+// and statements with named arguments are followed by separate fetch
+func WithPiggyback() Option {
+	return func(output *Output) {
+		output.piggyback = true
+	}
+}
+
+// Output read DBMS_OUTPUT of one session
+type Output struct {
+	conn       execer
+	bufferSize int
+	fetchSize  int
+	sink       io.Writer
+	piggyback  bool
+	lines      []string
+	closed     bool
+}
+
+// NewOutput enable DBMS_OUTPUT on the session of conn
+func NewOutput(ctx context.Context, conn *sql.Conn, options ...Option) (*Output, error) {
+	if conn == nil {
+		return nil, errors.New("dbms: nil connection")
+	}
+	return newOutput(ctx, conn, options)
+}
+
+// NewConnectionOutput enable DBMS_OUTPUT on go-ora connection
+func NewConnectionOutput(ctx context.Context, conn *go_ora.Connection, options ...Option) (*Output, error) {
+	if conn == nil {
+		return nil, errors.New("dbms: nil connection")
+	}
+	return newOutput(ctx, connectionExecer{conn}, options)
+}
+
+func newOutput(ctx context.Context, conn execer, options []Option) (*Output, error) {
+	output := &Output{conn: conn, fetchSize: MaxBufferSize}
+	for _, option := range options {
+		option(output)
+	}
+	if output.fetchSize > MaxBufferSize {
+		output.fetchSize = MaxBufferSize
+	}
+	if output.fetchSize < MinBufferSize {
+		output.fetchSize = MinBufferSize
+	}
+	var bufferSize interface{}
+	if output.bufferSize > 0 {
+		bufferSize = output.bufferSize
+	}
+	_, err := conn.ExecContext(ctx, `BEGIN DBMS_OUTPUT.ENABLE(:1); END;`, bufferSize)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+type contextKey struct{}
+
+// EnableOutput enable DBMS_OUTPUT on the session of conn and return context
+// that carry the output for GetOutput and DisableOutput
+func EnableOutput(ctx context.Context, conn *sql.Conn, options ...Option) (context.Context, error) {
+	output, err := NewOutput(ctx, conn, options...)
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, contextKey{}, output), nil
+}
+
+// FromContext return the output of context created by EnableOutput
+func FromContext(ctx context.Context) (*Output, bool) {
+	output, ok := ctx.Value(contextKey{}).(*Output)
+	return output, ok
+}
+
+// GetOutput read all lines of the output in the context
+func GetOutput(ctx context.Context) (string, error) {
+	output, ok := FromContext(ctx)
+	if !ok {
+		return "", errors.New("dbms: output is not enabled in the context")
+	}
+	return output.String(ctx)
+}
+
+// DisableOutput disable the output in the context
+func DisableOutput(ctx context.Context) error {
+	output, ok := FromContext(ctx)
+	if !ok {
+		return errors.New("dbms: output is not enabled in the context")
+	}
+	return output.Close(ctx)
+}
+
+// ExecContext execute the statement then read its output. the error of the
+// statement is returned before the error of reading output
+func (output *Output) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if output.closed {
+		return nil, errors.New("dbms: output is closed")
+	}
+	if output.piggyback && canPiggyback(query, args) {
+		var (
+			done, pending int
+			buffer, line  string
+		)
+		args = append(args[:len(args):len(args)], output.fetchSize, go_ora.Out{Dest: &done}, go_ora.Out{Dest: &buffer, Size: MaxBufferSize},
+			go_ora.Out{Dest: &pending}, go_ora.Out{Dest: &line, Size: MaxBufferSize})
+		result, err := output.conn.ExecContext(ctx, piggybackQuery(query), args...)
+		if err != nil {
+			return nil, err
+		}
+		if err = output.add(splitLines(buffer, pending == 1, line)); err != nil {
+			return result, err
+		}
+		if done == 1 {
+			return result, nil
+		}
+		return result, output.Flush(ctx)
+	}
+	result, err := output.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result, output.Flush(ctx)
+}
+
+// Flush read all available lines from the server. the lines are written into
+// the writer or kept for Lines
+func (output *Output) Flush(ctx context.Context) error {
+	for {
+		lines, done, err := output.fetch(ctx)
+		if err != nil {
+			return err
+		}
+		if err = output.add(lines); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// Lines return iterator of output lines. lines that are already read are
+// returned first then lines are fetched from the server until the buffer is
+// empty. lines returned by the iterator are not written into the writer.
+// iteration stop at the first error
+func (output *Output) Lines(ctx context.Context) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		done := false
+		for {
+			for len(output.lines) > 0 {
+				line := output.lines[0]
+				output.lines = output.lines[1:]
+				if !yield(line, nil) {
+					return
+				}
+			}
+			if done {
+				return
+			}
+			var (
+				lines []string
+				err   error
+			)
+			lines, done, err = output.fetch(ctx)
+			if err != nil {
+				yield("", err)
+				return
+			}
+			output.lines = lines
+		}
+	}
+}
+
+// String read all lines and return them joined with new line
+func (output *Output) String(ctx context.Context) (string, error) {
+	var builder strings.Builder
+	for line, err := range output.Lines(ctx) {
+		if err != nil {
+			return builder.String(), err
+		}
+		builder.WriteString(line)
+		builder.WriteByte('\n')
+	}
+	return builder.String(), nil
+}
+
+// Close disable DBMS_OUTPUT. lines that are not read are discarded
+func (output *Output) Close(ctx context.Context) error {
+	if output.closed {
+		return nil
+	}
+	output.closed = true
+	output.lines = nil
+	_, err := output.conn.ExecContext(ctx, `BEGIN DBMS_OUTPUT.DISABLE; END;`)
+	return err
+}
+
+func (output *Output) add(lines []string) error {
+	if output.sink == nil {
+		output.lines = append(output.lines, lines...)
+		return nil
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(output.sink, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (output *Output) fetch(ctx context.Context) (lines []string, done bool, err error) {
+	if output.closed {
+		return nil, true, errors.New("dbms: output is closed")
+	}
+	var (
+		state, pending int
+		buffer, line   string
+	)
+	_, err = output.conn.ExecContext(ctx, fetchQuery(), output.fetchSize, go_ora.Out{Dest: &state},
+		go_ora.Out{Dest: &buffer, Size: MaxBufferSize}, go_ora.Out{Dest: &pending},
+		go_ora.Out{Dest: &line, Size: MaxBufferSize})
+	if err != nil {
+		return nil, false, err
+	}
+	return splitLines(buffer, pending == 1, line), state == 1, nil
+}
+
+// fetchQuery return fetchBlock with positional parameters
+func fetchQuery() string {
+	return strings.NewReplacer("%max%", ":1", "%done%", ":2", "%buffer%", ":3",
+		"%pending%", ":4", "%line%", ":5").Replace(fetchBlock)
+}
+
+// piggybackQuery return PL/SQL block that run query then fetchBlock. parameters
+// of the fetch have unique names so they are bound after the query parameters
+func piggybackQuery(query string) string {
+	query = strings.TrimSpace(query)
+	if !strings.HasSuffix(query, ";") {
+		query += ";"
+	}
+	fetch := strings.NewReplacer("%max%", ":go_ora_out_max", "%done%", ":go_ora_out_done",
+		"%buffer%", ":go_ora_out_buffer", "%pending%", ":go_ora_out_pending",
+		"%line%", ":go_ora_out_line").Replace(fetchBlock)
+	return "BEGIN\n" + query + "\n" + fetch + "\nEND;"
+}
+
+// canPiggyback return true for PL/SQL block with positional arguments
+func canPiggyback(query string, args []interface{}) bool {
+	for _, arg := range args {
+		if _, ok := arg.(sql.NamedArg); ok {
+			return false
+		}
+	}
+	query = strings.ToUpper(strings.TrimSpace(query))
+	return strings.HasPrefix(query, "BEGIN") || strings.HasPrefix(query, "DECLARE")
+}
+
+// splitLines return lines of the buffer that end each line with new line then
+// the pending line
+func splitLines(buffer string, hasPending bool, pending string) []string {
+	var lines []string
+	if len(buffer) > 0 {
+		lines = strings.Split(strings.TrimSuffix(buffer, "\n"), "\n")
+	}
+	if hasPending {
+		lines = append(lines, pending)
+	}
+	return lines
+}
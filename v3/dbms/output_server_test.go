@@ -0,0 +1,261 @@
+package dbms_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/dbms"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func newServer(t *testing.T) *oratest.Server {
+	t.Helper()
+	server, err := oratest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+func openDB(t *testing.T, url string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("oracle", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// outputChunk is DBMS_OUTPUT lines returned by one fetch
+type outputChunk struct {
+	buffer  string
+	pending string
+	done    bool
+}
+
+// outputServer answer DBMS_OUTPUT statements. fetches (and fetches appended to
+// PL/SQL blocks) return the chunks in order then done
+type outputServer struct {
+	*oratest.Server
+	mu       sync.Mutex
+	chunks   []outputChunk
+	fetches  int
+	requests []*oratest.Request
+}
+
+func newOutputServer(t *testing.T, chunks ...outputChunk) *outputServer {
+	server := &outputServer{Server: newServer(t), chunks: chunks}
+	server.Handle("BEGIN DBMS_OUTPUT.ENABLE(:1); END;", oratest.ExecResult(0))
+	server.Handle("BEGIN DBMS_OUTPUT.DISABLE; END;", oratest.ExecResult(0))
+	server.Handle("UPDATE EMP SET SAL = 1", oratest.ExecResult(3))
+	server.HandleFunc(server.handle)
+	return server
+}
+
+func (server *outputServer) handle(req *oratest.Request) *oratest.Result {
+	if !strings.Contains(req.SQL, "DBMS_OUTPUT.GET_LINE") {
+		return nil
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.fetches++
+	server.requests = append(server.requests, req)
+	chunk := outputChunk{done: true}
+	if len(server.chunks) > 0 {
+		chunk = server.chunks[0]
+		server.chunks = server.chunks[1:]
+	}
+	// fetch parameters are the last five: max, done, buffer, pending, line.
+	// positions start from 1
+	done := len(req.Args) - 3
+	out := map[int]interface{}{done: 0, done + 1: chunk.buffer, done + 2: 0, done + 3: chunk.pending}
+	if chunk.done {
+		out[done] = 1
+	}
+	if len(chunk.pending) > 0 {
+		out[done+2] = 1
+	}
+	return &oratest.Result{Out: out}
+}
+
+func (server *outputServer) fetchCount() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.fetches
+}
+
+func (server *outputServer) countStatements(text string) int {
+	count := 0
+	for _, stmt := range server.Statements() {
+		if strings.Contains(stmt, text) {
+			count++
+		}
+	}
+	return count
+}
+
+func newOutput(t *testing.T, server *outputServer, options ...dbms.Option) *dbms.Output {
+	t.Helper()
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	output, err := dbms.NewOutput(ctx, conn, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+func TestOutputLines(t *testing.T) {
+	server := newOutputServer(t,
+		outputChunk{buffer: "first\nsecond\n", pending: "long line"},
+		outputChunk{buffer: "\nlast\n", done: true})
+	output := newOutput(t, server)
+	if count := server.countStatements("DBMS_OUTPUT.ENABLE"); count != 1 {
+		t.Fatalf("expected output to be enabled once got: %d", count)
+	}
+	ctx := context.Background()
+	var lines []string
+	for line, err := range output.Lines(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	expected := []string{"first", "second", "long line", "", "last"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected lines: %q got: %q", expected, lines)
+	}
+	if server.fetchCount() != 2 {
+		t.Errorf("expected 2 fetches got: %d", server.fetchCount())
+	}
+	// fetch is bound with positional parameters only
+	if args := server.requests[0].Args; len(args) != 5 || args[0] != int64(dbms.MaxBufferSize) {
+		t.Errorf("unexpected fetch arguments: %v", args)
+	}
+	if err := output.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if count := server.countStatements("DBMS_OUTPUT.DISABLE"); count != 1 {
+		t.Errorf("expected output to be disabled once got: %d", count)
+	}
+	for _, err := range output.Lines(ctx) {
+		if err == nil {
+			t.Error("expected error for lines of closed output")
+		}
+	}
+}
+
+func TestOutputFlush(t *testing.T) {
+	server := newOutputServer(t,
+		outputChunk{buffer: "one\n", pending: "two"},
+		outputChunk{buffer: "three\n", done: true})
+	var builder strings.Builder
+	output := newOutput(t, server, dbms.WithWriter(&builder), dbms.WithPiggyback())
+	ctx := context.Background()
+	// sql statement is followed by separate fetch even with piggyback
+	result, err := output.ExecContext(ctx, "UPDATE EMP SET SAL = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 3 {
+		t.Errorf("expected 3 rows affected got: %d", affected)
+	}
+	if builder.String() != "one\ntwo\nthree\n" {
+		t.Errorf("unexpected output: %q", builder.String())
+	}
+	if server.fetchCount() != 2 {
+		t.Errorf("expected 2 fetches got: %d", server.fetchCount())
+	}
+	// nothing left for Flush
+	builder.Reset()
+	if err = output.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if builder.Len() != 0 || server.fetchCount() != 3 {
+		t.Errorf("unexpected output after flush: %q, fetches: %d", builder.String(), server.fetchCount())
+	}
+}
+
+func TestOutputPiggyback(t *testing.T) {
+	var testScenarios = []struct {
+		name    string
+		chunks  []outputChunk
+		fetches int
+		lines   []string
+	}{
+		{"single round trip", []outputChunk{{buffer: "hello\n", done: true}}, 1, []string{"hello"}},
+		{"remaining lines fetched", []outputChunk{{buffer: "hello\n", pending: "big"}, {buffer: "end\n", done: true}},
+			2, []string{"hello", "big", "end"}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newOutputServer(t, tt.chunks...)
+			output := newOutput(t, server, dbms.WithPiggyback(), dbms.WithFetchSize(4000))
+			ctx := context.Background()
+			_, err := output.ExecContext(ctx, "BEGIN DBMS_OUTPUT.PUT_LINE(:1); END;", "hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if server.fetchCount() != tt.fetches {
+				t.Fatalf("expected %d fetches got: %d", tt.fetches, server.fetchCount())
+			}
+			req := server.requests[0]
+			if !strings.Contains(req.SQL, "DBMS_OUTPUT.PUT_LINE(:1)") {
+				t.Fatalf("expected fetch appended to the block got: %s", req.SQL)
+			}
+			// positional argument of the block is bound before fetch parameters
+			if len(req.Args) != 6 || req.Args[0] != "hello" || req.Args[1] != int64(4000) {
+				t.Errorf("unexpected arguments: %v", req.Args)
+			}
+			var lines []string
+			for line, err := range output.Lines(ctx) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				lines = append(lines, line)
+			}
+			if strings.Join(lines, "|") != strings.Join(tt.lines, "|") {
+				t.Errorf("expected lines: %q got: %q", tt.lines, lines)
+			}
+		})
+	}
+}
+
+func TestOutputContext(t *testing.T) {
+	server := newOutputServer(t, outputChunk{buffer: "a\nb\n", done: true})
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err = dbms.GetOutput(ctx); err == nil {
+		t.Error("expected error for context without output")
+	}
+	ctx, err = dbms.EnableOutput(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := dbms.GetOutput(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "a\nb\n" {
+		t.Errorf("unexpected output: %q", text)
+	}
+	if err = dbms.DisableOutput(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
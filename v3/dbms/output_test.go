@@ -0,0 +1,58 @@
+package dbms
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		buffer     string
+		hasPending bool
+		pending    string
+		expected   []string
+	}{
+		{"", false, "", nil},
+		{"\n", false, "", []string{""}},
+		{"first\nsecond\n", false, "", []string{"first", "second"}},
+		{"first\n", true, "long line", []string{"first", "long line"}},
+		{"", true, "", []string{""}},
+	}
+	for _, test := range tests {
+		got := splitLines(test.buffer, test.hasPending, test.pending)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("splitLines(%q): expected %q got: %q", test.buffer, test.expected, got)
+		}
+	}
+}
+
+func TestPiggybackQuery(t *testing.T) {
+	if !canPiggyback(" begin null; end;", []interface{}{1}) {
+		t.Error("expected PL/SQL block to be piggybacked")
+	}
+	if canPiggyback("INSERT INTO T VALUES(1)", nil) {
+		t.Error("expected SQL statement not to be piggybacked")
+	}
+	if canPiggyback("BEGIN :a := 1; END;", []interface{}{sql.Named("a", 1)}) {
+		t.Error("expected block with named argument not to be piggybacked")
+	}
+	query := piggybackQuery("BEGIN DBMS_OUTPUT.PUT_LINE(:1); END")
+	if !strings.HasPrefix(query, "BEGIN\nBEGIN DBMS_OUTPUT.PUT_LINE(:1); END;\n") || !strings.HasSuffix(query, "\nEND;") {
+		t.Errorf("unexpected query: %s", query)
+	}
+	// fetch parameters must appear in the order of the bind arguments
+	names := []string{":go_ora_out_max", ":go_ora_out_done", ":go_ora_out_buffer", ":go_ora_out_pending", ":go_ora_out_line"}
+	last := 0
+	for _, name := range names {
+		index := strings.Index(query, name)
+		if index < last {
+			t.Errorf("parameter %s is out of order", name)
+		}
+		last = index
+	}
+	if strings.Contains(fetchQuery(), "%") {
+		t.Errorf("fetch query contains placeholder: %s", fetchQuery())
+	}
+}
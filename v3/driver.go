@@ -132,6 +132,8 @@ func (driver *OracleDriver) init() {
 	driver.goTypeCoder[types.TyNumber] = &parameter_coder.NumberParameter{}
 
 	driver.goTypeCoder[types.TyBoolean] = &parameter_coder.BoolParameter{}
+	driver.goTypeCoder[types.TyBool] = &parameter_coder.BoolParameter{}
+	driver.goTypeCoder[types.TyNullBool] = &parameter_coder.BoolParameter{}
 
 	driver.goTypeCoder[types.TyTime] = &parameter_coder.DateParameter{}
 	driver.goTypeCoder[types.TyNullTime] = &parameter_coder.DateParameter{}
@@ -140,7 +142,10 @@ func (driver *OracleDriver) init() {
 	driver.goTypeCoder[types.TyInterval] = &parameter_coder.IntervalParameter{}
 
 	driver.goTypeCoder[types.TyVector] = &parameter_coder.VectorParameter{}
+	driver.goTypeCoder[types.TySparseVector] = &parameter_coder.VectorParameter{}
+	driver.goTypeCoder[types.TyBinaryVector] = &parameter_coder.VectorParameter{}
 	driver.goTypeCoder[types.TyJson] = &parameter_coder.JsonParameter{}
+	driver.goTypeCoder[types.TyXmlType] = &parameter_coder.XmlTypeParameter{}
 
 	driver.goTypeCoder[types.TyClob] = &parameter_coder.ClobParameter{}
 	driver.goTypeCoder[types.TyBlob] = &parameter_coder.BlobParameter{}
@@ -163,6 +168,9 @@ func (driver *OracleDriver) init() {
 	tempClob := &parameter_coder.ClobParameter{}
 	tempClob.CharsetForm = 2
 	driver.nameTypeCoder["NCLOB"] = tempClob
+	driver.nameTypeCoder["XMLTYPE"] = &parameter_coder.XmlTypeParameter{}
+	driver.nameTypeCoder["BOOLEAN"] = &parameter_coder.BoolParameter{}
+	driver.nameTypeCoder["PL/SQL BOOLEAN"] = &parameter_coder.BoolParameter{}
 
 	// initialize all
 	for _, coder := range driver.goTypeCoder {
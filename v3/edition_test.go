@@ -0,0 +1,87 @@
+package go_ora_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func TestLoginEdition(t *testing.T) {
+	server := newServer(t)
+	db := openDB(t, server.URL(map[string]string{"APPLICATION EDITION": "APP_V1"}))
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	db = openDB(t, server.URL(nil))
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	logins := server.Logins()
+	if len(logins) != 2 {
+		t.Fatalf("expected 2 logins got: %d", len(logins))
+	}
+	if edition := logins[0]["AUTH_ORA_EDITION"]; edition != "APP_V1" {
+		t.Errorf("expected AUTH_ORA_EDITION APP_V1 got: %q", edition)
+	}
+	if edition, ok := logins[1]["AUTH_ORA_EDITION"]; ok {
+		t.Errorf("expected no AUTH_ORA_EDITION got: %q", edition)
+	}
+}
+
+func TestSetEdition(t *testing.T) {
+	server := newServer(t)
+	server.Handle("ALTER SESSION SET EDITION = APP_V2", oratest.ExecResult(0))
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, edition := range []string{"", "2APP", "APP V2", "APP;DROP", "APP'", "ORA$BASE-1"} {
+		if err = go_ora.SetConnectionEdition(ctx, conn, edition); err == nil {
+			t.Errorf("expected error for edition name: %q", edition)
+		}
+	}
+	for _, statement := range server.Statements() {
+		if strings.HasPrefix(statement, "ALTER SESSION SET EDITION") {
+			t.Errorf("invalid edition name reached the server: %q", statement)
+		}
+	}
+	if err = go_ora.SetConnectionEdition(ctx, conn, " APP_V2 "); err != nil {
+		t.Fatal(err)
+	}
+	statements := server.Statements()
+	if last := statements[len(statements)-1]; last != "ALTER SESSION SET EDITION = APP_V2" {
+		t.Errorf("unexpected statement: %q", last)
+	}
+}
+
+func TestEditionReopen(t *testing.T) {
+	server := newServer(t)
+	server.Handle("ALTER SESSION SET EDITION = APP_V2", oratest.ExecResult(0))
+	conn, err := go_ora.NewConnection(server.URL(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err = conn.OpenWithContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.SetEdition(ctx, "APP_V2"); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	// the new edition is used when the connection is opened again
+	if err = conn.OpenWithContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	logins := server.Logins()
+	if len(logins) != 2 || logins[1]["AUTH_ORA_EDITION"] != "APP_V2" {
+		t.Errorf("expected AUTH_ORA_EDITION APP_V2 after reopen got: %v", logins)
+	}
+}
@@ -0,0 +1,434 @@
+package kerberos
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// message types and application tags (RFC 4120)
+const (
+	pvno = 5
+
+	tagTicket        = 1
+	tagAuthenticator = 2
+	tagEncTicketPart = 3
+	tagASReq         = 10
+	tagASRep         = 11
+	tagTGSReq        = 12
+	tagTGSRep        = 13
+	tagAPReq         = 14
+	tagEncASRepPart  = 25
+	tagEncTGSRepPart = 26
+	tagKRBError      = 30
+)
+
+// pre-authentication data types
+const (
+	paTGSReq       = 1
+	paEncTimestamp = 2
+	paETypeInfo2   = 19
+)
+
+// principal name types
+const (
+	NameTypePrincipal = 1
+	NameTypeSrvInst   = 2
+	NameTypeSrvHost   = 3
+)
+
+// key usage numbers (RFC 4120 section 7.5.1)
+const (
+	usageASReqTimestamp      = 1
+	usageKDCRepTicket        = 2
+	usageASRepEncPart        = 3
+	usageTGSReqChecksum      = 6
+	usageTGSReqAuthenticator = 7
+	usageTGSRepEncPart       = 8
+	usageAPReqAuthenticator  = 11
+)
+
+// PrincipalName is kerberos principal name without realm
+type PrincipalName struct {
+	NameType   int32    `asn1:"explicit,tag:0"`
+	NameString []string `asn1:"explicit,tag:1"`
+}
+
+type encryptedData struct {
+	EType  int32  `asn1:"explicit,tag:0"`
+	KVNO   int    `asn1:"optional,explicit,tag:1"`
+	Cipher []byte `asn1:"explicit,tag:2"`
+}
+
+// EncryptionKey is kerberos key with its encryption type
+type EncryptionKey struct {
+	KeyType  int32  `asn1:"explicit,tag:0"`
+	KeyValue []byte `asn1:"explicit,tag:1"`
+}
+
+type checksum struct {
+	CksumType int32  `asn1:"explicit,tag:0"`
+	Checksum  []byte `asn1:"explicit,tag:1"`
+}
+
+type paData struct {
+	Type  int32  `asn1:"explicit,tag:1"`
+	Value []byte `asn1:"explicit,tag:2"`
+}
+
+type etypeInfo2Entry struct {
+	EType     int32  `asn1:"explicit,tag:0"`
+	Salt      string `asn1:"optional,explicit,tag:1"`
+	S2KParams []byte `asn1:"optional,explicit,tag:2"`
+}
+
+type ticket struct {
+	TktVNO  int           `asn1:"explicit,tag:0"`
+	Realm   string        `asn1:"explicit,tag:1"`
+	SName   PrincipalName `asn1:"explicit,tag:2"`
+	EncPart encryptedData `asn1:"explicit,tag:3"`
+}
+
+type transitedEncoding struct {
+	TRType   int32  `asn1:"explicit,tag:0"`
+	Contents []byte `asn1:"explicit,tag:1"`
+}
+
+type encTicketPart struct {
+	Flags     asn1.BitString    `asn1:"explicit,tag:0"`
+	Key       EncryptionKey     `asn1:"explicit,tag:1"`
+	CRealm    string            `asn1:"explicit,tag:2"`
+	CName     PrincipalName     `asn1:"explicit,tag:3"`
+	Transited transitedEncoding `asn1:"explicit,tag:4"`
+	AuthTime  time.Time         `asn1:"generalized,explicit,tag:5"`
+	StartTime time.Time         `asn1:"generalized,optional,explicit,tag:6"`
+	EndTime   time.Time         `asn1:"generalized,explicit,tag:7"`
+	RenewTill time.Time         `asn1:"generalized,optional,explicit,tag:8"`
+	CAddr     asn1.RawValue     `asn1:"optional,explicit,tag:9"`
+	AuthData  asn1.RawValue     `asn1:"optional,explicit,tag:10"`
+}
+
+type kdcReqBody struct {
+	KDCOptions asn1.BitString  `asn1:"explicit,tag:0"`
+	CName      PrincipalName   `asn1:"optional,explicit,tag:1"`
+	Realm      string          `asn1:"explicit,tag:2"`
+	SName      PrincipalName   `asn1:"optional,explicit,tag:3"`
+	From       time.Time       `asn1:"generalized,optional,explicit,tag:4"`
+	Till       time.Time       `asn1:"generalized,explicit,tag:5"`
+	RTime      time.Time       `asn1:"generalized,optional,explicit,tag:6"`
+	Nonce      int64           `asn1:"explicit,tag:7"`
+	EType      []int32         `asn1:"explicit,tag:8"`
+	Addresses  asn1.RawValue   `asn1:"optional,explicit,tag:9"`
+	EncAuth    asn1.RawValue   `asn1:"optional,explicit,tag:10"`
+	Tickets    []asn1.RawValue `asn1:"optional,explicit,tag:11"`
+}
+
+type kdcReq struct {
+	PVNO    int           `asn1:"explicit,tag:1"`
+	MsgType int           `asn1:"explicit,tag:2"`
+	PAData  []paData      `asn1:"optional,explicit,tag:3"`
+	ReqBody asn1.RawValue `asn1:"explicit,tag:4"`
+}
+
+type kdcRep struct {
+	PVNO    int           `asn1:"explicit,tag:0"`
+	MsgType int           `asn1:"explicit,tag:1"`
+	PAData  []paData      `asn1:"optional,explicit,tag:2"`
+	CRealm  string        `asn1:"explicit,tag:3"`
+	CName   PrincipalName `asn1:"explicit,tag:4"`
+	Ticket  asn1.RawValue `asn1:"explicit,tag:5"`
+	EncPart encryptedData `asn1:"explicit,tag:6"`
+}
+
+type lastReq struct {
+	LRType  int32     `asn1:"explicit,tag:0"`
+	LRValue time.Time `asn1:"generalized,explicit,tag:1"`
+}
+
+type encKDCRepPart struct {
+	Key           EncryptionKey  `asn1:"explicit,tag:0"`
+	LastReqs      []lastReq      `asn1:"explicit,tag:1"`
+	Nonce         int64          `asn1:"explicit,tag:2"`
+	KeyExpiration time.Time      `asn1:"generalized,optional,explicit,tag:3"`
+	Flags         asn1.BitString `asn1:"explicit,tag:4"`
+	AuthTime      time.Time      `asn1:"generalized,explicit,tag:5"`
+	StartTime     time.Time      `asn1:"generalized,optional,explicit,tag:6"`
+	EndTime       time.Time      `asn1:"generalized,explicit,tag:7"`
+	RenewTill     time.Time      `asn1:"generalized,optional,explicit,tag:8"`
+	SRealm        string         `asn1:"explicit,tag:9"`
+	SName         PrincipalName  `asn1:"explicit,tag:10"`
+	CAddr         asn1.RawValue  `asn1:"optional,explicit,tag:11"`
+	EncPAData     asn1.RawValue  `asn1:"optional,explicit,tag:12"`
+}
+
+type apReq struct {
+	PVNO          int            `asn1:"explicit,tag:0"`
+	MsgType       int            `asn1:"explicit,tag:1"`
+	APOptions     asn1.BitString `asn1:"explicit,tag:2"`
+	Ticket        asn1.RawValue  `asn1:"explicit,tag:3"`
+	Authenticator encryptedData  `asn1:"explicit,tag:4"`
+}
+
+type authenticator struct {
+	AVNO      int           `asn1:"explicit,tag:0"`
+	CRealm    string        `asn1:"explicit,tag:1"`
+	CName     PrincipalName `asn1:"explicit,tag:2"`
+	Cksum     checksum      `asn1:"optional,explicit,tag:3"`
+	CUSec     int           `asn1:"explicit,tag:4"`
+	CTime     time.Time     `asn1:"generalized,explicit,tag:5"`
+	SubKey    EncryptionKey `asn1:"optional,explicit,tag:6"`
+	SeqNumber int64         `asn1:"optional,explicit,tag:7"`
+	AuthData  asn1.RawValue `asn1:"optional,explicit,tag:8"`
+}
+
+type paEncTSEnc struct {
+	PATimestamp time.Time `asn1:"generalized,explicit,tag:0"`
+	PAUSec      int       `asn1:"optional,explicit,tag:1"`
+}
+
+type krbError struct {
+	PVNO      int           `asn1:"explicit,tag:0"`
+	MsgType   int           `asn1:"explicit,tag:1"`
+	CTime     time.Time     `asn1:"generalized,optional,explicit,tag:2"`
+	CUSec     int           `asn1:"optional,explicit,tag:3"`
+	STime     time.Time     `asn1:"generalized,explicit,tag:4"`
+	SUSec     int           `asn1:"explicit,tag:5"`
+	ErrorCode int32         `asn1:"explicit,tag:6"`
+	CRealm    string        `asn1:"optional,explicit,tag:7"`
+	CName     PrincipalName `asn1:"optional,explicit,tag:8"`
+	Realm     string        `asn1:"explicit,tag:9"`
+	SName     PrincipalName `asn1:"explicit,tag:10"`
+	EText     string        `asn1:"optional,explicit,tag:11"`
+	EData     []byte        `asn1:"optional,explicit,tag:12"`
+}
+
+// KDC error codes used by the client
+const (
+	errPreAuthRequired = 25
+	errResponseTooBig  = 52
+)
+
+// KRBError is an error message returned by the KDC
+type KRBError struct {
+	Code  int32
+	Realm string
+	Text  string
+	eData []byte
+}
+
+func (e *KRBError) Error() string {
+	if len(e.Text) > 0 {
+		return fmt.Sprintf("kerberos error %d (%s): %s", e.Code, e.Realm, e.Text)
+	}
+	return fmt.Sprintf("kerberos error %d (%s)", e.Code, e.Realm)
+}
+
+// unmarshalApp parse DER data wrapped in application tag
+func unmarshalApp(data []byte, tag int, val interface{}) error {
+	rest, err := asn1.UnmarshalWithParams(data, val, fmt.Sprintf("application,explicit,tag:%d", tag))
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errors.New("kerberos: trailing data after message")
+	}
+	return nil
+}
+
+// applicationTag return the tag of DER data encoded with application class
+func applicationTag(data []byte) int {
+	if len(data) == 0 || data[0]&0xE0 != 0x60 {
+		return -1
+	}
+	return int(data[0] & 0x1F)
+}
+
+// DER encoding. encoding/asn1 can read GeneralString but can't write it so
+// kerberos messages are built with the following helpers
+
+func derTLV(first byte, content []byte) []byte {
+	output := []byte{first}
+	length := len(content)
+	switch {
+	case length < 0x80:
+		output = append(output, byte(length))
+	case length <= 0xFF:
+		output = append(output, 0x81, byte(length))
+	case length <= 0xFFFF:
+		output = append(output, 0x82, byte(length>>8), byte(length))
+	default:
+		output = append(output, 0x83, byte(length>>16), byte(length>>8), byte(length))
+	}
+	return append(output, content...)
+}
+
+func derSequence(items ...[]byte) []byte {
+	var content []byte
+	for _, item := range items {
+		content = append(content, item...)
+	}
+	return derTLV(0x30, content)
+}
+
+// derExplicit wrap content with context specific tag. nil content is omitted
+func derExplicit(tag int, content []byte) []byte {
+	if content == nil {
+		return nil
+	}
+	return derTLV(0xA0|byte(tag), content)
+}
+
+func derApplication(tag int, content []byte) []byte {
+	return derTLV(0x60|byte(tag), content)
+}
+
+func derInt(val int64) []byte {
+	output, _ := asn1.Marshal(val)
+	return output
+}
+
+func derOctetString(val []byte) []byte {
+	return derTLV(0x04, val)
+}
+
+func derGeneralString(val string) []byte {
+	return derTLV(0x1B, []byte(val))
+}
+
+func derTime(val time.Time) []byte {
+	return derTLV(0x18, []byte(val.UTC().Format("20060102150405Z")))
+}
+
+func derFlags(flags uint32) []byte {
+	return derTLV(0x03, []byte{0, byte(flags >> 24), byte(flags >> 16), byte(flags >> 8), byte(flags)})
+}
+
+func derIntSequence(values []int32) []byte {
+	items := make([][]byte, 0, len(values))
+	for _, val := range values {
+		items = append(items, derInt(int64(val)))
+	}
+	return derSequence(items...)
+}
+
+func (name PrincipalName) marshal() []byte {
+	items := make([][]byte, 0, len(name.NameString))
+	for _, part := range name.NameString {
+		items = append(items, derGeneralString(part))
+	}
+	return derSequence(
+		derExplicit(0, derInt(int64(name.NameType))),
+		derExplicit(1, derSequence(items...)),
+	)
+}
+
+func (data encryptedData) marshal() []byte {
+	var kvno []byte
+	if data.KVNO > 0 {
+		kvno = derExplicit(1, derInt(int64(data.KVNO)))
+	}
+	return derSequence(
+		derExplicit(0, derInt(int64(data.EType))),
+		kvno,
+		derExplicit(2, derOctetString(data.Cipher)),
+	)
+}
+
+func (key EncryptionKey) marshal() []byte {
+	return derSequence(
+		derExplicit(0, derInt(int64(key.KeyType))),
+		derExplicit(1, derOctetString(key.KeyValue)),
+	)
+}
+
+func (sum checksum) marshal() []byte {
+	return derSequence(
+		derExplicit(0, derInt(int64(sum.CksumType))),
+		derExplicit(1, derOctetString(sum.Checksum)),
+	)
+}
+
+func (pa paData) marshal() []byte {
+	return derSequence(
+		derExplicit(1, derInt(int64(pa.Type))),
+		derExplicit(2, derOctetString(pa.Value)),
+	)
+}
+
+func bitStringFlags(flags asn1.BitString) uint32 {
+	var output uint32
+	for i := 0; i < 32; i++ {
+		if flags.At(i) != 0 {
+			output |= 1 << (31 - i)
+		}
+	}
+	return output
+}
+
+func parsePrincipal(name string) (PrincipalName, string) {
+	realm := ""
+	if index := lastUnescaped(name, '@'); index >= 0 {
+		realm = name[index+1:]
+		name = name[:index]
+	}
+	parts := splitUnescaped(name, '/')
+	nameType := int32(NameTypePrincipal)
+	if len(parts) > 1 {
+		nameType = NameTypeSrvInst
+	}
+	return PrincipalName{NameType: nameType, NameString: parts}, realm
+}
+
+// String return principal components separated by /
+func (name PrincipalName) String() string {
+	output := ""
+	for i, part := range name.NameString {
+		if i > 0 {
+			output += "/"
+		}
+		output += part
+	}
+	return output
+}
+
+// Equal compare principal components ignoring name type
+func (name PrincipalName) Equal(other PrincipalName) bool {
+	if len(name.NameString) != len(other.NameString) {
+		return false
+	}
+	for i := range name.NameString {
+		if name.NameString[i] != other.NameString[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func lastUnescaped(input string, sep byte) int {
+	for i := len(input) - 1; i >= 0; i-- {
+		if input[i] == sep && (i == 0 || input[i-1] != '\\') {
+			return i
+		}
+	}
+	return -1
+}
+
+func splitUnescaped(input string, sep byte) []string {
+	var output []string
+	current := make([]byte, 0, len(input))
+	for i := 0; i < len(input); i++ {
+		if input[i] == '\\' && i+1 < len(input) {
+			i++
+			current = append(current, input[i])
+			continue
+		}
+		if input[i] == sep {
+			output = append(output, string(current))
+			current = current[:0]
+			continue
+		}
+		current = append(current, input[i])
+	}
+	return append(output, string(current))
+}
@@ -0,0 +1,146 @@
+package kerberos
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Credential is a ticket stored in credential cache
+type Credential struct {
+	Client       PrincipalName
+	ClientRealm  string
+	Server       PrincipalName
+	ServerRealm  string
+	Key          EncryptionKey
+	AuthTime     time.Time
+	StartTime    time.Time
+	EndTime      time.Time
+	RenewTill    time.Time
+	TicketFlags  uint32
+	Ticket       []byte
+	SecondTicket []byte
+}
+
+// IsValid return true if the ticket is not expired after the margin duration
+func (cred *Credential) IsValid(margin time.Duration) bool {
+	return time.Now().Add(margin).Before(cred.EndTime)
+}
+
+// CCache is MIT file credential cache (format version 0x503 and 0x504)
+type CCache struct {
+	Principal   PrincipalName
+	Realm       string
+	Credentials []Credential
+}
+
+// DefaultCCachePath return KRB5CCNAME environment variable or /tmp/krb5cc_<uid>
+func DefaultCCachePath() string {
+	if path := os.Getenv("KRB5CCNAME"); len(path) > 0 {
+		return strings.TrimPrefix(path, "FILE:")
+	}
+	return fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid())
+}
+
+// LoadCCache read credential cache file
+func LoadCCache(path string) (*CCache, error) {
+	data, err := os.ReadFile(strings.TrimPrefix(path, "FILE:"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseCCache(data)
+}
+
+// ParseCCache decode credential cache file content
+func ParseCCache(data []byte) (*CCache, error) {
+	if len(data) < 2 || data[0] != 5 {
+		return nil, errors.New("kerberos: invalid credential cache file")
+	}
+	version := data[1]
+	if version != 3 && version != 4 {
+		// versions 1 and 2 use native byte order and are not written by current tools
+		return nil, fmt.Errorf("kerberos: unsupported credential cache version: 0x05%02x", version)
+	}
+	reader := &binaryReader{data: data, index: 2}
+	if version == 4 {
+		// header tags (e.g. KDC time offset) are not used
+		headerLen := reader.uint16()
+		reader.read(int(headerLen))
+	}
+	output := &CCache{}
+	output.Principal, output.Realm = readCCachePrincipal(reader)
+	for !reader.eof() {
+		var cred Credential
+		cred.Client, cred.ClientRealm = readCCachePrincipal(reader)
+		cred.Server, cred.ServerRealm = readCCachePrincipal(reader)
+		cred.Key.KeyType = int32(reader.uint16())
+		if version == 3 {
+			// version 3 repeat the encryption type
+			reader.uint16()
+		}
+		cred.Key.KeyValue = reader.bytes32()
+		cred.AuthTime = ccacheTime(reader.uint32())
+		cred.StartTime = ccacheTime(reader.uint32())
+		cred.EndTime = ccacheTime(reader.uint32())
+		cred.RenewTill = ccacheTime(reader.uint32())
+		reader.uint8() // is_skey
+		cred.TicketFlags = reader.uint32()
+		// addresses and authorization data
+		for i := 0; i < 2; i++ {
+			count := reader.uint32()
+			for j := uint32(0); j < count && reader.err == nil; j++ {
+				reader.uint16()
+				reader.bytes32()
+			}
+		}
+		cred.Ticket = reader.bytes32()
+		cred.SecondTicket = reader.bytes32()
+		if reader.err != nil {
+			return nil, fmt.Errorf("kerberos: invalid credential cache: %v", reader.err)
+		}
+		// configuration entries are stored as credentials of X-CACHECONF: realm
+		if cred.ServerRealm == "X-CACHECONF:" {
+			continue
+		}
+		output.Credentials = append(output.Credentials, cred)
+	}
+	if reader.err != nil {
+		return nil, fmt.Errorf("kerberos: invalid credential cache: %v", reader.err)
+	}
+	return output, nil
+}
+
+func ccacheTime(val uint32) time.Time {
+	if val == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(val), 0)
+}
+
+func readCCachePrincipal(reader *binaryReader) (PrincipalName, string) {
+	var name PrincipalName
+	name.NameType = int32(reader.uint32())
+	count := reader.uint32()
+	realm := string(reader.bytes32())
+	for i := uint32(0); i < count && reader.err == nil; i++ {
+		name.NameString = append(name.NameString, string(reader.bytes32()))
+	}
+	return name, realm
+}
+
+// Credential return valid ticket of the server principal
+func (cache *CCache) Credential(server PrincipalName, realm string) (*Credential, error) {
+	for i := range cache.Credentials {
+		cred := &cache.Credentials[i]
+		if cred.ServerRealm == realm && cred.Server.Equal(server) && cred.Client.Equal(cache.Principal) &&
+			cred.ClientRealm == cache.Realm {
+			if !cred.IsValid(0) {
+				return nil, fmt.Errorf("kerberos: ticket of %s@%s is expired", server, realm)
+			}
+			return cred, nil
+		}
+	}
+	return nil, fmt.Errorf("kerberos: no ticket of %s@%s in credential cache", server, realm)
+}
@@ -0,0 +1,350 @@
+package kerberos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// KDC options
+const (
+	optionForwardable  uint32 = 0x40000000
+	optionCanonicalize uint32 = 0x00010000
+	optionRenewableOK  uint32 = 0x00000010
+)
+
+const (
+	maxReferrals   = 5
+	maxMessageSize = 1 << 24
+)
+
+func newNonce() (int64, error) {
+	val, err := rand.Int(rand.Reader, big.NewInt(0x7FFFFFFF))
+	if err != nil {
+		return 0, err
+	}
+	return val.Int64(), nil
+}
+
+func (client *Client) kdcOptions() uint32 {
+	options := optionCanonicalize | optionRenewableOK
+	if client.config.Forwardable {
+		options |= optionForwardable
+	}
+	return options
+}
+
+func (client *Client) reqBody(options uint32, cname *PrincipalName, realm string, sname PrincipalName, nonce int64, etypes []int32) []byte {
+	var cnameData []byte
+	if cname != nil {
+		cnameData = derExplicit(1, cname.marshal())
+	}
+	till := time.Now().Add(client.config.TicketLifetime)
+	return derSequence(
+		derExplicit(0, derFlags(options)),
+		cnameData,
+		derExplicit(2, derGeneralString(realm)),
+		derExplicit(3, sname.marshal()),
+		derExplicit(5, derTime(till)),
+		derExplicit(7, derInt(nonce)),
+		derExplicit(8, derIntSequence(etypes)),
+	)
+}
+
+func kdcRequest(tag int, padata []paData, body []byte) []byte {
+	var padataItems [][]byte
+	for _, pa := range padata {
+		padataItems = append(padataItems, pa.marshal())
+	}
+	var padataField []byte
+	if len(padataItems) > 0 {
+		padataField = derExplicit(3, derSequence(padataItems...))
+	}
+	return derApplication(tag, derSequence(
+		derExplicit(1, derInt(pvno)),
+		derExplicit(2, derInt(int64(tag))),
+		padataField,
+		derExplicit(4, body),
+	))
+}
+
+// asExchange get TGT using keys from keytab
+func (client *Client) asExchange() (*Credential, error) {
+	etypes := client.keytab.ETypes(client.principal, client.realm, client.config.TGSETypes)
+	if len(etypes) == 0 {
+		return nil, fmt.Errorf("kerberos: no key for %s@%s in keytab with permitted encryption types", client.principal, client.realm)
+	}
+	tgs := PrincipalName{NameType: NameTypeSrvInst, NameString: []string{"krbtgt", client.realm}}
+	preAuthEType := etypes[0]
+	var response []byte
+	var nonce int64
+	for attempt := 0; ; attempt++ {
+		var err error
+		nonce, err = newNonce()
+		if err != nil {
+			return nil, err
+		}
+		key, _, err := client.keytab.Key(client.principal, client.realm, preAuthEType, 0)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		timestamp := derSequence(
+			derExplicit(0, derTime(now)),
+			derExplicit(1, derInt(int64(now.Nanosecond()/1000))),
+		)
+		encTimestamp, err := encryptWithKey(key, usageASReqTimestamp, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		body := client.reqBody(client.kdcOptions(), &client.principal, client.realm, tgs, nonce, etypes)
+		request := kdcRequest(tagASReq, []paData{{Type: paEncTimestamp, Value: encTimestamp.marshal()}}, body)
+		response, err = client.send(client.realm, request)
+		if err != nil {
+			var krbErr *KRBError
+			if attempt == 0 && errors.As(err, &krbErr) && krbErr.Code == errPreAuthRequired {
+				// KDC require pre-authentication with another encryption type
+				if etype, ok := preAuthEncryptionType(krbErr.eData, etypes); ok && etype != preAuthEType {
+					preAuthEType = etype
+					continue
+				}
+			}
+			return nil, err
+		}
+		break
+	}
+	var rep kdcRep
+	if err := unmarshalApp(response, tagASRep, &rep); err != nil {
+		return nil, fmt.Errorf("kerberos: invalid AS-REP: %v", err)
+	}
+	key, _, err := client.keytab.Key(client.principal, client.realm, rep.EncPart.EType, uint32(rep.EncPart.KVNO))
+	if err != nil {
+		return nil, err
+	}
+	return client.kdcReply(&rep, key, usageASRepEncPart, nonce)
+}
+
+// preAuthEncryptionType select encryption type from PA-ETYPE-INFO2 of KRB-ERROR e-data
+func preAuthEncryptionType(eData []byte, available []int32) (int32, bool) {
+	var methods []paData
+	if _, err := asn1.Unmarshal(eData, &methods); err != nil {
+		return 0, false
+	}
+	for _, method := range methods {
+		if method.Type != paETypeInfo2 {
+			continue
+		}
+		var entries []etypeInfo2Entry
+		if _, err := asn1.Unmarshal(method.Value, &entries); err != nil {
+			return 0, false
+		}
+		for _, entry := range entries {
+			for _, etype := range available {
+				if entry.EType == etype {
+					return etype, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// kdcReply decrypt enc-part of AS-REP or TGS-REP and return received ticket
+func (client *Client) kdcReply(rep *kdcRep, key EncryptionKey, usage uint32, nonce int64) (*Credential, error) {
+	plain, err := decryptWithKey(key, usage, rep.EncPart)
+	if err != nil {
+		return nil, err
+	}
+	var part encKDCRepPart
+	tag := applicationTag(plain)
+	// some KDCs use EncASRepPart tag for TGS-REP
+	if tag != tagEncASRepPart && tag != tagEncTGSRepPart {
+		return nil, errors.New("kerberos: invalid encrypted part of KDC reply")
+	}
+	if err = unmarshalApp(plain, tag, &part); err != nil {
+		return nil, fmt.Errorf("kerberos: invalid encrypted part of KDC reply: %v", err)
+	}
+	if part.Nonce != nonce {
+		return nil, errors.New("kerberos: KDC reply nonce doesn't match request")
+	}
+	return &Credential{
+		Client:      rep.CName,
+		ClientRealm: rep.CRealm,
+		Server:      part.SName,
+		ServerRealm: part.SRealm,
+		Key:         part.Key,
+		AuthTime:    part.AuthTime,
+		StartTime:   part.StartTime,
+		EndTime:     part.EndTime,
+		RenewTill:   part.RenewTill,
+		TicketFlags: bitStringFlags(part.Flags),
+		Ticket:      rep.Ticket.Bytes,
+	}, nil
+}
+
+// tgsExchange get ticket of the server principal using TGT. cross realm
+// referrals returned by the KDC are followed
+func (client *Client) tgsExchange(tgt *Credential, server PrincipalName, realm string) (*Credential, error) {
+	for hop := 0; hop < maxReferrals; hop++ {
+		if len(tgt.Server.NameString) != 2 || tgt.Server.NameString[0] != "krbtgt" {
+			return nil, fmt.Errorf("kerberos: %s is not a ticket granting ticket", tgt.Server)
+		}
+		kdcRealm := tgt.Server.NameString[1]
+		nonce, err := newNonce()
+		if err != nil {
+			return nil, err
+		}
+		body := client.reqBody(client.kdcOptions(), nil, realm, server, nonce, client.config.TGSETypes)
+		bodyChecksum, err := checksumWithKey(tgt.Key, usageTGSReqChecksum, body)
+		if err != nil {
+			return nil, err
+		}
+		apReqData, err := client.apReq(tgt, &bodyChecksum, usageTGSReqAuthenticator)
+		if err != nil {
+			return nil, err
+		}
+		request := kdcRequest(tagTGSReq, []paData{{Type: paTGSReq, Value: apReqData}}, body)
+		response, err := client.send(kdcRealm, request)
+		if err != nil {
+			return nil, err
+		}
+		var rep kdcRep
+		if err = unmarshalApp(response, tagTGSRep, &rep); err != nil {
+			return nil, fmt.Errorf("kerberos: invalid TGS-REP: %v", err)
+		}
+		cred, err := client.kdcReply(&rep, tgt.Key, usageTGSRepEncPart, nonce)
+		if err != nil {
+			return nil, err
+		}
+		isTGT := len(cred.Server.NameString) == 2 && cred.Server.NameString[0] == "krbtgt"
+		if !isTGT || cred.Server.Equal(server) {
+			return cred, nil
+		}
+		// referral TGT to another realm
+		tgt = cred
+	}
+	return nil, fmt.Errorf("kerberos: too many referrals getting ticket of %s@%s", server, realm)
+}
+
+// apReq build AP-REQ for the ticket with an authenticator encrypted by the session key
+func (client *Client) apReq(cred *Credential, sum *checksum, usage uint32) ([]byte, error) {
+	now := time.Now().UTC()
+	var cksum []byte
+	if sum != nil {
+		cksum = derExplicit(3, sum.marshal())
+	}
+	auth := derApplication(tagAuthenticator, derSequence(
+		derExplicit(0, derInt(pvno)),
+		derExplicit(1, derGeneralString(cred.ClientRealm)),
+		derExplicit(2, cred.Client.marshal()),
+		cksum,
+		derExplicit(4, derInt(int64(now.Nanosecond()/1000))),
+		derExplicit(5, derTime(now)),
+	))
+	encAuth, err := encryptWithKey(cred.Key, usage, auth)
+	if err != nil {
+		return nil, err
+	}
+	return derApplication(tagAPReq, derSequence(
+		derExplicit(0, derInt(pvno)),
+		derExplicit(1, derInt(tagAPReq)),
+		derExplicit(2, derFlags(0)),
+		derExplicit(3, cred.Ticket),
+		derExplicit(4, encAuth.marshal()),
+	)), nil
+}
+
+// send request to KDCs of the realm. KRB-ERROR reply is returned as *KRBError
+func (client *Client) send(realm string, request []byte) ([]byte, error) {
+	addresses, err := client.config.KDCAddresses(realm)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, address := range addresses {
+		useTCP := client.config.UDPPreferenceLimit <= 1 || len(request) > client.config.UDPPreferenceLimit
+		var response []byte
+		if !useTCP {
+			response, err = client.exchange("udp", address, request)
+			if err == nil {
+				if krbErr := parseKRBError(response); krbErr != nil && krbErr.Code == errResponseTooBig {
+					useTCP = true
+				}
+			}
+		}
+		if useTCP {
+			response, err = client.exchange("tcp", address, request)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if krbErr := parseKRBError(response); krbErr != nil {
+			return nil, krbErr
+		}
+		return response, nil
+	}
+	return nil, fmt.Errorf("kerberos: can't reach KDC of realm %s: %v", realm, lastErr)
+}
+
+func parseKRBError(response []byte) *KRBError {
+	if applicationTag(response) != tagKRBError {
+		return nil
+	}
+	var msg krbError
+	if err := unmarshalApp(response, tagKRBError, &msg); err != nil {
+		return &KRBError{Code: -1, Text: "invalid KRB-ERROR: " + err.Error()}
+	}
+	return &KRBError{Code: msg.ErrorCode, Realm: msg.Realm, Text: strings.TrimSpace(msg.EText), eData: msg.EData}
+}
+
+func (client *Client) exchange(network, address string, request []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), client.config.KDCTimeout)
+	defer cancel()
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if network == "udp" {
+		if _, err = conn.Write(request); err != nil {
+			return nil, err
+		}
+		buffer := make([]byte, 0x10000)
+		size, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		return buffer[:size], nil
+	}
+	// TCP messages are prefixed with 4 bytes length
+	data := make([]byte, 4, 4+len(request))
+	binary.BigEndian.PutUint32(data, uint32(len(request)))
+	if _, err = conn.Write(append(data, request...)); err != nil {
+		return nil, err
+	}
+	if _, err = io.ReadFull(conn, data[:4]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(data[:4])
+	if size > maxMessageSize {
+		return nil, errors.New("kerberos: invalid KDC response length")
+	}
+	response := make([]byte, size)
+	if _, err = io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
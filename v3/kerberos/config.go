@@ -0,0 +1,362 @@
+package kerberos
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Realm hold KDC addresses of a realm from [realms] section
+type Realm struct {
+	Name string
+	KDC  []string
+}
+
+// Config is the subset of krb5.conf used by the client
+type Config struct {
+	DefaultRealm string
+	// TGSETypes is the list of encryption types requested from the KDC
+	TGSETypes []int32
+	// TicketLifetime of requested tickets (default 10h)
+	TicketLifetime time.Duration
+	// ClockSkew is the maximum tolerated difference between client and KDC time (default 5m)
+	ClockSkew time.Duration
+	// KDCTimeout is the timeout for each request sent to a KDC (default 3s)
+	KDCTimeout time.Duration
+	// UDPPreferenceLimit is the maximum message size sent over UDP. 1 means always use TCP
+	UDPPreferenceLimit int
+	// DNSLookupKDC enable lookup of KDC from DNS SRV records when the realm has no kdc entry
+	DNSLookupKDC bool
+	// DNSCanonicalizeHostname resolve server host to its canonical name before building SPN
+	DNSCanonicalizeHostname bool
+	Forwardable             bool
+	Realms                  map[string]*Realm
+	// DomainRealm maps host (or .domain) to realm
+	DomainRealm map[string]string
+}
+
+// NewConfig return configuration with default values
+func NewConfig() *Config {
+	return &Config{
+		TGSETypes:          append([]int32{}, defaultETypes...),
+		TicketLifetime:     10 * time.Hour,
+		ClockSkew:          5 * time.Minute,
+		KDCTimeout:         3 * time.Second,
+		UDPPreferenceLimit: 1465,
+		DNSLookupKDC:       true,
+		Realms:             map[string]*Realm{},
+		DomainRealm:        map[string]string{},
+	}
+}
+
+// DefaultConfigPath return KRB5_CONFIG environment variable or /etc/krb5.conf
+func DefaultConfigPath() string {
+	if path := os.Getenv("KRB5_CONFIG"); len(path) > 0 {
+		// KRB5_CONFIG may contain a list of files; the first one is used
+		return strings.Split(path, string(os.PathListSeparator))[0]
+	}
+	return "/etc/krb5.conf"
+}
+
+// LoadConfig read krb5.conf file. include and includedir directives are followed
+func LoadConfig(path string) (*Config, error) {
+	config := NewConfig()
+	err := config.loadFile(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ParseConfig read krb5.conf content from reader
+func ParseConfig(reader io.Reader) (*Config, error) {
+	config := NewConfig()
+	err := config.parse(reader, 0)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (config *Config) loadFile(path string, depth int) error {
+	if depth > 8 {
+		return errors.New("kerberos: krb5.conf include nested too deeply")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return config.parse(file, depth)
+}
+
+type configParser struct {
+	section string
+	realm   *Realm
+	// nested hold names of open groups (name = { ... })
+	nested []string
+}
+
+func (config *Config) parse(reader io.Reader, depth int) error {
+	parser := configParser{}
+	scanner := bufio.NewScanner(reader)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "include ") || strings.HasPrefix(line, "includedir ") {
+			err := config.include(line, depth)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return fmt.Errorf("kerberos: krb5.conf line %d: invalid section", lineNo)
+			}
+			parser = configParser{section: strings.ToLower(strings.TrimSpace(line[1:end]))}
+			continue
+		}
+		if line == "}" {
+			if len(parser.nested) == 0 {
+				return fmt.Errorf("kerberos: krb5.conf line %d: unexpected }", lineNo)
+			}
+			parser.nested = parser.nested[:len(parser.nested)-1]
+			if len(parser.nested) == 0 {
+				parser.realm = nil
+			}
+			continue
+		}
+		index := strings.Index(line, "=")
+		if index < 0 {
+			return fmt.Errorf("kerberos: krb5.conf line %d: expected name = value", lineNo)
+		}
+		name := strings.TrimSpace(line[:index])
+		value := strings.TrimSpace(line[index+1:])
+		if value == "{" {
+			parser.nested = append(parser.nested, name)
+			if parser.section == "realms" && len(parser.nested) == 1 {
+				parser.realm = config.realm(name)
+			}
+			continue
+		}
+		err := config.setValue(&parser, name, value)
+		if err != nil {
+			return fmt.Errorf("kerberos: krb5.conf line %d: %v", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (config *Config) include(line string, depth int) error {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return fmt.Errorf("kerberos: invalid directive: %s", line)
+	}
+	if fields[0] == "include" {
+		return config.loadFile(fields[1], depth+1)
+	}
+	entries, err := os.ReadDir(fields[1])
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// same rules as MIT: alphanumeric, dash and underscore or ending with .conf
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".conf") || strings.IndexFunc(name, func(r rune) bool {
+			return !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		}) < 0) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err = config.loadFile(filepath.Join(fields[1], name), depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (config *Config) realm(name string) *Realm {
+	realm, ok := config.Realms[name]
+	if !ok {
+		realm = &Realm{Name: name}
+		config.Realms[name] = realm
+	}
+	return realm
+}
+
+func (config *Config) setValue(parser *configParser, name, value string) (err error) {
+	switch parser.section {
+	case "libdefaults":
+		if len(parser.nested) > 0 {
+			// realm specific libdefaults are ignored
+			return nil
+		}
+		switch strings.ToLower(name) {
+		case "default_realm":
+			config.DefaultRealm = value
+		case "default_tgs_enctypes", "default_tkt_enctypes", "permitted_enctypes":
+			var etypes []int32
+			for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' }) {
+				var temp []int32
+				temp, err = parseEType(item)
+				if err != nil {
+					return err
+				}
+				etypes = append(etypes, temp...)
+			}
+			if len(etypes) > 0 {
+				config.TGSETypes = etypes
+			}
+		case "ticket_lifetime":
+			config.TicketLifetime, err = parseDuration(value)
+		case "clockskew":
+			config.ClockSkew, err = parseDuration(value)
+		case "kdc_timeout":
+			config.KDCTimeout, err = parseDuration(value)
+		case "udp_preference_limit":
+			config.UDPPreferenceLimit, err = strconv.Atoi(value)
+		case "dns_lookup_kdc", "dns_fallback":
+			config.DNSLookupKDC = parseBool(value)
+		case "dns_canonicalize_hostname":
+			config.DNSCanonicalizeHostname = parseBool(value)
+		case "forwardable":
+			config.Forwardable = parseBool(value)
+		}
+	case "realms":
+		if parser.realm != nil && len(parser.nested) == 1 && strings.ToLower(name) == "kdc" {
+			parser.realm.KDC = append(parser.realm.KDC, value)
+		}
+	case "domain_realm":
+		config.DomainRealm[strings.ToLower(name)] = value
+	}
+	return err
+}
+
+func parseBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
+// parseDuration read krb5.conf duration: seconds, h:m[:s] or go duration
+// with day unit (e.g. 1d 10h)
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if strings.Contains(value, ":") {
+		parts := strings.Split(value, ":")
+		var total time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		for i, part := range parts {
+			val, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+			total += time.Duration(val) * units[i]
+		}
+		return total, nil
+	}
+	var total time.Duration
+	for _, field := range strings.Fields(value) {
+		if strings.HasSuffix(field, "d") {
+			days, err := strconv.Atoi(strings.TrimSuffix(field, "d"))
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+			total += time.Duration(days) * 24 * time.Hour
+			continue
+		}
+		temp, err := time.ParseDuration(field)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		total += temp
+	}
+	return total, nil
+}
+
+// RealmForHost return the realm of the host from [domain_realm] section.
+// the longest matching domain is used and default realm is returned if no match
+func (config *Config) RealmForHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if realm, ok := config.DomainRealm[host]; ok {
+		return realm
+	}
+	for domain := host; ; {
+		if realm, ok := config.DomainRealm["."+domain]; ok {
+			return realm
+		}
+		if realm, ok := config.DomainRealm[domain]; ok && domain != host {
+			return realm
+		}
+		index := strings.Index(domain, ".")
+		if index < 0 {
+			break
+		}
+		domain = domain[index+1:]
+	}
+	return config.DefaultRealm
+}
+
+// KDCAddresses return host:port of KDC servers of the realm. if the realm
+// has no kdc entry DNS SRV records are used when DNSLookupKDC is enabled
+func (config *Config) KDCAddresses(realm string) ([]string, error) {
+	var output []string
+	if entry, ok := config.Realms[realm]; ok {
+		for _, kdc := range entry.KDC {
+			output = append(output, kdcAddress(kdc))
+		}
+	}
+	if len(output) > 0 {
+		return output, nil
+	}
+	if config.DNSLookupKDC {
+		for _, proto := range []string{"tcp", "udp"} {
+			_, records, err := net.LookupSRV("kerberos", proto, realm)
+			if err != nil {
+				continue
+			}
+			for _, record := range records {
+				output = append(output, net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))))
+			}
+			if len(output) > 0 {
+				return output, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("kerberos: no KDC found for realm %s", realm)
+}
+
+// kdcAddress add default port (88) and strip optional protocol prefix
+func kdcAddress(kdc string) string {
+	kdc = strings.TrimPrefix(strings.TrimPrefix(kdc, "tcp/"), "udp/")
+	if _, _, err := net.SplitHostPort(kdc); err == nil {
+		return kdc
+	}
+	return net.JoinHostPort(strings.Trim(kdc, "[]"), "88")
+}
@@ -0,0 +1,106 @@
+package kerberos
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKrb5Conf = `
+# sample configuration
+[libdefaults]
+	default_realm = EXAMPLE.COM
+	default_tgs_enctypes = aes256-cts-hmac-sha1-96 des3-cbc-sha1 arcfour-hmac
+	ticket_lifetime = 1d 2h
+	clockskew = 300
+	udp_preference_limit = 1
+	dns_lookup_kdc = false
+	forwardable = true
+
+[realms]
+	EXAMPLE.COM = {
+		kdc = kdc1.example.com
+		kdc = kdc2.example.com:750
+		admin_server = kdc1.example.com
+		auth_to_local = {
+			kdc = ignored.example.com
+		}
+	}
+	CORP.EXAMPLE.COM = {
+		kdc = [::1]:88
+	}
+
+[domain_realm]
+	.corp.example.com = CORP.EXAMPLE.COM
+	special.example.com = SPECIAL.COM
+`
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader(testKrb5Conf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DefaultRealm != "EXAMPLE.COM" {
+		t.Errorf("default realm: %s", config.DefaultRealm)
+	}
+	if len(config.TGSETypes) != 2 || config.TGSETypes[0] != ETypeAES256CTSHMACSHA196 || config.TGSETypes[1] != ETypeRC4HMAC {
+		t.Errorf("encryption types: %v", config.TGSETypes)
+	}
+	if config.TicketLifetime != 26*time.Hour || config.ClockSkew != 5*time.Minute {
+		t.Errorf("durations: %v %v", config.TicketLifetime, config.ClockSkew)
+	}
+	if config.UDPPreferenceLimit != 1 || config.DNSLookupKDC || !config.Forwardable {
+		t.Errorf("unexpected libdefaults: %+v", config)
+	}
+	kdc, err := config.KDCAddresses("EXAMPLE.COM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(kdc, ",") != "kdc1.example.com:88,kdc2.example.com:750" {
+		t.Errorf("kdc addresses: %v", kdc)
+	}
+	kdc, _ = config.KDCAddresses("CORP.EXAMPLE.COM")
+	if len(kdc) != 1 || kdc[0] != "[::1]:88" {
+		t.Errorf("kdc addresses: %v", kdc)
+	}
+	if _, err = config.KDCAddresses("OTHER.COM"); err == nil {
+		t.Error("expected error for realm without kdc")
+	}
+	for host, realm := range map[string]string{
+		"db.corp.example.com":  "CORP.EXAMPLE.COM",
+		"DB.CORP.Example.com.": "CORP.EXAMPLE.COM",
+		"special.example.com":  "SPECIAL.COM",
+		"db.example.com":       "EXAMPLE.COM",
+	} {
+		if got := config.RealmForHost(host); got != realm {
+			t.Errorf("realm of %s: got %s, want %s", host, got, realm)
+		}
+	}
+}
+
+func TestLoadConfigInclude(t *testing.T) {
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "conf.d"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "conf.d", "realm.conf"), []byte("[realms]\nTEST.COM = {\n kdc = 127.0.0.1\n}\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main := "[libdefaults]\ndefault_realm = TEST.COM\nincludedir " + filepath.Join(dir, "conf.d") + "\n"
+	err = os.WriteFile(filepath.Join(dir, "krb5.conf"), []byte(main), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(filepath.Join(dir, "krb5.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kdc, err := config.KDCAddresses("TEST.COM")
+	if err != nil || len(kdc) != 1 || kdc[0] != "127.0.0.1:88" {
+		t.Errorf("kdc addresses: %v, %v", kdc, err)
+	}
+}
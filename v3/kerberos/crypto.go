@@ -0,0 +1,406 @@
+package kerberos
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// encryption types
+const (
+	ETypeAES128CTSHMACSHA196 int32 = 17
+	ETypeAES256CTSHMACSHA196 int32 = 18
+	ETypeRC4HMAC             int32 = 23
+)
+
+// checksum types
+const (
+	cksumHMACSHA196AES128 int32 = 15
+	cksumHMACSHA196AES256 int32 = 16
+	cksumHMACMD5          int32 = -138
+	cksumGSSAPI           int32 = 0x8003
+)
+
+// defaultETypes in order of preference
+var defaultETypes = []int32{ETypeAES256CTSHMACSHA196, ETypeAES128CTSHMACSHA196, ETypeRC4HMAC}
+
+type encryptionType interface {
+	keySize() int
+	encrypt(key []byte, usage uint32, plain []byte) ([]byte, error)
+	decrypt(key []byte, usage uint32, cipherText []byte) ([]byte, error)
+	checksumType() int32
+	checksum(key []byte, usage uint32, data []byte) ([]byte, error)
+}
+
+func getEncryptionType(etype int32) (encryptionType, error) {
+	switch etype {
+	case ETypeAES128CTSHMACSHA196:
+		return aesCTSHMACSHA1{size: 16, cksum: cksumHMACSHA196AES128}, nil
+	case ETypeAES256CTSHMACSHA196:
+		return aesCTSHMACSHA1{size: 32, cksum: cksumHMACSHA196AES256}, nil
+	case ETypeRC4HMAC:
+		return rc4HMAC{}, nil
+	default:
+		return nil, fmt.Errorf("kerberos: unsupported encryption type: %d", etype)
+	}
+}
+
+func encryptWithKey(key EncryptionKey, usage uint32, plain []byte) (encryptedData, error) {
+	etype, err := getEncryptionType(key.KeyType)
+	if err != nil {
+		return encryptedData{}, err
+	}
+	cipherText, err := etype.encrypt(key.KeyValue, usage, plain)
+	if err != nil {
+		return encryptedData{}, err
+	}
+	return encryptedData{EType: key.KeyType, Cipher: cipherText}, nil
+}
+
+func decryptWithKey(key EncryptionKey, usage uint32, data encryptedData) ([]byte, error) {
+	if key.KeyType != data.EType {
+		return nil, fmt.Errorf("kerberos: key encryption type %d doesn't match data encryption type %d", key.KeyType, data.EType)
+	}
+	etype, err := getEncryptionType(key.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	return etype.decrypt(key.KeyValue, usage, data.Cipher)
+}
+
+func checksumWithKey(key EncryptionKey, usage uint32, data []byte) (checksum, error) {
+	etype, err := getEncryptionType(key.KeyType)
+	if err != nil {
+		return checksum{}, err
+	}
+	sum, err := etype.checksum(key.KeyValue, usage, data)
+	if err != nil {
+		return checksum{}, err
+	}
+	return checksum{CksumType: etype.checksumType(), Checksum: sum}, nil
+}
+
+// parseEType convert krb5.conf encryption type name or number
+func parseEType(name string) ([]int32, error) {
+	switch strings.ToLower(name) {
+	case "aes256-cts-hmac-sha1-96", "aes256-cts", "aes256-sha1":
+		return []int32{ETypeAES256CTSHMACSHA196}, nil
+	case "aes128-cts-hmac-sha1-96", "aes128-cts", "aes128-sha1":
+		return []int32{ETypeAES128CTSHMACSHA196}, nil
+	case "arcfour-hmac", "rc4-hmac", "arcfour-hmac-md5", "rc4":
+		return []int32{ETypeRC4HMAC}, nil
+	case "aes":
+		return []int32{ETypeAES256CTSHMACSHA196, ETypeAES128CTSHMACSHA196}, nil
+	case "default", "des3-cbc-sha1", "des3-hmac-sha1", "des3", "des-cbc-crc", "des-cbc-md5", "des",
+		"aes256-cts-hmac-sha384-192", "aes128-cts-hmac-sha256-128", "camellia256-cts-cmac",
+		"camellia128-cts-cmac", "camellia":
+		// known but not supported types are skipped
+		return nil, nil
+	}
+	val, err := strconv.Atoi(name)
+	if err != nil {
+		return nil, fmt.Errorf("kerberos: unknown encryption type: %s", name)
+	}
+	return []int32{int32(val)}, nil
+}
+
+// aesCTSHMACSHA1 implement aes128-cts-hmac-sha1-96 and aes256-cts-hmac-sha1-96 (RFC 3962)
+type aesCTSHMACSHA1 struct {
+	size  int
+	cksum int32
+}
+
+func (e aesCTSHMACSHA1) keySize() int {
+	return e.size
+}
+
+func (e aesCTSHMACSHA1) checksumType() int32 {
+	return e.cksum
+}
+
+func (e aesCTSHMACSHA1) deriveKey(key []byte, usage uint32, kind byte) ([]byte, error) {
+	constant := make([]byte, 5)
+	binary.BigEndian.PutUint32(constant, usage)
+	constant[4] = kind
+	return deriveRandom(key, constant, e.size)
+}
+
+func (e aesCTSHMACSHA1) encrypt(key []byte, usage uint32, plain []byte) ([]byte, error) {
+	ke, err := e.deriveKey(key, usage, 0xAA)
+	if err != nil {
+		return nil, err
+	}
+	ki, err := e.deriveKey(key, usage, 0x55)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, aes.BlockSize, aes.BlockSize+len(plain))
+	if _, err = rand.Read(data); err != nil {
+		return nil, err
+	}
+	data = append(data, plain...)
+	output, err := encryptCTS(ke, data)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha1.New, ki)
+	mac.Write(data)
+	return append(output, mac.Sum(nil)[:12]...), nil
+}
+
+func (e aesCTSHMACSHA1) decrypt(key []byte, usage uint32, cipherText []byte) ([]byte, error) {
+	if len(cipherText) < aes.BlockSize+12 {
+		return nil, errors.New("kerberos: cipher text is too short")
+	}
+	ke, err := e.deriveKey(key, usage, 0xAA)
+	if err != nil {
+		return nil, err
+	}
+	ki, err := e.deriveKey(key, usage, 0x55)
+	if err != nil {
+		return nil, err
+	}
+	macIndex := len(cipherText) - 12
+	data, err := decryptCTS(ke, cipherText[:macIndex])
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha1.New, ki)
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil)[:12], cipherText[macIndex:]) {
+		return nil, errors.New("kerberos: integrity check failed (wrong key?)")
+	}
+	return data[aes.BlockSize:], nil
+}
+
+func (e aesCTSHMACSHA1) checksum(key []byte, usage uint32, data []byte) ([]byte, error) {
+	kc, err := e.deriveKey(key, usage, 0x99)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha1.New, kc)
+	mac.Write(data)
+	return mac.Sum(nil)[:12], nil
+}
+
+// deriveRandom is DR function of RFC 3961 for AES keys. the result is used
+// directly as a key as random-to-key is identity for AES
+func deriveRandom(key, constant []byte, size int) ([]byte, error) {
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	block := nfold(constant, aes.BlockSize*8)
+	output := make([]byte, 0, size+aes.BlockSize)
+	for len(output) < size {
+		next := make([]byte, aes.BlockSize)
+		blk.Encrypt(next, block)
+		output = append(output, next...)
+		block = next
+	}
+	return output[:size], nil
+}
+
+// nfold stretch or fold the input to n bits (RFC 3961 section 5.1)
+func nfold(input []byte, n int) []byte {
+	inBits := len(input) * 8
+	lcm := n * inBits / gcd(n, inBits)
+	// concatenate copies of the input each one rotated right 13 bits more than the previous
+	buffer := make([]byte, lcm/8)
+	for i := 0; i < lcm/inBits; i++ {
+		copy(buffer[i*len(input):], rotateRight(input, 13*i))
+	}
+	// one's complement addition of n-bit chunks
+	output := make([]byte, n/8)
+	for offset := 0; offset < len(buffer); offset += n / 8 {
+		carry := 0
+		for i := n/8 - 1; i >= 0; i-- {
+			sum := int(output[i]) + int(buffer[offset+i]) + carry
+			output[i] = byte(sum)
+			carry = sum >> 8
+		}
+		for carry > 0 {
+			for i := n/8 - 1; i >= 0 && carry > 0; i-- {
+				sum := int(output[i]) + carry
+				output[i] = byte(sum)
+				carry = sum >> 8
+			}
+		}
+	}
+	return output
+}
+
+func rotateRight(input []byte, step int) []byte {
+	bits := len(input) * 8
+	output := make([]byte, len(input))
+	for i := 0; i < bits; i++ {
+		src := ((i-step)%bits + bits) % bits
+		if input[src/8]&(0x80>>(src%8)) != 0 {
+			output[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return output
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// encryptCTS is AES CBC with cipher text stealing and zero IV (RFC 3962)
+func encryptCTS(key, plain []byte) ([]byte, error) {
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	size := len(plain)
+	if size < aes.BlockSize {
+		return nil, errors.New("kerberos: CTS input shorter than block size")
+	}
+	if size == aes.BlockSize {
+		output := make([]byte, size)
+		blk.Encrypt(output, plain)
+		return output, nil
+	}
+	padded := make([]byte, (size+aes.BlockSize-1)/aes.BlockSize*aes.BlockSize)
+	copy(padded, plain)
+	output := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(blk, make([]byte, aes.BlockSize)).CryptBlocks(output, padded)
+	// swap the last two blocks and truncate
+	count := len(output)
+	last := append([]byte{}, output[count-aes.BlockSize:]...)
+	beforeLast := append([]byte{}, output[count-2*aes.BlockSize:count-aes.BlockSize]...)
+	copy(output[count-2*aes.BlockSize:], last)
+	copy(output[count-aes.BlockSize:], beforeLast)
+	return output[:size], nil
+}
+
+func decryptCTS(key, cipherText []byte) ([]byte, error) {
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	size := len(cipherText)
+	if size < aes.BlockSize {
+		return nil, errors.New("kerberos: CTS input shorter than block size")
+	}
+	if size == aes.BlockSize {
+		output := make([]byte, size)
+		blk.Decrypt(output, cipherText)
+		return output, nil
+	}
+	lastLen := size - (size-1)/aes.BlockSize*aes.BlockSize
+	headLen := size - lastLen - aes.BlockSize
+	output := make([]byte, size)
+	iv := make([]byte, aes.BlockSize)
+	if headLen > 0 {
+		cipher.NewCBCDecrypter(blk, iv).CryptBlocks(output[:headLen], cipherText[:headLen])
+		iv = cipherText[headLen-aes.BlockSize : headLen]
+	}
+	// the block before the partial one is the encryption of the last (padded) block
+	temp := make([]byte, aes.BlockSize)
+	blk.Decrypt(temp, cipherText[headLen:headLen+aes.BlockSize])
+	partial := cipherText[headLen+aes.BlockSize:]
+	full := make([]byte, aes.BlockSize)
+	copy(full, partial)
+	copy(full[lastLen:], temp[lastLen:])
+	for i := 0; i < lastLen; i++ {
+		output[headLen+aes.BlockSize+i] = temp[i] ^ partial[i]
+	}
+	blk.Decrypt(temp, full)
+	for i := 0; i < aes.BlockSize; i++ {
+		output[headLen+i] = temp[i] ^ iv[i]
+	}
+	return output, nil
+}
+
+// rc4HMAC implement arcfour-hmac-md5 (RFC 4757)
+type rc4HMAC struct{}
+
+func (rc4HMAC) keySize() int {
+	return 16
+}
+
+func (rc4HMAC) checksumType() int32 {
+	return cksumHMACMD5
+}
+
+func rc4Usage(usage uint32) []byte {
+	switch usage {
+	case 3:
+		usage = 8
+	case 9:
+		usage = 8
+	case 23:
+		usage = 13
+	}
+	output := make([]byte, 4)
+	binary.LittleEndian.PutUint32(output, usage)
+	return output
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(md5.New, key)
+	for _, item := range data {
+		mac.Write(item)
+	}
+	return mac.Sum(nil)
+}
+
+func (rc4HMAC) encrypt(key []byte, usage uint32, plain []byte) ([]byte, error) {
+	k1 := hmacMD5(key, rc4Usage(usage))
+	data := make([]byte, 8, 8+len(plain))
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	data = append(data, plain...)
+	sum := hmacMD5(k1, data)
+	k3 := hmacMD5(k1, sum)
+	stream, err := rc4.NewCipher(k3)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]byte, len(data))
+	stream.XORKeyStream(output, data)
+	return append(sum, output...), nil
+}
+
+func (rc4HMAC) decrypt(key []byte, usage uint32, cipherText []byte) ([]byte, error) {
+	if len(cipherText) < 24 {
+		return nil, errors.New("kerberos: cipher text is too short")
+	}
+	k1 := hmacMD5(key, rc4Usage(usage))
+	sum := cipherText[:16]
+	k3 := hmacMD5(k1, sum)
+	stream, err := rc4.NewCipher(k3)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]byte, len(cipherText)-16)
+	stream.XORKeyStream(output, cipherText[16:])
+	if !hmac.Equal(hmacMD5(k1, output), sum) {
+		return nil, errors.New("kerberos: integrity check failed (wrong key?)")
+	}
+	return output[8:], nil
+}
+
+func (rc4HMAC) checksum(key []byte, usage uint32, data []byte) ([]byte, error) {
+	ksign := hmacMD5(key, []byte("signaturekey\x00"))
+	usageBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(usageBytes, usage)
+	hash := md5.New()
+	hash.Write(usageBytes)
+	hash.Write(data)
+	return hmacMD5(ksign, hash.Sum(nil)), nil
+}
@@ -0,0 +1,109 @@
+package kerberos
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestNFold(t *testing.T) {
+	// test vectors from RFC 3961 appendix A.1
+	tests := []struct {
+		input  string
+		bits   int
+		output string
+	}{
+		{"012345", 64, "be072631276b1955"},
+		{"password", 56, "78a07b6caf85fa"},
+		{"Rough Consensus, and Running Code", 64, "bb6ed30870b7f0e0"},
+		{"password", 168, "59e4a8ca7c0385c3c37b3f6d2000247cb6e6bd5b3e"},
+		{"MASSACHVSETTS INSTITVTE OF TECHNOLOGY", 192, "db3b0d8f0b061e603282b308a50841229ad798fab9540c1b"},
+		{"Q", 168, "518a54a215a8452a518a54a215a8452a518a54a215"},
+		{"ba", 168, "fb25d531ae8974499f52fd92ea9857c4ba24cf297e"},
+		{"kerberos", 64, "6b65726265726f73"},
+		{"kerberos", 128, "6b65726265726f737b9b5b2b93132b93"},
+	}
+	for _, test := range tests {
+		got := hex.EncodeToString(nfold([]byte(test.input), test.bits))
+		if got != test.output {
+			t.Errorf("%d-fold(%q) = %s, want %s", test.bits, test.input, got, test.output)
+		}
+	}
+}
+
+func TestCTS(t *testing.T) {
+	key, _ := hex.DecodeString("636869636b656e207465726979616b69")
+	// RFC 3962 appendix B
+	plain, _ := hex.DecodeString("4920776f756c64206c696b652074686520")
+	expected := "c6353568f2bf8cb4d8a580362da7ff7f97"
+	output, err := encryptCTS(key, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(output) != expected {
+		t.Errorf("CTS encrypt: got %x, want %s", output, expected)
+	}
+	for size := 16; size <= 64; size++ {
+		plain = make([]byte, size)
+		_, _ = rand.Read(plain)
+		output, err = encryptCTS(key, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size%16 == 0 && size > 16 {
+			// full blocks: CBC with the last two blocks swapped
+			blk, _ := aes.NewCipher(key)
+			cbc := make([]byte, size)
+			cipher.NewCBCEncrypter(blk, make([]byte, 16)).CryptBlocks(cbc, plain)
+			cbc = append(append(cbc[:size-32:size-32], cbc[size-16:]...), cbc[size-32:size-16]...)
+			if !bytes.Equal(cbc, output) {
+				t.Errorf("CTS encrypt of %d bytes doesn't match CBC", size)
+			}
+		}
+		decrypted, err := decryptCTS(key, output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, plain) {
+			t.Errorf("CTS decrypt of %d bytes: got %x, want %x", size, decrypted, plain)
+		}
+	}
+}
+
+func TestEncryptionTypes(t *testing.T) {
+	for _, etype := range defaultETypes {
+		enc, err := getEncryptionType(etype)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := EncryptionKey{KeyType: etype, KeyValue: make([]byte, enc.keySize())}
+		_, _ = rand.Read(key.KeyValue)
+		for _, plain := range [][]byte{{}, []byte("a"), []byte("test data longer than one aes block")} {
+			data, err := encryptWithKey(key, usageAPReqAuthenticator, plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := decryptWithKey(key, usageAPReqAuthenticator, data)
+			if err != nil {
+				t.Fatalf("etype %d: %v", etype, err)
+			}
+			if !bytes.Equal(output, plain) {
+				t.Errorf("etype %d: got %q, want %q", etype, output, plain)
+			}
+			if _, err = decryptWithKey(key, usageTGSRepEncPart, data); err == nil {
+				t.Errorf("etype %d: decrypt with another key usage should fail", etype)
+			}
+		}
+		sum1, err := checksumWithKey(key, usageTGSReqChecksum, []byte("body"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum2, _ := checksumWithKey(key, usageTGSReqChecksum, []byte("body"))
+		if sum1.CksumType != enc.checksumType() || !bytes.Equal(sum1.Checksum, sum2.Checksum) {
+			t.Errorf("etype %d: checksum isn't deterministic", etype)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package kerberos is a pure Go Kerberos 5 client used for database
+// authentication with AUTH TYPE=KERBEROS.
+//
+// Client login with keys from keytab file or with tickets of MIT credential
+// cache (kinit), get service ticket of the database service principal and
+// build AP-REQ token. Client implement configurations.KerberosAuthInterface:
+//
+//	client, err := kerberos.NewClientWithKeytabFile("scott@EXAMPLE.COM", "/etc/scott.keytab")
+//	connector := go_ora.NewConnector(url).(*go_ora.OracleConnector)
+//	connector.WithKerberosAuth(client)
+//
+// supported encryption types are aes256-cts-hmac-sha1-96, aes128-cts-hmac-sha1-96
+// and arcfour-hmac
+package kerberos
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sijms/go-ora/v3/configurations"
+)
+
+// GSS-API context flags sent in the authenticator checksum (RFC 4121)
+const (
+	gssFlagDelegate = 1
+	gssFlagMutual   = 2
+)
+
+// ticketMargin is the minimum remaining lifetime of a cached ticket
+const ticketMargin = time.Minute
+
+// Client get tickets from KDC and build AP-REQ tokens. it is safe for
+// concurrent use and cache TGT and service tickets until they expire
+type Client struct {
+	config    *Config
+	principal PrincipalName
+	realm     string
+	keytab    *Keytab
+	ccache    *CCache
+	mutex     sync.Mutex
+	tgt       *Credential
+	tickets   map[string]*Credential
+}
+
+var _ configurations.KerberosAuthInterface = (*Client)(nil)
+
+// NewClientWithKeytab create client that login the principal (user@REALM) with keys
+// from keytab. default realm of the config is used when principal has no realm
+func NewClientWithKeytab(config *Config, principal string, keytab *Keytab) (*Client, error) {
+	if config == nil || keytab == nil {
+		return nil, errors.New("kerberos: config and keytab are required")
+	}
+	name, realm := parsePrincipal(principal)
+	if len(realm) == 0 {
+		realm = config.DefaultRealm
+	}
+	if len(realm) == 0 {
+		return nil, fmt.Errorf("kerberos: realm of %s is not specified and krb5.conf has no default_realm", principal)
+	}
+	return &Client{
+		config:    config,
+		principal: name,
+		realm:     realm,
+		keytab:    keytab,
+		tickets:   map[string]*Credential{},
+	}, nil
+}
+
+// NewClientWithCCache create client that use tickets of credential cache. the
+// cache should contain TGT of the default principal or the required service ticket
+func NewClientWithCCache(config *Config, cache *CCache) (*Client, error) {
+	if config == nil || cache == nil {
+		return nil, errors.New("kerberos: config and credential cache are required")
+	}
+	return &Client{
+		config:    config,
+		principal: cache.Principal,
+		realm:     cache.Realm,
+		ccache:    cache,
+		tickets:   map[string]*Credential{},
+	}, nil
+}
+
+// NewClientWithKeytabFile load krb5.conf from DefaultConfigPath and the keytab
+// file (DefaultKeytabPath if empty)
+func NewClientWithKeytabFile(principal, keytabPath string) (*Client, error) {
+	config, err := LoadConfig(DefaultConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(keytabPath) == 0 {
+		keytabPath = DefaultKeytabPath()
+	}
+	keytab, err := LoadKeytab(keytabPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithKeytab(config, principal, keytab)
+}
+
+// NewClientWithCCacheFile load krb5.conf from DefaultConfigPath and the
+// credential cache file (DefaultCCachePath if empty)
+func NewClientWithCCacheFile(ccachePath string) (*Client, error) {
+	config, err := LoadConfig(DefaultConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(ccachePath) == 0 {
+		ccachePath = DefaultCCachePath()
+	}
+	cache, err := LoadCCache(ccachePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithCCache(config, cache)
+}
+
+// Principal return client principal as name@REALM
+func (client *Client) Principal() string {
+	return client.principal.String() + "@" + client.realm
+}
+
+// Authenticate return AP-REQ for service/server principal. it is called
+// during advanced negotiation with the kerberos service name and host sent
+// by the database server
+func (client *Client) Authenticate(server, service string) ([]byte, error) {
+	spn, realm := client.ServicePrincipal(service, server)
+	cred, err := client.ServiceTicket(spn, realm)
+	if err != nil {
+		return nil, err
+	}
+	return client.apReq(cred, gssChecksum(gssFlagMutual), usageAPReqAuthenticator)
+}
+
+// ServicePrincipal return host based service principal and its realm from domain_realm mapping
+func (client *Client) ServicePrincipal(service, host string) (PrincipalName, string) {
+	host = canonicalHost(host, client.config.DNSCanonicalizeHostname)
+	return PrincipalName{NameType: NameTypeSrvHost, NameString: []string{service, host}}, client.config.RealmForHost(host)
+}
+
+// ServiceTicket return cached ticket of the service principal or get a new one from KDC
+func (client *Client) ServiceTicket(spn PrincipalName, realm string) (*Credential, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	cacheKey := spn.String() + "@" + realm
+	if cred, ok := client.tickets[cacheKey]; ok && cred.IsValid(ticketMargin) {
+		return cred, nil
+	}
+	if client.ccache != nil {
+		if cred, err := client.ccache.Credential(spn, realm); err == nil && cred.IsValid(ticketMargin) {
+			client.tickets[cacheKey] = cred
+			return cred, nil
+		}
+	}
+	tgt, err := client.getTGT()
+	if err != nil {
+		return nil, err
+	}
+	cred, err := client.tgsExchange(tgt, spn, realm)
+	if err != nil {
+		return nil, err
+	}
+	client.tickets[cacheKey] = cred
+	return cred, nil
+}
+
+// getTGT return valid TGT of the client realm. it should be called with mutex locked
+func (client *Client) getTGT() (*Credential, error) {
+	if client.tgt != nil && client.tgt.IsValid(ticketMargin) {
+		return client.tgt, nil
+	}
+	var err error
+	var tgt *Credential
+	if client.keytab != nil {
+		tgt, err = client.asExchange()
+	} else {
+		tgs := PrincipalName{NameType: NameTypeSrvInst, NameString: []string{"krbtgt", client.realm}}
+		tgt, err = client.ccache.Credential(tgs, client.realm)
+		if err == nil && !tgt.IsValid(ticketMargin) {
+			err = fmt.Errorf("kerberos: TGT of %s is expired; renew it with kinit", client.Principal())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	client.tgt = tgt
+	return tgt, nil
+}
+
+// gssChecksum build authenticator checksum of GSS-API kerberos mechanism
+// (RFC 4121 section 4.1.1) with no channel binding
+func gssChecksum(flags uint32) *checksum {
+	data := make([]byte, 24)
+	binary.LittleEndian.PutUint32(data, 16)
+	binary.LittleEndian.PutUint32(data[20:], flags)
+	return &checksum{CksumType: cksumGSSAPI, Checksum: data}
+}
+
+func canonicalHost(host string, useDNS bool) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if useDNS && net.ParseIP(host) == nil {
+		if cname, err := net.LookupCNAME(host); err == nil && len(cname) > 0 {
+			host = strings.TrimSuffix(strings.ToLower(cname), ".")
+		}
+	}
+	return host
+}
+
+// SPN return service/host principal for each server address of the connect
+// descriptor (or EZConnect host[:port]/service string). service is the kerberos
+// service name of the database (sqlnet.kerberos5_service, usually oracle)
+func SPN(service, connStr string) ([]string, error) {
+	var hosts []string
+	if strings.Contains(connStr, "(") {
+		servers, err := configurations.ExtractServers(connStr)
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range servers {
+			hosts = append(hosts, server.Addr)
+		}
+	} else if len(connStr) > 0 {
+		host := strings.TrimPrefix(connStr, "//")
+		if index := strings.Index(host, "/"); index >= 0 {
+			host = host[:index]
+		}
+		if temp, _, err := net.SplitHostPort(host); err == nil {
+			host = temp
+		}
+		hosts = append(hosts, strings.Trim(host, "[]"))
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("kerberos: no server address in connect string")
+	}
+	output := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		output = append(output, service+"/"+canonicalHost(host, false))
+	}
+	return output, nil
+}
@@ -0,0 +1,472 @@
+package kerberos
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testKDC is in-process KDC that answer AS-REQ with encrypted timestamp and TGS-REQ over TCP
+type testKDC struct {
+	t        *testing.T
+	realm    string
+	keys     map[string]EncryptionKey
+	listener net.Listener
+	mutex    sync.Mutex
+	requests int
+}
+
+func newTestKDC(t *testing.T, realm string) *testKDC {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kdc := &testKDC{t: t, realm: realm, keys: map[string]EncryptionKey{}, listener: listener}
+	kdc.keys["krbtgt/"+realm] = randomKey(ETypeAES256CTSHMACSHA196)
+	go kdc.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return kdc
+}
+
+func randomKey(etype int32) EncryptionKey {
+	enc, _ := getEncryptionType(etype)
+	key := EncryptionKey{KeyType: etype, KeyValue: make([]byte, enc.keySize())}
+	_, _ = rand.Read(key.KeyValue)
+	return key
+}
+
+func (kdc *testKDC) config() *Config {
+	config := NewConfig()
+	config.DefaultRealm = kdc.realm
+	config.UDPPreferenceLimit = 1
+	config.DNSLookupKDC = false
+	config.Realms[kdc.realm] = &Realm{Name: kdc.realm, KDC: []string{kdc.listener.Addr().String()}}
+	config.DomainRealm[".example.com"] = kdc.realm
+	return config
+}
+
+func (kdc *testKDC) requestCount() int {
+	kdc.mutex.Lock()
+	defer kdc.mutex.Unlock()
+	return kdc.requests
+}
+
+func (kdc *testKDC) serve() {
+	for {
+		conn, err := kdc.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			header := make([]byte, 4)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			request := make([]byte, binary.BigEndian.Uint32(header))
+			if _, err := io.ReadFull(conn, request); err != nil {
+				return
+			}
+			kdc.mutex.Lock()
+			kdc.requests++
+			kdc.mutex.Unlock()
+			response := kdc.handle(request)
+			binary.BigEndian.PutUint32(header, uint32(len(response)))
+			_, _ = conn.Write(append(header, response...))
+		}()
+	}
+}
+
+func (kdc *testKDC) handle(request []byte) []byte {
+	var req kdcReq
+	tag := applicationTag(request)
+	if err := unmarshalApp(request, tag, &req); err != nil {
+		kdc.t.Errorf("KDC: invalid request: %v", err)
+		return kdc.krbError(40, nil)
+	}
+	var body kdcReqBody
+	if _, err := asn1.Unmarshal(req.ReqBody.Bytes, &body); err != nil {
+		kdc.t.Errorf("KDC: invalid request body: %v", err)
+		return kdc.krbError(40, nil)
+	}
+	switch tag {
+	case tagASReq:
+		return kdc.handleAS(&req, &body)
+	case tagTGSReq:
+		return kdc.handleTGS(&req, &body)
+	}
+	return kdc.krbError(40, nil)
+}
+
+func (kdc *testKDC) handleAS(req *kdcReq, body *kdcReqBody) []byte {
+	clientKey, ok := kdc.keys[body.CName.String()]
+	if !ok {
+		return kdc.krbError(6, nil)
+	}
+	preAuthOK := false
+	for _, pa := range req.PAData {
+		if pa.Type != paEncTimestamp {
+			continue
+		}
+		var data encryptedData
+		if _, err := asn1.Unmarshal(pa.Value, &data); err != nil || data.EType != clientKey.KeyType {
+			break
+		}
+		plain, err := decryptWithKey(clientKey, usageASReqTimestamp, data)
+		if err != nil {
+			return kdc.krbError(24, nil)
+		}
+		var ts paEncTSEnc
+		if _, err = asn1.Unmarshal(plain, &ts); err != nil || time.Since(ts.PATimestamp) > time.Minute {
+			return kdc.krbError(24, nil)
+		}
+		preAuthOK = true
+	}
+	if !preAuthOK {
+		info := derSequence(derSequence(derExplicit(0, derInt(int64(clientKey.KeyType)))))
+		methods := derSequence(paData{Type: paETypeInfo2, Value: info}.marshal())
+		return kdc.krbError(errPreAuthRequired, methods)
+	}
+	return kdc.reply(tagASRep, body, body.CName, kdc.realm, clientKey, usageASRepEncPart, tagEncASRepPart)
+}
+
+func (kdc *testKDC) handleTGS(req *kdcReq, body *kdcReqBody) []byte {
+	if len(req.PAData) != 1 || req.PAData[0].Type != paTGSReq {
+		return kdc.krbError(40, nil)
+	}
+	var ap apReq
+	if err := unmarshalApp(req.PAData[0].Value, tagAPReq, &ap); err != nil {
+		kdc.t.Errorf("KDC: invalid PA-TGS-REQ: %v", err)
+		return kdc.krbError(40, nil)
+	}
+	tgtPart, err := decryptTicket(ap.Ticket.Bytes, kdc.keys["krbtgt/"+kdc.realm])
+	if err != nil {
+		return kdc.krbError(31, nil)
+	}
+	auth, err := decryptAuthenticator(ap.Authenticator, tgtPart.Key, usageTGSReqAuthenticator)
+	if err != nil || !auth.CName.Equal(tgtPart.CName) {
+		return kdc.krbError(31, nil)
+	}
+	sum, err := checksumWithKey(tgtPart.Key, usageTGSReqChecksum, req.ReqBody.Bytes)
+	if err != nil || auth.Cksum.CksumType != sum.CksumType || !bytes.Equal(auth.Cksum.Checksum, sum.Checksum) {
+		return kdc.krbError(31, nil)
+	}
+	return kdc.reply(tagTGSRep, body, tgtPart.CName, tgtPart.CRealm, tgtPart.Key, usageTGSRepEncPart, tagEncTGSRepPart)
+}
+
+// reply issue ticket of the requested server encrypted with server key
+func (kdc *testKDC) reply(tag int, body *kdcReqBody, cname PrincipalName, crealm string, replyKey EncryptionKey, usage uint32, partTag int) []byte {
+	serverKey, ok := kdc.keys[body.SName.String()]
+	if !ok {
+		return kdc.krbError(7, nil)
+	}
+	sessionKey := randomKey(body.EType[0])
+	now := time.Now().UTC()
+	endTime := now.Add(time.Hour)
+	encTicket := derApplication(tagEncTicketPart, derSequence(
+		derExplicit(0, derFlags(0)),
+		derExplicit(1, sessionKey.marshal()),
+		derExplicit(2, derGeneralString(crealm)),
+		derExplicit(3, cname.marshal()),
+		derExplicit(4, derSequence(derExplicit(0, derInt(1)), derExplicit(1, derOctetString(nil)))),
+		derExplicit(5, derTime(now)),
+		derExplicit(7, derTime(endTime)),
+	))
+	ticketEnc, _ := encryptWithKey(serverKey, usageKDCRepTicket, encTicket)
+	ticketEnc.KVNO = 1
+	ticketData := derApplication(tagTicket, derSequence(
+		derExplicit(0, derInt(pvno)),
+		derExplicit(1, derGeneralString(kdc.realm)),
+		derExplicit(2, body.SName.marshal()),
+		derExplicit(3, ticketEnc.marshal()),
+	))
+	encPart := derApplication(partTag, derSequence(
+		derExplicit(0, sessionKey.marshal()),
+		derExplicit(1, derSequence()),
+		derExplicit(2, derInt(body.Nonce)),
+		derExplicit(4, derFlags(0)),
+		derExplicit(5, derTime(now)),
+		derExplicit(7, derTime(endTime)),
+		derExplicit(9, derGeneralString(kdc.realm)),
+		derExplicit(10, body.SName.marshal()),
+	))
+	repEnc, _ := encryptWithKey(replyKey, usage, encPart)
+	return derApplication(tag, derSequence(
+		derExplicit(0, derInt(pvno)),
+		derExplicit(1, derInt(int64(tag))),
+		derExplicit(3, derGeneralString(crealm)),
+		derExplicit(4, cname.marshal()),
+		derExplicit(5, ticketData),
+		derExplicit(6, repEnc.marshal()),
+	))
+}
+
+func (kdc *testKDC) krbError(code int32, eData []byte) []byte {
+	var eDataField []byte
+	if eData != nil {
+		eDataField = derExplicit(12, derOctetString(eData))
+	}
+	return derApplication(tagKRBError, derSequence(
+		derExplicit(0, derInt(pvno)),
+		derExplicit(1, derInt(tagKRBError)),
+		derExplicit(4, derTime(time.Now())),
+		derExplicit(5, derInt(0)),
+		derExplicit(6, derInt(int64(code))),
+		derExplicit(9, derGeneralString(kdc.realm)),
+		derExplicit(10, PrincipalName{NameType: NameTypeSrvInst, NameString: []string{"krbtgt", kdc.realm}}.marshal()),
+		eDataField,
+	))
+}
+
+func decryptTicket(data []byte, key EncryptionKey) (*encTicketPart, error) {
+	var tkt ticket
+	if err := unmarshalApp(data, tagTicket, &tkt); err != nil {
+		return nil, err
+	}
+	plain, err := decryptWithKey(key, usageKDCRepTicket, tkt.EncPart)
+	if err != nil {
+		return nil, err
+	}
+	var part encTicketPart
+	if err = unmarshalApp(plain, tagEncTicketPart, &part); err != nil {
+		return nil, err
+	}
+	return &part, nil
+}
+
+func decryptAuthenticator(data encryptedData, key EncryptionKey, usage uint32) (*authenticator, error) {
+	plain, err := decryptWithKey(key, usage, data)
+	if err != nil {
+		return nil, err
+	}
+	var auth authenticator
+	if err = unmarshalApp(plain, tagAuthenticator, &auth); err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
+// marshalKeytab write keytab file content. an empty entry is written as deleted hole
+func marshalKeytab(entries []KeytabEntry) []byte {
+	output := []byte{5, 2}
+	for _, entry := range entries {
+		var record []byte
+		record = binary.BigEndian.AppendUint16(record, uint16(len(entry.Principal.NameString)))
+		record = binary.BigEndian.AppendUint16(record, uint16(len(entry.Realm)))
+		record = append(record, entry.Realm...)
+		for _, part := range entry.Principal.NameString {
+			record = binary.BigEndian.AppendUint16(record, uint16(len(part)))
+			record = append(record, part...)
+		}
+		record = binary.BigEndian.AppendUint32(record, uint32(entry.Principal.NameType))
+		record = binary.BigEndian.AppendUint32(record, uint32(entry.Timestamp.Unix()))
+		record = append(record, byte(entry.KVNO))
+		record = binary.BigEndian.AppendUint16(record, uint16(entry.Key.KeyType))
+		record = binary.BigEndian.AppendUint16(record, uint16(len(entry.Key.KeyValue)))
+		record = append(record, entry.Key.KeyValue...)
+		record = binary.BigEndian.AppendUint32(record, entry.KVNO)
+		size := int32(len(record))
+		if len(entry.Realm) == 0 {
+			size = -size
+		}
+		output = binary.BigEndian.AppendUint32(output, uint32(size))
+		output = append(output, record...)
+	}
+	return output
+}
+
+func appendCCachePrincipal(output []byte, name PrincipalName, realm string) []byte {
+	output = binary.BigEndian.AppendUint32(output, uint32(name.NameType))
+	output = binary.BigEndian.AppendUint32(output, uint32(len(name.NameString)))
+	output = binary.BigEndian.AppendUint32(output, uint32(len(realm)))
+	output = append(output, realm...)
+	for _, part := range name.NameString {
+		output = binary.BigEndian.AppendUint32(output, uint32(len(part)))
+		output = append(output, part...)
+	}
+	return output
+}
+
+// marshalCCache write version 4 credential cache file content
+func marshalCCache(principal PrincipalName, realm string, creds []*Credential) []byte {
+	output := []byte{5, 4, 0, 12, 0, 1, 0, 8, 0, 0, 0, 0, 0, 0, 0, 0}
+	output = appendCCachePrincipal(output, principal, realm)
+	for _, cred := range creds {
+		output = appendCCachePrincipal(output, cred.Client, cred.ClientRealm)
+		output = appendCCachePrincipal(output, cred.Server, cred.ServerRealm)
+		output = binary.BigEndian.AppendUint16(output, uint16(cred.Key.KeyType))
+		output = binary.BigEndian.AppendUint32(output, uint32(len(cred.Key.KeyValue)))
+		output = append(output, cred.Key.KeyValue...)
+		for _, val := range []time.Time{cred.AuthTime, cred.StartTime, cred.EndTime, cred.RenewTill} {
+			var seconds uint32
+			if !val.IsZero() {
+				seconds = uint32(val.Unix())
+			}
+			output = binary.BigEndian.AppendUint32(output, seconds)
+		}
+		output = append(output, 0)
+		output = binary.BigEndian.AppendUint32(output, cred.TicketFlags)
+		output = append(output, 0, 0, 0, 0, 0, 0, 0, 0)
+		output = binary.BigEndian.AppendUint32(output, uint32(len(cred.Ticket)))
+		output = append(output, cred.Ticket...)
+		output = binary.BigEndian.AppendUint32(output, 0)
+	}
+	return output
+}
+
+// verifyAPReq decrypt AP-REQ sent to database server and check client name and GSS checksum
+func verifyAPReq(t *testing.T, token []byte, serviceKey EncryptionKey, client string) {
+	t.Helper()
+	var ap apReq
+	if err := unmarshalApp(token, tagAPReq, &ap); err != nil {
+		t.Fatalf("invalid AP-REQ: %v", err)
+	}
+	part, err := decryptTicket(ap.Ticket.Bytes, serviceKey)
+	if err != nil {
+		t.Fatalf("can't decrypt service ticket: %v", err)
+	}
+	auth, err := decryptAuthenticator(ap.Authenticator, part.Key, usageAPReqAuthenticator)
+	if err != nil {
+		t.Fatalf("can't decrypt authenticator: %v", err)
+	}
+	if got := auth.CName.String() + "@" + auth.CRealm; got != client {
+		t.Errorf("authenticator client: got %s, want %s", got, client)
+	}
+	if auth.Cksum.CksumType != cksumGSSAPI || len(auth.Cksum.Checksum) != 24 ||
+		binary.LittleEndian.Uint32(auth.Cksum.Checksum[20:]) != gssFlagMutual {
+		t.Errorf("unexpected GSS checksum: %d %x", auth.Cksum.CksumType, auth.Cksum.Checksum)
+	}
+}
+
+func TestKeytabLogin(t *testing.T) {
+	kdc := newTestKDC(t, "EXAMPLE.COM")
+	// KDC has only aes128 key of the client so the first pre-authentication
+	// with aes256 is retried with encryption type from PA-ETYPE-INFO2
+	aes128Key := randomKey(ETypeAES128CTSHMACSHA196)
+	kdc.keys["scott"] = aes128Key
+	serviceKey := randomKey(ETypeAES256CTSHMACSHA196)
+	kdc.keys["oracle/db.example.com"] = serviceKey
+	scott := PrincipalName{NameType: NameTypePrincipal, NameString: []string{"scott"}}
+	data := marshalKeytab([]KeytabEntry{
+		{Principal: scott, Realm: "EXAMPLE.COM", KVNO: 2, Key: randomKey(ETypeAES256CTSHMACSHA196)},
+		{Principal: scott, Realm: "", KVNO: 1, Key: randomKey(ETypeAES128CTSHMACSHA196)},
+		{Principal: scott, Realm: "EXAMPLE.COM", KVNO: 300, Key: aes128Key},
+	})
+	keytab, err := ParseKeytab(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keytab.Entries) != 2 || keytab.Entries[1].KVNO != 300 {
+		t.Fatalf("unexpected keytab entries: %+v", keytab.Entries)
+	}
+	client, err := NewClientWithKeytab(kdc.config(), "scott", keytab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Principal() != "scott@EXAMPLE.COM" {
+		t.Errorf("principal: %s", client.Principal())
+	}
+	token, err := client.Authenticate("DB.Example.COM.", "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyAPReq(t, token, serviceKey, "scott@EXAMPLE.COM")
+	// AS-REQ twice (pre-authentication retry) and TGS-REQ
+	if count := kdc.requestCount(); count != 3 {
+		t.Errorf("expected 3 KDC requests, got %d", count)
+	}
+	token, err = client.Authenticate("db.example.com", "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyAPReq(t, token, serviceKey, "scott@EXAMPLE.COM")
+	if count := kdc.requestCount(); count != 3 {
+		t.Errorf("service ticket should be cached, got %d KDC requests", count)
+	}
+	_, err = client.Authenticate("unknown.example.com", "oracle")
+	var krbErr *KRBError
+	if !errors.As(err, &krbErr) || krbErr.Code != 7 {
+		t.Errorf("expected principal unknown error, got %v", err)
+	}
+}
+
+func TestCCacheLogin(t *testing.T) {
+	kdc := newTestKDC(t, "EXAMPLE.COM")
+	userKey := randomKey(ETypeRC4HMAC)
+	kdc.keys["adams"] = userKey
+	serviceKey := randomKey(ETypeAES128CTSHMACSHA196)
+	kdc.keys["oracle/db.example.com"] = serviceKey
+	adams := PrincipalName{NameType: NameTypePrincipal, NameString: []string{"adams"}}
+	// kinit: get TGT and store it in credential cache
+	keytab := &Keytab{Entries: []KeytabEntry{{Principal: adams, Realm: "EXAMPLE.COM", KVNO: 1, Key: userKey}}}
+	kinit, err := NewClientWithKeytab(kdc.config(), "adams@EXAMPLE.COM", keytab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tgt, err := kinit.getTGT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := ParseCCache(marshalCCache(adams, "EXAMPLE.COM", []*Credential{tgt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.Credentials) != 1 || cache.Credentials[0].Server.String() != "krbtgt/EXAMPLE.COM" ||
+		!bytes.Equal(cache.Credentials[0].Ticket, tgt.Ticket) {
+		t.Fatalf("unexpected credential cache: %+v", cache)
+	}
+	client, err := NewClientWithCCache(kdc.config(), cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := client.Authenticate("db.example.com", "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyAPReq(t, token, serviceKey, "adams@EXAMPLE.COM")
+
+	// expired TGT require kinit
+	expired := *tgt
+	expired.EndTime = time.Now().Add(-time.Minute)
+	cache, err = ParseCCache(marshalCCache(adams, "EXAMPLE.COM", []*Credential{&expired}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _ = NewClientWithCCache(kdc.config(), cache)
+	_, err = client.Authenticate("db.example.com", "oracle")
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expected expired ticket error, got %v", err)
+	}
+}
+
+func TestSPN(t *testing.T) {
+	tests := []struct {
+		connStr string
+		output  string
+	}{
+		{"(DESCRIPTION=(ADDRESS_LIST=(ADDRESS=(PROTOCOL=TCP)(HOST=DB1.example.com)(PORT=1521))(ADDRESS=(PROTOCOL=TCP)(HOST=db2.example.com)(PORT=1521)))(CONNECT_DATA=(SERVICE_NAME=orcl)))",
+			"oracle/db1.example.com,oracle/db2.example.com"},
+		{"db.example.com:1521/orcl", "oracle/db.example.com"},
+		{"//db.example.com/orcl", "oracle/db.example.com"},
+	}
+	for _, test := range tests {
+		spn, err := SPN("oracle", test.connStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(spn, ",") != test.output {
+			t.Errorf("SPN of %s: got %v, want %s", test.connStr, spn, test.output)
+		}
+	}
+	if _, err := SPN("oracle", ""); err == nil {
+		t.Error("expected error for empty connect string")
+	}
+}
@@ -0,0 +1,193 @@
+package kerberos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeytabEntry is a principal key stored in keytab file
+type KeytabEntry struct {
+	Principal PrincipalName
+	Realm     string
+	Timestamp time.Time
+	KVNO      uint32
+	Key       EncryptionKey
+}
+
+// Keytab hold keys of MIT keytab file (format version 0x502)
+type Keytab struct {
+	Entries []KeytabEntry
+}
+
+// DefaultKeytabPath return KRB5_CLIENT_KTNAME environment variable or /etc/krb5.keytab
+func DefaultKeytabPath() string {
+	if path := os.Getenv("KRB5_CLIENT_KTNAME"); len(path) > 0 {
+		return strings.TrimPrefix(path, "FILE:")
+	}
+	return "/etc/krb5.keytab"
+}
+
+// LoadKeytab read keytab file
+func LoadKeytab(path string) (*Keytab, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeytab(data)
+}
+
+// ParseKeytab decode keytab file content
+func ParseKeytab(data []byte) (*Keytab, error) {
+	if len(data) < 2 || data[0] != 5 {
+		return nil, errors.New("kerberos: invalid keytab file")
+	}
+	if data[1] != 2 {
+		return nil, fmt.Errorf("kerberos: unsupported keytab version: 0x05%02x", data[1])
+	}
+	output := &Keytab{}
+	reader := bytes.NewReader(data[2:])
+	for reader.Len() > 0 {
+		var size int32
+		if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			break
+		}
+		record := make([]byte, abs(size))
+		if _, err := io.ReadFull(reader, record); err != nil {
+			return nil, fmt.Errorf("kerberos: truncated keytab entry: %v", err)
+		}
+		// negative size is a deleted entry
+		if size < 0 {
+			continue
+		}
+		entry, err := parseKeytabEntry(record)
+		if err != nil {
+			return nil, err
+		}
+		output.Entries = append(output.Entries, entry)
+	}
+	return output, nil
+}
+
+func abs(val int32) int32 {
+	if val < 0 {
+		return -val
+	}
+	return val
+}
+
+func parseKeytabEntry(record []byte) (entry KeytabEntry, err error) {
+	reader := &binaryReader{data: record}
+	count := reader.uint16()
+	entry.Realm = string(reader.bytes16())
+	entry.Principal.NameString = make([]string, 0, count)
+	for i := 0; i < int(count); i++ {
+		entry.Principal.NameString = append(entry.Principal.NameString, string(reader.bytes16()))
+	}
+	entry.Principal.NameType = int32(reader.uint32())
+	entry.Timestamp = time.Unix(int64(reader.uint32()), 0)
+	entry.KVNO = uint32(reader.uint8())
+	entry.Key.KeyType = int32(reader.uint16())
+	entry.Key.KeyValue = reader.bytes16()
+	if reader.err == nil && len(reader.data)-reader.index >= 4 {
+		// 32-bit kvno overrides the 8-bit one when present
+		if kvno := reader.uint32(); kvno != 0 {
+			entry.KVNO = kvno
+		}
+	}
+	if reader.err != nil {
+		return entry, fmt.Errorf("kerberos: invalid keytab entry: %v", reader.err)
+	}
+	return entry, nil
+}
+
+// Key return the key of the principal with highest kvno for the encryption type.
+// kvno = 0 means any version
+func (kt *Keytab) Key(principal PrincipalName, realm string, etype int32, kvno uint32) (EncryptionKey, uint32, error) {
+	var found *KeytabEntry
+	for i := range kt.Entries {
+		entry := &kt.Entries[i]
+		if entry.Realm != realm || !entry.Principal.Equal(principal) || entry.Key.KeyType != etype {
+			continue
+		}
+		if kvno != 0 && entry.KVNO != kvno {
+			continue
+		}
+		if found == nil || entry.KVNO > found.KVNO {
+			found = entry
+		}
+	}
+	if found == nil {
+		return EncryptionKey{}, 0, fmt.Errorf("kerberos: no key for %s@%s with encryption type %d in keytab", principal, realm, etype)
+	}
+	return found.Key, found.KVNO, nil
+}
+
+// ETypes return encryption types available for the principal ordered by preference list
+func (kt *Keytab) ETypes(principal PrincipalName, realm string, preference []int32) []int32 {
+	var output []int32
+	for _, etype := range preference {
+		if _, _, err := kt.Key(principal, realm, etype, 0); err == nil {
+			output = append(output, etype)
+		}
+	}
+	return output
+}
+
+// binaryReader read big endian values and keep the first error
+type binaryReader struct {
+	data  []byte
+	index int
+	err   error
+}
+
+func (reader *binaryReader) read(size int) []byte {
+	if reader.err != nil {
+		return make([]byte, size)
+	}
+	if reader.index+size > len(reader.data) {
+		reader.err = io.ErrUnexpectedEOF
+		return make([]byte, size)
+	}
+	output := reader.data[reader.index : reader.index+size]
+	reader.index += size
+	return output
+}
+
+func (reader *binaryReader) eof() bool {
+	return reader.err != nil || reader.index >= len(reader.data)
+}
+
+func (reader *binaryReader) uint8() uint8 {
+	return reader.read(1)[0]
+}
+
+func (reader *binaryReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(reader.read(2))
+}
+
+func (reader *binaryReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(reader.read(4))
+}
+
+func (reader *binaryReader) bytes16() []byte {
+	size := reader.uint16()
+	return append([]byte{}, reader.read(int(size))...)
+}
+
+func (reader *binaryReader) bytes32() []byte {
+	size := reader.uint32()
+	if reader.err == nil && int(size) > len(reader.data)-reader.index {
+		reader.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	return append([]byte{}, reader.read(int(size))...)
+}
@@ -23,6 +23,26 @@ func NewOracleNetworkDESCryptor(key []byte, iv []byte) (*OracleNetworkDESCryptor
 	return output, nil
 }
 
+// NewOracleNetwork3DESCryptor create cryptor for 3DES112 (16 bytes key) and
+// 3DES168 (24 bytes key). it use CBC mode with the same padding of AES cryptor
+func NewOracleNetwork3DESCryptor(key []byte, iv []byte) (*OracleNetworkCBCCryptor, error) {
+	if len(key) == 16 {
+		// two keys variant: k3 = k1
+		key = append(key[:16:16], key[:8]...)
+	}
+	blk, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if iv == nil {
+		iv = []byte{1, 35, 69, 103, -119 + 256, -85 + 256, -51 + 256, -17 + 256}
+	}
+	if len(iv) < des.BlockSize {
+		return nil, errors.New("invalid 3DES initialization vector")
+	}
+	return &OracleNetworkCBCCryptor{blk: blk, iv: iv[:des.BlockSize]}, nil
+}
+
 func (sec *OracleNetworkDESCryptor) Reset() error {
 	return nil
 }
@@ -218,9 +218,10 @@ func NewOracleNetworkCBCEncrypter(key, iv []byte) (*OracleNetworkCBCCryptor, err
 
 func (sec *OracleNetworkCBCCryptor) Encrypt(input []byte) ([]byte, error) {
 	length := len(input)
+	blockSize := sec.blk.BlockSize()
 	num := 0
-	if length%16 > 0 {
-		num = 16 - (length % 16)
+	if length%blockSize > 0 {
+		num = blockSize - (length % blockSize)
 	}
 	if num > 0 {
 		input = append(input, make([]byte, num)...)
@@ -233,12 +234,13 @@ func (sec *OracleNetworkCBCCryptor) Encrypt(input []byte) ([]byte, error) {
 
 func (sec *OracleNetworkCBCCryptor) Decrypt(input []byte) ([]byte, error) {
 	length := len(input)
+	blockSize := sec.blk.BlockSize()
 	// length--
-	if (length-1)%16 != 0 {
+	if length == 0 || (length-1)%blockSize != 0 {
 		return nil, errors.New("invalid padding from cipher text")
 	}
 	num := int(input[length-1])
-	if num < 0 || num > 16 {
+	if num < 1 || num > blockSize || num > length {
 		return nil, errors.New("invalid padding from cipher text")
 	}
 	output := make([]byte, length-1)
@@ -16,6 +16,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sijms/go-ora/v3/configurations"
@@ -65,10 +66,44 @@ type Session struct {
 		roots              *x509.CertPool
 		tlsCertificates    []tls.Certificate
 	}
-	tracer trace.Tracer
+	tracer   trace.Tracer
+	recorder PacketRecorder
+	connID   uint32
 	basicSession
 }
 
+// PacketRecorder receive a copy of each packet written to or read from the
+// network. connID identify the network connection as the session open a new
+// one for each connect attempt and redirect
+type PacketRecorder interface {
+	RecordPacket(connID uint32, sent bool, packet []byte)
+}
+
+var lastConnID uint32
+
+// Capabilities return negotiated features needed to decode TTC messages
+func (session *Session) Capabilities() TTCCapabilities {
+	return TTCCapabilities{
+		TTCVersion:      session.TTCVersion,
+		HasEOS:          session.HasEOSCapability,
+		HasFSAP:         session.HasFSAPCapability,
+		UseBigClrChunks: session.UseBigClrChunks,
+	}
+}
+
+// SetPacketRecorder set the recorder of session packets. nil stop recording
+func (session *Session) SetPacketRecorder(recorder PacketRecorder) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.recorder = recorder
+}
+
+func (session *Session) recordPacket(sent bool, packet []byte) {
+	if session.recorder != nil {
+		session.recorder.RecordPacket(session.connID, sent, packet)
+	}
+}
+
 func NewSessionWithInputBufferForDebug(input []byte) *Session {
 	options := &configurations.ConnectionConfig{
 		AdvNegoServiceInfo: configurations.AdvNegoServiceInfo{AuthService: nil},
@@ -92,6 +127,15 @@ func NewSessionWithInputBufferForDebug(input []byte) *Session {
 	return ret
 }
 
+// NewSessionWithConn create session over already connected net.Conn. it is
+// used to run protocol exchanges against local responders in tests
+func NewSessionWithConn(conn net.Conn, config *configurations.ConnectionConfig, tracer trace.Tracer) *Session {
+	ret := NewSession(config, tracer)
+	ret.conn = conn
+	ret.reader = bufio.NewReaderSize(conn, read_buffer_size)
+	return ret
+}
+
 func NewSession(config *configurations.ConnectionConfig, tracer trace.Tracer) *Session {
 	ret := &Session{
 		conn:       nil,
@@ -316,7 +360,8 @@ func (session *Session) negotiate() {
 	host := connOption.GetActiveServer(false)
 
 	if tlsConfig := connOption.TLSConfig; tlsConfig != nil {
-		tlsConfig.ServerName = host.Addr
+		tlsConfig = tlsConfig.Clone()
+		connOption.ConfigureTLS(tlsConfig, host.Addr, connOption.ServiceName)
 		session.sslConn = tls.Client(session.conn, tlsConfig)
 		return
 	}
@@ -327,18 +372,14 @@ func (session *Session) negotiate() {
 			session.SSL.roots.AddCert(cert)
 		}
 	}
-	config := &tls.Config{
-		ServerName: host.Addr,
-	}
+	config := &tls.Config{}
 	if len(session.SSL.tlsCertificates) > 0 {
 		config.Certificates = session.SSL.tlsCertificates
 	}
 	if session.SSL.roots != nil {
 		config.RootCAs = session.SSL.roots
 	}
-	if !connOption.SSLVerify {
-		config.InsecureSkipVerify = true
-	}
+	connOption.ConfigureTLS(config, host.Addr, connOption.ServiceName)
 	session.sslConn = tls.Client(session.conn, config)
 }
 
@@ -482,60 +523,106 @@ func (session *Session) BreakConnection() error {
 // check if the client need to use SSL
 // then send connect packet to the server and
 // receive either accept, redirect or refuse packet
+//
+// if the server is not reachable or refuse the connection the next address
+// is tried (unless failover is disabled). when all addresses fail the whole
+// list is retried RetryCount times with backoff delay between rounds
 func (session *Session) Connect(ctx context.Context) error {
 	connOption := session.Context.connConfig
 	session.ResetBuffer()
 	session.Disconnect()
 	session.tracer.Print("Connect")
+	if connOption.LoadBalance && !session.Context.isRedirect {
+		connOption.ShuffleServers()
+	}
 	var err error
-	connected := false
-	var host *configurations.ServerAddr
-	loop := true
-	dialer := connOption.Dialer
-	if dialer == nil {
-		dialer = &net.Dialer{}
-		if session.Context.connConfig.ConnectTimeout > 0 {
-			dialer = &net.Dialer{
-				Timeout: session.Context.connConfig.ConnectTimeout,
+	for retry := 0; retry <= connOption.RetryCount; retry++ {
+		if retry > 0 {
+			delay := connOption.RetryBackoff(retry)
+			session.tracer.Printf("retry #%d after: %v", retry, delay)
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
 			}
-		} else {
-			dialer = &net.Dialer{}
 		}
-	}
-	// connOption.serverIndex = 0
-	for loop {
-		host = connOption.GetActiveServer(false)
-		if host == nil {
-			if err != nil {
+		connOption.ResetServerIndex()
+		for host := connOption.GetActiveServer(false); host != nil; host = connOption.GetActiveServer(true) {
+			start := time.Now()
+			var redirect bool
+			redirect, err = session.connectServer(ctx, host)
+			if connOption.OnConnectAttempt != nil {
+				connOption.OnConnectAttempt(configurations.ConnectAttempt{
+					Server:   *host,
+					Retry:    retry,
+					Duration: time.Since(start),
+					Err:      err,
+				})
+			}
+			if err == nil {
+				if redirect {
+					session.Context.isRedirect = true
+					return session.Connect(ctx)
+				}
+				return nil
+			}
+			session.Disconnect()
+			if ctx.Err() != nil {
+				return err
+			}
+			if connOption.DisableFailover {
+				// only the first address is tried once
 				return err
 			}
-			return errors.New("no available servers to connect to")
-		}
-		addr := host.NetworkAddr()
-		if len(session.Context.connConfig.UnixAddress) > 0 {
-			session.conn, err = dialer.DialContext(ctx, "unix", session.Context.connConfig.UnixAddress)
-		} else {
-			session.conn, err = dialer.DialContext(ctx, "tcp", addr)
 		}
+	}
+	if err == nil {
+		err = errors.New("no available servers to connect to")
+	}
+	return err
+}
 
-		if err != nil {
-			session.tracer.Printf("using: %s ..... [FAILED]", addr)
-			host = connOption.GetActiveServer(true)
-			if host == nil {
-				break
+// connectServer dial single server address and do connect packet exchange
+// return true if the server redirect the connection to another address
+func (session *Session) connectServer(ctx context.Context, host *configurations.ServerAddr) (bool, error) {
+	connOption := session.Context.connConfig
+	session.ResetBuffer()
+	dialer := connOption.Dialer
+	if dialer == nil {
+		if connOption.ConnectTimeout > 0 {
+			dialer = &net.Dialer{
+				Timeout: connOption.ConnectTimeout,
 			}
-			continue
+		} else {
+			dialer = &net.Dialer{}
 		}
-		session.tracer.Printf("using: %s ..... [SUCCEED]", addr)
-		connected = true
-		loop = false
 	}
-	if !connected {
-		return err
+	dialCtx := ctx
+	if connOption.TransportConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, connOption.TransportConnectTimeout)
+		defer cancel()
+	}
+	var err error
+	addr := host.NetworkAddr()
+	if len(connOption.UnixAddress) > 0 {
+		session.conn, err = dialer.DialContext(dialCtx, "unix", connOption.UnixAddress)
+	} else {
+		session.conn, err = dialer.DialContext(dialCtx, "tcp", addr)
+	}
+	if err != nil {
+		session.tracer.Printf("using: %s ..... [FAILED]", addr)
+		return false, err
 	}
+	session.connID = atomic.AddUint32(&lastConnID, 1)
+	session.tracer.Printf("using: %s ..... [SUCCEED]", addr)
 	err = connOption.UpdateSSL(host)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if connOption.SSL {
 		session.tracer.Print("Using SSL/TLS")
@@ -548,18 +635,18 @@ func (session *Session) Connect(ctx context.Context) error {
 	connectPacket := newConnectPacket(session.Context)
 	err = session.writePacket(connectPacket)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if uint16(connectPacket.length) == connectPacket.dataOffset {
 		session.PutBytes(connectPacket.buffer...)
 		err = session.Write()
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 	pck, err := session.readPacket()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if acceptPacket, ok := pck.(*AcceptPacket); ok {
@@ -568,35 +655,24 @@ func (session *Session) Connect(ctx context.Context) error {
 		session.Context.handshakeComplete = true
 		session.mu.Unlock()
 		session.tracer.Print("Handshake Complete")
-		return nil
+		return false, nil
 	}
 	if redirectPacket, ok := pck.(*RedirectPacket); ok {
 		session.tracer.Print("Redirect")
-		err = session.Context.connConfig.UpdateDatabaseInfoForRedirect(redirectPacket.redirectAddr, redirectPacket.reconnectData)
+		session.Disconnect()
+		err = connOption.UpdateDatabaseInfoForRedirect(redirectPacket.redirectAddr, redirectPacket.reconnectData)
 		if err != nil {
-			return err
+			return false, err
 		}
-		session.Context.connConfig.ResetServerIndex()
-		session.Context.isRedirect = true
-		return session.Connect(ctx)
+		connOption.ResetServerIndex()
+		return true, nil
 	}
-	if refusePacket, ok := pck.(*RefusePacket); ok {
+	if refusePacket, ok := pck.(*RefusePacket); ok && refusePacket != nil {
 		refusePacket.extractErrCode()
-		var addr string
-		var port int
-		if host != nil {
-			addr = host.Addr
-			port = host.Port
-		}
-		session.tracer.Printf("connection to %s:%d refused with error: %s", addr, port, refusePacket.Err.Error())
-		host = connOption.GetActiveServer(true)
-		if host == nil {
-			session.Disconnect()
-			return refusePacket.Err
-		}
-		return session.Connect(ctx)
+		session.tracer.Printf("connection to %s:%d refused with error: %s", host.Addr, host.Port, refusePacket.Err.Error())
+		return false, refusePacket.Err
 	}
-	return errors.New("connection refused by the server due to unknown reason")
+	return false, errors.New("connection refused by the server due to unknown reason")
 }
 
 func (session *Session) WriteFinalPacket() error {
@@ -826,6 +902,7 @@ func (session *Session) writePacket(pck PacketInterface) error {
 	session.sendPcks = append(session.sendPcks, pck)
 	tmp := pck.bytes()
 	session.tracer.LogPacket("Write packet:", tmp)
+	session.recordPacket(true, tmp)
 	err := session.initWrite()
 	if err != nil {
 		return err
@@ -903,6 +980,7 @@ func (session *Session) readPacketData() error {
 			return err
 		}
 		session.remainingBytes = 0
+		session.recordPacket(false, session.lastPacket.Bytes())
 		return nil
 	}
 	session.lastPacket.Reset()
@@ -930,6 +1008,7 @@ func (session *Session) readPacketData() error {
 		return err
 	}
 	session.tracer.LogPacket("Read packet:", session.lastPacket.Bytes())
+	session.recordPacket(false, session.lastPacket.Bytes())
 	return nil
 }
 
@@ -959,6 +1038,7 @@ func (session *Session) readPacket() (PacketInterface, error) {
 				if err != nil {
 					return err
 				}
+				session.recordPacket(true, pck.bytes())
 				if session.sslConn != nil {
 					_, err = session.sslConn.Write(pck.bytes())
 				} else if session.conn != nil {
@@ -34,17 +34,31 @@ type SummaryObject struct {
 	bindErrors           []BindError
 }
 
+// TTCCapabilities are negotiated session features that change the layout of
+// TTC messages
+type TTCCapabilities struct {
+	TTCVersion      uint8
+	HasEOS          bool
+	HasFSAP         bool
+	UseBigClrChunks bool
+}
+
 func NewSummary(session *Session) (*SummaryObject, error) {
+	return ReadSummary(session, session.Capabilities())
+}
+
+// ReadSummary read body of summary message (code 4) from any session reader
+func ReadSummary(session SessionReader, caps TTCCapabilities) (*SummaryObject, error) {
 	result := new(SummaryObject)
 	var err error
-	if session.HasEOSCapability {
+	if caps.HasEOS {
 		result.EndOfCallStatus, err = session.GetInt(4, true, true)
 		if err != nil {
 			return nil, err
 		}
 	}
-	if session.TTCVersion >= 3 {
-		if session.HasFSAPCapability {
+	if caps.TTCVersion >= 3 {
+		if caps.HasFSAP {
 			result.EndToEndECIDSequence, err = session.GetInt(2, true, true)
 			if err != nil {
 				return nil, err
@@ -84,7 +98,7 @@ func NewSummary(session *Session) (*SummaryObject, error) {
 	if err != nil {
 		return nil, err
 	}
-	if session.TTCVersion >= 4 {
+	if caps.TTCVersion >= 4 {
 		result.Flags, err = session.GetInt(2, true, true)
 		if err != nil {
 			return nil, err
@@ -155,7 +169,7 @@ func NewSummary(session *Session) (*SummaryObject, error) {
 		return nil, err
 	}
 	_, _ = session.GetDlc()
-	if session.TTCVersion < 7 {
+	if caps.TTCVersion < 7 {
 		_, _ = session.GetDlc()
 		_, _ = session.GetDlc()
 		_, _ = session.GetDlc()
@@ -173,7 +187,7 @@ func NewSummary(session *Session) (*SummaryObject, error) {
 			flag := num == 0xFE
 			for x := 0; x < length; x++ {
 				if flag {
-					if session.UseBigClrChunks {
+					if caps.UseBigClrChunks {
 						_, _ = session.GetInt(4, true, true)
 					} else {
 						_, _ = session.GetByte()
@@ -203,7 +217,7 @@ func NewSummary(session *Session) (*SummaryObject, error) {
 			flag := num == 0xFE
 			for x := 0; x < length; x++ {
 				if flag {
-					if session.UseBigClrChunks {
+					if caps.UseBigClrChunks {
 						_, _ = session.GetInt(4, true, true)
 					} else {
 						_, _ = session.GetByte()
@@ -240,7 +254,7 @@ func NewSummary(session *Session) (*SummaryObject, error) {
 				_, _ = session.GetByte()
 			}
 		}
-		if session.TTCVersion >= 7 {
+		if caps.TTCVersion >= 7 {
 			result.RetCode, err = session.GetInt(4, true, true)
 			if err != nil {
 				return nil, err
@@ -0,0 +1,148 @@
+package oratest
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+// O5LOGON with 12c password verifier (18453)
+const (
+	verifierType = 18453
+	vgenCount    = 4096
+	sderCount    = 3
+)
+
+// authState is the server side of O5LOGON between the two authentication calls
+type authState struct {
+	user       string
+	password   string
+	serverKey  []byte
+	encKey     []byte
+	cskSalt    []byte
+	knownUser  bool
+	serverSalt []byte
+}
+
+// newAuthState generate salts and session key of the user. unknown users get a
+// challenge too so the failure occur after sending password like real server
+func newAuthState(user, password string, known bool) (*authState, error) {
+	state := &authState{user: user, password: password, knownUser: known}
+	state.serverSalt = make([]byte, 16)
+	state.cskSalt = make([]byte, 16)
+	state.serverKey = make([]byte, 32)
+	for _, buffer := range [][]byte{state.serverSalt, state.cskSalt, state.serverKey} {
+		if _, err := rand.Read(buffer); err != nil {
+			return nil, err
+		}
+	}
+	message := append(append([]byte{}, state.serverSalt...), []byte("AUTH_PBKDF2_SPEEDY_KEY")...)
+	speedyKey := generateSpeedyKey(message, []byte(password), vgenCount)
+	hash := sha512.Sum512(append(speedyKey, state.serverSalt...))
+	state.encKey = hash[:32]
+	return state, nil
+}
+
+// writeChallenge write the answer of first authentication call
+func (state *authState) writeChallenge(ms *network.MemorySession) error {
+	eServerKey, err := cbcEncrypt(state.encKey, state.serverKey)
+	if err != nil {
+		return err
+	}
+	ms.PutBytes(8)
+	ms.PutUint(5, 4, true, true)
+	ms.PutKeyValString("AUTH_SESSKEY", strings.ToUpper(hex.EncodeToString(eServerKey)), 0)
+	// PutKeyVal can't write verifier type that doesn't fit in byte
+	ms.PutDlc([]byte("AUTH_VFR_DATA"))
+	ms.PutDlc([]byte(strings.ToUpper(hex.EncodeToString(state.serverSalt))))
+	ms.PutUint(verifierType, 4, true, true)
+	ms.PutKeyValString("AUTH_PBKDF2_CSK_SALT", strings.ToUpper(hex.EncodeToString(state.cskSalt)), 0)
+	ms.PutKeyValString("AUTH_PBKDF2_VGEN_COUNT", fmt.Sprint(vgenCount), 0)
+	ms.PutKeyValString("AUTH_PBKDF2_SDER_COUNT", fmt.Sprint(sderCount), 0)
+	return nil
+}
+
+// verify check the password sent in the second authentication call
+func (state *authState) verify(eClientKey, ePassword string) error {
+	if !state.knownUser {
+		return errors.New("unknown user")
+	}
+	temp, err := hex.DecodeString(eClientKey)
+	if err != nil {
+		return err
+	}
+	clientKey, err := cbcDecrypt(state.encKey, temp)
+	if err != nil {
+		return err
+	}
+	keyBuffer := fmt.Sprintf("%X", append(clientKey, state.serverKey...))
+	passwordKey := generateSpeedyKey(state.cskSalt, []byte(keyBuffer), sderCount)[:32]
+	temp, err = hex.DecodeString(ePassword)
+	if err != nil {
+		return err
+	}
+	password, err := cbcDecrypt(passwordKey, temp)
+	if err != nil {
+		return err
+	}
+	// remove padding and random prefix
+	if len(password) == 0 || int(password[len(password)-1]) > len(password) {
+		return errors.New("invalid password padding")
+	}
+	password = password[:len(password)-int(password[len(password)-1])]
+	if len(password) < 16 || !bytes.Equal(password[16:], []byte(state.password)) {
+		return errors.New("wrong password")
+	}
+	return nil
+}
+
+func generateSpeedyKey(buffer, key []byte, turns int) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(append(append([]byte{}, buffer...), 0, 0, 0, 1))
+	firstHash := mac.Sum(nil)
+	tempHash := append([]byte{}, firstHash...)
+	for index := 2; index <= turns; index++ {
+		mac.Reset()
+		mac.Write(tempHash)
+		tempHash = mac.Sum(nil)
+		for x := range firstHash {
+			firstHash[x] ^= tempHash[x]
+		}
+	}
+	return firstHash
+}
+
+func cbcEncrypt(key, data []byte) ([]byte, error) {
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%blk.BlockSize() != 0 {
+		return nil, errors.New("data is not multiple of block size")
+	}
+	output := make([]byte, len(data))
+	cipher.NewCBCEncrypter(blk, make([]byte, 16)).CryptBlocks(output, data)
+	return output, nil
+}
+
+func cbcDecrypt(key, data []byte) ([]byte, error) {
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%blk.BlockSize() != 0 {
+		return nil, errors.New("data is not multiple of block size")
+	}
+	output := make([]byte, len(data))
+	cipher.NewCBCDecrypter(blk, make([]byte, 16)).CryptBlocks(output, data)
+	return output, nil
+}
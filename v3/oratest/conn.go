@@ -0,0 +1,737 @@
+package oratest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+const (
+	// tnsVersion is the version in accept packet. it enable 4 bytes packet length
+	tnsVersion = 315
+	// ttcVersion is the maximum TTC version supported by the server
+	ttcVersion = 11
+	// defaultFetchSize is number of rows sent when the client doesn't ask
+	defaultFetchSize = 25
+	dbVersionNumber  = 0x13000000 // 19.0.0.0.0
+	dbVersionBanner  = "Oracle Database 19c (oratest fake server)"
+)
+
+// marker types
+const (
+	markerBreak     = 1
+	markerReset     = 2
+	markerInterrupt = 3
+)
+
+var serviceNameRegexp = regexp.MustCompile(`(?i)\(\s*SERVICE_NAME\s*=\s*([^)\s]+)\s*\)`)
+
+var returningRegexp = regexp.MustCompile(`(?is)^\s*(INSERT|UPDATE|DELETE|MERGE)\b.*\bRETURN(ING)?\s+.*\s+INTO\b`)
+
+// isReturning return true for dml with returning clause
+func isReturning(sql string) bool {
+	return returningRegexp.MatchString(sql)
+}
+
+type packet struct {
+	pckType uint8
+	data    []byte
+}
+
+// cursor is an opened statement on the server
+type cursor struct {
+	id     int
+	sql    string
+	binds  []bindDef
+	result *Result
+	rows   [][][]byte
+	offset int
+	query  bool
+}
+
+// serverConn serve one client connection
+type serverConn struct {
+	server       *Server
+	conn         net.Conn
+	handshake    bool
+	ttc          uint8
+	auth         *authState
+	cursors      map[int]*cursor
+	lastCursorID int
+	packets      chan packet
+}
+
+func newServerConn(server *Server, conn net.Conn) *serverConn {
+	return &serverConn{
+		server:  server,
+		conn:    conn,
+		ttc:     ttcVersion,
+		cursors: make(map[int]*cursor),
+	}
+}
+
+func (sc *serverConn) readPacket() (packet, error) {
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(sc.conn, head); err != nil {
+		return packet{}, err
+	}
+	var length int
+	if sc.handshake {
+		length = int(binary.BigEndian.Uint32(head))
+	} else {
+		length = int(binary.BigEndian.Uint16(head))
+	}
+	if length < 8 {
+		return packet{}, fmt.Errorf("oratest: invalid packet length: %d", length)
+	}
+	data := make([]byte, length)
+	copy(data, head)
+	if _, err := io.ReadFull(sc.conn, data[8:]); err != nil {
+		return packet{}, err
+	}
+	return packet{pckType: data[4], data: data}, nil
+}
+
+// writePacket write packet header followed by body
+func (sc *serverConn) writePacket(pckType uint8, body []byte) error {
+	output := make([]byte, 8, 8+len(body))
+	if sc.handshake {
+		binary.BigEndian.PutUint32(output, uint32(8+len(body)))
+	} else {
+		binary.BigEndian.PutUint16(output, uint16(8+len(body)))
+	}
+	output[4] = pckType
+	output = append(output, body...)
+	_, err := sc.conn.Write(output)
+	return err
+}
+
+func (sc *serverConn) writeData(payload []byte) error {
+	return sc.writePacket(uint8(network.DATA), append([]byte{0, 0}, payload...))
+}
+
+func (sc *serverConn) writeMarker(markerType uint8) error {
+	return sc.writePacket(uint8(network.MARKER), []byte{1, 0, markerType})
+}
+
+func (sc *serverConn) serve() {
+	if !sc.handshakeConnect() {
+		return
+	}
+	sc.packets = make(chan packet)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(sc.packets)
+		for {
+			pck, err := sc.readPacket()
+			if err != nil {
+				return
+			}
+			select {
+			case sc.packets <- pck:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for pck := range sc.packets {
+		if pck.pckType != uint8(network.DATA) {
+			// marker without running call
+			continue
+		}
+		if len(pck.data) < 10 || binary.BigEndian.Uint16(pck.data[8:])&0x40 != 0 {
+			return
+		}
+		if err := sc.handleData(pck.data[10:]); err != nil {
+			return
+		}
+	}
+}
+
+// handshakeConnect read connect packet then redirect, refuse or accept it
+func (sc *serverConn) handshakeConnect() bool {
+	pck, err := sc.readPacket()
+	if err != nil || pck.pckType != uint8(network.CONNECT) || len(pck.data) < 28 {
+		return false
+	}
+	dataLen := int(binary.BigEndian.Uint16(pck.data[24:]))
+	offset := int(binary.BigEndian.Uint16(pck.data[26:]))
+	var connectData string
+	if dataLen > 0 {
+		if len(pck.data) >= offset+dataLen {
+			connectData = string(pck.data[offset : offset+dataLen])
+		} else {
+			// long connect data is sent in separate data packet
+			pck, err = sc.readPacket()
+			if err != nil || pck.pckType != uint8(network.DATA) || len(pck.data) < 10 {
+				return false
+			}
+			connectData = string(pck.data[10:])
+		}
+	}
+	refuseCode, redirect := sc.server.connectAction()
+	if len(redirect) > 0 {
+		host, port, err := net.SplitHostPort(redirect)
+		if err != nil {
+			return false
+		}
+		data := fmt.Sprintf("(ADDRESS=(PROTOCOL=tcp)(HOST=%s)(PORT=%s))", host, port)
+		body := make([]byte, 2, 2+len(data))
+		binary.BigEndian.PutUint16(body, uint16(len(data)))
+		_ = sc.writePacket(uint8(network.REDIRECT), append(body, data...))
+		return false
+	}
+	if refuseCode == 0 && len(sc.server.ServiceName) > 0 {
+		match := serviceNameRegexp.FindStringSubmatch(connectData)
+		if len(match) > 1 && !strings.EqualFold(match[1], sc.server.ServiceName) {
+			refuseCode = 12514
+		}
+	}
+	if refuseCode != 0 {
+		message := fmt.Sprintf("(DESCRIPTION=(TMP=)(VSNNUM=0)(ERR=%d)(ERROR_STACK=(ERROR=(CODE=%d)(EMFI=4))))",
+			refuseCode, refuseCode)
+		body := make([]byte, 4, 4+len(message))
+		body[0], body[1] = 1, 0 // user and system reason
+		binary.BigEndian.PutUint16(body[2:], uint16(len(message)))
+		_ = sc.writePacket(uint8(network.REFUSE), append(body, message...))
+		return false
+	}
+	body := make([]byte, 37)
+	binary.BigEndian.PutUint16(body[0:], tnsVersion)
+	binary.BigEndian.PutUint16(body[2:], 0) // negotiated options
+	binary.BigEndian.PutUint16(body[4:], 0xFFFF)
+	binary.BigEndian.PutUint16(body[6:], 0xFFFF)
+	binary.BigEndian.PutUint16(body[8:], 1)   // his one
+	binary.BigEndian.PutUint16(body[10:], 0)  // data length
+	binary.BigEndian.PutUint16(body[12:], 45) // data offset
+	// no advanced negotiation
+	body[14], body[15] = 4, 4
+	binary.BigEndian.PutUint32(body[24:], 0x200000) // SDU
+	binary.BigEndian.PutUint32(body[28:], 0x200000) // TDU
+	if err = sc.writePacket(uint8(network.ACCEPT), body); err != nil {
+		return false
+	}
+	sc.handshake = true
+	return true
+}
+
+// newSession return memory session that decode the request and encode the reply
+func (sc *serverConn) newSession(payload []byte) *network.MemorySession {
+	ms := network.NewMemorySession(payload, nil, network.SessionProperties{ClrChunkSize: 0x40})
+	ms.TTCVersion = sc.ttc
+	return ms
+}
+
+// handleData process all calls in data packet and write the reply
+func (sc *serverConn) handleData(payload []byte) error {
+	if len(payload) == 0 {
+		return nil
+	}
+	ms := sc.newSession(payload)
+	for {
+		code, err := ms.GetByte()
+		if err != nil {
+			break
+		}
+		switch code {
+		case 1:
+			sc.writeProtocolNego(ms)
+			return sc.writeData(ms.GetWriteBuffer())
+		case 2:
+			// client compile time capabilities start at index 7 and [7] is its TTC version
+			if len(payload) > 14 && payload[14] < sc.ttc {
+				sc.ttc = payload[14]
+			}
+			return sc.writeData([]byte{2, 0})
+		case 0x11:
+			if !sc.handlePiggyback(ms) {
+				writeSummary(ms, sc.ttc, summary{})
+				return sc.writeData(ms.GetWriteBuffer())
+			}
+		case 3:
+			return sc.handleCall(ms)
+		default:
+			writeSummary(ms, sc.ttc, summary{})
+			return sc.writeData(ms.GetWriteBuffer())
+		}
+	}
+	return nil
+}
+
+func (sc *serverConn) writeProtocolNego(ms *network.MemorySession) {
+	ms.PutBytes(1, 6, 0)
+	ms.PutBytes([]byte("x86_64/Linux 2.4.xx\x00")...)
+	ms.PutUint(serverCharset, 2, false, false)
+	ms.PutBytes(1)                 // server flags
+	ms.PutUint(0, 2, false, false) // charset elements
+	// national charset is at [num3+3 : num3+5], num3 = 6 + [5] + [6]
+	numArray := make([]byte, 11)
+	binary.BigEndian.PutUint16(numArray[9:], serverNCharset)
+	ms.PutUint(len(numArray), 2, true, false)
+	ms.PutBytes(numArray...)
+	compileCaps := make([]byte, 40)
+	compileCaps[0], compileCaps[1] = 6, 1
+	compileCaps[4] = 234 // logon compatibility: O5LOGON with PBKDF2 key derivation
+	compileCaps[7] = ttcVersion
+	ms.PutBytes(uint8(len(compileCaps)))
+	ms.PutBytes(compileCaps...)
+	runtimeCaps := []byte{2, 0, 0, 0, 0, 0, 0}
+	ms.PutBytes(uint8(len(runtimeCaps)))
+	ms.PutBytes(runtimeCaps...)
+}
+
+// handlePiggyback process piggyback function that precede main call. return false
+// for unsupported function because the rest of packet can't be parsed
+func (sc *serverConn) handlePiggyback(ms *network.MemorySession) bool {
+	funcCode, err := ms.GetByte()
+	if err != nil {
+		return false
+	}
+	if _, err = sc.getSequence(ms); err != nil {
+		return false
+	}
+	switch funcCode {
+	case 0x69: // close cursors
+		if _, err = ms.GetByte(); err != nil {
+			return false
+		}
+		count, err := ms.GetInt(4, true, true)
+		if err != nil {
+			return false
+		}
+		for i := 0; i < count; i++ {
+			id, err := ms.GetInt(4, true, true)
+			if err != nil {
+				return false
+			}
+			delete(sc.cursors, id)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (sc *serverConn) getSequence(ms *network.MemorySession) (uint8, error) {
+	seq, err := ms.GetByte()
+	if err != nil {
+		return 0, err
+	}
+	if sc.ttc >= 18 {
+		_, err = ms.GetByte()
+	}
+	return seq, err
+}
+
+// handleCall process TTC function (message code 3)
+func (sc *serverConn) handleCall(ms *network.MemorySession) error {
+	funcCode, err := ms.GetByte()
+	if err != nil {
+		return err
+	}
+	if _, err = sc.getSequence(ms); err != nil {
+		return err
+	}
+	switch funcCode {
+	case 0x76:
+		err = sc.authPhaseOne(ms)
+	case 0x73:
+		err = sc.authPhaseTwo(ms)
+	case 0x3B:
+		ms.PutBytes(8)
+		ms.PutUint(len(dbVersionBanner), 2, true, true)
+		ms.PutClr([]byte(dbVersionBanner))
+		ms.PutUint(dbVersionNumber, 4, true, true)
+	case 0x5E:
+		return sc.execute(ms)
+	case 0x4E, 4:
+		return sc.reExecute(ms)
+	case 5:
+		return sc.fetch(ms)
+	case 0xE:
+		sc.server.logStatement("COMMIT")
+		writeSummary(ms, sc.ttc, summary{})
+	case 0xF:
+		sc.server.logStatement("ROLLBACK")
+		writeSummary(ms, sc.ttc, summary{})
+	default:
+		// logoff, commit, rollback, ping and others just succeed
+		writeSummary(ms, sc.ttc, summary{})
+	}
+	if err != nil {
+		return err
+	}
+	return sc.writeData(ms.GetWriteBuffer())
+}
+
+func (sc *serverConn) authPhaseOne(ms *network.MemorySession) error {
+	if _, err := ms.GetByte(); err != nil {
+		return err
+	}
+	userLen, err := ms.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	if _, err = ms.GetInt(4, true, true); err != nil { // logon mode
+		return err
+	}
+	if _, err = ms.GetByte(); err != nil {
+		return err
+	}
+	if _, err = ms.GetInt(4, true, true); err != nil { // key-value count
+		return err
+	}
+	if _, err = ms.GetBytes(2); err != nil {
+		return err
+	}
+	user, err := ms.GetString(userLen)
+	if err != nil {
+		return err
+	}
+	password, known := sc.server.password(user)
+	sc.auth, err = newAuthState(user, password, known)
+	if err != nil {
+		return err
+	}
+	if err = sc.auth.writeChallenge(ms); err != nil {
+		return err
+	}
+	writeSummary(ms, sc.ttc, summary{})
+	return nil
+}
+
+func (sc *serverConn) authPhaseTwo(ms *network.MemorySession) error {
+	var err error
+	// user flag, user length, logon mode, pointer, key-value count
+	if _, err = ms.GetByte(); err != nil {
+		return err
+	}
+	userLen, err := ms.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	if _, err = ms.GetInt(4, true, true); err != nil {
+		return err
+	}
+	if _, err = ms.GetByte(); err != nil {
+		return err
+	}
+	count, err := ms.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	if _, err = ms.GetBytes(2); err != nil {
+		return err
+	}
+	if userLen > 0 {
+		if _, err = ms.GetClr(); err != nil {
+			return err
+		}
+	}
+	values := make(map[string]string, count)
+	for i := 0; i < count; i++ {
+		key, val, _, err := ms.GetKeyVal()
+		if err != nil {
+			return err
+		}
+		values[string(key)] = string(val)
+	}
+	if sc.auth == nil || sc.auth.verify(values["AUTH_SESSKEY"], values["AUTH_PASSWORD"]) != nil {
+		writeSummary(ms, sc.ttc, summary{retCode: 1017,
+			message: "ORA-01017: invalid username/password; logon denied"})
+		return nil
+	}
+	sessionID := sc.server.newSession(values)
+	properties := [][2]string{
+		{"AUTH_SESSION_ID", strconv.Itoa(sessionID)},
+		{"AUTH_SERIAL_NUM", "1"},
+		{"AUTH_SC_INSTANCE_NAME", "oratest"},
+		{"AUTH_SC_SERVICE_NAME", sc.server.serviceName()},
+		{"AUTH_SC_DB_DOMAIN", ""},
+		{"AUTH_SC_DBUNIQUE_NAME", "ORATEST"},
+	}
+	ms.PutBytes(8)
+	ms.PutUint(len(properties), 2, true, true)
+	for _, prop := range properties {
+		ms.PutKeyValString(prop[0], prop[1], 0)
+	}
+	writeSummary(ms, sc.ttc, summary{})
+	return nil
+}
+
+// execute process parse/execute call (function 0x5E)
+func (sc *serverConn) execute(ms *network.MemorySession) error {
+	exeOp, err := ms.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	cursorID, err := ms.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	if _, err = ms.GetByte(); err != nil {
+		return err
+	}
+	sqlLen, err := ms.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	if _, err = ms.GetByte(); err != nil {
+		return err
+	}
+	if _, err = ms.GetInt(2, true, true); err != nil { // al8i4 count
+		return err
+	}
+	if _, err = ms.GetBytes(3); err != nil {
+		return err
+	}
+	rows, err := ms.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	if _, err = ms.GetInt(4, true, true); err != nil { // long fetch size
+		return err
+	}
+	if _, err = ms.GetByte(); err != nil {
+		return err
+	}
+	bindCount, err := ms.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	if _, err = ms.GetBytes(6); err != nil {
+		return err
+	}
+	defineCount, err := ms.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	if defineCount > 0 {
+		return sc.writeError(ms, cursorID, &Error{Code: 3115, Message: "ORA-03115: unsupported network datatype or representation"})
+	}
+	extra := 0
+	if sc.ttc >= 4 {
+		extra += 3
+	}
+	if sc.ttc >= 5 {
+		extra += 5
+	}
+	if extra > 0 {
+		if _, err = ms.GetBytes(extra); err != nil {
+			return err
+		}
+	}
+	if sc.ttc >= 7 {
+		if _, err = ms.GetByte(); err != nil {
+			return err
+		}
+		if _, err = ms.GetInt(4, true, true); err != nil { // array bind count
+			return err
+		}
+		if _, err = ms.GetByte(); err != nil {
+			return err
+		}
+	}
+	extra = 0
+	if sc.ttc >= 8 {
+		extra += 5
+	}
+	if sc.ttc >= 9 {
+		extra += 2
+	}
+	if extra > 0 {
+		if _, err = ms.GetBytes(extra); err != nil {
+			return err
+		}
+	}
+	var cur *cursor
+	if sqlLen > 0 {
+		text, err := ms.GetClr()
+		if err != nil {
+			return err
+		}
+		cur = sc.cursors[cursorID]
+		if cur == nil {
+			sc.lastCursorID++
+			cur = &cursor{id: sc.lastCursorID}
+			sc.cursors[cur.id] = cur
+		}
+		cur.sql = string(text)
+	} else if cur = sc.cursors[cursorID]; cur == nil {
+		return sc.writeError(ms, cursorID, &Error{Code: 1001, Message: "ORA-01001: invalid cursor"})
+	}
+	al8i4 := make([]int, 13)
+	for i := range al8i4 {
+		if al8i4[i], err = ms.GetInt(4, true, true); err != nil {
+			return err
+		}
+	}
+	if bindCount > 0 {
+		cur.binds = make([]bindDef, bindCount)
+		for i := range cur.binds {
+			if cur.binds[i], err = readBindDef(ms, sc.ttc); err != nil {
+				return err
+			}
+		}
+	}
+	if exeOp&0x20 == 0 {
+		writeSummary(ms, sc.ttc, summary{cursorID: cur.id})
+		return sc.writeData(ms.GetWriteBuffer())
+	}
+	if rows == 0 {
+		rows = al8i4[1]
+	}
+	return sc.run(ms, cur, readBindValues(ms, cur.binds), rows, sqlLen > 0)
+}
+
+// reExecute process execution of parsed cursor (function 0x4E and 4)
+func (sc *serverConn) reExecute(ms *network.MemorySession) error {
+	cursorID, err := ms.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	rows, err := ms.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	// execute options and flags
+	if _, err = ms.GetInt(2, true, true); err != nil {
+		return err
+	}
+	if _, err = ms.GetInt(2, true, true); err != nil {
+		return err
+	}
+	cur := sc.cursors[cursorID]
+	if cur == nil {
+		return sc.writeError(ms, cursorID, &Error{Code: 1001, Message: "ORA-01001: invalid cursor"})
+	}
+	return sc.run(ms, cur, readBindValues(ms, cur.binds), rows, false)
+}
+
+// run execute statement of the cursor and write the reply
+func (sc *serverConn) run(ms *network.MemorySession, cur *cursor, args []interface{}, rows int, describe bool) error {
+	result := sc.server.execute(&Request{SQL: cur.sql, Args: args})
+	if result.Disconnect {
+		return errors.New("disconnect")
+	}
+	if result.Delay > 0 && !sc.wait(result.Delay) {
+		return sc.writeBreak(cur.id)
+	}
+	if result.Err != nil {
+		return sc.writeError(ms, cur.id, result.Err)
+	}
+	cur.result, cur.query, cur.offset, cur.rows = result, result.isQuery(), 0, nil
+	if len(result.LTXID) > 0 {
+		writeLTXID(ms, result.LTXID)
+	}
+	if !cur.query {
+		if len(result.Out) > 0 {
+			if err := writeOutBinds(ms, cur.binds, result.Out, isReturning(cur.sql)); err != nil {
+				return sc.writeError(ms, cur.id, &Error{Code: 932, Message: "ORA-00932: inconsistent datatypes: " + err.Error()})
+			}
+		}
+		writeSummary(ms, sc.ttc, summary{cursorID: cur.id, curRow: result.RowsAffected})
+		return sc.writeData(ms.GetWriteBuffer())
+	}
+	cur.rows = make([][][]byte, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		values, err := encodeRow(result.Columns, row)
+		if err != nil {
+			return sc.writeError(ms, cur.id, &Error{Code: 932, Message: "ORA-00932: inconsistent datatypes: " + err.Error()})
+		}
+		cur.rows = append(cur.rows, values)
+	}
+	if describe {
+		writeDescribe(ms, sc.ttc, result.Columns)
+	}
+	return sc.writeRows(ms, cur, rows)
+}
+
+// fetch process fetch call (function 5)
+func (sc *serverConn) fetch(ms *network.MemorySession) error {
+	cursorID, err := ms.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	rows, err := ms.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	cur := sc.cursors[cursorID]
+	if cur == nil || !cur.query {
+		return sc.writeError(ms, cursorID, &Error{Code: 1001, Message: "ORA-01001: invalid cursor"})
+	}
+	return sc.writeRows(ms, cur, rows)
+}
+
+// writeRows send next rows of the cursor. summary with ORA-01403 tell the client
+// that no rows remain
+func (sc *serverConn) writeRows(ms *network.MemorySession, cur *cursor, count int) error {
+	if count <= 0 {
+		count = defaultFetchSize
+	}
+	end := min(cur.offset+count, len(cur.rows))
+	if end > cur.offset {
+		writeRows(ms, len(cur.result.Columns), cur.rows[cur.offset:end])
+	}
+	cur.offset = end
+	sum := summary{cursorID: cur.id, curRow: cur.offset}
+	if cur.offset >= len(cur.rows) {
+		sum.retCode, sum.message = 1403, "ORA-01403: no data found"
+	}
+	writeSummary(ms, sc.ttc, sum)
+	return sc.writeData(ms.GetWriteBuffer())
+}
+
+func (sc *serverConn) writeError(ms *network.MemorySession, cursorID int, oraErr *Error) error {
+	writeSummary(ms, sc.ttc, summary{cursorID: cursorID, retCode: oraErr.Code, message: oraErr.Message})
+	return sc.writeData(ms.GetWriteBuffer())
+}
+
+// wait sleep for the delay. it return false when the client send interrupt
+// marker before the delay end
+func (sc *serverConn) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return true
+		case pck, ok := <-sc.packets:
+			if !ok {
+				return true
+			}
+			if pck.pckType == uint8(network.MARKER) {
+				return false
+			}
+		}
+	}
+}
+
+// writeBreak answer client interrupt: send break marker, wait for client reset,
+// send reset marker then ORA-01013 error
+func (sc *serverConn) writeBreak(cursorID int) error {
+	if err := sc.writeMarker(markerBreak); err != nil {
+		return err
+	}
+	for pck := range sc.packets {
+		if pck.pckType == uint8(network.MARKER) && len(pck.data) > 10 && pck.data[10] == markerReset {
+			if err := sc.writeMarker(markerReset); err != nil {
+				return err
+			}
+			ms := sc.newSession(nil)
+			return sc.writeError(ms, cursorID, &Error{Code: 1013,
+				Message: "ORA-01013: user requested cancel of current operation"})
+		}
+	}
+	return io.EOF
+}
@@ -0,0 +1,454 @@
+package oratest
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sijms/go-ora/v3/converters"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/types"
+)
+
+const (
+	serverCharset  = 873  // AL32UTF8
+	serverNCharset = 2000 // AL16UTF16
+)
+
+// summary is the body of TTC message 4 that end each server call
+type summary struct {
+	cursorID int
+	curRow   int
+	retCode  int
+	message  string
+}
+
+func writeSummary(ms *network.MemorySession, ttc uint8, sum summary) {
+	ms.PutBytes(4)
+	ms.PutUint(sum.curRow, 4, true, true)
+	ms.PutUint(sum.retCode, 2, true, true)
+	ms.PutUint(0, 2, true, true) // array element with error
+	ms.PutUint(0, 2, true, true) // array element error number
+	ms.PutUint(sum.cursorID, 2, true, true)
+	ms.PutUint(0, 2, true, true) // error position
+	ms.PutBytes(0, 0)            // sql type, fatal error
+	if ttc >= 4 {
+		ms.PutUint(0, 2, true, true)
+		ms.PutUint(0, 2, true, true)
+	} else {
+		ms.PutBytes(0, 0)
+	}
+	ms.PutBytes(0, 0)            // upi param, warning flag
+	ms.PutUint(0, 4, true, true) // rba
+	ms.PutUint(0, 2, true, true) // partition id
+	ms.PutBytes(0)               // table id
+	ms.PutUint(0, 4, true, true) // block number
+	ms.PutUint(0, 2, true, true) // slot number
+	ms.PutUint(0, 4, true, true) // os error
+	ms.PutBytes(0, 0)            // statement number, call number
+	ms.PutUint(0, 2, true, true) // pad
+	ms.PutUint(0, 4, true, true) // success iterations
+	ms.PutDlc(nil)
+	if ttc < 7 {
+		ms.PutDlc(nil)
+		ms.PutDlc(nil)
+		ms.PutDlc(nil)
+	} else {
+		// no bind errors
+		ms.PutUint(0, 2, true, true)
+		ms.PutUint(0, 4, true, true)
+		ms.PutUint(0, 2, true, true)
+		ms.PutUint(sum.retCode, 4, true, true)
+		ms.PutUint(sum.curRow, 8, true, true)
+	}
+	if sum.retCode != 0 {
+		ms.PutClr([]byte(sum.message))
+	}
+}
+
+// columnInfo is column description sent to the client
+type columnInfo struct {
+	name        string
+	dataType    uint16
+	maxLen      int
+	charsetID   int
+	charsetForm uint8
+}
+
+func describeColumn(col Column) columnInfo {
+	info := columnInfo{name: col.Name}
+	switch col.Type {
+	case Number:
+		info.dataType, info.maxLen = types.NUMBER, 22
+	case Date:
+		info.dataType, info.maxLen = types.DATE, 7
+	case Timestamp:
+		info.dataType, info.maxLen = types.TimeStampDTY, 11
+	case Raw:
+		info.dataType, info.maxLen = types.RAW, col.Size
+		if info.maxLen == 0 {
+			info.maxLen = 2000
+		}
+	case BinaryDouble:
+		info.dataType, info.maxLen = types.IBDOUBLE, 8
+	default:
+		info.dataType, info.maxLen = types.NCHAR, col.Size
+		if info.maxLen == 0 {
+			info.maxLen = 4000
+		}
+		info.charsetID, info.charsetForm = serverCharset, 1
+	}
+	return info
+}
+
+// writeDescribe write TTC message 16 that describe result set columns
+func writeDescribe(ms *network.MemorySession, ttc uint8, columns []Column) {
+	infos := make([]columnInfo, len(columns))
+	maxRowSize := 0
+	for i, col := range columns {
+		infos[i] = describeColumn(col)
+		maxRowSize += infos[i].maxLen
+	}
+	ms.PutBytes(16, 0)
+	ms.PutUint(maxRowSize, 4, true, true)
+	ms.PutUint(len(infos), 4, true, true)
+	if len(infos) > 0 {
+		ms.PutBytes(1)
+	}
+	for _, info := range infos {
+		ms.PutBytes(uint8(info.dataType), 0, 0)
+		// scale
+		if info.dataType == types.NUMBER || info.dataType == types.TimeStampDTY {
+			ms.PutUint(0, 2, true, true)
+		} else {
+			ms.PutBytes(0)
+		}
+		ms.PutUint(info.maxLen, 4, true, true)
+		ms.PutUint(0, 4, true, true) // array size
+		if ttc >= 10 {
+			ms.PutUint(0, 8, true, true)
+		} else {
+			ms.PutUint(0, 4, true, true)
+		}
+		ms.PutDlc(nil)               // toid
+		ms.PutUint(0, 2, true, true) // version
+		ms.PutUint(info.charsetID, 2, true, true)
+		ms.PutBytes(info.charsetForm)
+		ms.PutUint(info.maxLen, 4, true, true) // max char length
+		if ttc >= 8 {
+			ms.PutUint(0, 4, true, true)
+		}
+		ms.PutBytes(1, 0) // allow null
+		ms.PutDlc([]byte(info.name))
+		ms.PutDlc(nil) // schema
+		ms.PutDlc(nil) // type name
+		if ttc >= 3 {
+			ms.PutUint(0, 2, true, true)
+		}
+		if ttc >= 6 {
+			ms.PutUint(0, 4, true, true)
+		}
+		if ttc >= 17 {
+			ms.PutDlc(nil) // domain schema
+			ms.PutDlc(nil) // domain name
+		}
+		if ttc >= 20 {
+			ms.PutUint(0, 4, true, true) // annotations
+		}
+		if ttc >= 24 {
+			ms.PutUint(0, 4, true, true)
+			ms.PutBytes(0, 0)
+		}
+	}
+	ms.PutDlc(nil)
+	if ttc >= 3 {
+		ms.PutUint(0, 4, true, true)
+		ms.PutUint(0, 4, true, true)
+	}
+	if ttc >= 4 {
+		ms.PutUint(0, 4, true, true)
+		ms.PutUint(0, 4, true, true)
+	}
+	if ttc >= 5 {
+		ms.PutDlc(nil)
+	}
+}
+
+// writeRows write row header (message 6) followed by message 7 for each row
+func writeRows(ms *network.MemorySession, columnCount int, rows [][][]byte) {
+	ms.PutBytes(6, 0)
+	ms.PutUint(columnCount, 2, true, true)
+	ms.PutUint(0, 4, true, true)
+	ms.PutUint(len(rows), 4, true, true)
+	ms.PutUint(0, 2, true, true)
+	ms.PutDlc(nil) // bit vector: all columns are sent
+	ms.PutDlc(nil)
+	for _, row := range rows {
+		ms.PutBytes(7)
+		for _, value := range row {
+			ms.PutClr(value)
+		}
+	}
+}
+
+// encodeRow return wire value of each column in the row
+func encodeRow(columns []Column, row []interface{}) ([][]byte, error) {
+	if len(row) != len(columns) {
+		return nil, fmt.Errorf("row has %d values while result has %d columns", len(row), len(columns))
+	}
+	output := make([][]byte, len(columns))
+	for i, col := range columns {
+		value, err := encodeValue(col.Type, row[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+		output[i] = value
+	}
+	return output, nil
+}
+
+func encodeValue(colType ColumnType, value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch colType {
+	case Number:
+		if val, ok := value.(float32); ok {
+			value = float64(val)
+		}
+		num, err := types.NewNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		return num.Bytes(), nil
+	case BinaryDouble:
+		switch val := value.(type) {
+		case float64:
+			return types.NewBinaryDouble(val).Bytes(), nil
+		case float32:
+			return types.NewBinaryDouble(float64(val)).Bytes(), nil
+		case int:
+			return types.NewBinaryDouble(float64(val)).Bytes(), nil
+		}
+		return nil, fmt.Errorf("cannot encode %T as BINARY_DOUBLE", value)
+	case Date, Timestamp:
+		t, ok := value.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("cannot encode %T as date", value)
+		}
+		if colType == Timestamp {
+			return types.NewTimeStamp(t).Bytes(), nil
+		}
+		date := types.Date{}
+		date.SetDataType(types.DATE)
+		if err := date.SetValue(t); err != nil {
+			return nil, err
+		}
+		return date.Bytes(), nil
+	case Raw:
+		if val, ok := value.([]byte); ok {
+			return val, nil
+		}
+		return nil, fmt.Errorf("cannot encode %T as RAW", value)
+	default:
+		switch val := value.(type) {
+		case string:
+			return []byte(val), nil
+		case []byte:
+			return val, nil
+		default:
+			return []byte(fmt.Sprint(value)), nil
+		}
+	}
+}
+
+// bindDef is the definition of bind variable sent with parse request
+type bindDef struct {
+	dataType    uint8
+	flag        uint8
+	charsetForm uint8
+}
+
+func readBindDef(ms *network.MemorySession, ttc uint8) (def bindDef, err error) {
+	var header []byte
+	header, err = ms.GetBytes(4) // data type, flag, precision, scale
+	if err != nil {
+		return
+	}
+	def.dataType, def.flag = header[0], header[1]
+	_, err = ms.GetInt(4, true, true) // max length
+	if err != nil {
+		return
+	}
+	_, err = ms.GetInt(4, true, true) // array size
+	if err != nil {
+		return
+	}
+	if ttc >= 10 {
+		_, err = ms.GetInt64(8, true, true)
+	} else {
+		_, err = ms.GetInt(4, true, true)
+	}
+	if err != nil {
+		return
+	}
+	_, err = ms.GetDlc() // toid
+	if err != nil {
+		return
+	}
+	_, err = ms.GetInt(2, true, true) // version
+	if err != nil {
+		return
+	}
+	_, err = ms.GetInt(2, true, true) // charset id
+	if err != nil {
+		return
+	}
+	def.charsetForm, err = ms.GetByte()
+	if err != nil {
+		return
+	}
+	_, err = ms.GetInt(4, true, true) // max char length
+	if err != nil {
+		return
+	}
+	if ttc >= 8 {
+		_, err = ms.GetInt(4, true, true)
+	}
+	return
+}
+
+// readBindValues read values that follow message code 7. reading stop at the
+// first error because values of unsupported types can't be skipped
+func readBindValues(ms *network.MemorySession, defs []bindDef) []interface{} {
+	code, err := ms.GetByte()
+	if err != nil || code != 7 {
+		return nil
+	}
+	output := make([]interface{}, 0, len(defs))
+	for _, def := range defs {
+		if def.flag == 0x80 {
+			continue
+		}
+		value, err := ms.GetClr()
+		if err != nil {
+			break
+		}
+		output = append(output, decodeBindValue(def, value))
+	}
+	return output
+}
+
+func decodeBindValue(def bindDef, value []byte) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch uint16(def.dataType) {
+	case types.NCHAR, types.CHAR:
+		if def.charsetForm == 2 {
+			return converters.NewStringConverter(serverNCharset).Decode(value)
+		}
+		return string(value)
+	case types.NUMBER:
+		num := types.Number{}
+		num.SetBytes(value)
+		text, err := num.String()
+		if err != nil {
+			return value
+		}
+		if val, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return val
+		}
+		if val, err := strconv.ParseFloat(text, 64); err == nil {
+			return val
+		}
+		return text
+	case types.IBFLOAT, types.IBDOUBLE:
+		num := types.Number{}
+		num.SetBytes(value)
+		num.SetDataType(uint16(def.dataType))
+		if val, err := num.Value(); err == nil {
+			return val
+		}
+	case types.DATE, types.TIMESTAMP, types.TIMESTAMPTZ, types.TimeStampDTY, types.TimeStampTZ_DTY:
+		date := types.Date{}
+		date.SetBytes(value)
+		date.SetDataType(uint16(def.dataType))
+		if val, err := date.Value(); err == nil {
+			return val
+		}
+	}
+	return value
+}
+
+// writeOutBinds write direction of each bind (message 11) followed by values of
+// output parameters (message 7). values of dml returning clause are prefixed
+// with the number of returned rows
+func writeOutBinds(ms *network.MemorySession, defs []bindDef, out map[int]interface{}, returning bool) error {
+	values := make([][]byte, len(defs))
+	for i, def := range defs {
+		value, ok := out[i+1]
+		if !ok {
+			continue
+		}
+		var err error
+		values[i], err = encodeOutValue(def, value)
+		if err != nil {
+			return fmt.Errorf("bind %d: %w", i+1, err)
+		}
+	}
+	ms.PutBytes(11, 0)
+	ms.PutUint(len(defs), 2, true, true)
+	ms.PutUint(0, 4, true, true)
+	ms.PutUint(1, 4, true, true)
+	ms.PutUint(0, 2, true, true)
+	ms.PutDlc(nil)
+	ms.PutDlc(nil)
+	for i := range defs {
+		if _, ok := out[i+1]; ok {
+			ms.PutBytes(16)
+		} else {
+			ms.PutBytes(32)
+		}
+	}
+	ms.PutBytes(7)
+	for i := range defs {
+		if _, ok := out[i+1]; ok {
+			if returning {
+				ms.PutUint(1, 4, true, true)
+			}
+			ms.PutClr(values[i])
+			ms.PutUint(0, 2, true, true)
+		}
+	}
+	return nil
+}
+
+// encodeOutValue encode output parameter according to its bind data type
+func encodeOutValue(def bindDef, value interface{}) ([]byte, error) {
+	switch uint16(def.dataType) {
+	case types.NUMBER:
+		return encodeValue(Number, value)
+	case types.IBDOUBLE:
+		return encodeValue(BinaryDouble, value)
+	case types.DATE:
+		return encodeValue(Date, value)
+	case types.TIMESTAMP, types.TimeStampDTY:
+		return encodeValue(Timestamp, value)
+	case types.RAW:
+		return encodeValue(Raw, value)
+	case types.NCHAR, types.CHAR, types.LongVarChar:
+		if text, ok := value.(string); ok && def.charsetForm == 2 {
+			return converters.NewStringConverter(serverNCharset).Encode(text), nil
+		}
+		return encodeValue(Varchar2, value)
+	}
+	return nil, fmt.Errorf("output parameter of data type %d is not supported", def.dataType)
+}
+
+// writeLTXID write server piggyback (message 23) with logical transaction id
+func writeLTXID(ms *network.MemorySession, ltxid []byte) {
+	ms.PutBytes(23, 7)
+	ms.PutUint(len(ltxid), 4, true, true)
+	ms.PutClr(ltxid)
+}
@@ -0,0 +1,92 @@
+package oratest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ColumnType is the type of result set column
+type ColumnType int
+
+const (
+	Varchar2 ColumnType = iota
+	Number
+	Date
+	Timestamp
+	Raw
+	BinaryDouble
+)
+
+// Column describe result set column. Size is the max length of Varchar2 and Raw
+// columns, 0 means 4000 and 2000 respectively
+type Column struct {
+	Name string
+	Type ColumnType
+	Size int
+}
+
+// Error is an Oracle error returned to the client in place of the result
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// Result is the server answer for a statement.
+//
+// query: Columns and Rows are sent as result set. row values can be nil,
+// string, []byte, integer and float numbers and time.Time according to column type.
+// DML and others: RowsAffected is returned.
+// Out set values of output parameters by bind position starting from 1, binds
+// without value are input parameters. values are encoded according to the bind
+// data type sent by the client.
+// LTXID is sent to the client as logical transaction id (Transaction Guard).
+// Err return Oracle error instead of the result. Delay postpone the answer so the
+// client timeouts and breaks. Disconnect drop the connection without answer
+type Result struct {
+	Columns      []Column
+	Rows         [][]interface{}
+	RowsAffected int
+	Out          map[int]interface{}
+	LTXID        []byte
+	Err          *Error
+	Delay        time.Duration
+	Disconnect   bool
+}
+
+// Request is statement received from the client with decoded bind values
+type Request struct {
+	SQL  string
+	Args []interface{}
+}
+
+// ErrorResult return result with Oracle error. message is prefixed with ORA-code
+// when it doesn't start with ORA-
+func ErrorResult(code int, message string) *Result {
+	if !strings.HasPrefix(message, "ORA-") {
+		message = fmt.Sprintf("ORA-%05d: %s", code, message)
+	}
+	return &Result{Err: &Error{Code: code, Message: message}}
+}
+
+// RowsResult return query result with varchar2 columns of the names
+func RowsResult(names []string, rows ...[]interface{}) *Result {
+	columns := make([]Column, len(names))
+	for i, name := range names {
+		columns[i] = Column{Name: name}
+	}
+	return &Result{Columns: columns, Rows: rows}
+}
+
+// ExecResult return DML result with number of rows affected
+func ExecResult(rowsAffected int) *Result {
+	return &Result{RowsAffected: rowsAffected}
+}
+
+func (result *Result) isQuery() bool {
+	return len(result.Columns) > 0
+}
@@ -0,0 +1,306 @@
+// Package oratest provide an in-process fake Oracle server for testing the driver
+// without a real database.
+//
+// the server listen on localhost and speak enough of TNS and TTC protocol to open
+// a session: connect/accept, protocol and data type negotiation, O5LOGON
+// authentication, execute/fetch, output parameters, commit/rollback, cursor
+// close and break/reset.
+// it doesn't parse SQL, statements are matched by text with the results registered
+// by the test (canned result sets, rows affected or errors) or answered by a
+// handler function. network level behaviors (refuse, redirect, delayed answers and
+// dropped connections) can be set to test timeouts, breaks and failover.
+//
+// unsupported: native network encryption, TCPS, LOB/LONG columns, REF CURSOR
+// and array binds
+package oratest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultUser     = "scott"
+	DefaultPassword = "tiger"
+	DefaultService  = "ORCL"
+)
+
+// HandlerFunc return the result of statements that are not registered with Handle.
+// returning nil answer the statement with ORA-00900
+type HandlerFunc func(req *Request) *Result
+
+// Server is a fake Oracle listener and database. create it with NewServer or
+// NewUnstartedServer then Start
+type Server struct {
+	// ServiceName accepted by the server. connect requests for another
+	// service are refused with ORA-12514. empty value accept any service
+	ServiceName string
+	listener    net.Listener
+	mu          sync.Mutex
+	users       map[string]string
+	results     map[string]*Result
+	handler     HandlerFunc
+	refuseCode  int
+	redirect    string
+	statements  []string
+	sessions    int
+	logins      []map[string]string
+	conns       map[net.Conn]struct{}
+	wg          sync.WaitGroup
+	closed      bool
+}
+
+// NewUnstartedServer return a server with default user and service name that
+// doesn't listen until Start is called
+func NewUnstartedServer() *Server {
+	return &Server{
+		ServiceName: DefaultService,
+		users:       map[string]string{strings.ToUpper(DefaultUser): DefaultPassword},
+		results:     make(map[string]*Result),
+		conns:       make(map[net.Conn]struct{}),
+	}
+}
+
+// NewServer create a server and start listening on random localhost port
+func NewServer() (*Server, error) {
+	server := NewUnstartedServer()
+	err := server.Start()
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// Start listening on 127.0.0.1 with random port
+func (server *Server) Start() error {
+	if server.listener != nil {
+		return errors.New("oratest: server already started")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("oratest: failed to listen: %w", err)
+	}
+	server.listener = listener
+	server.wg.Add(1)
+	go server.serve()
+	return nil
+}
+
+func (server *Server) serve() {
+	defer server.wg.Done()
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		server.mu.Lock()
+		if server.closed {
+			server.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		server.conns[conn] = struct{}{}
+		server.wg.Add(1)
+		server.mu.Unlock()
+		go func() {
+			defer server.wg.Done()
+			newServerConn(server, conn).serve()
+			server.mu.Lock()
+			delete(server.conns, conn)
+			server.mu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+// Close stop listening, drop client connections and wait for their goroutines
+func (server *Server) Close() error {
+	server.mu.Lock()
+	server.closed = true
+	server.mu.Unlock()
+	var err error
+	if server.listener != nil {
+		err = server.listener.Close()
+	}
+	server.CloseClientConnections()
+	server.wg.Wait()
+	return err
+}
+
+// CloseClientConnections drop all open client connections like a database
+// crash. the server continue accepting new connections
+func (server *Server) CloseClientConnections() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for conn := range server.conns {
+		_ = conn.Close()
+	}
+}
+
+// Host return the listening ip address
+func (server *Server) Host() string {
+	return server.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port return the listening port
+func (server *Server) Port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Addr return host:port of the server
+func (server *Server) Addr() string {
+	return server.listener.Addr().String()
+}
+
+// URL return go-ora connection string for the default user. options are added
+// as url query parameters
+func (server *Server) URL(options map[string]string) string {
+	ret := url.URL{
+		Scheme: "oracle",
+		User:   url.UserPassword(DefaultUser, DefaultPassword),
+		Host:   net.JoinHostPort(server.Host(), strconv.Itoa(server.Port())),
+		Path:   server.serviceName(),
+	}
+	if len(options) > 0 {
+		query := url.Values{}
+		for key, val := range options {
+			query.Add(key, val)
+		}
+		ret.RawQuery = query.Encode()
+	}
+	return ret.String()
+}
+
+// AddUser add user or change password of existing one. user names are case-insensitive
+func (server *Server) AddUser(user, password string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.users[strings.ToUpper(user)] = password
+}
+
+func (server *Server) password(user string) (string, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	password, ok := server.users[strings.ToUpper(user)]
+	return password, ok
+}
+
+// Handle register result for the statement. the text is matched after
+// collapsing white spaces. nil result remove the registration
+func (server *Server) Handle(sql string, result *Result) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if result == nil {
+		delete(server.results, normalizeSQL(sql))
+	} else {
+		server.results[normalizeSQL(sql)] = result
+	}
+}
+
+// HandleFunc set handler for statements that are not registered with Handle
+func (server *Server) HandleFunc(handler HandlerFunc) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.handler = handler
+}
+
+// RefuseConnections make the listener refuse next connect requests with the
+// error code (e.g. 12514 or 12516). code = 0 accept connections again
+func (server *Server) RefuseConnections(code int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.refuseCode = code
+}
+
+// RedirectTo make the listener redirect next connect requests to another
+// address in form of host:port. empty address stop redirection
+func (server *Server) RedirectTo(addr string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.redirect = addr
+}
+
+// Statements return text of statements executed on the server in order.
+// commit and rollback calls are logged as COMMIT and ROLLBACK
+func (server *Server) Statements() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]string{}, server.statements...)
+}
+
+// Sessions return number of successful logins
+func (server *Server) Sessions() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.sessions
+}
+
+// Logins return key/value pairs sent by the client in each successful login
+// (e.g. AUTH_ORA_EDITION) in order
+func (server *Server) Logins() []map[string]string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	ret := make([]map[string]string, len(server.logins))
+	for i, values := range server.logins {
+		ret[i] = make(map[string]string, len(values))
+		for key, val := range values {
+			ret[i][key] = val
+		}
+	}
+	return ret
+}
+
+func (server *Server) serviceName() string {
+	if len(server.ServiceName) == 0 {
+		return DefaultService
+	}
+	return server.ServiceName
+}
+
+func (server *Server) connectAction() (refuseCode int, redirect string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.refuseCode, server.redirect
+}
+
+func (server *Server) newSession(values map[string]string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.sessions++
+	server.logins = append(server.logins, values)
+	return server.sessions
+}
+
+// logStatement add text of call that isn't a statement (commit and rollback)
+func (server *Server) logStatement(text string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.statements = append(server.statements, text)
+}
+
+// execute find the result of request and log the statement
+func (server *Server) execute(req *Request) *Result {
+	server.mu.Lock()
+	server.statements = append(server.statements, req.SQL)
+	result, ok := server.results[normalizeSQL(req.SQL)]
+	handler := server.handler
+	server.mu.Unlock()
+	if ok {
+		return result
+	}
+	if handler != nil {
+		if result = handler(req); result != nil {
+			return result
+		}
+	}
+	return ErrorResult(900, "ORA-00900: invalid SQL statement")
+}
+
+func normalizeSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
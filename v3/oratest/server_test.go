@@ -0,0 +1,306 @@
+package oratest_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func newServer(t *testing.T) *oratest.Server {
+	t.Helper()
+	server, err := oratest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+func openDB(t *testing.T, url string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("oracle", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func oracleErrorCode(err error) int {
+	var oraErr *network.OracleError
+	if errors.As(err, &oraErr) {
+		return oraErr.ErrCode
+	}
+	return 0
+}
+
+func TestQuery(t *testing.T) {
+	server := newServer(t)
+	server.Handle("SELECT ID, NAME FROM EMP", &oratest.Result{
+		Columns: []oratest.Column{{Name: "ID", Type: oratest.Number}, {Name: "NAME"}},
+		Rows: [][]interface{}{
+			{1, "KING"},
+			{2, nil},
+			{3, "SMITH"},
+		},
+	})
+	db := openDB(t, server.URL(nil))
+	rows, err := db.Query("SELECT ID,  NAME\nFROM EMP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []int64
+	var names []string
+	for rows.Next() {
+		var id int64
+		var name sql.NullString
+		if err = rows.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+		names = append(names, name.String)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+	if strings.Join(names, ",") != "KING,,SMITH" {
+		t.Errorf("unexpected names: %v", names)
+	}
+	if server.Sessions() != 1 {
+		t.Errorf("expected 1 session got: %d", server.Sessions())
+	}
+}
+
+func TestExec(t *testing.T) {
+	server := newServer(t)
+	var args []interface{}
+	server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+		if strings.HasPrefix(req.SQL, "UPDATE") {
+			args = req.Args
+			return oratest.ExecResult(3)
+		}
+		return nil
+	})
+	db := openDB(t, server.URL(nil))
+	result, err := db.Exec("UPDATE EMP SET NAME = :1 WHERE DEPTNO = :2", "ALLEN", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 3 {
+		t.Errorf("expected 3 rows affected got: %d", affected)
+	}
+	if len(args) != 2 || args[0] != "ALLEN" || args[1] != int64(10) {
+		t.Errorf("unexpected bind values: %#v", args)
+	}
+}
+
+func TestError(t *testing.T) {
+	server := newServer(t)
+	server.Handle("DELETE FROM EMP", oratest.ErrorResult(2292, "integrity constraint violated - child record found"))
+	db := openDB(t, server.URL(nil))
+	_, err := db.Exec("DELETE FROM EMP")
+	if code := oracleErrorCode(err); code != 2292 {
+		t.Fatalf("expected ORA-02292 got: %v", err)
+	}
+	// connection remain usable after the error
+	if err = db.Ping(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	server := newServer(t)
+	db := openDB(t, go_ora.BuildUrl(server.Host(), server.Port(), oratest.DefaultService,
+		oratest.DefaultUser, "wrong", nil))
+	err := db.Ping()
+	if code := oracleErrorCode(err); code != 1017 {
+		t.Fatalf("expected ORA-01017 got: %v", err)
+	}
+	if server.Sessions() != 0 {
+		t.Errorf("expected no sessions got: %d", server.Sessions())
+	}
+}
+
+func TestBreak(t *testing.T) {
+	server := newServer(t)
+	server.Handle("BEGIN LONG_JOB; END;", &oratest.Result{Delay: 10 * time.Second})
+	db := openDB(t, server.URL(nil))
+	db.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := db.ExecContext(ctx, "BEGIN LONG_JOB; END;")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("call wasn't interrupted, elapsed: %v", elapsed)
+	}
+	server.Handle("UPDATE EMP SET SAL = 0", oratest.ExecResult(1))
+	if _, err = db.Exec("UPDATE EMP SET SAL = 0"); err != nil {
+		t.Error(err)
+	}
+	if server.Sessions() != 1 {
+		t.Errorf("expected connection reuse after break, sessions: %d", server.Sessions())
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	target := newServer(t)
+	listener := newServer(t)
+	listener.RedirectTo(target.Addr())
+	db := openDB(t, listener.URL(nil))
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if listener.Sessions() != 0 || target.Sessions() != 1 {
+		t.Errorf("expected session on redirect target, listener: %d, target: %d",
+			listener.Sessions(), target.Sessions())
+	}
+}
+
+func TestFailover(t *testing.T) {
+	primary := newServer(t)
+	standby := newServer(t)
+	primary.RefuseConnections(12516)
+	url := go_ora.BuildUrl(primary.Host(), primary.Port(), oratest.DefaultService,
+		oratest.DefaultUser, oratest.DefaultPassword, map[string]string{
+			"server": standby.Addr(),
+		})
+	db := openDB(t, url)
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if primary.Sessions() != 0 || standby.Sessions() != 1 {
+		t.Errorf("expected session on standby, primary: %d, standby: %d",
+			primary.Sessions(), standby.Sessions())
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	server := newServer(t)
+	db := openDB(t, go_ora.BuildUrl(server.Host(), server.Port(), "OTHER",
+		oratest.DefaultUser, oratest.DefaultPassword, nil))
+	err := db.Ping()
+	if err == nil || !strings.Contains(err.Error(), "12514") {
+		t.Fatalf("expected ORA-12514 got: %v", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := newServer(t)
+	hireDate := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+	result := &oratest.Result{
+		Columns: []oratest.Column{
+			{Name: "ID", Type: oratest.Number},
+			{Name: "HIRE_DATE", Type: oratest.Date},
+			{Name: "SALARY", Type: oratest.BinaryDouble},
+		},
+	}
+	for i := 0; i < 100; i++ {
+		result.Rows = append(result.Rows, []interface{}{i, hireDate, float64(i) / 2})
+	}
+	server.Handle("SELECT ID, HIRE_DATE, SALARY FROM EMP", result)
+	db := openDB(t, server.URL(map[string]string{"PREFETCH_ROWS": "10"}))
+	rows, err := db.Query("SELECT ID, HIRE_DATE, SALARY FROM EMP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		var id int
+		var date time.Time
+		var salary float64
+		if err = rows.Scan(&id, &date, &salary); err != nil {
+			t.Fatal(err)
+		}
+		if id != count || salary != float64(count)/2 || !date.Equal(hireDate) {
+			t.Errorf("unexpected row %d: %d, %v, %v", count, id, date, salary)
+		}
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 100 {
+		t.Errorf("expected 100 rows got: %d", count)
+	}
+}
+
+func TestOutParameters(t *testing.T) {
+	server := newServer(t)
+	var args []interface{}
+	server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+		args = req.Args
+		return &oratest.Result{Out: map[int]interface{}{2: 7, 3: "KING", 4: []byte{1, 2, 3}}}
+	})
+	db := openDB(t, server.URL(nil))
+	var (
+		id   int64
+		name string
+		raw  []byte
+	)
+	_, err := db.Exec("BEGIN GET_EMP(:1, :2, :3, :4); END;", 10, sql.Out{Dest: &id},
+		go_ora.Out{Dest: &name, Size: 100}, go_ora.Out{Dest: &raw, Size: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 || name != "KING" || string(raw) != "\x01\x02\x03" {
+		t.Errorf("unexpected output parameters: %d, %q, %v", id, name, raw)
+	}
+	if len(args) == 0 || args[0] != int64(10) {
+		t.Errorf("unexpected bind values: %#v", args)
+	}
+}
+
+func TestLoginsAndTransaction(t *testing.T) {
+	server := newServer(t)
+	server.Handle("UPDATE EMP SET SAL = 0", &oratest.Result{RowsAffected: 1, LTXID: []byte{1, 2, 3, 4}})
+	db := openDB(t, server.URL(nil))
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Exec("UPDATE EMP SET SAL = 0"); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	ltxid, err := go_ora.GetConnectionLTXID(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ltxid) != "\x01\x02\x03\x04" {
+		t.Errorf("unexpected ltxid: %v", ltxid)
+	}
+	statements := server.Statements()
+	if len(statements) < 2 || strings.Join(statements[len(statements)-2:], ";") != "UPDATE EMP SET SAL = 0;COMMIT" {
+		t.Errorf("unexpected statements: %q", statements)
+	}
+	logins := server.Logins()
+	if len(logins) != 1 || len(logins[0]["AUTH_SESSKEY"]) == 0 {
+		t.Errorf("unexpected logins: %v", logins)
+	}
+}
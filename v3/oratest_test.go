@@ -0,0 +1,28 @@
+package go_ora_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func newServer(t *testing.T) *oratest.Server {
+	t.Helper()
+	server, err := oratest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+func openDB(t *testing.T, url string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("oracle", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
@@ -2,13 +2,11 @@ package go_ora
 
 import (
 	"database/sql/driver"
-	"math"
-	"strings"
 
 	"github.com/sijms/go-ora/v3/configurations"
-	"github.com/sijms/go-ora/v3/converters"
 	"github.com/sijms/go-ora/v3/network"
 	"github.com/sijms/go-ora/v3/parameter_coder"
+	"github.com/sijms/go-ora/v3/ttc"
 	oraTypes "github.com/sijms/go-ora/v3/types"
 )
 
@@ -79,142 +77,41 @@ type ParameterInfo struct {
 // load get parameter information form network session
 func (par *ParameterInfo) load(conn *Connection) error {
 	session := conn.session
-	par.getDataFromServer = true
-	dataType, err := session.GetByte()
-	if err != nil {
-		return err
-	}
-	par.DataType = uint16(dataType)
-	par.Flag, err = session.GetByte()
+	col, err := ttc.ReadColumn(session, session.Capabilities(), session.StrConv)
 	if err != nil {
 		return err
 	}
-	par.Precision, err = session.GetByte()
-	// precision, err := session.GetInt(1, false, false)
-	// var scale int
-	switch par.DataType {
-	case oraTypes.NUMBER:
-		fallthrough
-	case oraTypes.TimeStampDTY:
-		fallthrough
-	case oraTypes.TimeStampTZ_DTY:
-		fallthrough
-	case oraTypes.INTERVALDS_DTY:
-		fallthrough
-	case oraTypes.TIMESTAMP:
-		fallthrough
-	case oraTypes.TIMESTAMPTZ:
-		fallthrough
-	case oraTypes.IntervalDS:
-		fallthrough
-	case oraTypes.TimeStampLTZ_DTY:
-		fallthrough
-	case oraTypes.TimeStampLTZ:
-		scale, err := session.GetInt(2, true, true)
-		if err != nil {
-			return err
-		}
-
-		if scale == -127 {
-			par.Precision = uint8(math.Ceil(float64(par.Precision) * 0.30103))
-			par.Scale = 0xFF
-		} else {
-			par.Scale = uint8(scale)
-		}
-	default:
-		par.Scale, err = session.GetByte()
-		// scale, err = session.GetInt(1, false, false)
-	}
-	// if par.Scale == uint8(-127) {
-	//
-	// }
-	if par.DataType == oraTypes.NUMBER && par.Precision == 0 && (par.Scale == 0 || par.Scale == 0xFF) {
-		par.Precision = 38
-		par.Scale = 0xFF
-	}
+	par.fromColumn(conn, col)
+	return nil
+}
 
-	// par.Scale = uint16(scale)
-	// par.Precision = uint16(precision)
-	par.MaxLen, err = session.GetInt64(4, true, true)
-	if err != nil {
-		return err
-	}
-	switch par.DataType {
-	case oraTypes.ROWID:
-		par.MaxLen = 128
-	case oraTypes.DATE:
-		par.MaxLen = int64(converters.MAX_LEN_DATE)
-	case oraTypes.IBFLOAT:
-		par.MaxLen = 4
-	case oraTypes.IBDOUBLE:
-		par.MaxLen = 8
-	case oraTypes.TimeStampTZ_DTY:
-		par.MaxLen = int64(converters.MAX_LEN_TIMESTAMP)
-	case oraTypes.INTERVALYM_DTY:
-		fallthrough
-	case oraTypes.INTERVALDS_DTY:
-		fallthrough
-	case oraTypes.IntervalYM:
-		fallthrough
-	case oraTypes.IntervalDS:
-		par.MaxLen = 11
-	}
-	par.ArraySize, err = session.GetInt(4, true, true)
-	if err != nil {
-		return err
-	}
-	if session.TTCVersion >= 10 {
-		par.ContFlag, err = session.GetInt(8, true, true)
-	} else {
-		par.ContFlag, err = session.GetInt(4, true, true)
-	}
-	if err != nil {
-		return err
-	}
-	par.ToID, err = session.GetDlc()
-	par.Version, err = session.GetInt(2, true, true)
-	if err != nil {
-		return err
-	}
-	par.CharsetID, err = session.GetInt(2, true, true)
-	if err != nil {
-		return err
-	}
-	par.CharsetForm, err = session.GetInt(1, false, false)
-	if err != nil {
-		return err
-	}
-	par.MaxCharLen, err = session.GetInt64(4, true, true)
-	if err != nil {
-		return err
-	}
-	if session.TTCVersion >= 8 {
-		par.oaccollid, err = session.GetInt(4, true, true)
-	}
-	num1, err := session.GetInt(1, false, false)
-	if err != nil {
-		return err
-	}
-	par.AllowNull = num1 > 0
-	_, err = session.GetByte() //  v7 length of name
-	if err != nil {
-		return err
-	}
-	bName, err := session.GetDlc()
-	if err != nil {
-		return err
-	}
-	par.Name = session.StrConv.Decode(bName)
-	bName, err = session.GetDlc() // schema name
-	if err != nil {
-		return err
-	}
-	par.SchemaName = strings.ToUpper(session.StrConv.Decode(bName))
-	bName, err = session.GetDlc()
-	if err != nil {
-		return err
-	}
-	par.TypeName = strings.ToUpper(session.StrConv.Decode(bName))
+// fromColumn set parameter information from column decoded by ttc package
+func (par *ParameterInfo) fromColumn(conn *Connection, col *ttc.Column) {
+	par.getDataFromServer = true
+	par.DataType = col.DataType
+	par.Flag = col.Flag
+	par.Precision = col.Precision
+	par.Scale = col.Scale
+	par.MaxLen = col.MaxLen
+	par.ArraySize = col.ArraySize
+	par.ContFlag = col.ContFlag
+	par.ToID = col.ToID
+	par.Version = col.Version
+	par.CharsetID = col.CharsetID
+	par.CharsetForm = col.CharsetForm
+	par.MaxCharLen = col.MaxCharLen
+	par.oaccollid = col.CollationID
+	par.AllowNull = col.AllowNull
+	par.Name = col.Name
+	par.SchemaName = col.SchemaName
+	par.TypeName = col.TypeName
+	par.IsJson = col.IsJson
+	par.DomainSchema = col.DomainSchema
+	par.DomainName = col.DomainName
+	par.Annotations = col.Annotations
+	par.VectorDim = col.VectorDim
+	par.VectorFormat = col.VectorFormat
+	par.VectorFlag = col.VectorFlag
 	if par.DataType == oraTypes.XMLType && par.TypeName != "XMLTYPE" {
 		for typName, cusTyp := range conn.cusTyp {
 			if typName == par.TypeName {
@@ -223,106 +120,13 @@ func (par *ParameterInfo) load(conn *Connection) error {
 			}
 		}
 	}
-	if par.TypeName == "XMLTYPE" {
-		par.DataType = oraTypes.XMLType
-		par.IsXmlType = true
-	}
-	if session.TTCVersion < 3 {
-		return nil
-	}
-	_, err = session.GetInt(2, true, true)
-	if session.TTCVersion < 6 {
-		return nil
-	}
-	var uds_flags int
-	uds_flags, err = session.GetInt(4, true, true)
-	par.IsJson = (uds_flags & 0x500) > 0
-	if session.TTCVersion < 17 {
-		return nil
-	}
-	bName, err = session.GetDlc()
-	if err != nil {
-		return err
-	}
-	par.DomainSchema = strings.ToUpper(session.StrConv.Decode(bName))
-	bName, err = session.GetDlc()
-	if err != nil {
-		return err
-	}
-	par.DomainName = strings.ToUpper(session.StrConv.Decode(bName))
-	if session.TTCVersion < 20 {
-		return nil
-	}
-	numAnnotations, err := session.GetInt(4, true, true)
-	if err != nil {
-		return err
-	}
-	if numAnnotations > 0 {
-		par.Annotations = make(map[string]string)
-		_, err = session.GetByte()
-		if err != nil {
-			return err
-		}
-		numAnnotations, err = session.GetInt(4, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetByte()
-		if err != nil {
-			return err
-		}
-		for i := 0; i < numAnnotations; i++ {
-			bKey, bValue, _, err := session.GetKeyVal()
-			if err != nil {
-				return err
-			}
-			key := session.StrConv.Decode(bKey)
-			value := session.StrConv.Decode(bValue)
-			par.Annotations[key] = value
-		}
-		_, err = session.GetInt(4, true, true)
-		if err != nil {
-			return err
-		}
-	}
-	if session.TTCVersion < 24 {
-		return nil
-	}
+	par.IsXmlType = par.TypeName == "XMLTYPE"
 	if par.DataType == oraTypes.VECTOR {
-		par.VectorDim, err = session.GetInt(4, true, true)
-		if err != nil {
-			return err
-		}
-		par.VectorFormat, err = session.GetByte()
-		if err != nil {
-			return err
-		}
-		par.VectorFlag, err = session.GetByte()
-
 		par.VectorType = oraTypes.VECTOR_DENSE
 		if par.VectorFlag&2 == 2 {
 			par.VectorType = oraTypes.VECTOR_SPARSE
 		}
-		//colMetaData.m_vectorDim = (int)mEngine.UnmarshalUB4(bAsync, false);
-		//colMetaData.m_vectorNumFormat = (VectorNumFormat)mEngine.UnmarshalUB1(bAsync, false);
-		//colMetaData.m_vectorFlag = (int)((byte)mEngine.UnmarshalUB1(bAsync, false));
-		//colMetaData.m_vectorType = (((colMetaData.m_vectorFlag & 2) != 0) ? OracleVectorType.Sparse : OracleVectorType.Dense);
-	} else {
-		_, err = session.GetInt(4, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetInt(1, true, true)
-		if err != nil {
-			return err
-		}
-		_, err = session.GetInt(1, true, true)
-		if err != nil {
-			return err
-		}
 	}
-
-	return nil
 }
 
 // write parameter information to network session
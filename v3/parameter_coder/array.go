@@ -98,6 +98,10 @@ func (param *ArrayParameter) Encode(input interface{}, conn IConnection) (err er
 				}
 			}
 			param.UpdateParameterInfo(coder.GetParameterInfo())
+			// bool items are encoded as NUMBER before 23ai
+			if param.DataType == types.BOOLEAN && coder.GetParameterInfo().DataType == types.NUMBER {
+				param.DataType = types.NUMBER
+			}
 			if param.DataType == types.NCHAR {
 				param.MaxLen = conn.GetMaxStringLength()
 				param.MaxCharLen = param.MaxLen // / converters.MaxBytePerChar(par.CharsetID)
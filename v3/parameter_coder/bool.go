@@ -5,6 +5,12 @@ import (
 	"github.com/sijms/go-ora/v3/types"
 )
 
+// nativeBooleanSupporter is implemented by connections that report if the server
+// support SQL BOOLEAN. connections without it are treated as supporting it
+type nativeBooleanSupporter interface {
+	SupportNativeBoolean() bool
+}
+
 type BoolParameter struct {
 	BasicParameter
 }
@@ -20,8 +26,18 @@ func (param *BoolParameter) Init() {
 	param.DataType = types.BOOLEAN
 }
 
-func (param *BoolParameter) Encode(input interface{}, _ IConnection) error {
+// Encode bool, sql.NullBool and types.Bool values. if the server doesn't support SQL
+// BOOLEAN (before 23ai) go bool values are sent as NUMBER 1/0 while types.Bool is always
+// sent as BOOLEAN so it can be used as PL/SQL boolean
+func (param *BoolParameter) Encode(input interface{}, conn IConnection) error {
 	param.Init()
+	switch input.(type) {
+	case types.Bool, *types.Bool:
+	default:
+		if supporter, ok := conn.(nativeBooleanSupporter); ok && !supporter.SupportNativeBoolean() {
+			return param.encodeAsNumber(input)
+		}
+	}
 	encoder := &types.Bool{}
 	encoder.SetDataType(param.DataType)
 	err := encoder.SetValue(input)
@@ -38,7 +54,45 @@ func (param *BoolParameter) Encode(input interface{}, _ IConnection) error {
 	return nil
 }
 
+func (param *BoolParameter) encodeAsNumber(input interface{}) error {
+	temp := &types.Bool{}
+	err := temp.SetValue(input)
+	if err != nil {
+		return err
+	}
+	value, err := temp.Value()
+	if err != nil {
+		return err
+	}
+	encoder := &types.Number{}
+	encoder.SetDataType(types.NUMBER)
+	if value != nil {
+		if value.(bool) {
+			err = encoder.SetValue(1)
+		} else {
+			err = encoder.SetValue(0)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	param.DataType = types.NUMBER
+	param.MaxLen = types.MaxLenNumber
+	param.BValue = encoder.Bytes()
+	return nil
+}
+
 func (param *BoolParameter) Decode(_ IConnection) (interface{}, error) {
+	if param.DataType == types.NUMBER {
+		decoder := &types.Number{}
+		decoder.SetBytes(param.BValue)
+		decoder.SetDataType(param.DataType)
+		value, err := decoder.Value()
+		if err != nil || value == nil {
+			return nil, err
+		}
+		return value != "0", nil
+	}
 	decoder := &types.Bool{}
 	decoder.SetBytes(param.BValue)
 	decoder.SetDataType(param.DataType)
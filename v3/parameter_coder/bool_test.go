@@ -0,0 +1,162 @@
+package parameter_coder
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/types"
+)
+
+type boolConn struct {
+	IConnection
+	native bool
+}
+
+func (conn *boolConn) SupportNativeBoolean() bool {
+	return conn.native
+}
+
+func (conn *boolConn) GetSession() network.SessionReadWriter {
+	return network.NewMemorySession(nil, nil, network.SessionProperties{ClrChunkSize: 0x40})
+}
+
+func (conn *boolConn) GetParameterCoder(input interface{}) (OracleParameterCoder, error) {
+	return &BoolParameter{}, nil
+}
+
+func TestBoolParameter(t *testing.T) {
+	param := &BoolParameter{}
+	err := param.Encode(true, &boolConn{native: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param.DataType != types.BOOLEAN || !bytes.Equal(param.BValue, []byte{1, 1}) {
+		t.Errorf("expected native boolean got type: %d, value: %v", param.DataType, param.BValue)
+	}
+	// before 23ai go bool is sent as NUMBER
+	param = &BoolParameter{}
+	err = param.Encode(sql.NullBool{Bool: true, Valid: true}, &boolConn{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param.DataType != types.NUMBER {
+		t.Errorf("expected number got type: %d", param.DataType)
+	}
+	value, err := param.Decode(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != true {
+		t.Errorf("expected true got: %v", value)
+	}
+	// types.Bool is always PL/SQL boolean
+	var plBool types.Bool
+	_ = plBool.SetValue(false)
+	param = &BoolParameter{}
+	err = param.Encode(plBool, &boolConn{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param.DataType != types.BOOLEAN {
+		t.Errorf("expected boolean got type: %d", param.DataType)
+	}
+	value, err = param.Decode(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != false {
+		t.Errorf("expected false got: %v", value)
+	}
+}
+
+func TestBoolParameterConnection(t *testing.T) {
+	// connection that doesn't report boolean support is treated as 23ai
+	param := &BoolParameter{}
+	err := param.Encode(true, &vectorConn{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param.DataType != types.BOOLEAN {
+		t.Errorf("expected boolean got type: %d", param.DataType)
+	}
+}
+
+func TestBoolArrayParameter(t *testing.T) {
+	one, _ := types.NewNumber(1)
+	zero, _ := types.NewNumber(0)
+	var testScenarios = []struct {
+		name     string
+		native   bool
+		input    interface{}
+		dataType uint16
+		items    [][]byte
+	}{
+		{"native", true, []bool{true, false}, types.BOOLEAN, [][]byte{{1, 1}, {1, 0}}},
+		{"native null", true, []sql.NullBool{{Bool: true, Valid: true}, {}}, types.BOOLEAN, [][]byte{{1, 1}, nil}},
+		{"number", false, []bool{true, false}, types.NUMBER, [][]byte{one.Bytes(), zero.Bytes()}},
+		{"number null", false, []*sql.NullBool{{}, {Bool: false, Valid: true}}, types.NUMBER, [][]byte{nil, zero.Bytes()}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			param := &ArrayParameter{}
+			err := param.Encode(tt.input, &boolConn{native: tt.native})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if param.DataType != tt.dataType || param.ArraySize != len(tt.items) {
+				t.Fatalf("expected type %d with %d items got type: %d, size: %d", tt.dataType, len(tt.items), param.DataType, param.ArraySize)
+			}
+			session := network.NewMemorySession(param.BValue, nil, network.SessionProperties{ClrChunkSize: 0x40})
+			if _, err = session.GetInt(4, true, true); err != nil {
+				t.Fatal(err)
+			}
+			for i, expected := range tt.items {
+				item, err := session.GetClr()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(item, expected) {
+					t.Errorf("item %d: expected %v got: %v", i, expected, item)
+				}
+			}
+		})
+	}
+}
+
+func TestBoolUDTAttribute(t *testing.T) {
+	prop := network.SessionProperties{ClrChunkSize: 0x40}
+	for _, native := range []bool{true, false} {
+		param := &BoolParameter{}
+		param.SetAsUDTPar()
+		err := param.Encode(true, &boolConn{native: native})
+		if err != nil {
+			t.Fatal(err)
+		}
+		session := network.NewMemorySession(nil, nil, prop)
+		err = param.Write(session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// attribute is written as fixed CLR of the encoded value
+		reader := network.NewMemorySession(session.GetWriteBuffer(), nil, prop)
+		value, err := reader.GetFixedClr()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(value, param.BValue) {
+			t.Errorf("expected attribute %v got: %v", param.BValue, value)
+		}
+		decoder := &BoolParameter{}
+		decoder.SetParameterInfo(param.GetParameterInfo())
+		decoder.SetBytes(value)
+		output, err := decoder.Decode(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output != true {
+			t.Errorf("native: %v expected true got: %v", native, output)
+		}
+	}
+}
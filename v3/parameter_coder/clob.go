@@ -1,6 +1,7 @@
 package parameter_coder
 
 import (
+	"github.com/sijms/go-ora/v3/converters"
 	"github.com/sijms/go-ora/v3/types"
 )
 
@@ -107,16 +108,20 @@ func (param *ClobParameter) Decode(conn IConnection) (interface{}, error) {
 	decoder := &types.Clob{}
 	decoder.SetStreamer(param.streamer)
 	decoder.SetBytes(param.BValue)
-	locator := param.streamer.GetLocator()
 	var err error
+	decoder.Conv, err = clobConverter(conn, param.streamer, param.CharsetID, param.CharsetForm)
+	return decoder, err
+}
+
+// clobConverter return string converter for clob data. variable width clobs are
+// stored in utf-16
+func clobConverter(conn IConnection, streamer types.LobStreamer, charsetID, charsetForm int) (converters.IStringConverter, error) {
+	locator := streamer.GetLocator()
 	if locator.IsVarWidthChar() {
-		if param.streamer.DatabaseVersionNumber() < 10200 && locator.IsLittleEndian() {
-			decoder.Conv, err = conn.GetStringCoder(2002, 0)
-		} else {
-			decoder.Conv, err = conn.GetStringCoder(2000, 0)
+		if streamer.DatabaseVersionNumber() < 10200 && locator.IsLittleEndian() {
+			return conn.GetStringCoder(2002, 0)
 		}
-	} else {
-		decoder.Conv, err = conn.GetStringCoder(param.CharsetID, param.CharsetForm)
+		return conn.GetStringCoder(2000, 0)
 	}
-	return decoder, err
+	return conn.GetStringCoder(charsetID, charsetForm)
 }
@@ -0,0 +1,94 @@
+package parameter_coder
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/types"
+)
+
+var vectorProp = network.SessionProperties{ClrChunkSize: 0x40}
+
+type vectorConn struct {
+	IConnection
+}
+
+func (conn *vectorConn) GetSession() network.SessionReadWriter {
+	return network.NewMemorySession(nil, nil, vectorProp)
+}
+
+func (conn *vectorConn) GetParameterCoder(input interface{}) (OracleParameterCoder, error) {
+	return &VectorParameter{}, nil
+}
+
+func TestVectorArrayParameter(t *testing.T) {
+	var testScenarios = []struct {
+		name  string
+		input interface{}
+	}{
+		{"sparse", []types.SparseVector{
+			{Dimensions: 10, Indices: []uint32{1, 9}, Values: []float32{0.5, -1}},
+			{Dimensions: 4, Indices: []uint32{0}, Values: []int8{-7}},
+		}},
+		{"sparse pointers", []*types.SparseVector{
+			{Dimensions: 100, Indices: []uint32{50}, Values: []float64{2.5}},
+		}},
+		{"binary", []types.BinaryVector{{0xA5}, {0x0F, 0xF0}}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			param := &ArrayParameter{}
+			err := param.Encode(tt.input, &vectorConn{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			length := reflect.ValueOf(tt.input).Len()
+			if param.DataType != types.VECTOR || param.ArraySize != length {
+				t.Fatalf("expected VECTOR array of %d items got type: %d, size: %d", length, param.DataType, param.ArraySize)
+			}
+			session := network.NewMemorySession(param.BValue, nil, vectorProp)
+			size, err := session.GetInt(4, true, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != length {
+				t.Fatalf("expected array size %d got: %d", length, size)
+			}
+			for i := 0; i < length; i++ {
+				item := reflect.ValueOf(tt.input).Index(i).Interface()
+				expected, err := types.CreateVector(item)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// each item is written as locator followed by vector image
+				if _, err = session.GetInt(4, true, true); err != nil {
+					t.Fatal(err)
+				}
+				if _, err = session.GetClr(); err != nil {
+					t.Fatal(err)
+				}
+				image, err := session.GetClr()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(image, expected.Bytes()) {
+					t.Errorf("item %d: expected image %v got: %v", i, expected.Bytes(), image)
+				}
+				vector := &types.Vector{}
+				vector.SetBytes(image)
+				value, err := vector.Value()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sparse, ok := item.(*types.SparseVector); ok {
+					item = *sparse
+				}
+				if !reflect.DeepEqual(value, item) {
+					t.Errorf("item %d: expected %#v got: %#v", i, item, value)
+				}
+			}
+		})
+	}
+}
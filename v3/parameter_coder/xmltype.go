@@ -0,0 +1,169 @@
+package parameter_coder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/types"
+)
+
+const (
+	xmlTypeFlagLob       uint32 = 0x1
+	xmlTypeFlagString    uint32 = 0x4
+	xmlTypeFlagSkipNext4 uint32 = 0x100000
+	objNoPrefixSeg       byte   = 0x4
+	objLongLength        byte   = 0xFE
+)
+
+var errXmlTypeAttribute = errors.New("XMLTYPE is not supported inside object or collection types")
+
+// XmlTypeParameter encode XMLTYPE input as CLOB and decode XMLTYPE image
+// returned for columns and output parameters
+type XmlTypeParameter struct {
+	ClobParameter
+}
+
+func (param *XmlTypeParameter) Copy() OracleParameterCoder {
+	ret := new(XmlTypeParameter)
+	*ret = *param
+	return ret
+}
+
+func (param *XmlTypeParameter) Encode(input interface{}, conn IConnection) error {
+	if param.IsUDTPar || param.IsArrayPar {
+		return errXmlTypeAttribute
+	}
+	encoder := &types.XmlType{}
+	err := encoder.SetValue(input)
+	if err != nil {
+		return err
+	}
+	if encoder.IsLob() {
+		return param.ClobParameter.Encode(encoder.Clob, conn)
+	}
+	value, err := encoder.Value()
+	if err != nil {
+		return err
+	}
+	return param.ClobParameter.Encode(value, conn)
+}
+
+func (param *XmlTypeParameter) Read(session network.SessionReader) error {
+	if param.DataType != types.XMLType {
+		return param.ClobParameter.Read(session)
+	}
+	if param.IsUDTPar || param.IsArrayPar {
+		return errXmlTypeAttribute
+	}
+	var err error
+	_, err = session.GetDlc() // toid
+	if err != nil {
+		return err
+	}
+	_, err = session.GetBytes(3) // oid, snapshot and version
+	if err != nil {
+		return err
+	}
+	var size int
+	size, err = session.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	_, err = session.GetBytes(2) // flags
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		_, err = session.GetBytes(2)
+		param.BValue = nil
+		return err
+	}
+	param.BValue, err = param.BasicRead(session)
+	return err
+}
+
+func (param *XmlTypeParameter) Decode(conn IConnection) (interface{}, error) {
+	if param.DataType != types.XMLType {
+		temp, err := param.ClobParameter.Decode(conn)
+		if err != nil {
+			return nil, err
+		}
+		return &types.XmlType{Clob: *temp.(*types.Clob)}, nil
+	}
+	if len(param.BValue) == 0 {
+		return nil, nil
+	}
+	flag, data, err := decodeXmlTypeImage(param.BValue)
+	if err != nil {
+		return nil, err
+	}
+	ret := &types.XmlType{}
+	if flag&xmlTypeFlagString > 0 {
+		ret.Conv, err = conn.GetStringCoder(0, 1)
+		if err != nil {
+			return nil, err
+		}
+		ret.SetBytes(data)
+		return ret, nil
+	}
+	if flag&xmlTypeFlagLob > 0 {
+		streamer := conn.NewLobStreamer()
+		streamer.SetLocator(data)
+		ret.SetStreamer(streamer)
+		ret.Conv, err = clobConverter(conn, streamer, 0, 1)
+		return ret, err
+	}
+	// binary XML (CSX) is encoded by server token tables
+	return nil, fmt.Errorf("unsupported XMLTYPE image flag: 0x%X. binary XML should be selected as text with XMLSERIALIZE(DOCUMENT col AS CLOB) or col.getClobVal()", flag)
+}
+
+// decodeXmlTypeImage return flag and data of pickled XMLTYPE image.
+// data is either the document text or CLOB locator according to flag
+func decodeXmlTypeImage(image []byte) (flag uint32, data []byte, err error) {
+	errImage := errors.New("invalid XMLTYPE image")
+	readLength := func(pos int) (int, int, error) {
+		if pos >= len(image) {
+			return 0, pos, errImage
+		}
+		if image[pos] != objLongLength {
+			return int(image[pos]), pos + 1, nil
+		}
+		if pos+5 > len(image) {
+			return 0, pos, errImage
+		}
+		return int(binary.BigEndian.Uint32(image[pos+1:])), pos + 5, nil
+	}
+	if len(image) < 3 {
+		return 0, nil, errImage
+	}
+	imageFlags := image[0]
+	// skip flags, version and image length
+	_, pos, err := readLength(2)
+	if err != nil {
+		return 0, nil, err
+	}
+	if imageFlags&objNoPrefixSeg == 0 {
+		var prefixLen int
+		prefixLen, pos, err = readLength(pos)
+		if err != nil {
+			return 0, nil, err
+		}
+		pos += prefixLen
+	}
+	// skip xml header byte
+	pos++
+	if pos+4 > len(image) {
+		return 0, nil, errImage
+	}
+	flag = binary.BigEndian.Uint32(image[pos:])
+	pos += 4
+	if flag&xmlTypeFlagSkipNext4 > 0 {
+		pos += 4
+	}
+	if pos > len(image) {
+		return 0, nil, errImage
+	}
+	return flag, image[pos:], nil
+}
@@ -0,0 +1,35 @@
+package parameter_coder
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeXmlTypeImage(t *testing.T) {
+	text := []byte("<a>1</a>")
+	// image with prefix segment and string flag
+	image := []byte{0x80, 0x01, 0x00, 0x02, 0xAA, 0xBB, 0x01, 0x00, 0x00, 0x00, 0x04}
+	image = append(image, text...)
+	flag, data, err := decodeXmlTypeImage(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag&xmlTypeFlagString == 0 || !bytes.Equal(data, text) {
+		t.Errorf("unexpected image decode: flag=0x%X, data=%s", flag, data)
+	}
+	// image without prefix segment, lob flag and skip next 4 bytes
+	locator := []byte{0, 0x54, 0, 1, 2, 3}
+	image = []byte{0x84, 0x01, 0xFE, 0, 0, 0, 0x20, 0x01, 0x00, 0x10, 0x00, 0x01, 0, 0, 0, 0}
+	image = append(image, locator...)
+	flag, data, err = decodeXmlTypeImage(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag&xmlTypeFlagLob == 0 || !bytes.Equal(data, locator) {
+		t.Errorf("unexpected image decode: flag=0x%X, data=%v", flag, data)
+	}
+	_, _, err = decodeXmlTypeImage([]byte{0x84, 0x01})
+	if err == nil {
+		t.Error("expected error for short image")
+	}
+}
@@ -2,6 +2,7 @@ package go_ora
 
 import (
 	"database/sql"
+	"errors"
 	"reflect"
 
 	"github.com/sijms/go-ora/v3/converters"
@@ -635,6 +636,27 @@ func (par *ParameterInfo) init() {
 	par.oPrimValue = nil
 }
 
+// encodeXmlTypeOutput define output parameter as SYS.XMLTYPE object. input XMLTYPE is
+// sent as CLOB so in/out parameters are not supported
+func (par *ParameterInfo) encodeXmlTypeOutput(connection *Connection) (err error) {
+	if par.Direction == InOut {
+		return errors.New("XMLTYPE in/out parameter is not supported, use separate input and output parameters")
+	}
+	par.ToID, err = connection.getXMLTypeTOID()
+	if err != nil {
+		return err
+	}
+	par.DataType = oraTypes.XMLType
+	par.TypeName = "XMLTYPE"
+	par.IsXmlType = true
+	par.Version = 1
+	par.MaxLen = 2000
+	par.CharsetID = 0
+	par.CharsetForm = 0
+	par.BValue = nil
+	return nil
+}
+
 func (par *ParameterInfo) encodeValue(size int64, connection *Connection) error {
 	par.init()
 	var err error
@@ -706,6 +728,12 @@ func (par *ParameterInfo) encodeValue(size int64, connection *Connection) error
 		return err
 	}
 	par.UpdateParameterInfo(par.encoder.GetParameterInfo())
+	if _, ok := par.encoder.(*parameter_coder.XmlTypeParameter); ok && par.Direction != Input {
+		err = par.encodeXmlTypeOutput(connection)
+		if err != nil {
+			return err
+		}
+	}
 	if par.MaxLen < size {
 		par.MaxLen = size
 	}
@@ -8,11 +8,9 @@ import (
 	"github.com/sijms/go-ora/v3/network"
 	"github.com/sijms/go-ora/v3/trace"
 	types "github.com/sijms/go-ora/v3/types"
-	"github.com/sijms/go-ora/v3/utils"
 
 	"io"
 	"reflect"
-	"strings"
 )
 
 type Row []driver.Value
@@ -203,8 +201,23 @@ func (resultSet *ResultSet) setObjectValue(obj reflect.Value, colIndex int) erro
 	return types.RCopy(obj, resultSet.currentRow[colIndex])
 }
 
+// columnNames return names of columns starting from index
+func (resultSet *ResultSet) columnNames(index int) []string {
+	cols := (*resultSet.cols)[index:]
+	ret := make([]string, len(cols))
+	for i, col := range cols {
+		ret[i] = col.Name
+	}
+	return ret
+}
+
 // Scan act like scan in sql package return row values to dest variable pointers
 func (resultSet *ResultSet) Scan(dest ...interface{}) error {
+	return resultSet.scan(defaultStructMapper, dest...)
+}
+
+// scan row values to dest with struct fields matched by mapper
+func (resultSet *ResultSet) scan(mapper *StructMapper, dest ...interface{}) error {
 	if resultSet.lastErr != nil {
 		return resultSet.lastErr
 	}
@@ -221,31 +234,25 @@ func (resultSet *ResultSet) Scan(dest ...interface{}) error {
 		}
 		destTyp = destTyp.Elem()
 
-		// if struct and tag
-		if destTyp.Kind() == reflect.Struct {
-			processedFields := 0
-			for x := 0; x < destTyp.NumField(); x++ {
-				if srcIndex+processedFields >= len(resultSet.currentRow) {
-					continue
-				}
-				field := destTyp.Field(x)
-				name, _, _, _ := utils.ExtractTag(field.Tag.Get("db"))
-				if len(name) == 0 {
-					continue
-				}
-				colInfo := (*resultSet.cols)[srcIndex+processedFields]
-				if !strings.EqualFold(colInfo.Name, name) {
-					continue
+		// struct with fields matched to columns by name. the last destination take
+		// all remaining columns otherwise the struct take consecutive matched columns
+		if isNestedStruct(destTyp) {
+			columns := resultSet.columnNames(srcIndex)
+			plan := mapper.scanPlan(destTyp, columns)
+			count := len(columns)
+			if destIndex < len(dest)-1 {
+				count = 0
+				for count < len(plan.fields) && plan.fields[count] != nil {
+					count++
 				}
-				err := resultSet.setObjectValue(reflect.ValueOf(dest[destIndex]).Elem().Field(x), srcIndex+processedFields)
-				// err := setFieldValue(reflect.ValueOf(dest[destIndex]).Elem().Field(x), colInfo.cusType, dataSet.currentRow[srcIndex+processedFields])
+				plan = mapper.scanPlan(destTyp, columns[:count])
+			}
+			if plan.matched > 0 {
+				err := plan.scanStruct(reflect.ValueOf(dest[destIndex]).Elem(), resultSet.currentRow[srcIndex:srcIndex+count])
 				if err != nil {
 					return err
 				}
-				processedFields++
-			}
-			if processedFields != 0 {
-				srcIndex = srcIndex + processedFields - 1
+				srcIndex = srcIndex + count - 1
 				continue
 			}
 		}
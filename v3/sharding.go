@@ -0,0 +1,135 @@
+package go_ora
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/sijms/go-ora/v3/configurations"
+)
+
+// WithShardingKey sets sharding key and super sharding key (optional) sent in
+// CONNECT_DATA of each new connection so the shard director route it to the
+// shard holding the key
+func (connector *OracleConnector) WithShardingKey(key, superKey *configurations.ShardingKey) {
+	connector.shardingKey = key
+	connector.superShardingKey = superKey
+}
+
+// ShardPool keep separate database/sql pool for each sharding key so a
+// connection is reused only for requests of the same key. number of per-key
+// pools is limited (default 100) and the least recently used pool is closed
+// when a new key exceed the limit
+//
+//	pool := go_ora.NewShardPool(connector, func(db *sql.DB) { db.SetMaxOpenConns(10) })
+//	defer pool.Close()
+//	key, _ := configurations.NewShardingKey(customerID)
+//	conn, err := pool.Conn(ctx, key, nil)
+type ShardPool struct {
+	connector *OracleConnector
+	configure func(db *sql.DB)
+	mu        sync.Mutex
+	pools     map[shardPoolKey]*shardPoolEntry
+	maxPools  int
+	useCount  uint64
+	closed    bool
+}
+
+type shardPoolKey struct {
+	key, superKey string
+}
+
+type shardPoolEntry struct {
+	db      *sql.DB
+	lastUse uint64
+}
+
+const defaultMaxShardPools = 100
+
+// NewShardPool create pool of connections opened by connector. configure is
+// optional and called for each new per-key *sql.DB to set its pool limits
+func NewShardPool(connector *OracleConnector, configure func(db *sql.DB)) *ShardPool {
+	return &ShardPool{
+		connector: connector,
+		configure: configure,
+		pools:     map[shardPoolKey]*shardPoolEntry{},
+		maxPools:  defaultMaxShardPools,
+	}
+}
+
+// SetMaxPools set maximum number of per-key pools (0 for no limit). evicted
+// pool is closed: connections in use are closed when they are released
+func (pool *ShardPool) SetMaxPools(maxPools int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.maxPools = maxPools
+	pool.evict()
+}
+
+// evict close least recently used pools above the limit
+func (pool *ShardPool) evict() {
+	for pool.maxPools > 0 && len(pool.pools) > pool.maxPools {
+		var oldKey shardPoolKey
+		var oldEntry *shardPoolEntry
+		for key, entry := range pool.pools {
+			if oldEntry == nil || entry.lastUse < oldEntry.lastUse {
+				oldKey, oldEntry = key, entry
+			}
+		}
+		delete(pool.pools, oldKey)
+		_ = oldEntry.db.Close()
+	}
+}
+
+// DB return pool of connections routed by key and superKey (may be nil)
+func (pool *ShardPool) DB(key, superKey *configurations.ShardingKey) (*sql.DB, error) {
+	if key == nil && superKey == nil {
+		return nil, errors.New("sharding key or super sharding key is required")
+	}
+	poolKey := shardPoolKey{key: key.String(), superKey: superKey.String()}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.closed {
+		return nil, errors.New("shard pool is closed")
+	}
+	pool.useCount++
+	if entry, ok := pool.pools[poolKey]; ok {
+		entry.lastUse = pool.useCount
+		return entry.db, nil
+	}
+	temp := *pool.connector
+	temp.shardingKey = key
+	temp.superShardingKey = superKey
+	db := sql.OpenDB(&temp)
+	if pool.configure != nil {
+		pool.configure(db)
+	}
+	pool.pools[poolKey] = &shardPoolEntry{db: db, lastUse: pool.useCount}
+	pool.evict()
+	return db, nil
+}
+
+// Conn return connection routed by key and superKey (may be nil)
+func (pool *ShardPool) Conn(ctx context.Context, key, superKey *configurations.ShardingKey) (*sql.Conn, error) {
+	db, err := pool.DB(key, superKey)
+	if err != nil {
+		return nil, err
+	}
+	return db.Conn(ctx)
+}
+
+// Close close all per-key pools
+func (pool *ShardPool) Close() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.closed = true
+	var err error
+	for key, entry := range pool.pools {
+		if tempErr := entry.db.Close(); tempErr != nil && err == nil {
+			err = tempErr
+		}
+		delete(pool.pools, key)
+	}
+	return err
+}
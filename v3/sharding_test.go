@@ -0,0 +1,47 @@
+package go_ora_test
+
+import (
+	"testing"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/configurations"
+)
+
+func TestShardPool(t *testing.T) {
+	server := newServer(t)
+	connector := go_ora.NewConnector(server.URL(nil)).(*go_ora.OracleConnector)
+	pool := go_ora.NewShardPool(connector, nil)
+	defer pool.Close()
+	pool.SetMaxPools(2)
+	keys := make([]*configurations.ShardingKey, 3)
+	for i := range keys {
+		keys[i], _ = configurations.NewShardingKey(i + 1)
+	}
+	first, err := pool.DB(keys[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := pool.DB(keys[1], nil)
+	if temp, _ := pool.DB(keys[0], nil); temp != first {
+		t.Error("expected the same pool for the same key")
+	}
+	// third key evict the least recently used pool
+	if _, err = pool.DB(keys[2], nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = second.Ping(); err == nil {
+		t.Error("expected evicted pool to be closed")
+	}
+	if err = first.Ping(); err != nil {
+		t.Errorf("expected recently used pool to stay open: %v", err)
+	}
+	// key and super key are not joined so they can't collide
+	key1, _ := configurations.NewShardingKey("a\x00")
+	key2, _ := configurations.NewShardingKey("a")
+	superKey, _ := configurations.NewShardingKey("\x00")
+	db1, _ := pool.DB(key1, nil)
+	db2, _ := pool.DB(key2, superKey)
+	if db1 == db2 {
+		t.Error("expected separate pools for different keys")
+	}
+}
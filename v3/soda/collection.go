@@ -0,0 +1,332 @@
+package soda
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/types"
+	"github.com/sijms/go-ora/v3/types/oson"
+)
+
+// timestamp format used by soda for creation and last modified time
+const timeFormat = "2006-01-02T15:04:05.000000Z"
+
+type Collection struct {
+	Name     string
+	Metadata *Metadata
+	db       Queryer
+}
+
+// IndexSpec describe soda index. index with Fields create functional index on
+// these paths while index without fields create json search index
+type IndexSpec struct {
+	Name      string       `json:"name"`
+	Fields    []IndexField `json:"fields,omitempty"`
+	Unique    bool         `json:"unique,omitempty"`
+	Language  string       `json:"language,omitempty"`
+	Dataguide string       `json:"dataguide,omitempty"`
+	SearchOn  string       `json:"search_on,omitempty"`
+}
+
+type IndexField struct {
+	Path      string `json:"path"`
+	DataType  string `json:"datatype,omitempty"`
+	MaxLength int    `json:"maxlength,omitempty"`
+	Order     string `json:"order,omitempty"`
+}
+
+// sqlArgs collect statement arguments and return their placeholders
+type sqlArgs []interface{}
+
+func (args *sqlArgs) add(value interface{}) string {
+	*args = append(*args, value)
+	return ":" + strconv.Itoa(len(*args))
+}
+
+// Find start operation on collection documents
+func (coll *Collection) Find() *Operation {
+	return &Operation{coll: coll}
+}
+
+// Get return document with key or ErrDocumentNotFound
+func (coll *Collection) Get(ctx context.Context, key string) (*Document, error) {
+	return coll.Find().Key(key).GetOne(ctx)
+}
+
+// Replace overwrite document with key. return false if document is not found
+func (coll *Collection) Replace(ctx context.Context, key string, doc *Document) (bool, error) {
+	return coll.Find().Key(key).ReplaceOne(ctx, doc)
+}
+
+// Remove delete document with key. return false if document is not found
+func (coll *Collection) Remove(ctx context.Context, key string) (bool, error) {
+	count, err := coll.Find().Key(key).Remove(ctx)
+	return count > 0, err
+}
+
+// Insert add document to collection. document key is assigned according to
+// key assignment method of the collection (client assigned keys should be set
+// in doc.Key) and Key, Version, CreatedOn and LastModified are updated
+func (coll *Collection) Insert(ctx context.Context, doc *Document) error {
+	meta := coll.Metadata
+	if meta.ReadOnly {
+		return ErrReadOnly
+	}
+	key, err := coll.newKey(ctx, doc)
+	if err != nil {
+		return err
+	}
+	var (
+		columns []string
+		values  []string
+		args    sqlArgs
+	)
+	if meta.keyMethod() != KeyEmbeddedOID {
+		columns = append(columns, quote(meta.KeyColumn.Name))
+		values = append(values, coll.keyPlaceholder(&args, key))
+	}
+	content, err := coll.contentValue(doc)
+	if err != nil {
+		return err
+	}
+	columns = append(columns, quote(meta.ContentColumn.Name))
+	values = append(values, args.add(content))
+	now := time.Now().UTC()
+	version, err := coll.newVersion(doc, now)
+	if err != nil {
+		return err
+	}
+	if meta.versionMethod() != VersionNone {
+		columns = append(columns, quote(meta.VersionColumn.Name))
+		values = append(values, args.add(version))
+	}
+	if meta.CreationTimeColumn != nil {
+		columns = append(columns, quote(meta.CreationTimeColumn.Name))
+		values = append(values, args.add(now))
+	}
+	if meta.LastModifiedColumn != nil {
+		columns = append(columns, quote(meta.LastModifiedColumn.Name))
+		values = append(values, args.add(now))
+	}
+	if meta.MediaTypeColumn != nil {
+		columns = append(columns, quote(meta.MediaTypeColumn.Name))
+		values = append(values, args.add(coll.mediaType(doc)))
+	}
+	_, err = coll.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", meta.table(),
+		strings.Join(columns, ", "), strings.Join(values, ", ")), args...)
+	if err != nil {
+		return err
+	}
+	doc.Key = key
+	doc.Version = version
+	doc.CreatedOn = now.Format(timeFormat)
+	doc.LastModified = doc.CreatedOn
+	return nil
+}
+
+// CreateIndex create index on collection using DBMS_SODA
+func (coll *Collection) CreateIndex(ctx context.Context, spec *IndexSpec) error {
+	if spec == nil || len(spec.Name) == 0 {
+		return fmt.Errorf("soda: index spec should have a name")
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	_, err = coll.db.ExecContext(ctx, `DECLARE
+	l_coll SODA_COLLECTION_T;
+	l_status NUMBER;
+BEGIN
+	l_coll := DBMS_SODA.OPEN_COLLECTION(:1);
+	l_status := l_coll.CREATE_INDEX(:2);
+END;`, coll.Name, string(data))
+	return err
+}
+
+// DropIndex drop index by name. return false if index doesn't exist. force drop
+// index even if it is in use
+func (coll *Collection) DropIndex(ctx context.Context, name string, force bool) (bool, error) {
+	var status int64
+	forceValue := 0
+	if force {
+		forceValue = 1
+	}
+	_, err := coll.db.ExecContext(ctx, `DECLARE
+	l_coll SODA_COLLECTION_T;
+BEGIN
+	l_coll := DBMS_SODA.OPEN_COLLECTION(:1);
+	:2 := l_coll.DROP_INDEX(:3, :4 = 1);
+END;`, coll.Name, go_ora.Out{Dest: &status}, name, forceValue)
+	if err != nil {
+		return false, err
+	}
+	return status == 1, nil
+}
+
+// newKey return key of new document according to key assignment method
+func (coll *Collection) newKey(ctx context.Context, doc *Document) (string, error) {
+	meta := coll.Metadata
+	switch meta.keyMethod() {
+	case KeyClient:
+		if len(doc.Key) == 0 {
+			return "", fmt.Errorf("soda: collection %s use client assigned keys", coll.Name)
+		}
+		return doc.Key, nil
+	case KeyUUID:
+		return newUUID()
+	case KeyGUID:
+		var key string
+		err := coll.db.QueryRowContext(ctx, "SELECT RAWTOHEX(SYS_GUID()) FROM DUAL").Scan(&key)
+		return key, err
+	case KeySequence:
+		var key string
+		err := coll.db.QueryRowContext(ctx, fmt.Sprintf("SELECT TO_CHAR(%s.NEXTVAL) FROM DUAL",
+			quote(meta.KeyColumn.SequenceName))).Scan(&key)
+		return key, err
+	case KeyEmbeddedOID:
+		// key is virtual column computed from _id field of the content
+		var temp struct {
+			ID interface{} `json:"_id"`
+		}
+		err := json.Unmarshal(doc.Content, &temp)
+		if err != nil {
+			return "", err
+		}
+		if temp.ID == nil {
+			return "", fmt.Errorf("soda: collection %s require _id field in document", coll.Name)
+		}
+		return fmt.Sprint(temp.ID), nil
+	}
+	return "", fmt.Errorf("soda: unsupported key assignment method: %s", meta.KeyColumn.AssignmentMethod)
+}
+
+// newVersion return version of document content. sequential version start with 1
+// and is incremented by the server on replace
+func (coll *Collection) newVersion(doc *Document, now time.Time) (string, error) {
+	switch coll.Metadata.versionMethod() {
+	case VersionNone:
+		return "", nil
+	case VersionUUID:
+		return newUUID()
+	case VersionSHA256:
+		hash := sha256.Sum256(doc.Content)
+		return strings.ToUpper(hex.EncodeToString(hash[:])), nil
+	case VersionMD5:
+		hash := md5.Sum(doc.Content)
+		return strings.ToUpper(hex.EncodeToString(hash[:])), nil
+	case VersionTimestamp:
+		return strconv.FormatInt(now.UnixMicro(), 10), nil
+	case VersionSequential:
+		return "1", nil
+	}
+	return "", fmt.Errorf("soda: unsupported version method: %s", coll.Metadata.VersionColumn.Method)
+}
+
+// contentValue return document content as value suitable for content column type
+func (coll *Collection) contentValue(doc *Document) (interface{}, error) {
+	switch coll.Metadata.contentType() {
+	case "BLOB", "RAW":
+		return doc.Content, nil
+	case "JSON":
+		if !doc.isJson() {
+			return nil, fmt.Errorf("soda: collection %s accept only json documents", coll.Name)
+		}
+		value, err := doc.jsonValue()
+		if err != nil {
+			return nil, err
+		}
+		ret := &types.Json{Coder: &oson.Oson{}}
+		err = ret.SetValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return ret, nil
+	default:
+		if !doc.isJson() {
+			return nil, fmt.Errorf("soda: collection %s accept only json documents", coll.Name)
+		}
+		return string(doc.Content), nil
+	}
+}
+
+func (coll *Collection) mediaType(doc *Document) string {
+	if len(doc.MediaType) == 0 {
+		return "application/json"
+	}
+	return doc.MediaType
+}
+
+// keyPlaceholder bind key and return its sql expression
+func (coll *Collection) keyPlaceholder(args *sqlArgs, key string) string {
+	if coll.Metadata.isRawKey() {
+		return "HEXTORAW(" + args.add(key) + ")"
+	}
+	return args.add(key)
+}
+
+// contentExpression return content column as json expression for JSON_EXISTS
+// and JSON_VALUE
+func (coll *Collection) contentExpression() string {
+	column := quote(coll.Metadata.ContentColumn.Name)
+	switch coll.Metadata.contentType() {
+	case "BLOB", "RAW":
+		return column + " FORMAT JSON"
+	}
+	return column
+}
+
+// selectList return key, content, version, creation time, last modified and
+// media type expressions in this order
+func (coll *Collection) selectList() string {
+	meta := coll.Metadata
+	parts := make([]string, 0, 6)
+	if meta.isRawKey() {
+		parts = append(parts, "RAWTOHEX("+quote(meta.KeyColumn.Name)+")")
+	} else {
+		parts = append(parts, "TO_CHAR("+quote(meta.KeyColumn.Name)+")")
+	}
+	if meta.contentType() == "JSON" {
+		parts = append(parts, "JSON_SERIALIZE("+quote(meta.ContentColumn.Name)+" RETURNING CLOB)")
+	} else {
+		parts = append(parts, quote(meta.ContentColumn.Name))
+	}
+	if meta.versionMethod() != VersionNone {
+		parts = append(parts, "TO_CHAR("+quote(meta.VersionColumn.Name)+")")
+	} else {
+		parts = append(parts, "NULL")
+	}
+	for _, column := range []*Column{meta.CreationTimeColumn, meta.LastModifiedColumn} {
+		if column != nil {
+			parts = append(parts, "TO_CHAR("+quote(column.Name)+`, 'YYYY-MM-DD"T"HH24:MI:SS.FF6"Z"')`)
+		} else {
+			parts = append(parts, "NULL")
+		}
+	}
+	if meta.MediaTypeColumn != nil {
+		parts = append(parts, quote(meta.MediaTypeColumn.Name))
+	} else {
+		parts = append(parts, "NULL")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// newUUID return random uuid as 32 uppercase hex digits
+func newUUID() (string, error) {
+	data := make([]byte, 16)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+	data[6] = (data[6] & 0x0F) | 0x40
+	data[8] = (data[8] & 0x3F) | 0x80
+	return strings.ToUpper(hex.EncodeToString(data)), nil
+}
@@ -0,0 +1,296 @@
+package soda_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/oratest"
+	"github.com/sijms/go-ora/v3/soda"
+)
+
+const testDescriptor = `{"tableName": "EMP",
+"keyColumn": {"name": "ID", "sqlType": "VARCHAR2", "assignmentMethod": "CLIENT"},
+"contentColumn": {"name": "JSON_DOCUMENT", "sqlType": "VARCHAR2"},
+"versionColumn": {"name": "VERSION", "method": "%s"},
+"lastModifiedColumn": {"name": "LAST_MODIFIED"},
+"creationTimeColumn": {"name": "CREATED_ON"}}`
+
+const selectList = `SELECT TO_CHAR("ID"), "JSON_DOCUMENT", TO_CHAR("VERSION"), ` +
+	`TO_CHAR("CREATED_ON", 'YYYY-MM-DD"T"HH24:MI:SS.FF6"Z"'), ` +
+	`TO_CHAR("LAST_MODIFIED", 'YYYY-MM-DD"T"HH24:MI:SS.FF6"Z"'), NULL FROM "EMP"`
+
+// sodaServer answer collection descriptor query and pass other statements to
+// handler. requests are recorded with their bind values
+type sodaServer struct {
+	*oratest.Server
+	mu       sync.Mutex
+	requests []*oratest.Request
+}
+
+func newSodaServer(t *testing.T, versionMethod string, handler oratest.HandlerFunc) *sodaServer {
+	t.Helper()
+	server, err := oratest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	ret := &sodaServer{Server: server}
+	descriptor := strings.Replace(testDescriptor, "%s", versionMethod, 1)
+	server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+		if strings.Contains(req.SQL, "USER_SODA_COLLECTIONS") {
+			return oratest.RowsResult([]string{"JSON_DESCRIPTOR"}, []interface{}{descriptor})
+		}
+		ret.mu.Lock()
+		ret.requests = append(ret.requests, req)
+		ret.mu.Unlock()
+		return handler(req)
+	})
+	return ret
+}
+
+// last return the last recorded request
+func (server *sodaServer) last(t *testing.T) *oratest.Request {
+	t.Helper()
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.requests) == 0 {
+		t.Fatal("no statement is received")
+	}
+	return server.requests[len(server.requests)-1]
+}
+
+func openCollection(t *testing.T, server *sodaServer) *soda.Collection {
+	t.Helper()
+	db, err := sql.Open("oracle", server.URL(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	coll, err := soda.New(db).OpenCollection(context.Background(), "emp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return coll
+}
+
+func checkRequest(t *testing.T, req *oratest.Request, sql string, args ...interface{}) {
+	t.Helper()
+	if req.SQL != sql {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", sql, req.SQL)
+	}
+	if len(args) > len(req.Args) {
+		t.Fatalf("expected %d binds got: %v", len(args), req.Args)
+	}
+	for i, arg := range args {
+		if arg != nil && !reflect.DeepEqual(arg, req.Args[i]) {
+			t.Errorf("bind %d: expected %v (%T) got %v (%T)", i+1, arg, arg, req.Args[i], req.Args[i])
+		}
+	}
+}
+
+func TestCollectionInsertGet(t *testing.T) {
+	content := `{"name":"Ali"}`
+	hash := sha256.Sum256([]byte(content))
+	version := strings.ToUpper(hex.EncodeToString(hash[:]))
+	server := newSodaServer(t, "SHA256", func(req *oratest.Request) *oratest.Result {
+		switch {
+		case strings.HasPrefix(req.SQL, "INSERT"):
+			return oratest.ExecResult(1)
+		case strings.HasPrefix(req.SQL, selectList) && len(req.Args) > 0 && req.Args[0] == "k1":
+			return oratest.RowsResult([]string{"ID", "CONTENT", "VERSION", "CREATED_ON", "LAST_MODIFIED", "MEDIA_TYPE"},
+				[]interface{}{"k1", content, version, "2024-01-02T03:04:05.000000Z", "2024-01-02T03:04:05.000000Z", nil})
+		case strings.HasPrefix(req.SQL, selectList):
+			return oratest.RowsResult([]string{"ID", "CONTENT", "VERSION", "CREATED_ON", "LAST_MODIFIED", "MEDIA_TYPE"})
+		}
+		return nil
+	})
+	coll := openCollection(t, server)
+	ctx := context.Background()
+	doc, err := soda.NewDocument(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Key = "k1"
+	err = coll.Insert(ctx, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), `INSERT INTO "EMP" ("ID", "JSON_DOCUMENT", "VERSION", "CREATED_ON", "LAST_MODIFIED") `+
+		`VALUES (:1, :2, :3, :4, :5)`, "k1", content, version)
+	if doc.Version != version || len(doc.CreatedOn) == 0 || doc.LastModified != doc.CreatedOn {
+		t.Errorf("unexpected document metadata after insert: %+v", doc)
+	}
+	// client assigned key is required
+	err = coll.Insert(ctx, &soda.Document{Content: []byte(content)})
+	if err == nil {
+		t.Error("expected error for document without key")
+	}
+
+	doc, err = coll.Get(ctx, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), selectList+` WHERE "ID" = :1 ORDER BY "ID" FETCH NEXT :2 ROWS ONLY`, "k1", int64(1))
+	if doc.Key != "k1" || string(doc.Content) != content || doc.Version != version ||
+		doc.CreatedOn != "2024-01-02T03:04:05.000000Z" || doc.MediaType != "application/json" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	_, err = coll.Get(ctx, "k2")
+	if !errors.Is(err, soda.ErrDocumentNotFound) {
+		t.Errorf("expected ErrDocumentNotFound got: %v", err)
+	}
+}
+
+func TestCollectionReplaceRemove(t *testing.T) {
+	content := `{"name":"Ahmed"}`
+	hash := sha256.Sum256([]byte(content))
+	version := strings.ToUpper(hex.EncodeToString(hash[:]))
+	server := newSodaServer(t, "SHA256", func(req *oratest.Request) *oratest.Result {
+		// only version "V1" and key "k1" exist
+		for _, arg := range req.Args {
+			if arg == "V0" || arg == "k2" {
+				return oratest.ExecResult(0)
+			}
+		}
+		return oratest.ExecResult(1)
+	})
+	coll := openCollection(t, server)
+	ctx := context.Background()
+	doc, err := soda.NewDocument(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := coll.Replace(ctx, "k1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), `UPDATE "EMP" SET "JSON_DOCUMENT" = :1, "VERSION" = :2, "LAST_MODIFIED" = :3 `+
+		`WHERE "ID" = :4`, content, version, nil, "k1")
+	if !ok || doc.Key != "k1" || doc.Version != version || len(doc.LastModified) == 0 {
+		t.Errorf("unexpected replace result: %v, %+v", ok, doc)
+	}
+	// optimistic locking with version (etag)
+	ok, err = coll.Find().Key("k1").Version("V1").ReplaceOne(ctx, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), `UPDATE "EMP" SET "JSON_DOCUMENT" = :1, "VERSION" = :2, "LAST_MODIFIED" = :3 `+
+		`WHERE "ID" = :4 AND TO_CHAR("VERSION") = :5`, content, version, nil, "k1", "V1")
+	if !ok {
+		t.Error("expected replace with matching version to succeed")
+	}
+	ok, err = coll.Find().Key("k1").Version("V0").ReplaceOne(ctx, doc)
+	if err != nil || ok {
+		t.Errorf("expected replace with stale version to fail got: %v, %v", ok, err)
+	}
+
+	ok, err = coll.Remove(ctx, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), `DELETE FROM "EMP" WHERE "ID" = :1`, "k1")
+	if !ok {
+		t.Error("expected remove to succeed")
+	}
+	ok, err = coll.Remove(ctx, "k2")
+	if err != nil || ok {
+		t.Errorf("expected remove of missing document to return false got: %v, %v", ok, err)
+	}
+	count, err := coll.Find().Key("k1").Version("V1").Remove(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), `DELETE FROM "EMP" WHERE "ID" = :1 AND TO_CHAR("VERSION") = :2`, "k1", "V1")
+	if count != 1 {
+		t.Errorf("expected 1 document removed got: %d", count)
+	}
+}
+
+func TestCollectionSequentialVersion(t *testing.T) {
+	server := newSodaServer(t, "SEQUENTIAL", func(req *oratest.Request) *oratest.Result {
+		if strings.HasPrefix(req.SQL, "INSERT") {
+			return oratest.ExecResult(1)
+		}
+		return &oratest.Result{RowsAffected: 1, Out: map[int]interface{}{len(req.Args): 2}}
+	})
+	coll := openCollection(t, server)
+	ctx := context.Background()
+	doc, err := soda.NewDocument(map[string]interface{}{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Key = "k1"
+	if err = coll.Insert(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "1" {
+		t.Errorf("expected version 1 after insert got: %s", doc.Version)
+	}
+	ok, err := coll.Replace(ctx, "k1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), `UPDATE "EMP" SET "JSON_DOCUMENT" = :1, "VERSION" = "VERSION" + 1, `+
+		`"LAST_MODIFIED" = :2 WHERE "ID" = :3 RETURNING "VERSION" INTO :4`, `{"a":1}`, nil, "k1")
+	if !ok || doc.Version != "2" {
+		t.Errorf("expected version 2 after replace got: %v, %s", ok, doc.Version)
+	}
+}
+
+func TestCollectionIndex(t *testing.T) {
+	server := newSodaServer(t, "NONE", func(req *oratest.Request) *oratest.Result {
+		if strings.Contains(req.SQL, "DROP_INDEX") {
+			status := 1
+			if req.Args[2] == "MISSING_IDX" {
+				status = 0
+			}
+			return &oratest.Result{Out: map[int]interface{}{2: status}}
+		}
+		return oratest.ExecResult(0)
+	})
+	coll := openCollection(t, server)
+	ctx := context.Background()
+	err := coll.CreateIndex(ctx, &soda.IndexSpec{Name: "NAME_IDX", Unique: true,
+		Fields: []soda.IndexField{{Path: "name", DataType: "string", MaxLength: 100}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := server.last(t)
+	if !strings.Contains(req.SQL, "DBMS_SODA.OPEN_COLLECTION(:1)") || !strings.Contains(req.SQL, "l_coll.CREATE_INDEX(:2)") {
+		t.Errorf("unexpected create index sql: %s", req.SQL)
+	}
+	checkRequest(t, req, req.SQL, "emp",
+		`{"name":"NAME_IDX","fields":[{"path":"name","datatype":"string","maxlength":100}],"unique":true}`)
+	if err = coll.CreateIndex(ctx, &soda.IndexSpec{}); err == nil {
+		t.Error("expected error for index spec without name")
+	}
+
+	ok, err := coll.DropIndex(ctx, "NAME_IDX", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = server.last(t)
+	if !strings.Contains(req.SQL, ":2 := l_coll.DROP_INDEX(:3, :4 = 1)") {
+		t.Errorf("unexpected drop index sql: %s", req.SQL)
+	}
+	checkRequest(t, req, req.SQL, "emp", nil, "NAME_IDX", int64(1))
+	if !ok {
+		t.Error("expected drop index to return true")
+	}
+	ok, err = coll.DropIndex(ctx, "MISSING_IDX", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, server.last(t), req.SQL, "emp", nil, "MISSING_IDX", int64(0))
+	if ok {
+		t.Error("expected drop of missing index to return false")
+	}
+}
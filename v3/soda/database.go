@@ -0,0 +1,121 @@
+// Package soda implement Simple Oracle Document Access over database/sql.
+// collections are managed by DBMS_SODA package while documents are read and
+// written with sql on the collection table so any *sql.DB, *sql.Conn or *sql.Tx
+// opened with go-ora can be used
+package soda
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	go_ora "github.com/sijms/go-ora/v3"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("soda: collection not found")
+	ErrDocumentNotFound   = errors.New("soda: document not found")
+	ErrReadOnly           = errors.New("soda: collection is read only")
+)
+
+// Queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type Queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type Database struct {
+	db Queryer
+}
+
+func New(db Queryer) *Database {
+	return &Database{db: db}
+}
+
+// CreateCollection create collection or open it if it exists with the same
+// metadata. nil metadata use server default
+func (database *Database) CreateCollection(ctx context.Context, name string, metadata *Metadata) (*Collection, error) {
+	var descriptor interface{}
+	if metadata != nil {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, err
+		}
+		descriptor = string(data)
+	}
+	_, err := database.db.ExecContext(ctx, `DECLARE
+	l_coll SODA_COLLECTION_T;
+BEGIN
+	l_coll := DBMS_SODA.CREATE_COLLECTION(:1, :2);
+END;`, name, descriptor)
+	if err != nil {
+		return nil, err
+	}
+	return database.OpenCollection(ctx, name)
+}
+
+// OpenCollection return ErrCollectionNotFound if collection doesn't exist
+func (database *Database) OpenCollection(ctx context.Context, name string) (*Collection, error) {
+	var descriptor string
+	err := database.db.QueryRowContext(ctx,
+		"SELECT JSON_DESCRIPTOR FROM USER_SODA_COLLECTIONS WHERE URI_NAME = :1", name).Scan(&descriptor)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, err
+	}
+	metadata, err := parseMetadata(descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("soda: invalid metadata of collection %s: %w", name, err)
+	}
+	return &Collection{Name: name, Metadata: metadata, db: database.db}, nil
+}
+
+// DropCollection drop collection and its table. return false if collection
+// doesn't exist
+func (database *Database) DropCollection(ctx context.Context, name string) (bool, error) {
+	var status int64
+	_, err := database.db.ExecContext(ctx, "BEGIN :1 := DBMS_SODA.DROP_COLLECTION(:2); END;",
+		go_ora.Out{Dest: &status}, name)
+	if err != nil {
+		return false, err
+	}
+	return status == 1, nil
+}
+
+// CollectionNames return names of collections in current schema ordered by name.
+// names start from startName (inclusive) and limit <= 0 return all
+func (database *Database) CollectionNames(ctx context.Context, startName string, limit int) ([]string, error) {
+	builder := strings.Builder{}
+	builder.WriteString("SELECT URI_NAME FROM USER_SODA_COLLECTIONS")
+	args := make([]interface{}, 0, 2)
+	if len(startName) > 0 {
+		args = append(args, startName)
+		builder.WriteString(" WHERE URI_NAME >= :1")
+	}
+	builder.WriteString(" ORDER BY URI_NAME")
+	if limit > 0 {
+		args = append(args, limit)
+		builder.WriteString(fmt.Sprintf(" FETCH FIRST :%d ROWS ONLY", len(args)))
+	}
+	rows, err := database.db.QueryContext(ctx, builder.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []string
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, name)
+	}
+	return ret, rows.Err()
+}
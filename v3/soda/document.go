@@ -0,0 +1,57 @@
+package soda
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Document is a collection document with its metadata. Key, Version, CreatedOn and
+// LastModified are filled by the collection on read and write
+type Document struct {
+	Key          string
+	Version      string
+	CreatedOn    string
+	LastModified string
+	MediaType    string
+	Content      []byte
+}
+
+// NewDocument create json document from content. []byte, string and json.RawMessage
+// are used as json text while other values are marshaled with encoding/json
+func NewDocument(content interface{}) (*Document, error) {
+	doc := &Document{MediaType: "application/json"}
+	switch value := content.(type) {
+	case []byte:
+		doc.Content = value
+	case json.RawMessage:
+		doc.Content = value
+	case string:
+		doc.Content = []byte(value)
+	default:
+		data, err := json.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		doc.Content = data
+	}
+	return doc, nil
+}
+
+// Unmarshal decode document content into v
+func (doc *Document) Unmarshal(v interface{}) error {
+	return json.Unmarshal(doc.Content, v)
+}
+
+// isJson return true for documents with json media type
+func (doc *Document) isJson() bool {
+	return len(doc.MediaType) == 0 || doc.MediaType == "application/json"
+}
+
+// jsonValue decode content into map/array for binding as JSON data type
+func (doc *Document) jsonValue() (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(doc.Content))
+	decoder.UseNumber()
+	var ret interface{}
+	err := decoder.Decode(&ret)
+	return ret, err
+}
@@ -0,0 +1,116 @@
+package soda
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// key assignment methods
+const (
+	KeyUUID        = "UUID"
+	KeyGUID        = "GUID"
+	KeySequence    = "SEQUENCE"
+	KeyClient      = "CLIENT"
+	KeyEmbeddedOID = "EMBEDDED_OID"
+)
+
+// version methods
+const (
+	VersionUUID       = "UUID"
+	VersionSHA256     = "SHA256"
+	VersionMD5        = "MD5"
+	VersionTimestamp  = "TIMESTAMP"
+	VersionSequential = "SEQUENTIAL"
+	VersionNone       = "NONE"
+)
+
+// Metadata is the collection descriptor stored by the server. empty fields take
+// the server default when the collection is created
+type Metadata struct {
+	SchemaName         string         `json:"schemaName,omitempty"`
+	TableName          string         `json:"tableName,omitempty"`
+	ViewName           string         `json:"viewName,omitempty"`
+	KeyColumn          *KeyColumn     `json:"keyColumn,omitempty"`
+	ContentColumn      *ContentColumn `json:"contentColumn,omitempty"`
+	VersionColumn      *VersionColumn `json:"versionColumn,omitempty"`
+	LastModifiedColumn *Column        `json:"lastModifiedColumn,omitempty"`
+	CreationTimeColumn *Column        `json:"creationTimeColumn,omitempty"`
+	MediaTypeColumn    *Column        `json:"mediaTypeColumn,omitempty"`
+	ReadOnly           bool           `json:"readOnly,omitempty"`
+}
+
+type Column struct {
+	Name string `json:"name"`
+}
+
+type KeyColumn struct {
+	Name             string `json:"name,omitempty"`
+	SqlType          string `json:"sqlType,omitempty"`
+	MaxLength        int    `json:"maxLength,omitempty"`
+	AssignmentMethod string `json:"assignmentMethod,omitempty"`
+	SequenceName     string `json:"sequenceName,omitempty"`
+}
+
+type ContentColumn struct {
+	Name       string `json:"name,omitempty"`
+	SqlType    string `json:"sqlType,omitempty"`
+	MaxLength  int    `json:"maxLength,omitempty"`
+	Validation string `json:"validation,omitempty"`
+	JsonFormat string `json:"jsonFormat,omitempty"`
+}
+
+type VersionColumn struct {
+	Name   string `json:"name,omitempty"`
+	Method string `json:"method,omitempty"`
+}
+
+func parseMetadata(descriptor string) (*Metadata, error) {
+	ret := new(Metadata)
+	err := json.Unmarshal([]byte(descriptor), ret)
+	if err != nil {
+		return nil, err
+	}
+	if ret.KeyColumn == nil {
+		ret.KeyColumn = &KeyColumn{Name: "ID", SqlType: "VARCHAR2", AssignmentMethod: KeyUUID}
+	}
+	if ret.ContentColumn == nil {
+		ret.ContentColumn = &ContentColumn{Name: "JSON_DOCUMENT", SqlType: "BLOB"}
+	}
+	return ret, nil
+}
+
+// table return qualified name of the table (or view) that hold collection documents
+func (meta *Metadata) table() string {
+	name := meta.TableName
+	if len(name) == 0 {
+		name = meta.ViewName
+	}
+	if len(meta.SchemaName) > 0 {
+		return quote(meta.SchemaName) + "." + quote(name)
+	}
+	return quote(name)
+}
+
+func (meta *Metadata) keyMethod() string {
+	return strings.ToUpper(meta.KeyColumn.AssignmentMethod)
+}
+
+func (meta *Metadata) versionMethod() string {
+	if meta.VersionColumn == nil || len(meta.VersionColumn.Name) == 0 {
+		return VersionNone
+	}
+	return strings.ToUpper(meta.VersionColumn.Method)
+}
+
+func (meta *Metadata) contentType() string {
+	return strings.ToUpper(meta.ContentColumn.SqlType)
+}
+
+func (meta *Metadata) isRawKey() bool {
+	return strings.ToUpper(meta.KeyColumn.SqlType) == "RAW"
+}
+
+// quote return oracle quoted identifier
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
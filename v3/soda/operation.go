@@ -0,0 +1,267 @@
+package soda
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	go_ora "github.com/sijms/go-ora/v3"
+)
+
+// Operation select collection documents by key, version and query-by-example
+// filter. builder methods return the same operation so they can be chained
+//
+//	docs, err := coll.Find().Filter(`{"age": {"$gt": 30}}`).Limit(10).GetDocuments(ctx)
+type Operation struct {
+	coll    *Collection
+	keys    []string
+	filter  interface{}
+	version string
+	skip    int
+	limit   int
+}
+
+// Key select document by key
+func (op *Operation) Key(key string) *Operation {
+	op.keys = []string{key}
+	return op
+}
+
+// Keys select documents by keys
+func (op *Operation) Keys(keys ...string) *Operation {
+	op.keys = keys
+	return op
+}
+
+// Filter select documents matching query-by-example filter. filter is json text
+// ([]byte or string), map[string]interface{} or struct marshaled by encoding/json
+func (op *Operation) Filter(filter interface{}) *Operation {
+	op.filter = filter
+	return op
+}
+
+// Version select document only if its version match. used for optimistic locking
+// with ReplaceOne and Remove
+func (op *Operation) Version(version string) *Operation {
+	op.version = version
+	return op
+}
+
+func (op *Operation) Skip(count int) *Operation {
+	op.skip = count
+	return op
+}
+
+func (op *Operation) Limit(count int) *Operation {
+	op.limit = count
+	return op
+}
+
+// where return WHERE clause and query-by-example translator holding $orderby
+func (op *Operation) where(args *sqlArgs) (string, *qbe, error) {
+	meta := op.coll.Metadata
+	var conditions []string
+	if len(op.keys) > 0 {
+		placeholders := make([]string, len(op.keys))
+		for i, key := range op.keys {
+			placeholders[i] = op.coll.keyPlaceholder(args, key)
+		}
+		if len(placeholders) == 1 {
+			conditions = append(conditions, quote(meta.KeyColumn.Name)+" = "+placeholders[0])
+		} else {
+			conditions = append(conditions, quote(meta.KeyColumn.Name)+" IN ("+strings.Join(placeholders, ", ")+")")
+		}
+	}
+	if len(op.version) > 0 {
+		if meta.versionMethod() == VersionNone {
+			return "", nil, fmt.Errorf("soda: collection %s has no version column", op.coll.Name)
+		}
+		conditions = append(conditions, "TO_CHAR("+quote(meta.VersionColumn.Name)+") = "+args.add(op.version))
+	}
+	translator := &qbe{}
+	filter, err := parseFilter(op.filter)
+	if err != nil {
+		return "", nil, err
+	}
+	path, err := translator.translate(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(path) > 0 {
+		passing := translator.passing(len(*args) + 1)
+		*args = append(*args, translator.binds...)
+		conditions = append(conditions, fmt.Sprintf("JSON_EXISTS(%s, %s%s)",
+			op.coll.contentExpression(), sqlString("$?("+path+")"), passing))
+	}
+	if len(conditions) == 0 {
+		return "", translator, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), translator, nil
+}
+
+// GetDocuments return documents selected by the operation
+func (op *Operation) GetDocuments(ctx context.Context) ([]*Document, error) {
+	var args sqlArgs
+	where, translator, err := op.where(&args)
+	if err != nil {
+		return nil, err
+	}
+	orderBy, err := translator.orderByClause(op.coll.contentExpression())
+	if err != nil {
+		return nil, err
+	}
+	if len(orderBy) == 0 && (op.skip > 0 || op.limit > 0) {
+		// stable order for pagination
+		orderBy = " ORDER BY " + quote(op.coll.Metadata.KeyColumn.Name)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s%s", op.coll.selectList(), op.coll.Metadata.table(), where, orderBy)
+	if op.skip > 0 {
+		query += " OFFSET " + args.add(op.skip) + " ROWS"
+	}
+	if op.limit > 0 {
+		query += " FETCH NEXT " + args.add(op.limit) + " ROWS ONLY"
+	}
+	rows, err := op.coll.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []*Document
+	for rows.Next() {
+		var (
+			doc                                         = &Document{}
+			version, createdOn, lastModified, mediaType sql.NullString
+		)
+		err = rows.Scan(&doc.Key, &doc.Content, &version, &createdOn, &lastModified, &mediaType)
+		if err != nil {
+			return nil, err
+		}
+		doc.Version = version.String
+		doc.CreatedOn = createdOn.String
+		doc.LastModified = lastModified.String
+		doc.MediaType = mediaType.String
+		if len(doc.MediaType) == 0 {
+			doc.MediaType = "application/json"
+		}
+		ret = append(ret, doc)
+	}
+	return ret, rows.Err()
+}
+
+// GetOne return first document selected by the operation or ErrDocumentNotFound
+func (op *Operation) GetOne(ctx context.Context) (*Document, error) {
+	limit := op.limit
+	op.limit = 1
+	docs, err := op.GetDocuments(ctx)
+	op.limit = limit
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+	return docs[0], nil
+}
+
+// Count return number of documents selected by the operation
+func (op *Operation) Count(ctx context.Context) (int64, error) {
+	if op.skip > 0 || op.limit > 0 {
+		return 0, fmt.Errorf("soda: skip and limit are not supported with count")
+	}
+	var args sqlArgs
+	where, _, err := op.where(&args)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = op.coll.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s%s",
+		op.coll.Metadata.table(), where), args...).Scan(&count)
+	return count, err
+}
+
+// Remove delete documents selected by the operation and return their count
+func (op *Operation) Remove(ctx context.Context) (int64, error) {
+	if op.coll.Metadata.ReadOnly {
+		return 0, ErrReadOnly
+	}
+	if op.skip > 0 || op.limit > 0 {
+		return 0, fmt.Errorf("soda: skip and limit are not supported with remove")
+	}
+	var args sqlArgs
+	where, _, err := op.where(&args)
+	if err != nil {
+		return 0, err
+	}
+	result, err := op.coll.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s%s", op.coll.Metadata.table(), where), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ReplaceOne overwrite content of the document selected by key (and version if
+// set). return false if no document match. Key, Version and LastModified of doc
+// are updated on success
+func (op *Operation) ReplaceOne(ctx context.Context, doc *Document) (bool, error) {
+	coll := op.coll
+	meta := coll.Metadata
+	if meta.ReadOnly {
+		return false, ErrReadOnly
+	}
+	if len(op.keys) != 1 {
+		return false, fmt.Errorf("soda: replace require exactly one key")
+	}
+	var args sqlArgs
+	content, err := coll.contentValue(doc)
+	if err != nil {
+		return false, err
+	}
+	sets := []string{quote(meta.ContentColumn.Name) + " = " + args.add(content)}
+	now := time.Now().UTC()
+	version, err := coll.newVersion(doc, now)
+	if err != nil {
+		return false, err
+	}
+	var sequence int64
+	returning := ""
+	switch meta.versionMethod() {
+	case VersionNone:
+	case VersionSequential:
+		column := quote(meta.VersionColumn.Name)
+		sets = append(sets, column+" = "+column+" + 1")
+		returning = " RETURNING " + column + " INTO "
+	default:
+		sets = append(sets, quote(meta.VersionColumn.Name)+" = "+args.add(version))
+	}
+	if meta.LastModifiedColumn != nil {
+		sets = append(sets, quote(meta.LastModifiedColumn.Name)+" = "+args.add(now))
+	}
+	if meta.MediaTypeColumn != nil {
+		sets = append(sets, quote(meta.MediaTypeColumn.Name)+" = "+args.add(coll.mediaType(doc)))
+	}
+	where, _, err := op.where(&args)
+	if err != nil {
+		return false, err
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s%s", meta.table(), strings.Join(sets, ", "), where)
+	if len(returning) > 0 {
+		query += returning + args.add(go_ora.Out{Dest: &sequence})
+	}
+	result, err := coll.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	if meta.versionMethod() == VersionSequential {
+		version = fmt.Sprint(sequence)
+	}
+	doc.Key = op.keys[0]
+	doc.Version = version
+	doc.LastModified = now.Format(timeFormat)
+	return true, nil
+}
@@ -0,0 +1,410 @@
+package soda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sijms/go-ora/v3/types"
+)
+
+// comparison operators of query-by-example and their sql/json path equivalent
+var qbeComparison = map[string]string{
+	"$eq":  "==",
+	"$ne":  "!=",
+	"$gt":  ">",
+	"$gte": ">=",
+	"$lt":  "<",
+	"$lte": "<=",
+}
+
+type orderItem struct {
+	path     string
+	dataType string
+	desc     bool
+}
+
+// qbe translate query-by-example filter into json path predicate used inside
+// JSON_EXISTS. values are passed as path variables ($B0, $B1, ...) bound to
+// sql placeholders
+type qbe struct {
+	binds   []interface{}
+	orderBy []orderItem
+}
+
+// parseFilter accept filter as json text ([]byte or string), map or struct
+func parseFilter(filter interface{}) (map[string]interface{}, error) {
+	var data []byte
+	switch value := filter.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return value, nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	case json.RawMessage:
+		data = value
+	default:
+		var err error
+		data, err = json.Marshal(filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var ret map[string]interface{}
+	err := decoder.Decode(&ret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return ret, nil
+}
+
+// translate return json path predicate of the filter. empty string is returned
+// when filter has no conditions
+func (q *qbe) translate(filter map[string]interface{}) (string, error) {
+	if len(filter) == 0 {
+		return "", nil
+	}
+	query := filter
+	if orderBy, ok := filter["$orderby"]; ok {
+		err := q.parseOrderBy(orderBy)
+		if err != nil {
+			return "", err
+		}
+		query = make(map[string]interface{}, len(filter))
+		for key, value := range filter {
+			if key != "$orderby" {
+				query[key] = value
+			}
+		}
+	}
+	if temp, ok := query["$query"]; ok {
+		if len(query) > 1 {
+			return "", fmt.Errorf("$query can only be combined with $orderby")
+		}
+		obj, ok := temp.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("$query should be an object")
+		}
+		query = obj
+	}
+	if len(query) == 0 {
+		return "", nil
+	}
+	return q.object(query, "@")
+}
+
+func (q *qbe) object(obj map[string]interface{}, prefix string) (string, error) {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		var cond string
+		var err error
+		switch key {
+		case "$and", "$or", "$nor":
+			cond, err = q.logical(key, obj[key], prefix)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return "", fmt.Errorf("unsupported operator %s", key)
+			}
+			cond, err = q.field(prefix+fieldPath(key), obj[key])
+		}
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, cond)
+	}
+	return join(parts, " && "), nil
+}
+
+func (q *qbe) logical(operator string, value interface{}, prefix string) (string, error) {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return "", fmt.Errorf("%s should be a non empty array", operator)
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s items should be objects", operator)
+		}
+		cond, err := q.object(obj, prefix)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, cond)
+	}
+	switch operator {
+	case "$and":
+		return join(parts, " && "), nil
+	case "$or":
+		return join(parts, " || "), nil
+	default:
+		return "!(" + strings.Join(parts, " || ") + ")", nil
+	}
+}
+
+// field translate condition on path. value is either operators object, nested
+// object of fields or scalar for equality
+func (q *qbe) field(path string, value interface{}) (string, error) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return q.compare(path, "$eq", value)
+	}
+	hasOperator := false
+	for key := range obj {
+		if strings.HasPrefix(key, "$") {
+			hasOperator = true
+			break
+		}
+	}
+	if !hasOperator {
+		return q.object(obj, path)
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		cond, err := q.operator(path, key, obj[key])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, cond)
+	}
+	return join(parts, " && "), nil
+}
+
+func (q *qbe) operator(path, operator string, value interface{}) (string, error) {
+	if _, ok := qbeComparison[operator]; ok {
+		return q.compare(path, operator, value)
+	}
+	switch operator {
+	case "$in", "$nin":
+		items, ok := value.([]interface{})
+		if !ok || len(items) == 0 {
+			return "", fmt.Errorf("%s should be a non empty array", operator)
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			cond, err := q.compare(path, "$eq", item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, cond)
+		}
+		if operator == "$nin" {
+			return "!(" + strings.Join(parts, " || ") + ")", nil
+		}
+		return join(parts, " || "), nil
+	case "$exists":
+		exists, err := qbeBool(value)
+		if err != nil {
+			return "", fmt.Errorf("$exists: %w", err)
+		}
+		if exists {
+			return "exists(" + path + ")", nil
+		}
+		return "!exists(" + path + ")", nil
+	case "$startsWith", "$hasSubstring":
+		text, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("%s should be a string", operator)
+		}
+		keyword := "starts with"
+		if operator == "$hasSubstring" {
+			keyword = "has substring"
+		}
+		return path + " " + keyword + " " + q.bind(text), nil
+	case "$regex":
+		text, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("$regex should be a string")
+		}
+		// like_regex accept only string literal
+		return path + " like_regex " + pathString(text), nil
+	case "$not":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("$not should be an object of operators")
+		}
+		cond, err := q.field(path, obj)
+		if err != nil {
+			return "", err
+		}
+		return "!(" + cond + ")", nil
+	}
+	return "", fmt.Errorf("unsupported operator %s", operator)
+}
+
+func (q *qbe) compare(path, operator string, value interface{}) (string, error) {
+	op := qbeComparison[operator]
+	switch value := value.(type) {
+	case nil:
+		return path + " " + op + " null", nil
+	case bool:
+		if value {
+			return path + " " + op + " true", nil
+		}
+		return path + " " + op + " false", nil
+	case json.Number:
+		num, err := types.NewNumber(value.String())
+		if err != nil {
+			return "", err
+		}
+		return path + " " + op + " " + q.bind(*num), nil
+	case string:
+		return path + " " + op + " " + q.bind(value), nil
+	case float64, float32, int, int64, int32:
+		return path + " " + op + " " + q.bind(value), nil
+	}
+	return "", fmt.Errorf("%s: unsupported value %v", operator, value)
+}
+
+// bind add value to path variables and return its name
+func (q *qbe) bind(value interface{}) string {
+	name := fmt.Sprintf("$B%d", len(q.binds))
+	q.binds = append(q.binds, value)
+	return name
+}
+
+// passing return PASSING clause for path variables. placeholders are numbered
+// starting from first
+func (q *qbe) passing(first int) string {
+	if len(q.binds) == 0 {
+		return ""
+	}
+	parts := make([]string, len(q.binds))
+	for i := range q.binds {
+		parts[i] = fmt.Sprintf(":%d AS \"B%d\"", first+i, i)
+	}
+	return " PASSING " + strings.Join(parts, ", ")
+}
+
+func (q *qbe) parseOrderBy(value interface{}) error {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		// json object lose field order so keys are sorted by name. use array
+		// form to control sort order
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			direction := fmt.Sprint(value[key])
+			if direction != "1" && direction != "-1" {
+				return fmt.Errorf("$orderby: direction of %s should be 1 or -1", key)
+			}
+			q.orderBy = append(q.orderBy, orderItem{path: key, desc: direction == "-1"})
+		}
+	case []interface{}:
+		for _, item := range value {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("$orderby: items should be objects")
+			}
+			path, _ := obj["path"].(string)
+			if len(path) == 0 {
+				return fmt.Errorf("$orderby: missing path")
+			}
+			temp := orderItem{path: path}
+			if dataType, ok := obj["datatype"].(string); ok {
+				temp.dataType = strings.ToUpper(dataType)
+			}
+			if order, ok := obj["order"].(string); ok {
+				switch strings.ToLower(order) {
+				case "asc":
+				case "desc":
+					temp.desc = true
+				default:
+					return fmt.Errorf("$orderby: invalid order %s", order)
+				}
+			}
+			q.orderBy = append(q.orderBy, temp)
+		}
+	default:
+		return fmt.Errorf("$orderby should be an object or array")
+	}
+	return nil
+}
+
+// orderByClause return ORDER BY clause for content expression
+func (q *qbe) orderByClause(content string) (string, error) {
+	if len(q.orderBy) == 0 {
+		return "", nil
+	}
+	parts := make([]string, len(q.orderBy))
+	for i, item := range q.orderBy {
+		dataType := item.dataType
+		switch dataType {
+		case "", "STRING", "VARCHAR2":
+			dataType = "VARCHAR2(4000)"
+		case "NUMBER", "DATE", "TIMESTAMP":
+		default:
+			return "", fmt.Errorf("$orderby: unsupported datatype %s", item.dataType)
+		}
+		parts[i] = fmt.Sprintf("JSON_VALUE(%s, %s RETURNING %s)", content,
+			sqlString("$"+fieldPath(item.path)), dataType)
+		if item.desc {
+			parts[i] += " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
+
+// fieldPath convert dot notation field name into quoted json path steps. array
+// steps like items[0] are kept outside the quotes
+func fieldPath(name string) string {
+	builder := strings.Builder{}
+	for _, step := range strings.Split(name, ".") {
+		array := ""
+		if index := strings.IndexByte(step, '['); index > 0 && strings.HasSuffix(step, "]") {
+			step, array = step[:index], step[index:]
+		}
+		builder.WriteString(".")
+		builder.WriteString(pathString(step))
+		builder.WriteString(array)
+	}
+	return builder.String()
+}
+
+// pathString return json path string literal
+func pathString(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return `"` + strings.ReplaceAll(text, `"`, `\"`) + `"`
+}
+
+// sqlString return sql string literal
+func sqlString(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+func qbeBool(value interface{}) (bool, error) {
+	switch value := value.(type) {
+	case bool:
+		return value, nil
+	case json.Number:
+		return value.String() != "0", nil
+	}
+	return false, fmt.Errorf("expected boolean value")
+}
+
+func join(parts []string, separator string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, separator) + ")"
+}
@@ -0,0 +1,98 @@
+package soda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQBETranslate(t *testing.T) {
+	tests := []struct {
+		filter string
+		path   string
+		binds  int
+	}{
+		{`{"name": "Ali"}`, `@."name" == $B0`, 1},
+		{`{"age": {"$gt": 30, "$lte": 50}}`, `(@."age" > $B0 && @."age" <= $B1)`, 2},
+		{`{"address.city": "Cairo", "active": true}`, `(@."active" == true && @."address"."city" == $B0)`, 1},
+		{`{"address": {"city": "Cairo"}}`, `@."address"."city" == $B0`, 1},
+		{`{"$or": [{"a": 1}, {"b": null}]}`, `(@."a" == $B0 || @."b" == null)`, 1},
+		{`{"tag": {"$in": ["x", "y"]}}`, `(@."tag" == $B0 || @."tag" == $B1)`, 2},
+		{`{"tag": {"$nin": ["x"]}}`, `!(@."tag" == $B0)`, 1},
+		{`{"email": {"$exists": false}}`, `!exists(@."email")`, 0},
+		{`{"name": {"$startsWith": "A"}}`, `@."name" starts with $B0`, 1},
+		{`{"name": {"$regex": "^A.*\"x"}}`, `@."name" like_regex "^A.*\"x"`, 0},
+		{`{"age": {"$not": {"$lt": 18}}}`, `!(@."age" < $B0)`, 1},
+		{`{"items[0].qty": 2}`, `@."items"[0]."qty" == $B0`, 1},
+		{`{"$query": {"a": 1}, "$orderby": {"a": -1}}`, `@."a" == $B0`, 1},
+		{`{"$orderby": {"a": 1}}`, ``, 0},
+	}
+	for _, test := range tests {
+		filter, err := parseFilter(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		translator := &qbe{}
+		path, err := translator.translate(filter)
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if path != test.path {
+			t.Errorf("%s: expected: %s got: %s", test.filter, test.path, path)
+		}
+		if len(translator.binds) != test.binds {
+			t.Errorf("%s: expected %d binds got: %d", test.filter, test.binds, len(translator.binds))
+		}
+	}
+}
+
+func TestQBEErrors(t *testing.T) {
+	for _, filter := range []string{
+		`{"$foo": 1}`,
+		`{"a": {"$bar": 1}}`,
+		`{"$or": {"a": 1}}`,
+		`{"a": {"$in": 1}}`,
+		`{"$orderby": {"a": 2}}`,
+	} {
+		temp, err := parseFilter(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = (&qbe{}).translate(temp)
+		if err == nil {
+			t.Errorf("%s: expected error", filter)
+		}
+	}
+}
+
+func TestOperationWhere(t *testing.T) {
+	meta, err := parseMetadata(`{"tableName": "EMP", "keyColumn": {"name": "ID", "sqlType": "RAW", "assignmentMethod": "GUID"},
+"contentColumn": {"name": "DOC", "sqlType": "BLOB"}, "versionColumn": {"name": "VER", "method": "SHA256"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coll := &Collection{Name: "emp", Metadata: meta}
+	var args sqlArgs
+	where, translator, err := coll.Find().Key("AB").Version("V1").
+		Filter(map[string]interface{}{"name": "x", "$orderby": map[string]interface{}{"age": -1}}).where(&args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ` WHERE "ID" = HEXTORAW(:1) AND TO_CHAR("VER") = :2 AND JSON_EXISTS("DOC" FORMAT JSON, '$?(@."name" == $B0)' PASSING :3 AS "B0")`
+	if where != expected {
+		t.Errorf("expected: %s got: %s", expected, where)
+	}
+	if len(args) != 3 || args[2] != "x" {
+		t.Errorf("unexpected args: %v", args)
+	}
+	orderBy, err := translator.orderByClause(coll.contentExpression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(orderBy, `JSON_VALUE("DOC" FORMAT JSON, '$."age"' RETURNING VARCHAR2(4000)) DESC`) {
+		t.Errorf("unexpected order by: %s", orderBy)
+	}
+	if meta.table() != `"EMP"` {
+		t.Errorf("unexpected table: %s", meta.table())
+	}
+}
@@ -0,0 +1,326 @@
+package go_ora
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sijms/go-ora/v3/types"
+	"github.com/sijms/go-ora/v3/utils"
+)
+
+// structField is path of struct field matched to a column
+type structField struct {
+	index []int
+	// pointer fields are left nil for NULL values
+	ptr bool
+}
+
+// scanPlan map each column of query to struct field (nil for unmatched columns)
+type scanPlan struct {
+	fields  []*structField
+	matched int
+}
+
+type scanPlanKey struct {
+	typ     reflect.Type
+	columns string
+}
+
+// StructMapper match query columns to struct fields by db tag and by name of
+// untagged fields returned by its name mapper. scan plans are cached per mapper
+// so it should be created once and reused
+type StructMapper struct {
+	nameMapper func(fieldName string) string
+	plans      sync.Map
+}
+
+// defaultStructMapper match tagged fields only
+var defaultStructMapper = &StructMapper{}
+
+// NewStructMapper return mapper that match untagged fields to columns named by
+// nameMapper (e.g. strings.ToUpper or SnakeCase). nil match tagged fields only
+func NewStructMapper(nameMapper func(fieldName string) string) *StructMapper {
+	return &StructMapper{nameMapper: nameMapper}
+}
+
+// SnakeCase map field name like FirstName to FIRST_NAME
+func SnakeCase(fieldName string) string {
+	runes := []rune(fieldName)
+	builder := strings.Builder{}
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+			builder.WriteByte('_')
+		}
+		builder.WriteRune(unicode.ToUpper(r))
+	}
+	return builder.String()
+}
+
+// scanPlan return cached plan that match columns to fields of typ by name
+// regardless of their order
+func (mapper *StructMapper) scanPlan(typ reflect.Type, columns []string) *scanPlan {
+	key := scanPlanKey{typ: typ, columns: strings.Join(columns, "\x00")}
+	if plan, ok := mapper.plans.Load(key); ok {
+		return plan.(*scanPlan)
+	}
+	fields := map[string]*structField{}
+	collectFields(typ, nil, "", mapper.nameMapper, fields, map[reflect.Type]bool{})
+	plan := &scanPlan{fields: make([]*structField, len(columns))}
+	for i, column := range columns {
+		if field, ok := fields[strings.ToUpper(column)]; ok {
+			plan.fields[i] = field
+			plan.matched++
+		}
+	}
+	mapper.plans.Store(key, plan)
+	return plan
+}
+
+// collectFields add fields of typ to names. embedded structs are flattened and
+// nested structs are matched with their name as prefix (e.g. ADDRESS_CITY).
+// fields of outer struct take precedence over fields of embedded ones
+func collectFields(typ reflect.Type, index []int, prefix string, mapper func(string) string,
+	names map[string]*structField, visited map[reflect.Type]bool) {
+	if visited[typ] {
+		return
+	}
+	visited[typ] = true
+	defer delete(visited, typ)
+	type nested struct {
+		index  []int
+		typ    reflect.Type
+		prefix string
+	}
+	var later []nested
+	for x := 0; x < typ.NumField(); x++ {
+		field := typ.Field(x)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		name, _, _, _ := utils.ExtractTag(tag)
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), x)
+		baseType := field.Type
+		if baseType.Kind() == reflect.Ptr {
+			baseType = baseType.Elem()
+		}
+		if field.Anonymous && len(name) == 0 && baseType.Kind() == reflect.Struct {
+			later = append(later, nested{fieldIndex, baseType, prefix})
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			if mapper == nil {
+				if isNestedStruct(baseType) {
+					later = append(later, nested{fieldIndex, baseType, prefix + strings.ToUpper(field.Name) + "_"})
+				}
+				continue
+			}
+			name = mapper(field.Name)
+		}
+		name = prefix + strings.ToUpper(name)
+		if _, ok := names[name]; !ok {
+			names[name] = &structField{index: fieldIndex, ptr: field.Type.Kind() == reflect.Ptr}
+		}
+		if isNestedStruct(baseType) {
+			later = append(later, nested{fieldIndex, baseType, name + "_"})
+		}
+	}
+	for _, item := range later {
+		collectFields(item.typ, item.index, item.prefix, mapper, names, visited)
+	}
+}
+
+// isNestedStruct return true for struct types that hold other columns and are
+// not a single column value (time, scanner or user defined type)
+func isNestedStruct(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) ||
+		reflect.PointerTo(typ).Implements(types.TyScanner) {
+		return false
+	}
+	for x := 0; x < typ.NumField(); x++ {
+		if _, ok := typ.Field(x).Tag.Lookup("udt"); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldByIndex return field of value allocating nil embedded pointers on the path
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(x)
+	}
+	return value
+}
+
+// scanStruct copy row values of columns matched by plan into struct value
+func (plan *scanPlan) scanStruct(value reflect.Value, row []driver.Value) error {
+	for i, field := range plan.fields {
+		if field == nil {
+			continue
+		}
+		fieldValue := fieldByIndex(value, field.index)
+		if field.ptr && row[i] == nil {
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+			continue
+		}
+		err := types.RCopy(fieldValue, row[i])
+		if err != nil {
+			return fmt.Errorf("go-ora: scan field %s: %w", value.Type().FieldByIndex(field.index).Name, err)
+		}
+	}
+	return nil
+}
+
+// targets return scan destinations of struct value for sql.Rows.Scan
+func (plan *scanPlan) targets(value reflect.Value) []interface{} {
+	ret := make([]interface{}, len(plan.fields))
+	for i, field := range plan.fields {
+		if field == nil {
+			ret[i] = new(interface{})
+			continue
+		}
+		ret[i] = fieldByIndex(value, field.index).Addr().Interface()
+	}
+	return ret
+}
+
+// sliceDest check that dest is pointer to slice and return the slice and element type
+func sliceDest(dest interface{}) (reflect.Value, reflect.Type, bool, error) {
+	rValue := reflect.ValueOf(dest)
+	if rValue.Kind() != reflect.Ptr || rValue.IsNil() || rValue.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, nil, false, errors.New("go-ora: ScanAll require pointer to slice")
+	}
+	slice := rValue.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	return slice, elemType, isPtr, nil
+}
+
+func appendElem(slice, elem reflect.Value, isPtr bool) {
+	if isPtr {
+		slice.Set(reflect.Append(slice, elem))
+	} else {
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+}
+
+// ScanAll read remaining rows into dest which is pointer to slice of structs,
+// pointers to structs or single column values. struct fields are matched by db tag
+func (resultSet *ResultSet) ScanAll(dest interface{}) error {
+	return defaultStructMapper.ScanResultSet(resultSet, dest)
+}
+
+// ScanAll read remaining rows of current result set into dest
+func (dataSet *DataSet) ScanAll(dest interface{}) error {
+	return defaultStructMapper.ScanResultSet(dataSet.currentResultSet(), dest)
+}
+
+// ScanAll read remaining rows of sql.Rows into dest which is pointer to slice of
+// structs, pointers to structs or single column values. columns are matched to
+// struct fields by db tag regardless of their order. rows are not closed
+func ScanAll(rows *sql.Rows, dest interface{}) error {
+	return defaultStructMapper.ScanAll(rows, dest)
+}
+
+// ScanResultSet read remaining rows of resultSet into dest with fields matched
+// by the mapper
+func (mapper *StructMapper) ScanResultSet(resultSet *ResultSet, dest interface{}) error {
+	slice, elemType, isPtr, err := sliceDest(dest)
+	if err != nil {
+		return err
+	}
+	for resultSet.Next_() {
+		elem := reflect.New(elemType)
+		err = resultSet.scan(mapper, elem.Interface())
+		if err != nil {
+			return err
+		}
+		appendElem(slice, elem, isPtr)
+	}
+	return resultSet.Err()
+}
+
+// ScanAll read remaining rows of sql.Rows into dest with fields matched by the
+// mapper. rows are not closed
+func (mapper *StructMapper) ScanAll(rows *sql.Rows, dest interface{}) error {
+	slice, elemType, isPtr, err := sliceDest(dest)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	var plan *scanPlan
+	if isNestedStruct(elemType) {
+		plan = mapper.scanPlan(elemType, columns)
+		if plan.matched == 0 {
+			return fmt.Errorf("go-ora: no column match fields of %v", elemType)
+		}
+	} else if len(columns) != 1 {
+		return fmt.Errorf("go-ora: can't scan %d columns into %v", len(columns), elemType)
+	}
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if plan != nil {
+			err = rows.Scan(plan.targets(elem.Elem())...)
+		} else {
+			err = rows.Scan(elem.Interface())
+		}
+		if err != nil {
+			return err
+		}
+		appendElem(slice, elem, isPtr)
+	}
+	return rows.Err()
+}
+
+// Queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// QueryStructs run query and return its rows as values of T
+//
+//	users, err := go_ora.QueryStructs[User](ctx, db, "SELECT * FROM USERS WHERE DEPT = :1", dept)
+func QueryStructs[T any](ctx context.Context, db Queryer, query string, args ...any) ([]T, error) {
+	return QueryStructsWith[T](ctx, defaultStructMapper, db, query, args...)
+}
+
+// QueryStructsWith run query and return its rows as values of T with fields
+// matched by mapper
+func QueryStructsWith[T any](ctx context.Context, mapper *StructMapper, db Queryer, query string, args ...any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []T
+	err = mapper.ScanAll(rows, &ret)
+	return ret, err
+}
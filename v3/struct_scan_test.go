@@ -0,0 +1,212 @@
+package go_ora_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+type ScanAudit struct {
+	CreatedBy string `db:"CREATED_BY"`
+}
+
+type ScanOwner struct {
+	Owner string `db:"OWNER"`
+}
+
+type scanAddress struct {
+	City string `db:"CITY"`
+}
+
+type scanUser struct {
+	ScanAudit
+	*ScanOwner
+	ID      int64       `db:"ID"`
+	Name    string      `db:"NAME"`
+	Email   *string     `db:"EMAIL"`
+	Secret  string      `db:"-"`
+	Address scanAddress `db:"ADDRESS"`
+}
+
+// usersResult return users with columns in different order than struct fields
+func usersResult() *oratest.Result {
+	return &oratest.Result{
+		Columns: []oratest.Column{{Name: "NAME"}, {Name: "ADDRESS_CITY"}, {Name: "ID", Type: oratest.Number},
+			{Name: "OWNER"}, {Name: "SECRET"}, {Name: "EMAIL"}, {Name: "CREATED_BY"}},
+		Rows: [][]interface{}{
+			{"KING", "CAIRO", 1, "HR", "X", "king@mail.com", "ADMIN"},
+			{"SMITH", "ROME", 2, "IT", "Y", nil, "APP"},
+		},
+	}
+}
+
+func expectedUsers() []scanUser {
+	email := "king@mail.com"
+	return []scanUser{
+		{ScanAudit: ScanAudit{"ADMIN"}, ScanOwner: &ScanOwner{"HR"}, ID: 1, Name: "KING", Email: &email,
+			Address: scanAddress{"CAIRO"}},
+		{ScanAudit: ScanAudit{"APP"}, ScanOwner: &ScanOwner{"IT"}, ID: 2, Name: "SMITH",
+			Address: scanAddress{"ROME"}},
+	}
+}
+
+func TestStructScan(t *testing.T) {
+	const query = "SELECT * FROM USERS"
+	server := newServer(t)
+	server.Handle(query, usersResult())
+	db := openDB(t, server.URL(nil))
+	// data set is read from driver connection of pinned session
+	queryDataSet := func(t *testing.T, scan func(dataSet *go_ora.DataSet) error) error {
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.Raw(func(driverConn interface{}) error {
+			stmt := go_ora.NewStmt(query, driverConn.(*go_ora.Connection))
+			defer stmt.Close()
+			dataSet, err := stmt.Query_(nil)
+			if err != nil {
+				return err
+			}
+			return scan(dataSet)
+		})
+	}
+	var testScenarios = []struct {
+		name string
+		scan func(t *testing.T) ([]scanUser, error)
+	}{
+		{"sql rows", func(t *testing.T) ([]scanUser, error) {
+			rows, err := db.Query(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			var users []scanUser
+			err = go_ora.ScanAll(rows, &users)
+			return users, err
+		}},
+		{"sql rows into pointers", func(t *testing.T) ([]scanUser, error) {
+			rows, err := db.Query(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			var users []*scanUser
+			err = go_ora.ScanAll(rows, &users)
+			var ret []scanUser
+			for _, user := range users {
+				ret = append(ret, *user)
+			}
+			return ret, err
+		}},
+		{"query structs", func(t *testing.T) ([]scanUser, error) {
+			return go_ora.QueryStructs[scanUser](context.Background(), db, query)
+		}},
+		{"data set", func(t *testing.T) ([]scanUser, error) {
+			var users []scanUser
+			err := queryDataSet(t, func(dataSet *go_ora.DataSet) error {
+				return dataSet.ScanAll(&users)
+			})
+			return users, err
+		}},
+		{"data set scan", func(t *testing.T) ([]scanUser, error) {
+			var users []scanUser
+			err := queryDataSet(t, func(dataSet *go_ora.DataSet) error {
+				for dataSet.Next_() {
+					var user scanUser
+					if err := dataSet.Scan(&user); err != nil {
+						return err
+					}
+					users = append(users, user)
+				}
+				return dataSet.Err()
+			})
+			return users, err
+		}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := tt.scan(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := expectedUsers(); !reflect.DeepEqual(users, expected) {
+				t.Errorf("expected: %+v got: %+v", expected, users)
+			}
+		})
+	}
+}
+
+func TestStructScanNameMapper(t *testing.T) {
+	type person struct {
+		FirstName string
+		LastName  string
+		Age       int64 `db:"YEARS"`
+	}
+	const query = "SELECT * FROM PERSONS"
+	server := newServer(t)
+	server.Handle(query, &oratest.Result{
+		Columns: []oratest.Column{{Name: "LAST_NAME"}, {Name: "YEARS", Type: oratest.Number}, {Name: "FIRST_NAME"}},
+		Rows:    [][]interface{}{{"SMITH", 30, "JOHN"}},
+	})
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	var testScenarios = []struct {
+		name     string
+		mapper   *go_ora.StructMapper
+		expected []person
+	}{
+		// plans cached by the tagged only mapper are not used by other mappers
+		{"tagged only", go_ora.NewStructMapper(nil), []person{{Age: 30}}},
+		{"snake case", go_ora.NewStructMapper(go_ora.SnakeCase), []person{{"JOHN", "SMITH", 30}}},
+		{"upper case", go_ora.NewStructMapper(strings.ToUpper), []person{{Age: 30}}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := go_ora.QueryStructsWith[person](ctx, tt.mapper, db, query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(persons, tt.expected) {
+				t.Errorf("expected: %+v got: %+v", tt.expected, persons)
+			}
+		})
+	}
+	// mapper with sql rows
+	mapper := go_ora.NewStructMapper(go_ora.SnakeCase)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var persons []person
+	if err = mapper.ScanAll(rows, &persons); err != nil {
+		t.Fatal(err)
+	}
+	if len(persons) != 1 || persons[0].FirstName != "JOHN" {
+		t.Errorf("unexpected persons: %+v", persons)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	var testScenarios = []struct {
+		input    string
+		expected string
+	}{
+		{"ID", "ID"},
+		{"FirstName", "FIRST_NAME"},
+		{"UserID", "USER_ID"},
+		{"HTTPServer", "HTTP_SERVER"},
+		{"name", "NAME"},
+	}
+	for _, tt := range testScenarios {
+		if output := go_ora.SnakeCase(tt.input); output != tt.expected {
+			t.Errorf("SnakeCase(%s): expected %s got: %s", tt.input, tt.expected, output)
+		}
+	}
+}
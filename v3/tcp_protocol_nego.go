@@ -1,11 +1,10 @@
 package go_ora
 
 import (
-	"encoding/binary"
-	"errors"
 	"fmt"
 
 	"github.com/sijms/go-ora/v3/converters"
+	"github.com/sijms/go-ora/v3/ttc"
 )
 
 type TCPNego struct {
@@ -41,36 +40,17 @@ func (nego *TCPNego) readMessage() error {
 	if err != nil {
 		return err
 	}
-	if nego.MessageCode != 1 {
+	if nego.MessageCode != ttc.MsgProtocol {
 		return fmt.Errorf("message code error: received code %d and expected code is 1", nego.MessageCode)
 	}
-	var protocolServerVersion uint8
-	protocolServerVersion, err = session.GetByte()
-	if err != nil {
-		return err
-	}
-	switch protocolServerVersion {
-	case 4:
-		nego.OracleVersion = 7230
-	case 5:
-		nego.OracleVersion = 8030
-	case 6:
-		nego.OracleVersion = 8100
-	default:
-		return fmt.Errorf("unsupported protocol server version: %d", protocolServerVersion)
-	}
-	_, err = session.GetByte()
-	if err != nil {
-		return err
-	}
-	nego.ProtocolServerString, err = session.GetNullTermString()
-	if err != nil {
-		return err
-	}
-	nego.ServerCharset, err = session.GetInt(2, false, false)
+	msg, err := ttc.NewDecoder(session, session.Capabilities()).Decode(nego.MessageCode)
 	if err != nil {
 		return err
 	}
+	protocol := msg.(*ttc.Protocol)
+	nego.OracleVersion = protocol.OracleVersion
+	nego.ProtocolServerString = protocol.Banner
+	nego.ServerCharset = protocol.Charset
 	tracer.Print("Server Charset: ", nego.ServerCharset)
 	// create string converter object
 	if nego.conn.sStrConv == nil {
@@ -80,28 +60,8 @@ func (nego *TCPNego) readMessage() error {
 		}
 	}
 	nego.conn.session.StrConv = nego.conn.sStrConv
-	nego.ServerFlags, err = session.GetByte()
-	if err != nil {
-		return err
-	}
-	var charsetElem int
-	charsetElem, err = session.GetInt(2, false, false)
-	if err != nil {
-		return err
-	}
-	if charsetElem > 0 {
-		_, _ = session.GetBytes(charsetElem * 5)
-	}
-	len1, err := session.GetInt(2, false, true)
-	if err != nil {
-		return err
-	}
-	numArray, err := session.GetBytes(len1)
-	if err != nil {
-		return err
-	}
-	num3 := int(6 + (numArray[5]) + (numArray[6]))
-	nego.ServerNCharset = int(binary.BigEndian.Uint16(numArray[(num3 + 3):(num3 + 5)]))
+	nego.ServerFlags = protocol.Flags
+	nego.ServerNCharset = protocol.NCharset
 	if nego.conn.nStrConv == nil {
 		nego.conn.nStrConv = converters.NewStringConverter(nego.ServerNCharset)
 		if nego.conn.nStrConv == nil {
@@ -109,32 +69,12 @@ func (nego *TCPNego) readMessage() error {
 		}
 	}
 	tracer.Print("Server National Charset: ", nego.ServerNCharset)
-	len2, err := session.GetByte()
-	if err != nil {
-		return err
-	}
-	nego.ServerCompileTimeCaps, err = session.GetBytes(int(len2))
-	if err != nil {
-		return err
-	}
-	len3, err := session.GetByte()
-	if err != nil {
-		return err
-	}
-	nego.ServerRuntimeCaps, err = session.GetBytes(int(len3))
-	if err != nil {
-		return err
-	}
-	if len(nego.ServerCompileTimeCaps) > 15 && nego.ServerCompileTimeCaps[15]&1 != 0 {
-		session.HasEOSCapability = true
-	}
-	if len(nego.ServerCompileTimeCaps) > 16 && nego.ServerCompileTimeCaps[16]&1 != 0 {
-		session.HasFSAPCapability = true
-	}
-	if nego.ServerCompileTimeCaps == nil || len(nego.ServerCompileTimeCaps) < 8 {
-		return errors.New("server compile time caps length less than 8")
-	}
-	if len(nego.ServerCompileTimeCaps) > 37 && nego.ServerCompileTimeCaps[37]&32 != 0 {
+	nego.ServerCompileTimeCaps = protocol.ServerCompileTimeCaps
+	nego.ServerRuntimeCaps = protocol.ServerRuntimeCaps
+	caps := protocol.Capabilities(session.TTCVersion)
+	session.HasEOSCapability = session.HasEOSCapability || caps.HasEOS
+	session.HasFSAPCapability = session.HasFSAPCapability || caps.HasFSAP
+	if caps.UseBigClrChunks {
 		session.UseBigClrChunks = true
 		session.ClrChunkSize = 0x7FFF
 	}
@@ -0,0 +1,69 @@
+package go_ora
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const getLTXIDOutcome = `DECLARE
+	l_committed BOOLEAN;
+	l_completed BOOLEAN;
+BEGIN
+	DBMS_APP_CONT.GET_LTXID_OUTCOME(:1, l_committed, l_completed);
+	:2 := CASE WHEN l_committed THEN 1 ELSE 0 END;
+	:3 := CASE WHEN l_completed THEN 1 ELSE 0 END;
+END;`
+
+// execer is the ExecContext of sql.DB, sql.Conn and sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// TransactionOutcome is the result of DBMS_APP_CONT.GET_LTXID_OUTCOME
+type TransactionOutcome struct {
+	Committed         bool
+	UserCallCompleted bool
+}
+
+// LTXID return the logical transaction id piggybacked by the server.
+// the value is available only when the service is configured with COMMIT_OUTCOME=TRUE
+// (Transaction Guard). save it before commit so the outcome can be checked
+// from another session if the commit fails with recoverable error
+func (conn *Connection) LTXID() []byte {
+	if len(conn.ltxid) == 0 {
+		return nil
+	}
+	ret := make([]byte, len(conn.ltxid))
+	copy(ret, conn.ltxid)
+	return ret
+}
+
+// GetConnectionLTXID return the logical transaction id of the session behind sql.Conn
+func GetConnectionLTXID(conn *sql.Conn) (ltxid []byte, err error) {
+	err = conn.Raw(func(driverConn interface{}) error {
+		oraConn, ok := driverConn.(*Connection)
+		if !ok {
+			return errors.New("the driver used is not a go-ora driver type")
+		}
+		ltxid = oraConn.LTXID()
+		return nil
+	})
+	return
+}
+
+// GetTransactionOutcome call DBMS_APP_CONT.GET_LTXID_OUTCOME using exec (*sql.DB,
+// *sql.Conn or *sql.Tx) to know if the transaction identified by ltxid is committed.
+// it should be called from a new session, it also blocks the in-flight transaction
+// of the old session from committing later
+func GetTransactionOutcome(ctx context.Context, exec execer, ltxid []byte) (*TransactionOutcome, error) {
+	if len(ltxid) == 0 {
+		return nil, errors.New("empty logical transaction id")
+	}
+	var committed, completed int64
+	_, err := exec.ExecContext(ctx, getLTXIDOutcome, ltxid, sql.Out{Dest: &committed}, sql.Out{Dest: &completed})
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionOutcome{Committed: committed == 1, UserCallCompleted: completed == 1}, nil
+}
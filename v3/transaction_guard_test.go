@@ -0,0 +1,88 @@
+package go_ora_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	go_ora "github.com/sijms/go-ora/v3"
+	"github.com/sijms/go-ora/v3/oratest"
+)
+
+func TestLTXID(t *testing.T) {
+	server := newServer(t)
+	first := []byte{0xA1, 0xB2, 0xC3, 0xD4, 0xE5}
+	server.Handle("UPDATE EMP SET SAL = 1", &oratest.Result{RowsAffected: 1, LTXID: first})
+	server.Handle("UPDATE EMP SET SAL = 2", oratest.ExecResult(1))
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ltxid, err := go_ora.GetConnectionLTXID(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ltxid != nil {
+		t.Errorf("expected nil ltxid before piggyback got: %X", ltxid)
+	}
+	if _, err = conn.ExecContext(ctx, "UPDATE EMP SET SAL = 1"); err != nil {
+		t.Fatal(err)
+	}
+	ltxid, err = go_ora.GetConnectionLTXID(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ltxid, first) {
+		t.Fatalf("expected ltxid %X got: %X", first, ltxid)
+	}
+	// returned value is a copy
+	ltxid[0] = 0
+	// statement without piggyback keep the last ltxid
+	if _, err = conn.ExecContext(ctx, "UPDATE EMP SET SAL = 2"); err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Raw(func(driverConn interface{}) error {
+		if ltxid := driverConn.(*go_ora.Connection).LTXID(); !bytes.Equal(ltxid, first) {
+			t.Errorf("expected ltxid %X got: %X", first, ltxid)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionOutcome(t *testing.T) {
+	server := newServer(t)
+	ltxid := []byte{1, 2, 3, 4}
+	var args []interface{}
+	server.HandleFunc(func(req *oratest.Request) *oratest.Result {
+		if !strings.Contains(req.SQL, "DBMS_APP_CONT.GET_LTXID_OUTCOME") {
+			return nil
+		}
+		args = req.Args
+		return &oratest.Result{Out: map[int]interface{}{2: 1, 3: 0}}
+	})
+	db := openDB(t, server.URL(nil))
+	ctx := context.Background()
+	outcome, err := go_ora.GetTransactionOutcome(ctx, db, ltxid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !outcome.Committed || outcome.UserCallCompleted {
+		t.Errorf("unexpected outcome: %+v", outcome)
+	}
+	if len(args) == 0 {
+		t.Fatal("expected ltxid bind")
+	}
+	if value, _ := args[0].([]byte); !bytes.Equal(value, ltxid) {
+		t.Errorf("expected ltxid bind %X got: %#v", ltxid, args[0])
+	}
+	if _, err = go_ora.GetTransactionOutcome(ctx, db, nil); err == nil {
+		t.Error("expected error for empty ltxid")
+	}
+}
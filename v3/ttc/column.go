@@ -0,0 +1,299 @@
+package ttc
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sijms/go-ora/v3/converters"
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/types"
+)
+
+// Column is column information of describe message. the same layout is used
+// for output parameters
+type Column struct {
+	DataType     uint16
+	Flag         uint8
+	Precision    uint8
+	Scale        uint8
+	MaxLen       int64
+	ArraySize    int
+	ContFlag     int
+	ToID         []byte
+	Version      int
+	CharsetID    int
+	CharsetForm  int
+	MaxCharLen   int64
+	CollationID  int
+	AllowNull    bool
+	Name         string
+	SchemaName   string
+	TypeName     string
+	IsJson       bool
+	DomainSchema string
+	DomainName   string
+	Annotations  map[string]string
+	VectorDim    int
+	VectorFormat uint8
+	VectorFlag   uint8
+}
+
+// IsLongType return true for LONG and LONG RAW columns
+func (col *Column) IsLongType() bool {
+	return col.DataType == types.LONG ||
+		col.DataType == types.LongRaw ||
+		col.DataType == types.LongVarChar ||
+		col.DataType == types.LongVarRaw
+}
+
+// ReadColumn read column information from r
+func ReadColumn(r network.SessionReader, caps network.TTCCapabilities, strConv converters.IStringConverter) (*Column, error) {
+	col := new(Column)
+	d := &Decoder{r: r, caps: caps, StrConv: strConv}
+	err := d.readColumn(col)
+	if err != nil {
+		return nil, err
+	}
+	return col, nil
+}
+
+func (d *Decoder) decodeString(input []byte) string {
+	if d.StrConv == nil {
+		return string(input)
+	}
+	return d.StrConv.Decode(input)
+}
+
+func (d *Decoder) readColumn(col *Column) error {
+	dataType, err := d.r.GetByte()
+	if err != nil {
+		return err
+	}
+	col.DataType = uint16(dataType)
+	col.Flag, err = d.r.GetByte()
+	if err != nil {
+		return err
+	}
+	col.Precision, err = d.r.GetByte()
+	if err != nil {
+		return err
+	}
+	switch col.DataType {
+	case types.NUMBER, types.TimeStampDTY, types.TimeStampTZ_DTY, types.INTERVALDS_DTY,
+		types.TIMESTAMP, types.TIMESTAMPTZ, types.IntervalDS, types.TimeStampLTZ_DTY, types.TimeStampLTZ:
+		scale, err := d.r.GetInt(2, true, true)
+		if err != nil {
+			return err
+		}
+		if scale == -127 {
+			col.Precision = uint8(math.Ceil(float64(col.Precision) * 0.30103))
+			col.Scale = 0xFF
+		} else {
+			col.Scale = uint8(scale)
+		}
+	default:
+		col.Scale, err = d.r.GetByte()
+		if err != nil {
+			return err
+		}
+	}
+	if col.DataType == types.NUMBER && col.Precision == 0 && (col.Scale == 0 || col.Scale == 0xFF) {
+		col.Precision = 38
+		col.Scale = 0xFF
+	}
+	col.MaxLen, err = d.r.GetInt64(4, true, true)
+	if err != nil {
+		return err
+	}
+	switch col.DataType {
+	case types.ROWID:
+		col.MaxLen = 128
+	case types.DATE:
+		col.MaxLen = int64(converters.MAX_LEN_DATE)
+	case types.IBFLOAT:
+		col.MaxLen = 4
+	case types.IBDOUBLE:
+		col.MaxLen = 8
+	case types.TimeStampTZ_DTY:
+		col.MaxLen = int64(converters.MAX_LEN_TIMESTAMP)
+	case types.INTERVALYM_DTY, types.INTERVALDS_DTY, types.IntervalYM, types.IntervalDS:
+		col.MaxLen = 11
+	}
+	col.ArraySize, err = d.r.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	if d.caps.TTCVersion >= 10 {
+		col.ContFlag, err = d.r.GetInt(8, true, true)
+	} else {
+		col.ContFlag, err = d.r.GetInt(4, true, true)
+	}
+	if err != nil {
+		return err
+	}
+	col.ToID, err = d.r.GetDlc()
+	if err != nil {
+		return err
+	}
+	col.Version, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	col.CharsetID, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return err
+	}
+	col.CharsetForm, err = d.r.GetInt(1, false, false)
+	if err != nil {
+		return err
+	}
+	col.MaxCharLen, err = d.r.GetInt64(4, true, true)
+	if err != nil {
+		return err
+	}
+	if d.caps.TTCVersion >= 8 {
+		col.CollationID, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return err
+		}
+	}
+	allowNull, err := d.r.GetInt(1, false, false)
+	if err != nil {
+		return err
+	}
+	col.AllowNull = allowNull > 0
+	// v7 length of name
+	if _, err = d.r.GetByte(); err != nil {
+		return err
+	}
+	name, err := d.r.GetDlc()
+	if err != nil {
+		return err
+	}
+	col.Name = d.decodeString(name)
+	name, err = d.r.GetDlc()
+	if err != nil {
+		return err
+	}
+	col.SchemaName = strings.ToUpper(d.decodeString(name))
+	name, err = d.r.GetDlc()
+	if err != nil {
+		return err
+	}
+	col.TypeName = strings.ToUpper(d.decodeString(name))
+	if col.TypeName == "XMLTYPE" {
+		col.DataType = types.XMLType
+	}
+	if d.caps.TTCVersion < 3 {
+		return nil
+	}
+	if _, err = d.r.GetInt(2, true, true); err != nil {
+		return err
+	}
+	if d.caps.TTCVersion < 6 {
+		return nil
+	}
+	udsFlags, err := d.r.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	col.IsJson = udsFlags&0x500 > 0
+	if d.caps.TTCVersion < 17 {
+		return nil
+	}
+	name, err = d.r.GetDlc()
+	if err != nil {
+		return err
+	}
+	col.DomainSchema = strings.ToUpper(d.decodeString(name))
+	name, err = d.r.GetDlc()
+	if err != nil {
+		return err
+	}
+	col.DomainName = strings.ToUpper(d.decodeString(name))
+	if d.caps.TTCVersion < 20 {
+		return nil
+	}
+	numAnnotations, err := d.r.GetInt(4, true, true)
+	if err != nil {
+		return err
+	}
+	if numAnnotations > 0 {
+		col.Annotations = make(map[string]string)
+		if _, err = d.r.GetByte(); err != nil {
+			return err
+		}
+		numAnnotations, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return err
+		}
+		for x := 0; x < numAnnotations; x++ {
+			key, value, _, err := d.r.GetKeyVal()
+			if err != nil {
+				return err
+			}
+			col.Annotations[d.decodeString(key)] = d.decodeString(value)
+		}
+		if _, err = d.r.GetInt(4, true, true); err != nil {
+			return err
+		}
+	}
+	if d.caps.TTCVersion < 24 {
+		return nil
+	}
+	if col.DataType == types.VECTOR {
+		col.VectorDim, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return err
+		}
+		col.VectorFormat, err = d.r.GetByte()
+		if err != nil {
+			return err
+		}
+		col.VectorFlag, err = d.r.GetByte()
+		return err
+	}
+	if _, err = d.r.GetInt(4, true, true); err != nil {
+		return err
+	}
+	if _, err = d.r.GetInt(1, true, true); err != nil {
+		return err
+	}
+	_, err = d.r.GetInt(1, true, true)
+	return err
+}
+
+// readValue read raw value of the column in row data message
+func (col *Column) readValue(r network.SessionReader) ([]byte, error) {
+	switch col.DataType {
+	case types.NCHAR, types.CHAR, types.RAW:
+		if col.MaxLen == 0 {
+			return nil, nil
+		}
+	case types.ROWID, types.UROWID, types.REFCURSOR, types.XMLType, types.OCIXMLType,
+		types.OCIClobLocator, types.OCIBlobLocator, types.OCIFileLocator, types.JSON, types.VECTOR:
+		return nil, &UnsupportedMessageError{MsgCode: MsgRowData,
+			Reason: fmt.Sprintf("column %s has data type %d that is decoded by the driver", col.Name, col.DataType)}
+	}
+	if col.ArraySize > 0 {
+		return nil, &UnsupportedMessageError{MsgCode: MsgRowData,
+			Reason: fmt.Sprintf("column %s is an array", col.Name)}
+	}
+	value, err := r.GetClr()
+	if err != nil {
+		return nil, err
+	}
+	if col.IsLongType() {
+		if _, err = r.GetInt(4, true, true); err != nil {
+			return nil, err
+		}
+		if _, err = r.GetInt(4, true, true); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
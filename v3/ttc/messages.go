@@ -0,0 +1,593 @@
+package ttc
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+// KeyValue is key/value pair sent in return parameters and piggyback messages
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+	Num   int
+}
+
+// Error is the error message (summary) that end the call
+type Error struct {
+	Summary *network.SummaryObject
+}
+
+func (msg *Error) Code() uint8 { return MsgError }
+
+// RowHeader start a set of rows or io vector
+type RowHeader struct {
+	ColumnCount     int
+	RowCount        int
+	UACBufferLength int
+	// BitVector has bit for each column that is sent in the next row. empty bit
+	// vector means all columns are sent
+	BitVector []byte
+}
+
+func (msg *RowHeader) Code() uint8 { return MsgRowHeader }
+
+// RowData is row of the current result set. values of columns that are not sent
+// are copied from the previous row
+type RowData struct {
+	Values [][]byte
+}
+
+func (msg *RowData) Code() uint8 { return MsgRowData }
+
+// ReturnParameters (RPA) carry values returned by the call. fields are filled
+// according to decoder Call
+type ReturnParameters struct {
+	// Values are al8o4 values of execute call. first two are the SCN
+	Values    []int
+	KeyValues []KeyValue
+	QueryID   uint64
+	// ArrayRowCounts are rows affected by each element of array bind
+	ArrayRowCounts []int64
+	// Banner and Number are returned by version call
+	Banner string
+	Number int
+}
+
+func (msg *ReturnParameters) Code() uint8 { return MsgReturnParameters }
+
+// Status end the call without error
+type Status struct {
+	EndOfCallStatus int
+	ECIDSequence    int
+}
+
+func (msg *Status) Code() uint8 { return MsgStatus }
+
+// IOVector describe direction of each bind variable
+type IOVector struct {
+	Header RowHeader
+	// Directions contain 32 for input, 16 for output and 48 for input/output
+	Directions []uint8
+}
+
+func (msg *IOVector) Code() uint8 { return MsgIOVector }
+
+// Warning is sent by the server for successful call with warning like
+// compilation errors
+type Warning struct {
+	RetCode int
+	Flag    int
+	Message string
+}
+
+func (msg *Warning) Code() uint8 { return MsgWarning }
+
+func (msg *Warning) String() string {
+	return fmt.Sprintf("warning %d: %s", msg.RetCode, msg.Message)
+}
+
+// Describe contain columns of the result set
+type Describe struct {
+	MaxRowSize int
+	Columns    []Column
+}
+
+func (msg *Describe) Code() uint8 { return MsgDescribe }
+
+// FlushOutBinds ask the client to answer with the same code before the server
+// continue
+type FlushOutBinds struct{}
+
+func (msg *FlushOutBinds) Code() uint8 { return MsgFlushOutBinds }
+
+// BitVector tell which columns are sent in the next row
+type BitVector struct {
+	ColumnsSent int
+	BitVector   []byte
+}
+
+func (msg *BitVector) Code() uint8 { return MsgBitVector }
+
+// server side piggyback operation codes
+const (
+	PiggybackQueryCache       uint8 = 1
+	PiggybackOSPID            uint8 = 2
+	PiggybackTraceEvent       uint8 = 3
+	PiggybackSessionReturn    uint8 = 4
+	PiggybackNLS              uint8 = 5
+	PiggybackLTXID            uint8 = 7
+	PiggybackReplayContext    uint8 = 8
+	PiggybackExtSync          uint8 = 9
+	PiggybackSessionSignature uint8 = 10
+)
+
+// ServerPiggyback is server side notification sent within the response
+type ServerPiggyback struct {
+	OpCode uint8
+	// KeyValues are nls values of session return and nls operations
+	KeyValues []KeyValue
+	Flag      int
+	SessionID int
+	SerialID  int
+	// Data is server process id of OSPID operation and the logical transaction
+	// id of LTXID operation
+	Data []byte
+}
+
+func (msg *ServerPiggyback) Code() uint8 { return MsgServerPiggyback }
+
+// Renegotiate tell that the server rejected fast negotiation. the client should
+// redo protocol and data type negotiation
+type Renegotiate struct{}
+
+func (msg *Renegotiate) Code() uint8 { return MsgRenegotiate }
+
+func (d *Decoder) getInts(count, size int) ([]int, error) {
+	output := make([]int, count)
+	var err error
+	for x := 0; x < count; x++ {
+		output[x], err = d.r.GetInt(size, true, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+func (d *Decoder) getKeyValues(count int) ([]KeyValue, error) {
+	output := make([]KeyValue, 0, count)
+	for x := 0; x < count; x++ {
+		key, val, num, err := d.r.GetKeyVal()
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, KeyValue{Key: key, Value: val, Num: num})
+	}
+	return output, nil
+}
+
+func (d *Decoder) readError() (Message, error) {
+	summary, err := network.ReadSummary(d.r, d.caps)
+	if err != nil {
+		return nil, err
+	}
+	return &Error{Summary: summary}, nil
+}
+
+func (d *Decoder) columnCount() int {
+	return len(d.Columns)
+}
+
+func (d *Decoder) setBitVector(bitVector []byte) {
+	count := d.columnCount()
+	if len(d.sent) != count {
+		d.sent = make([]bool, count)
+	}
+	for x := 0; x < count; x++ {
+		d.sent[x] = len(bitVector) == 0 || (x/8 < len(bitVector) && bitVector[x/8]&(1<<(x%8)) > 0)
+	}
+}
+
+func (d *Decoder) readHeader() (*RowHeader, error) {
+	var err error
+	msg := new(RowHeader)
+	if _, err = d.r.GetByte(); err != nil {
+		return nil, err
+	}
+	msg.ColumnCount, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	num, err := d.r.GetInt(4, true, true)
+	if err != nil {
+		return nil, err
+	}
+	msg.ColumnCount += num * 0x100
+	msg.RowCount, err = d.r.GetInt(4, true, true)
+	if err != nil {
+		return nil, err
+	}
+	msg.UACBufferLength, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	msg.BitVector, err = d.r.GetDlc()
+	if err != nil {
+		return nil, err
+	}
+	_, err = d.r.GetDlc()
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (d *Decoder) readRowHeader() (Message, error) {
+	msg, err := d.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	d.setBitVector(msg.BitVector)
+	return msg, nil
+}
+
+func (d *Decoder) readRowData() (Message, error) {
+	count := d.columnCount()
+	if count == 0 {
+		return nil, errNoColumns
+	}
+	if len(d.sent) != count {
+		d.setBitVector(nil)
+	}
+	if len(d.previous) != count {
+		d.previous = make([][]byte, count)
+	}
+	msg := &RowData{Values: make([][]byte, count)}
+	for x := range d.Columns {
+		if d.sent[x] {
+			value, err := d.Columns[x].readValue(d.r)
+			if err != nil {
+				return nil, err
+			}
+			d.previous[x] = value
+		}
+		msg.Values[x] = d.previous[x]
+	}
+	return msg, nil
+}
+
+func (d *Decoder) readReturnParameters() (Message, error) {
+	msg := new(ReturnParameters)
+	switch d.Call {
+	case CallAuthPhaseOne, CallAuthPhaseTwo:
+		size := 2
+		if d.Call == CallAuthPhaseOne {
+			size = 4
+		}
+		count, err := d.r.GetInt(size, true, true)
+		if err != nil {
+			return nil, err
+		}
+		msg.KeyValues, err = d.getKeyValues(count)
+		if err != nil {
+			return nil, err
+		}
+		return msg, nil
+	case CallVersion:
+		length, err := d.r.GetInt(2, true, true)
+		if err != nil {
+			return nil, err
+		}
+		msg.Banner, err = d.r.GetString(length)
+		if err != nil {
+			return nil, err
+		}
+		msg.Number, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return nil, err
+		}
+		return msg, nil
+	case CallLob:
+		return nil, &UnsupportedMessageError{MsgCode: MsgReturnParameters, Reason: "lob locators depend on the request"}
+	}
+	size, err := d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	msg.Values, err = d.getInts(size, 4)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = d.r.GetInt(2, true, true); err != nil {
+		return nil, err
+	}
+	size, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	msg.KeyValues, err = d.getKeyValues(size)
+	if err != nil {
+		return nil, err
+	}
+	if d.caps.TTCVersion >= 4 {
+		size, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return nil, err
+		}
+		if size > 0 {
+			bty, err := d.r.GetBytes(size)
+			if err != nil {
+				return nil, err
+			}
+			if len(bty) >= 8 {
+				msg.QueryID = binary.LittleEndian.Uint64(bty[size-8:])
+			}
+		}
+	}
+	if d.caps.TTCVersion >= 7 && d.ArrayRowCounts {
+		size, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return nil, err
+		}
+		msg.ArrayRowCounts = make([]int64, size)
+		for x := 0; x < size; x++ {
+			msg.ArrayRowCounts[x], err = d.r.GetInt64(8, true, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return msg, nil
+}
+
+func (d *Decoder) readStatus() (Message, error) {
+	var err error
+	msg := new(Status)
+	if d.caps.HasEOS {
+		msg.EndOfCallStatus, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if d.caps.HasFSAP {
+		msg.ECIDSequence, err = d.r.GetInt(2, true, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+func (d *Decoder) readIOVector() (Message, error) {
+	header, err := d.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	msg := &IOVector{Header: *header}
+	msg.Directions, err = d.r.GetBytes(header.ColumnCount)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (d *Decoder) readWarning() (Message, error) {
+	var err error
+	msg := new(Warning)
+	msg.RetCode, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	length, err := d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	msg.Flag, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	if msg.RetCode != 0 && length != 0 {
+		text, err := d.r.GetClr()
+		if err != nil {
+			return nil, err
+		}
+		msg.Message = string(text)
+	}
+	return msg, nil
+}
+
+func (d *Decoder) readDescribe() (Message, error) {
+	size, err := d.r.GetByte()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = d.r.GetBytes(int(size)); err != nil {
+		return nil, err
+	}
+	msg := new(Describe)
+	msg.MaxRowSize, err = d.r.GetInt(4, true, true)
+	if err != nil {
+		return nil, err
+	}
+	count, err := d.r.GetInt(4, true, true)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+	}
+	msg.Columns = make([]Column, count)
+	for x := range msg.Columns {
+		err = d.readColumn(&msg.Columns[x])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err = d.r.GetDlc(); err != nil {
+		return nil, err
+	}
+	if d.caps.TTCVersion >= 3 {
+		if _, err = d.getInts(2, 4); err != nil {
+			return nil, err
+		}
+	}
+	if d.caps.TTCVersion >= 4 {
+		if _, err = d.getInts(2, 4); err != nil {
+			return nil, err
+		}
+	}
+	if d.caps.TTCVersion >= 5 {
+		if _, err = d.r.GetDlc(); err != nil {
+			return nil, err
+		}
+	}
+	d.Columns = msg.Columns
+	d.sent = nil
+	d.previous = nil
+	return msg, nil
+}
+
+func (d *Decoder) readBitVector() (Message, error) {
+	count := d.columnCount()
+	if count == 0 {
+		return nil, errNoColumns
+	}
+	var err error
+	msg := new(BitVector)
+	msg.ColumnsSent, err = d.r.GetInt(2, true, true)
+	if err != nil {
+		return nil, err
+	}
+	length := count / 8
+	if count%8 > 0 {
+		length++
+	}
+	msg.BitVector, err = d.r.GetBytes(length)
+	if err != nil {
+		return nil, err
+	}
+	d.setBitVector(msg.BitVector)
+	return msg, nil
+}
+
+func (d *Decoder) readServerPiggyback() (Message, error) {
+	opCode, err := d.r.GetByte()
+	if err != nil {
+		return nil, err
+	}
+	msg := &ServerPiggyback{OpCode: opCode}
+	switch opCode {
+	case 0:
+		_, err = d.r.GetByte()
+	case PiggybackQueryCache, PiggybackTraceEvent:
+		// notification without data
+	case PiggybackOSPID:
+		var length int
+		length, err = d.r.GetInt(2, true, true)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+		msg.Data, err = d.r.GetBytes(length)
+	case PiggybackSessionReturn:
+		if _, err = d.r.GetInt(2, true, true); err != nil {
+			return nil, err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+		var count int
+		count, err = d.r.GetInt(2, true, true)
+		if err != nil {
+			return nil, err
+		}
+		msg.KeyValues, err = d.getKeyValues(count)
+		if err != nil {
+			return nil, err
+		}
+		var values []int
+		values, err = d.getInts(2, 4)
+		if err != nil {
+			return nil, err
+		}
+		msg.Flag, msg.SessionID = values[0], values[1]
+		msg.SerialID, err = d.r.GetInt(2, true, true)
+	case PiggybackNLS:
+		if _, err = d.r.GetInt(2, true, true); err != nil {
+			return nil, err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+		var count int
+		count, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+		msg.KeyValues, err = d.getKeyValues(count)
+		if err != nil {
+			return nil, err
+		}
+		_, err = d.r.GetInt(4, true, true)
+	case PiggybackLTXID:
+		var length int
+		length, err = d.r.GetInt(4, true, true)
+		if err != nil {
+			return nil, err
+		}
+		if length > 0 {
+			msg.Data, err = d.r.GetClr()
+			if len(msg.Data) > length {
+				msg.Data = msg.Data[:length]
+			}
+		}
+	case PiggybackReplayContext:
+		if _, err = d.r.GetInt(2, true, true); err != nil {
+			return nil, err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+		if _, err = d.getInts(2, 4); err != nil {
+			return nil, err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+		_, err = d.r.GetDlc()
+	case PiggybackExtSync:
+		if _, err = d.r.GetInt(2, true, true); err != nil {
+			return nil, err
+		}
+		_, err = d.r.GetByte()
+	case PiggybackSessionSignature:
+		if _, err = d.r.GetInt(2, true, true); err != nil {
+			return nil, err
+		}
+		if _, err = d.r.GetByte(); err != nil {
+			return nil, err
+		}
+		// signature flags, client and server signatures
+		for i := 0; i < 3; i++ {
+			if _, err = d.r.GetInt(8, true, true); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, &UnsupportedMessageError{MsgCode: MsgServerPiggyback,
+			Reason: fmt.Sprintf("unknown operation code %d", opCode)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
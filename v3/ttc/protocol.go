@@ -0,0 +1,117 @@
+package ttc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/sijms/go-ora/v3/network"
+)
+
+// Protocol is the server answer of protocol negotiation
+type Protocol struct {
+	ProtocolVersion uint8
+	// OracleVersion is the lowest server version that use the protocol version
+	OracleVersion         int
+	Banner                string
+	Charset               int
+	Flags                 uint8
+	NCharset              int
+	ServerCompileTimeCaps []byte
+	ServerRuntimeCaps     []byte
+}
+
+func (msg *Protocol) Code() uint8 { return MsgProtocol }
+
+// Capabilities return capabilities of session that use the client TTC version
+// with this server
+func (msg *Protocol) Capabilities(clientTTCVersion uint8) network.TTCCapabilities {
+	caps := msg.ServerCompileTimeCaps
+	output := network.TTCCapabilities{TTCVersion: clientTTCVersion}
+	if len(caps) > 7 && caps[7] < output.TTCVersion {
+		output.TTCVersion = caps[7]
+	}
+	output.HasEOS = len(caps) > 15 && caps[15]&1 != 0
+	output.HasFSAP = len(caps) > 16 && caps[16]&1 != 0
+	output.UseBigClrChunks = len(caps) > 37 && caps[37]&32 != 0
+	return output
+}
+
+func (d *Decoder) readProtocol() (Message, error) {
+	var err error
+	msg := new(Protocol)
+	msg.ProtocolVersion, err = d.r.GetByte()
+	if err != nil {
+		return nil, err
+	}
+	switch msg.ProtocolVersion {
+	case 4:
+		msg.OracleVersion = 7230
+	case 5:
+		msg.OracleVersion = 8030
+	case 6:
+		msg.OracleVersion = 8100
+	default:
+		return nil, fmt.Errorf("unsupported protocol server version: %d", msg.ProtocolVersion)
+	}
+	if _, err = d.r.GetByte(); err != nil {
+		return nil, err
+	}
+	msg.Banner, err = d.r.GetNullTermString()
+	if err != nil {
+		return nil, err
+	}
+	msg.Charset, err = d.r.GetInt(2, false, false)
+	if err != nil {
+		return nil, err
+	}
+	msg.Flags, err = d.r.GetByte()
+	if err != nil {
+		return nil, err
+	}
+	charsetElem, err := d.r.GetInt(2, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if charsetElem > 0 {
+		if _, err = d.r.GetBytes(charsetElem * 5); err != nil {
+			return nil, err
+		}
+	}
+	length, err := d.r.GetInt(2, false, true)
+	if err != nil {
+		return nil, err
+	}
+	numArray, err := d.r.GetBytes(length)
+	if err != nil {
+		return nil, err
+	}
+	if len(numArray) < 7 {
+		return nil, errors.New("invalid protocol negotiation message")
+	}
+	index := int(6 + numArray[5] + numArray[6])
+	if len(numArray) < index+5 {
+		return nil, errors.New("invalid protocol negotiation message")
+	}
+	msg.NCharset = int(binary.BigEndian.Uint16(numArray[index+3 : index+5]))
+	size, err := d.r.GetByte()
+	if err != nil {
+		return nil, err
+	}
+	msg.ServerCompileTimeCaps, err = d.r.GetBytes(int(size))
+	if err != nil {
+		return nil, err
+	}
+	size, err = d.r.GetByte()
+	if err != nil {
+		return nil, err
+	}
+	msg.ServerRuntimeCaps, err = d.r.GetBytes(int(size))
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.ServerCompileTimeCaps) < 8 {
+		return nil, errors.New("server compile time caps length less than 8")
+	}
+	return msg, nil
+}
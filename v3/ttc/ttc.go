@@ -0,0 +1,265 @@
+// Package ttc decode messages of Two-Task Common protocol (TTC) that the server
+// send in answer of client calls.
+//
+// the response of each call is a stream of messages, each one start with a
+// message code byte and end with error message (code 4, called summary in the
+// driver) or status message (code 9). the layout of some messages depend on the
+// negotiated capabilities and on the call, so the Decoder hold both.
+//
+// the decoder is used by the driver and by diagnostic tools like oracap. message
+// codes that are unknown or can't be decoded without call specific data return
+// *UnknownMessageError and *UnsupportedMessageError instead of reading garbage
+package ttc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sijms/go-ora/v3/converters"
+	"github.com/sijms/go-ora/v3/network"
+)
+
+// message codes
+const (
+	MsgProtocol         uint8 = 1
+	MsgDataTypes        uint8 = 2
+	MsgFunction         uint8 = 3
+	MsgError            uint8 = 4
+	MsgRowHeader        uint8 = 6
+	MsgRowData          uint8 = 7
+	MsgReturnParameters uint8 = 8
+	MsgStatus           uint8 = 9
+	MsgIOVector         uint8 = 11
+	MsgLobData          uint8 = 14
+	MsgWarning          uint8 = 15
+	MsgDescribe         uint8 = 16
+	MsgFlushOutBinds    uint8 = 19
+	MsgBitVector        uint8 = 21
+	MsgServerPiggyback  uint8 = 23
+	MsgImplicitResults  uint8 = 27
+	MsgRenegotiate      uint8 = 28
+	// MsgPiggyback is sent by the client before function call
+	MsgPiggyback uint8 = 0x11
+)
+
+var messageNames = map[uint8]string{
+	MsgProtocol:         "protocol negotiation",
+	MsgDataTypes:        "data type negotiation",
+	MsgFunction:         "function",
+	MsgError:            "error",
+	MsgRowHeader:        "row header",
+	MsgRowData:          "row data",
+	MsgReturnParameters: "return parameters",
+	MsgStatus:           "status",
+	MsgIOVector:         "io vector",
+	MsgLobData:          "lob data",
+	MsgWarning:          "warning",
+	MsgDescribe:         "describe information",
+	MsgFlushOutBinds:    "flush out binds",
+	MsgBitVector:        "bit vector",
+	MsgServerPiggyback:  "server side piggyback",
+	MsgImplicitResults:  "implicit result set",
+	MsgRenegotiate:      "renegotiate",
+	MsgPiggyback:        "piggyback function",
+}
+
+// function codes of calls sent with MsgFunction and MsgPiggyback
+const (
+	FuncExecute        uint8 = 4
+	FuncFetch          uint8 = 5
+	FuncLogoff         uint8 = 9
+	FuncCommit         uint8 = 0xE
+	FuncRollback       uint8 = 0xF
+	FuncVersion        uint8 = 0x3B
+	FuncReExecuteFetch uint8 = 0x4E
+	FuncExecuteAll     uint8 = 0x5E
+	FuncLob            uint8 = 0x60
+	FuncCloseCursors   uint8 = 0x69
+	FuncAuthPhaseTwo   uint8 = 0x73
+	FuncAuthPhaseOne   uint8 = 0x76
+	FuncAQEnqueue      uint8 = 0x79
+	FuncAQDequeue      uint8 = 0x7A
+	FuncAQArray        uint8 = 0x91
+	FuncPing           uint8 = 0x93
+)
+
+var functionNames = map[uint8]string{
+	FuncExecute:        "execute",
+	FuncFetch:          "fetch",
+	FuncLogoff:         "logoff",
+	FuncCommit:         "commit",
+	FuncRollback:       "rollback",
+	FuncVersion:        "database version",
+	FuncReExecuteFetch: "re-execute and fetch",
+	FuncExecuteAll:     "parse/execute/fetch",
+	FuncLob:            "lob operation",
+	FuncCloseCursors:   "close cursors",
+	FuncAuthPhaseTwo:   "authentication phase two",
+	FuncAuthPhaseOne:   "authentication phase one",
+	FuncAQEnqueue:      "aq enqueue",
+	FuncAQDequeue:      "aq dequeue",
+	FuncAQArray:        "aq array operation",
+	FuncPing:           "ping",
+}
+
+// MessageName return description of message code
+func MessageName(code uint8) string {
+	if name, ok := messageNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown message %d", code)
+}
+
+// FunctionName return description of function code
+func FunctionName(code uint8) string {
+	if name, ok := functionNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown function 0x%X", code)
+}
+
+// Call identify the request whose response is decoded. return parameters message
+// has different layout for each call
+type Call int
+
+const (
+	// CallExecute is execute, fetch and all other calls with general return parameters
+	CallExecute Call = iota
+	CallAuthPhaseOne
+	CallAuthPhaseTwo
+	CallVersion
+	// CallLob return parameters contain locators that can't be decoded without the request
+	CallLob
+)
+
+// CallOf return the call of function code
+func CallOf(funcCode uint8) Call {
+	switch funcCode {
+	case FuncAuthPhaseOne:
+		return CallAuthPhaseOne
+	case FuncAuthPhaseTwo:
+		return CallAuthPhaseTwo
+	case FuncVersion:
+		return CallVersion
+	case FuncLob:
+		return CallLob
+	default:
+		return CallExecute
+	}
+}
+
+// Message is decoded TTC message
+type Message interface {
+	Code() uint8
+}
+
+// UnknownMessageError is returned for message code that is not part of the protocol
+type UnknownMessageError struct {
+	MsgCode uint8
+}
+
+func (err *UnknownMessageError) Error() string {
+	return fmt.Sprintf("TTC error: received code %d during response reading", err.MsgCode)
+}
+
+// UnsupportedMessageError is returned for message that need data from the request
+// to be decoded or isn't implemented by the decoder
+type UnsupportedMessageError struct {
+	MsgCode uint8
+	Reason  string
+}
+
+func (err *UnsupportedMessageError) Error() string {
+	return fmt.Sprintf("ttc: can't decode message %d (%s): %s", err.MsgCode, MessageName(err.MsgCode), err.Reason)
+}
+
+// Decoder read messages from session reader
+type Decoder struct {
+	r    network.SessionReader
+	caps network.TTCCapabilities
+	// Call is the request of the decoded response
+	Call Call
+	// ArrayRowCounts tell that return parameters carry rows affected by each
+	// element of array bind (TTC version >= 7)
+	ArrayRowCounts bool
+	// StrConv decode names of describe message. nil keep the bytes as is
+	StrConv converters.IStringConverter
+	// Columns of current result set. it is set by describe message and used to
+	// decode row data and bit vector messages
+	Columns  []Column
+	sent     []bool
+	previous [][]byte
+}
+
+// NewDecoder create decoder that read messages from r using negotiated capabilities
+func NewDecoder(r network.SessionReader, caps network.TTCCapabilities) *Decoder {
+	return &Decoder{r: r, caps: caps}
+}
+
+// Capabilities return the capabilities used for decoding
+func (d *Decoder) Capabilities() network.TTCCapabilities {
+	return d.caps
+}
+
+// Reset change the reader and keep the columns of current result set. it is
+// used when each packet of the response is decoded alone
+func (d *Decoder) Reset(r network.SessionReader) {
+	d.r = r
+}
+
+// Next read message code then decode the message
+func (d *Decoder) Next() (Message, error) {
+	code, err := d.r.GetByte()
+	if err != nil {
+		return nil, err
+	}
+	return d.Decode(code)
+}
+
+// Decode read body of message whose code is already read
+func (d *Decoder) Decode(code uint8) (Message, error) {
+	switch code {
+	case MsgProtocol:
+		return d.readProtocol()
+	case MsgError:
+		return d.readError()
+	case MsgRowHeader:
+		return d.readRowHeader()
+	case MsgRowData:
+		return d.readRowData()
+	case MsgReturnParameters:
+		return d.readReturnParameters()
+	case MsgStatus:
+		return d.readStatus()
+	case MsgIOVector:
+		return d.readIOVector()
+	case MsgWarning:
+		return d.readWarning()
+	case MsgDescribe:
+		return d.readDescribe()
+	case MsgFlushOutBinds:
+		return &FlushOutBinds{}, nil
+	case MsgBitVector:
+		return d.readBitVector()
+	case MsgServerPiggyback:
+		return d.readServerPiggyback()
+	case MsgRenegotiate:
+		return &Renegotiate{}, nil
+	case MsgLobData:
+		return nil, &UnsupportedMessageError{MsgCode: code, Reason: "lob data size depend on the request"}
+	case MsgImplicitResults:
+		return nil, &UnsupportedMessageError{MsgCode: code, Reason: "cursors are not decoded"}
+	default:
+		return nil, &UnknownMessageError{MsgCode: code}
+	}
+}
+
+// IsEndOfCall return true for messages that end the response of a call
+func IsEndOfCall(msg Message) bool {
+	if msg == nil {
+		return false
+	}
+	return msg.Code() == MsgError || msg.Code() == MsgStatus
+}
+
+var errNoColumns = errors.New("row data received without column description")
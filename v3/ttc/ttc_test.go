@@ -0,0 +1,298 @@
+package ttc_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sijms/go-ora/v3/network"
+	"github.com/sijms/go-ora/v3/ttc"
+	"github.com/sijms/go-ora/v3/types"
+)
+
+var prop = network.SessionProperties{ClrChunkSize: 0x40}
+
+func newWriter() *network.MemorySession {
+	return network.NewMemorySession(nil, nil, prop)
+}
+
+func newDecoder(w *network.MemorySession, caps network.TTCCapabilities) *ttc.Decoder {
+	return ttc.NewDecoder(network.NewMemorySession(w.GetWriteBuffer(), nil, prop), caps)
+}
+
+// putColumn write column information with TTC version 6 layout
+func putColumn(w *network.MemorySession, name string, dataType uint8, maxLen int) {
+	w.PutBytes(dataType, 0, 0, 0)
+	w.PutUint(maxLen, 4, true, true)
+	w.PutUint(0, 4, true, true) // array size
+	w.PutUint(0, 4, true, true) // cont flag
+	w.PutBytes(0)               // toid
+	w.PutUint(0, 2, true, true) // version
+	w.PutUint(873, 2, true, true)
+	w.PutBytes(1)
+	w.PutUint(maxLen, 4, true, true)
+	w.PutBytes(1, 0)
+	w.PutDlc([]byte(name))
+	w.PutBytes(0, 0)            // schema and type name
+	w.PutUint(0, 2, true, true) // TTC >= 3
+	w.PutUint(0, 4, true, true) // uds flags
+}
+
+func putRowHeader(w *network.MemorySession, columnCount, rowCount int, bitVector []byte) {
+	w.PutBytes(ttc.MsgRowHeader, 0)
+	w.PutUint(columnCount, 2, true, true)
+	w.PutUint(0, 4, true, true)
+	w.PutUint(rowCount, 4, true, true)
+	w.PutUint(0, 2, true, true)
+	w.PutDlc(bitVector)
+	w.PutBytes(0)
+}
+
+func TestDecodeQueryResponse(t *testing.T) {
+	caps := network.TTCCapabilities{TTCVersion: 6, HasEOS: true, HasFSAP: true}
+	w := newWriter()
+	w.PutBytes(ttc.MsgDescribe, 0)
+	w.PutUint(20, 4, true, true)
+	w.PutUint(2, 4, true, true)
+	w.PutBytes(0)
+	putColumn(w, "ID", uint8(types.NUMBER), 22)
+	putColumn(w, "NAME", uint8(types.NCHAR), 10)
+	w.PutBytes(0)
+	w.PutUint(0, 4, true, true)
+	w.PutUint(0, 4, true, true)
+	w.PutUint(0, 4, true, true)
+	w.PutUint(0, 4, true, true)
+	w.PutBytes(0)
+	putRowHeader(w, 2, 2, nil)
+	w.PutBytes(ttc.MsgRowData)
+	w.PutClr([]byte{0xC1, 2})
+	w.PutClr([]byte("KING"))
+	w.PutBytes(ttc.MsgBitVector)
+	w.PutUint(1, 2, true, true)
+	w.PutBytes(1)
+	w.PutBytes(ttc.MsgRowData)
+	w.PutClr([]byte{0xC1, 3})
+	w.PutBytes(ttc.MsgStatus)
+	w.PutUint(7, 4, true, true)
+	w.PutUint(3, 2, true, true)
+
+	decoder := newDecoder(w, caps)
+	var msgs []ttc.Message
+	for {
+		msg, err := decoder.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+		if ttc.IsEndOfCall(msg) {
+			break
+		}
+	}
+	codes := make([]uint8, len(msgs))
+	for x, msg := range msgs {
+		codes[x] = msg.Code()
+	}
+	expected := []uint8{ttc.MsgDescribe, ttc.MsgRowHeader, ttc.MsgRowData, ttc.MsgBitVector, ttc.MsgRowData, ttc.MsgStatus}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Fatalf("expected messages %v got: %v", expected, codes)
+	}
+	describe := msgs[0].(*ttc.Describe)
+	if len(describe.Columns) != 2 || describe.Columns[0].Name != "ID" || describe.Columns[1].Name != "NAME" {
+		t.Errorf("unexpected columns: %+v", describe.Columns)
+	}
+	if col := describe.Columns[0]; col.Precision != 38 || col.Scale != 0xFF {
+		t.Errorf("expected NUMBER(38) got precision: %d scale: %d", col.Precision, col.Scale)
+	}
+	if row := msgs[2].(*ttc.RowData); !reflect.DeepEqual(row.Values, [][]byte{{0xC1, 2}, []byte("KING")}) {
+		t.Errorf("unexpected first row: %v", row.Values)
+	}
+	// NAME column isn't sent in the second row
+	if row := msgs[4].(*ttc.RowData); !reflect.DeepEqual(row.Values, [][]byte{{0xC1, 3}, []byte("KING")}) {
+		t.Errorf("unexpected second row: %v", row.Values)
+	}
+	if status := msgs[5].(*ttc.Status); status.EndOfCallStatus != 7 || status.ECIDSequence != 3 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestDecodeReturnParameters(t *testing.T) {
+	caps := network.TTCCapabilities{TTCVersion: 7}
+	queryID := make([]byte, 8)
+	binary.LittleEndian.PutUint64(queryID, 0x1234)
+	w := newWriter()
+	w.PutBytes(ttc.MsgReturnParameters)
+	w.PutUint(3, 2, true, true)
+	w.PutUint(10, 4, true, true)
+	w.PutUint(20, 4, true, true)
+	w.PutUint(30, 4, true, true)
+	w.PutUint(0, 2, true, true)
+	w.PutUint(1, 2, true, true)
+	w.PutKeyValString("", "+02:00", 163)
+	w.PutUint(len(queryID), 4, true, true)
+	w.PutBytes(queryID...)
+	w.PutUint(2, 4, true, true)
+	w.PutUint(1, 8, true, true)
+	w.PutUint(5, 8, true, true)
+	decoder := newDecoder(w, caps)
+	decoder.ArrayRowCounts = true
+	msg, err := decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpa := msg.(*ttc.ReturnParameters)
+	if !reflect.DeepEqual(rpa.Values, []int{10, 20, 30}) {
+		t.Errorf("unexpected values: %v", rpa.Values)
+	}
+	if len(rpa.KeyValues) != 1 || rpa.KeyValues[0].Num != 163 || !bytes.Equal(rpa.KeyValues[0].Value, []byte("+02:00")) {
+		t.Errorf("unexpected key values: %+v", rpa.KeyValues)
+	}
+	if rpa.QueryID != 0x1234 {
+		t.Errorf("expected query id 0x1234 got: 0x%X", rpa.QueryID)
+	}
+	if !reflect.DeepEqual(rpa.ArrayRowCounts, []int64{1, 5}) {
+		t.Errorf("unexpected array row counts: %v", rpa.ArrayRowCounts)
+	}
+
+	w = newWriter()
+	w.PutBytes(ttc.MsgReturnParameters)
+	w.PutUint(1, 4, true, true)
+	w.PutKeyValString("AUTH_SESSKEY", "ABCD", 0)
+	decoder = newDecoder(w, caps)
+	decoder.Call = ttc.CallOf(ttc.FuncAuthPhaseOne)
+	msg, err = decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpa = msg.(*ttc.ReturnParameters)
+	if len(rpa.KeyValues) != 1 || string(rpa.KeyValues[0].Key) != "AUTH_SESSKEY" || string(rpa.KeyValues[0].Value) != "ABCD" {
+		t.Errorf("unexpected auth key values: %+v", rpa.KeyValues)
+	}
+}
+
+func TestDecodeLTXID(t *testing.T) {
+	caps := network.TTCCapabilities{TTCVersion: 6}
+	ltxid := []byte{1, 2, 3, 4, 5}
+	w := newWriter()
+	w.PutBytes(ttc.MsgServerPiggyback, ttc.PiggybackLTXID)
+	w.PutUint(len(ltxid), 4, true, true)
+	w.PutClr(ltxid)
+	w.PutBytes(ttc.MsgServerPiggyback, ttc.PiggybackLTXID)
+	w.PutUint(0, 4, true, true)
+	decoder := newDecoder(w, caps)
+	msg, err := decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if piggyback := msg.(*ttc.ServerPiggyback); piggyback.OpCode != ttc.PiggybackLTXID || !bytes.Equal(piggyback.Data, ltxid) {
+		t.Errorf("unexpected piggyback: %+v", piggyback)
+	}
+	msg, err = decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if piggyback := msg.(*ttc.ServerPiggyback); piggyback.Data != nil {
+		t.Errorf("expected empty ltxid got: %X", piggyback.Data)
+	}
+}
+
+func TestDecodeServerPiggyback(t *testing.T) {
+	var testScenarios = []struct {
+		name   string
+		opCode uint8
+		write  func(w *network.MemorySession)
+	}{
+		{"query cache", ttc.PiggybackQueryCache, func(w *network.MemorySession) {}},
+		{"trace event", ttc.PiggybackTraceEvent, func(w *network.MemorySession) {}},
+		{"replay context", ttc.PiggybackReplayContext, func(w *network.MemorySession) {
+			w.PutUint(1, 2, true, true)
+			w.PutBytes(1)
+			w.PutUint(0, 4, true, true)
+			w.PutUint(0, 4, true, true)
+			w.PutBytes(0)
+			w.PutDlc([]byte{5, 6})
+		}},
+		{"ext sync", ttc.PiggybackExtSync, func(w *network.MemorySession) {
+			w.PutUint(1, 2, true, true)
+			w.PutBytes(1)
+		}},
+		{"session signature", ttc.PiggybackSessionSignature, func(w *network.MemorySession) {
+			w.PutUint(1, 2, true, true)
+			w.PutBytes(1)
+			w.PutUint(3, 8, true, true)
+			w.PutUint(uint64(0x1122334455667788), 8, true, true)
+			w.PutUint(uint64(0x8877665544332211), 8, true, true)
+		}},
+	}
+	caps := network.TTCCapabilities{TTCVersion: 6}
+	ltxid := []byte{1, 2, 3}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWriter()
+			w.PutBytes(ttc.MsgServerPiggyback, tt.opCode)
+			tt.write(w)
+			// following message is read from the right position
+			w.PutBytes(ttc.MsgServerPiggyback, ttc.PiggybackLTXID)
+			w.PutUint(len(ltxid), 4, true, true)
+			w.PutClr(ltxid)
+			decoder := newDecoder(w, caps)
+			msg, err := decoder.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if piggyback := msg.(*ttc.ServerPiggyback); piggyback.OpCode != tt.opCode {
+				t.Errorf("expected operation code %d got: %d", tt.opCode, piggyback.OpCode)
+			}
+			msg, err = decoder.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if piggyback := msg.(*ttc.ServerPiggyback); !bytes.Equal(piggyback.Data, ltxid) {
+				t.Errorf("expected ltxid %v got: %+v", ltxid, piggyback)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	caps := network.TTCCapabilities{TTCVersion: 6}
+	w := newWriter()
+	w.PutBytes(0x63)
+	_, err := newDecoder(w, caps).Next()
+	var unknown *ttc.UnknownMessageError
+	if !errors.As(err, &unknown) || unknown.MsgCode != 0x63 {
+		t.Errorf("expected unknown message error got: %v", err)
+	}
+	if err != nil && err.Error() != "TTC error: received code 99 during response reading" {
+		t.Errorf("unexpected error message: %s", err)
+	}
+
+	w = newWriter()
+	w.PutBytes(ttc.MsgServerPiggyback, 0x40)
+	_, err = newDecoder(w, caps).Next()
+	var unsupported *ttc.UnsupportedMessageError
+	if !errors.As(err, &unsupported) || unsupported.MsgCode != ttc.MsgServerPiggyback {
+		t.Errorf("expected unsupported message error got: %v", err)
+	}
+
+	w = newWriter()
+	w.PutBytes(ttc.MsgRowData)
+	if _, err = newDecoder(w, caps).Next(); err == nil {
+		t.Error("expected error for row data without columns")
+	}
+
+	w = newWriter()
+	w.PutBytes(ttc.MsgWarning)
+	w.PutUint(24344, 2, true, true)
+	w.PutUint(5, 2, true, true)
+	w.PutUint(0, 2, true, true)
+	w.PutClr([]byte("error"))
+	msg, err := newDecoder(w, caps).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warning := msg.(*ttc.Warning); warning.RetCode != 24344 || warning.Message != "error" {
+		t.Errorf("unexpected warning: %+v", warning)
+	}
+}
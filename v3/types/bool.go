@@ -1,7 +1,6 @@
 package types
 
 import (
-	"bytes"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
@@ -33,6 +32,14 @@ func (bl *Bool) SetValue(input interface{}) error {
 		} else {
 			bl.bValue = []byte{1, 0}
 		}
+	case sql.NullBool:
+		if !value.Valid {
+			bl.bValue = nil
+			return nil
+		}
+		return bl.SetValue(value.Bool)
+	case *sql.NullBool:
+		return bl.SetValue(*value)
 	default:
 		return fmt.Errorf("cannot set value of type %T into Bool", input)
 	}
@@ -43,10 +50,12 @@ func (bl *Bool) Value() (interface{}, error) {
 	if bl.bValue == nil {
 		return nil, nil
 	}
-	if bytes.Equal(bl.bValue, []byte{1, 1}) {
-		return true, nil
-	}
-	return false, nil
+	// true is sent as {1, 1} while false may be sent as {1, 0} or single byte {0}
+	return len(bl.bValue) > 0 && bl.bValue[len(bl.bValue)-1] == 1, nil
+}
+
+func (bl *Bool) GetMaxLen() int64 {
+	return MaxLenBool
 }
 
 func (bl *Bool) Scan(input interface{}) error {
@@ -60,6 +60,10 @@ func defaultCopy(dest reflect.Value, src any) error {
 	//}
 	// if the source implements the oracle type interface, use it
 	if temp, ok := src.(OracleType); ok {
+		// CopyTo of oracle types accept pointer to the destination
+		if dest.CanAddr() {
+			return temp.CopyTo(dest.Addr().Interface())
+		}
 		return temp.CopyTo(dest.Interface())
 	}
 	if dest.Kind() == reflect.Interface {
@@ -3,6 +3,7 @@ package types
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestCreateNewType(t *testing.T) {
@@ -88,3 +89,63 @@ func TestCopyStringFromOthers(t *testing.T) {
 		t.Fatal("error copy true to string")
 	}
 }
+
+func TestRCopyOracleType(t *testing.T) {
+	date := NewTimeStamp(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+	str := &String{}
+	_ = str.SetValue("hello")
+	raw := &Raw{}
+	_ = raw.SetValue([]byte{1, 2, 3})
+	boolean := &Bool{}
+	_ = boolean.SetValue(true)
+	clob := &Clob{}
+	_ = clob.SetValue("text")
+	vector, err := CreateVector([]float32{1.5, -2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testScenarios = []struct {
+		name     string
+		src      any
+		dest     any
+		expected any
+	}{
+		{"date to time", date, new(time.Time), time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)},
+		{"string to string", str, new(string), "hello"},
+		{"string to bytes", str, new([]byte), []byte("hello")},
+		{"raw to bytes", raw, new([]byte), []byte{1, 2, 3}},
+		{"raw to string", raw, new(string), "\x01\x02\x03"},
+		{"bool to bool", boolean, new(bool), true},
+		{"clob to string", clob, new(string), "text"},
+		{"vector to float32 array", vector, new([]float32), []float32{1.5, -2}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RCopy(reflect.ValueOf(tt.dest), tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := reflect.ValueOf(tt.dest).Elem().Interface(); !reflect.DeepEqual(got, tt.expected) {
+				if tm, ok := got.(time.Time); !ok || !tm.Equal(tt.expected.(time.Time)) {
+					t.Errorf("expected: %v and got: %v", tt.expected, got)
+				}
+			}
+		})
+	}
+	// struct fields are addressable and receive the value through RCopy
+	var row struct {
+		Data []byte
+		Name string
+	}
+	err = RCopy(reflect.ValueOf(&row).Elem().Field(0), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RCopy(reflect.ValueOf(&row).Elem().Field(1), str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(row.Data, []byte{1, 2, 3}) || row.Name != "hello" {
+		t.Errorf("unexpected struct copy: %+v", row)
+	}
+}
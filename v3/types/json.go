@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
@@ -23,6 +24,12 @@ type JsonDecoder interface {
 type JsonEncoder interface {
 	EncodeJson(input interface{}) ([]byte, error)
 }
+
+// JsonUnmarshaler implemented by coders that decode json data directly into go
+// structures (honouring json tags) without passing through map[string]interface{}
+type JsonUnmarshaler interface {
+	UnmarshalJson(data []byte, v interface{}) error
+}
 type Json struct {
 	Basic
 	Coder JsonCoder
@@ -139,14 +146,62 @@ func (js *Json) CopyTo(dest driver.Value) error {
 		} else {
 			*dst = value.([]interface{})
 		}
-	default:
+	case nil:
 		return fmt.Errorf("cannot copy Json to variable of type %T", dest)
+	default:
+		return js.Unmarshal(dest)
 	}
 	return nil
 }
 
+// Unmarshal decode json document into v. v can be pointer to struct with json tags,
+// map, slice or any value accepted by encoding/json
+func (js *Json) Unmarshal(v interface{}) error {
+	if coder, ok := js.Coder.(JsonUnmarshaler); ok {
+		return coder.UnmarshalJson(js.bValue, v)
+	}
+	value, err := js.Value()
+	if err != nil {
+		return err
+	}
+	temp, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(temp, v)
+}
+
 func (js *Json) Read(ctx context.Context) error {
 	var err error
 	js.bValue, err = js.ReadFromPos(ctx, 0)
 	return err
 }
+
+type jsonTarget struct {
+	dest interface{}
+}
+
+// JsonTarget return sql.Scanner that decode Json column into dest. dest can be pointer
+// to struct with json tags
+//
+//	var order Order
+//	err := db.QueryRow("SELECT DOC FROM ORDERS").Scan(types.JsonTarget(&order))
+func JsonTarget(dest interface{}) sql.Scanner {
+	return &jsonTarget{dest: dest}
+}
+
+func (target *jsonTarget) Scan(input interface{}) error {
+	switch value := input.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value), target.dest)
+	case []byte:
+		return json.Unmarshal(value, target.dest)
+	case *Json:
+		return value.Unmarshal(target.dest)
+	case Json:
+		return value.Unmarshal(target.dest)
+	}
+	return fmt.Errorf("cannot scan type %T into json target", input)
+}
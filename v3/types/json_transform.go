@@ -0,0 +1,153 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JsonTransform build JSON_TRANSFORM expression used for partial update of
+// json document. when the column is stored as OSON the server update only
+// the modified parts of the document instead of rewriting the whole value
+//
+//	expr, args, err := types.NewJsonTransform().
+//		Set("$.name", "new name").
+//		Append("$.tags", "sale").
+//		Remove("$.draft").
+//		Expression("DOC", 1)
+//	_, err = db.Exec("UPDATE ORDERS SET DOC = "+expr+" WHERE ID = :3", append(args, id)...)
+type JsonTransform struct {
+	operations []jsonOperation
+}
+
+type jsonOperation struct {
+	name    string
+	path    string
+	value   interface{}
+	hasBind bool
+	option  string
+}
+
+func NewJsonTransform() *JsonTransform {
+	return &JsonTransform{}
+}
+
+func (transform *JsonTransform) add(name, path string, value interface{}, hasBind bool, option string) *JsonTransform {
+	transform.operations = append(transform.operations, jsonOperation{
+		name: name, path: path, value: value, hasBind: hasBind, option: option,
+	})
+	return transform
+}
+
+// Set replace value at path or create it if missing
+func (transform *JsonTransform) Set(path string, value interface{}) *JsonTransform {
+	return transform.add("SET", path, value, true, "")
+}
+
+// Insert add value at path. error is raised by the server if the path exist
+func (transform *JsonTransform) Insert(path string, value interface{}) *JsonTransform {
+	return transform.add("INSERT", path, value, true, "")
+}
+
+// Replace update value at path. missing path is ignored
+func (transform *JsonTransform) Replace(path string, value interface{}) *JsonTransform {
+	return transform.add("REPLACE", path, value, true, "")
+}
+
+// Append add value to the end of array at path
+func (transform *JsonTransform) Append(path string, value interface{}) *JsonTransform {
+	return transform.add("APPEND", path, value, true, "")
+}
+
+// Remove delete field or array element at path
+func (transform *JsonTransform) Remove(path string) *JsonTransform {
+	return transform.add("REMOVE", path, nil, false, "")
+}
+
+// Rename change name of the field at path
+func (transform *JsonTransform) Rename(path, newName string) *JsonTransform {
+	return transform.add("RENAME", path, nil, false, "= "+quoteJsonPath(newName))
+}
+
+// Keep remove all fields except the ones at paths
+func (transform *JsonTransform) Keep(paths ...string) *JsonTransform {
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = quoteJsonPath(path)
+	}
+	return transform.add("KEEP", "", nil, false, strings.Join(quoted, ", "))
+}
+
+// Len return number of operations
+func (transform *JsonTransform) Len() int {
+	return len(transform.operations)
+}
+
+// Expression return JSON_TRANSFORM expression applied on column and values that
+// should be passed as arguments. placeholders are numbered starting from
+// firstPlaceholder. objects, arrays and structs are passed as json text with FORMAT JSON
+func (transform *JsonTransform) Expression(column string, firstPlaceholder int) (string, []interface{}, error) {
+	if len(transform.operations) == 0 {
+		return "", nil, fmt.Errorf("json transform has no operations")
+	}
+	builder := strings.Builder{}
+	builder.WriteString("JSON_TRANSFORM(")
+	builder.WriteString(column)
+	args := make([]interface{}, 0, len(transform.operations))
+	for _, operation := range transform.operations {
+		builder.WriteString(", ")
+		builder.WriteString(operation.name)
+		if len(operation.path) > 0 {
+			builder.WriteString(" ")
+			builder.WriteString(quoteJsonPath(operation.path))
+		}
+		if operation.hasBind {
+			value, isJson, err := jsonTransformValue(operation.value)
+			if err != nil {
+				return "", nil, err
+			}
+			builder.WriteString(fmt.Sprintf(" = :%d", firstPlaceholder+len(args)))
+			if isJson {
+				builder.WriteString(" FORMAT JSON")
+			}
+			args = append(args, value)
+		}
+		if len(operation.option) > 0 {
+			builder.WriteString(" ")
+			builder.WriteString(operation.option)
+		}
+	}
+	builder.WriteString(")")
+	return builder.String(), args, nil
+}
+
+func quoteJsonPath(path string) string {
+	return "'" + strings.ReplaceAll(path, "'", "''") + "'"
+}
+
+// jsonTransformValue return value for binding. scalars are bound as it is while
+// other values are converted to json text
+func jsonTransformValue(input interface{}) (interface{}, bool, error) {
+	switch value := input.(type) {
+	case nil, string, bool, time.Time, []byte, Number, *Number:
+		return value, false, nil
+	case json.RawMessage:
+		return string(value), true, nil
+	}
+	rValue := reflect.ValueOf(input)
+	switch rValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rValue.Int(), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rValue.Uint(), false, nil
+	case reflect.Float32, reflect.Float64:
+		return rValue.Float(), false, nil
+	}
+	temp, err := json.Marshal(input)
+	if err != nil {
+		return nil, false, err
+	}
+	return string(temp), true, nil
+}
@@ -0,0 +1,26 @@
+package types
+
+import "testing"
+
+func TestJsonTransform(t *testing.T) {
+	expr, args, err := NewJsonTransform().
+		Set("$.name", "new").
+		Append("$.tags", map[string]interface{}{"id": 1}).
+		Remove("$.o'ld").
+		Rename("$.a", "b").
+		Expression("DOC", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `JSON_TRANSFORM(DOC, SET '$.name' = :2, APPEND '$.tags' = :3 FORMAT JSON, REMOVE '$.o''ld', RENAME '$.a' = 'b')`
+	if expr != expected {
+		t.Errorf("expected: %s got: %s", expected, expr)
+	}
+	if len(args) != 2 || args[0] != "new" || args[1] != `{"id":1}` {
+		t.Errorf("unexpected args: %v", args)
+	}
+	_, _, err = NewJsonTransform().Expression("DOC", 1)
+	if err == nil {
+		t.Error("expected error for empty transform")
+	}
+}
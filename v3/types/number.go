@@ -501,7 +501,7 @@ func (number *Number) encodeFloat64(input float64) error {
 	number.bValue = make([]byte, MaxLenBDouble)
 	temp := math.Float64bits(input)
 	binary.BigEndian.PutUint64(number.bValue, temp)
-	if input > 0 {
+	if !math.Signbit(input) {
 		number.bValue[0] = number.bValue[0] | 128
 	} else {
 		xorBuffer(number.bValue, 8)
@@ -513,7 +513,7 @@ func (number *Number) encodeFloat32(input float32) error {
 	number.bValue = make([]byte, MaxLenBFloat)
 	temp := math.Float32bits(input)
 	binary.BigEndian.PutUint32(number.bValue, temp)
-	if input > 0 {
+	if !math.Signbit(float64(input)) {
 		number.bValue[0] = number.bValue[0] | 128
 	} else {
 		xorBuffer(number.bValue, 4)
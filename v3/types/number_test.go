@@ -0,0 +1,65 @@
+package types
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestBinaryDouble(t *testing.T) {
+	testScenarios := []struct {
+		description string
+		input       float64
+		expected    []byte
+	}{
+		{"zero", 0.0, []byte{0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{"negative zero", math.Copysign(0, -1), []byte{0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"positive", 1.5, []byte{0xBF, 0xF8, 0, 0, 0, 0, 0, 0}},
+		{"negative", -1.5, []byte{0x40, 0x07, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+	for _, scenario := range testScenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			number := NewBinaryDouble(scenario.input)
+			if !bytes.Equal(number.Bytes(), scenario.expected) {
+				t.Fatalf("expected bytes %X got: %X", scenario.expected, number.Bytes())
+			}
+			value, err := number.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, ok := value.(float64)
+			if !ok || output != scenario.input || math.Signbit(output) != math.Signbit(scenario.input) {
+				t.Errorf("expected %v got: %v", scenario.input, value)
+			}
+		})
+	}
+}
+
+func TestBinaryFloat(t *testing.T) {
+	testScenarios := []struct {
+		description string
+		input       float32
+		expected    []byte
+	}{
+		{"zero", 0.0, []byte{0x80, 0, 0, 0}},
+		{"negative zero", float32(math.Copysign(0, -1)), []byte{0x7F, 0xFF, 0xFF, 0xFF}},
+		{"positive", 1.5, []byte{0xBF, 0xC0, 0, 0}},
+		{"negative", -1.5, []byte{0x40, 0x3F, 0xFF, 0xFF}},
+	}
+	for _, scenario := range testScenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			number := NewBinaryFloat(scenario.input)
+			if !bytes.Equal(number.Bytes(), scenario.expected) {
+				t.Fatalf("expected bytes %X got: %X", scenario.expected, number.Bytes())
+			}
+			value, err := number.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, ok := value.(float32)
+			if !ok || output != scenario.input || math.Signbit(float64(output)) != math.Signbit(float64(scenario.input)) {
+				t.Errorf("expected %v got: %v", scenario.input, value)
+			}
+		})
+	}
+}
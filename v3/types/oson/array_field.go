@@ -5,8 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"reflect"
-
-	"github.com/sijms/go-ora/v3/types"
 )
 
 type arrayField struct {
@@ -26,43 +24,9 @@ func NewarrayField(value interface{}, header *header) (*arrayField, error) {
 	length := rValue.Len()
 	for i := 0; i < length; i++ {
 		var field Field
-		item := rValue.Index(i)
-		if item.IsNil() || !item.IsValid() {
-			field = &nullField{}
-		} else {
-			item = reflect.ValueOf(item.Interface())
-			switch item.Kind() {
-			case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				fallthrough
-			case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				fallthrough
-			case reflect.Float32, reflect.Float64:
-				temp, err := types.NewNumber(item.Interface())
-				if err != nil {
-					return nil, err
-				}
-				field = &numberField{value: *temp}
-			case reflect.String:
-				field = NewstringField(item.String())
-			case reflect.Bool:
-				field = &booleanField{value: item.Bool()}
-			case reflect.Slice, reflect.Array:
-				field, err = NewarrayField(item.Interface(), header)
-				if err != nil {
-					return nil, err
-				}
-			case reflect.Map:
-				if temp, ok := item.Interface().(map[string]interface{}); ok {
-					field, err = NewobjectField(temp, header)
-					if err != nil {
-						return nil, err
-					}
-				} else {
-					return nil, fmt.Errorf("invalid JSON Object at index: %d", i)
-				}
-			default:
-				return nil, fmt.Errorf("unsupported type: %s at index: %d", rValue.Type(), i)
-			}
+		field, err = newField(rValue.Index(i).Interface(), header)
+		if err != nil {
+			return nil, fmt.Errorf("%w at index: %d", err, i)
 		}
 		if field == nil {
 			return nil, fmt.Errorf("no value for index %d", i)
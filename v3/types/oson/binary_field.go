@@ -0,0 +1,42 @@
+package oson
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// binaryField hold RAW value. opCode 58 used for length < 0x10000 otherwise 59
+type binaryField struct {
+	value []byte
+	basicField
+}
+
+func (field *binaryField) Value() (interface{}, error) {
+	return field.value, nil
+}
+
+func (field *binaryField) Encode() ([]byte, error) {
+	buffer := bytes.NewBuffer(nil)
+	var err error
+	length := len(field.value)
+	if length < 0x10000 {
+		field.opCode = 58
+		err = buffer.WriteByte(field.opCode)
+		if err != nil {
+			return nil, err
+		}
+		err = binary.Write(buffer, binary.BigEndian, uint16(length))
+	} else {
+		field.opCode = 59
+		err = buffer.WriteByte(field.opCode)
+		if err != nil {
+			return nil, err
+		}
+		err = binary.Write(buffer, binary.BigEndian, uint32(length))
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = buffer.Write(field.value)
+	return buffer.Bytes(), err
+}
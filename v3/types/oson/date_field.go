@@ -20,7 +20,25 @@ func (field *dateField) Encode() ([]byte, error) {
 	var err error
 	data := field.value.Bytes()
 	length := len(data)
+	switch length {
+	case 7:
+		if field.value.GetDataType() == types.TIMESTAMP {
+			field.opCode = 0x7D
+		} else {
+			field.opCode = 60
+		}
+	case 11:
+		field.opCode = 57
+	case 13:
+		field.opCode = 0x7C
+	default:
+		return nil, fmt.Errorf("invalid date/time length (%d) for dateField", length)
+	}
 	buffer := bytes.NewBuffer(nil)
+	err = buffer.WriteByte(field.opCode)
+	if err != nil {
+		return nil, err
+	}
 	// here encode field
 	var written int
 	written, err = buffer.Write(data)
@@ -40,12 +58,17 @@ func (field *dateField) Decode(input []byte) error {
 	var length int
 	switch field.opCode {
 	case 60:
+		length = 7
+		field.value.SetDataType(types.DATE)
 	case 0x7D:
 		length = 7
+		field.value.SetDataType(types.TIMESTAMP)
 	case 57:
 		length = 11
+		field.value.SetDataType(types.TIMESTAMP)
 	case 0x7C:
 		length = 13
+		field.value.SetDataType(types.TIMESTAMPTZ)
 	default:
 		return fmt.Errorf("invalid opCode (%d) for dateField", field.opCode)
 	}
@@ -0,0 +1,74 @@
+package oson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/sijms/go-ora/v3/types"
+)
+
+// newField create oson field for value. value should be normalized first (see marshalValue)
+// so only maps, slices and scalars are expected here
+func newField(value interface{}, header *header) (Field, error) {
+	if value == nil {
+		return &nullField{}, nil
+	}
+	var err error
+	switch value := value.(type) {
+	case time.Time:
+		data := types.Date{}
+		data.SetDataType(types.TIMESTAMPTZ)
+		err = data.SetValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return &dateField{value: data}, nil
+	case time.Duration:
+		return &intervalDSField{value: value}, nil
+	case IntervalYM:
+		return &intervalYMField{value: value}, nil
+	case []byte:
+		return &binaryField{value: value}, nil
+	case types.Number:
+		return &numberField{value: value}, nil
+	case json.Number:
+		return newNumberField(value.String())
+	case *big.Int:
+		return newNumberField(value.String())
+	case *big.Float:
+		return newNumberField(value.Text('f', -1))
+	case map[string]interface{}:
+		return NewobjectField(value, header)
+	}
+	rValue := reflect.ValueOf(value)
+	switch rValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return newNumberField(rValue.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return newNumberField(rValue.Uint())
+	case reflect.Float32:
+		return newNumberField(float32(rValue.Float()))
+	case reflect.Float64:
+		return newNumberField(rValue.Float())
+	case reflect.String:
+		return NewstringField(rValue.String()), nil
+	case reflect.Bool:
+		return &booleanField{value: rValue.Bool()}, nil
+	case reflect.Slice, reflect.Array:
+		return NewarrayField(value, header)
+	case reflect.Map:
+		return nil, fmt.Errorf("invalid JSON object of type: %s not decoded as Map[string]Any", rValue.Type())
+	}
+	return nil, fmt.Errorf("unsupported type: %s", rValue.Type())
+}
+
+func newNumberField(value interface{}) (*numberField, error) {
+	temp, err := types.NewNumber(value)
+	if err != nil {
+		return nil, err
+	}
+	return &numberField{value: *temp}, nil
+}
@@ -0,0 +1,88 @@
+package oson
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const intervalOffset = 0x80000000
+
+// IntervalYM represent oracle INTERVAL YEAR TO MONTH stored inside json document
+type IntervalYM struct {
+	Years  int
+	Months int
+}
+
+// intervalDSField INTERVAL DAY TO SECOND mapped to time.Duration
+type intervalDSField struct {
+	value time.Duration
+	basicField
+}
+
+func (field *intervalDSField) Value() (interface{}, error) {
+	return field.value, nil
+}
+
+func (field *intervalDSField) Encode() ([]byte, error) {
+	field.opCode = 62
+	value := field.value
+	day := value / (24 * time.Hour)
+	value -= day * 24 * time.Hour
+	hour := value / time.Hour
+	value -= hour * time.Hour
+	minute := value / time.Minute
+	value -= minute * time.Minute
+	second := value / time.Second
+	value -= second * time.Second
+	output := make([]byte, 12)
+	output[0] = field.opCode
+	binary.BigEndian.PutUint32(output[1:], uint32(int64(day)+intervalOffset))
+	output[5] = uint8(int(hour) + 60)
+	output[6] = uint8(int(minute) + 60)
+	output[7] = uint8(int(second) + 60)
+	binary.BigEndian.PutUint32(output[8:], uint32(int64(value)+intervalOffset))
+	return output, nil
+}
+
+func (field *intervalDSField) decode(data []byte) error {
+	if len(data) < 11 {
+		return errors.New("interval data length is too short")
+	}
+	day := int64(binary.BigEndian.Uint32(data)) - intervalOffset
+	hour := int64(data[4]) - 60
+	minute := int64(data[5]) - 60
+	second := int64(data[6]) - 60
+	nanoSec := int64(binary.BigEndian.Uint32(data[7:])) - intervalOffset
+	field.value = time.Duration(day)*24*time.Hour + time.Duration(hour)*time.Hour +
+		time.Duration(minute)*time.Minute + time.Duration(second)*time.Second + time.Duration(nanoSec)
+	return nil
+}
+
+// intervalYMField INTERVAL YEAR TO MONTH mapped to IntervalYM
+type intervalYMField struct {
+	value IntervalYM
+	basicField
+}
+
+func (field *intervalYMField) Value() (interface{}, error) {
+	return field.value, nil
+}
+
+func (field *intervalYMField) Encode() ([]byte, error) {
+	field.opCode = 61
+	output := make([]byte, 6)
+	output[0] = field.opCode
+	binary.BigEndian.PutUint32(output[1:], uint32(int64(field.value.Years)+intervalOffset))
+	output[5] = uint8(field.value.Months + 60)
+	return output, nil
+}
+
+func (field *intervalYMField) decode(data []byte) error {
+	if len(data) < 5 {
+		return errors.New("interval data length is too short")
+	}
+	field.value.Years = int(int64(binary.BigEndian.Uint32(data)) - intervalOffset)
+	field.value.Months = int(data[4]) - 60
+	return nil
+}
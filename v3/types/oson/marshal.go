@@ -0,0 +1,350 @@
+package oson
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sijms/go-ora/v3/types"
+)
+
+var (
+	tyJsonMarshaler   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	tyJsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	tyTextMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	tyTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	tyValuer          = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	tyScanner         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	tyTime            = reflect.TypeOf((*time.Time)(nil)).Elem()
+	tyNullTime        = reflect.TypeOf((*sql.NullTime)(nil)).Elem()
+	tyDuration        = reflect.TypeOf((*time.Duration)(nil)).Elem()
+	tyIntervalYM      = reflect.TypeOf((*IntervalYM)(nil)).Elem()
+	tyJsonNumber      = reflect.TypeOf((*json.Number)(nil)).Elem()
+	tyBigInt          = reflect.TypeOf((*big.Int)(nil)).Elem()
+	tyBigFloat        = reflect.TypeOf((*big.Float)(nil)).Elem()
+)
+
+// jsonField describe exported struct field as seen by encoding/json
+type jsonField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	asString  bool
+}
+
+var jsonFieldCache sync.Map // map[reflect.Type][]jsonField
+
+// structFields return json fields of struct type following encoding/json rules:
+// json tag name, "-" to skip, omitempty and string options and promoting fields
+// of embedded structs
+func structFields(rType reflect.Type) []jsonField {
+	if temp, ok := jsonFieldCache.Load(rType); ok {
+		return temp.([]jsonField)
+	}
+	var fields []jsonField
+	names := map[string]int{}
+	type level struct {
+		rType reflect.Type
+		index []int
+	}
+	current := []level{{rType: rType}}
+	visited := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var next []level
+		depthNames := map[string]bool{}
+		for _, lvl := range current {
+			if visited[lvl.rType] {
+				continue
+			}
+			visited[lvl.rType] = true
+			for i := 0; i < lvl.rType.NumField(); i++ {
+				sf := lvl.rType.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, options, _ := strings.Cut(tag, ",")
+				index := make([]int, len(lvl.index)+1)
+				copy(index, lvl.index)
+				index[len(lvl.index)] = i
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+					if name == "" && ft.Kind() == reflect.Struct {
+						next = append(next, level{rType: ft, index: index})
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				if name == "" {
+					name = sf.Name
+				}
+				// fields of shallower depth hide promoted fields with the same name
+				if _, found := names[name]; found && !depthNames[name] {
+					continue
+				}
+				field := jsonField{name: name, index: index}
+				for _, option := range strings.Split(options, ",") {
+					switch option {
+					case "omitempty":
+						field.omitEmpty = true
+					case "string":
+						field.asString = true
+					}
+				}
+				if pos, found := names[name]; found {
+					// two fields with the same name at the same depth: tagged one wins
+					if sf.Tag.Get("json") != "" {
+						fields[pos] = field
+					}
+					continue
+				}
+				names[name] = len(fields)
+				depthNames[name] = true
+				fields = append(fields, field)
+			}
+		}
+		current = next
+	}
+	jsonFieldCache.Store(rType, fields)
+	return fields
+}
+
+// fieldByIndex return field value for index. nil embedded pointer return invalid value
+// unless alloc is true
+func fieldByIndex(rValue reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rValue.Kind() == reflect.Ptr {
+			if rValue.IsNil() {
+				if !alloc || !rValue.CanSet() {
+					return reflect.Value{}
+				}
+				rValue.Set(reflect.New(rValue.Type().Elem()))
+			}
+			rValue = rValue.Elem()
+		}
+		rValue = rValue.Field(x)
+	}
+	return rValue
+}
+
+func isEmptyValue(rValue reflect.Value) bool {
+	switch rValue.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rValue.Len() == 0
+	case reflect.Bool:
+		return !rValue.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rValue.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rValue.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rValue.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rValue.IsNil()
+	}
+	return false
+}
+
+// marshalValue convert go value into tree of map[string]interface{}, []interface{} and
+// scalars that can be encoded as oson. structs are converted using json tags, values
+// implementing json.Marshaler or encoding.TextMarshaler are converted by their methods
+// and oracle extended scalars (time.Time, time.Duration, IntervalYM, []byte and
+// decimal numbers) are kept as it is
+func marshalValue(input interface{}) (interface{}, error) {
+	return marshalReflect(reflect.ValueOf(input))
+}
+
+func marshalReflect(rValue reflect.Value) (interface{}, error) {
+	if !rValue.IsValid() {
+		return nil, nil
+	}
+	if rValue.Kind() == reflect.Interface {
+		if rValue.IsNil() {
+			return nil, nil
+		}
+		return marshalReflect(rValue.Elem())
+	}
+	rType := rValue.Type()
+	if rType.Kind() == reflect.Ptr {
+		if rValue.IsNil() {
+			return nil, nil
+		}
+		if rType.Elem() == tyBigInt || rType.Elem() == tyBigFloat {
+			return rValue.Interface(), nil
+		}
+		return marshalReflect(rValue.Elem())
+	}
+	switch rType {
+	case tyTime, tyDuration, tyIntervalYM, tyJsonNumber, types.TyNumber:
+		return rValue.Interface(), nil
+	case tyNullTime:
+		temp := rValue.Interface().(sql.NullTime)
+		if !temp.Valid {
+			return nil, nil
+		}
+		return temp.Time, nil
+	case tyBigInt:
+		temp := rValue.Interface().(big.Int)
+		return &temp, nil
+	case tyBigFloat:
+		temp := rValue.Interface().(big.Float)
+		return &temp, nil
+	case types.TyDate:
+		temp := rValue.Interface().(types.Date)
+		return temp.Value()
+	case types.TyBoolean:
+		temp := rValue.Interface().(types.Bool)
+		return temp.Value()
+	}
+	if rValue.CanAddr() && reflect.PtrTo(rType).Implements(tyJsonMarshaler) {
+		rValue = rValue.Addr()
+		rType = rValue.Type()
+	}
+	if rType.Implements(tyJsonMarshaler) {
+		data, err := rValue.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var temp interface{}
+		err = decoder.Decode(&temp)
+		if err != nil {
+			return nil, err
+		}
+		return marshalValue(temp)
+	}
+	if rType.Implements(tyTextMarshaler) {
+		data, err := rValue.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+	if rType.Implements(tyValuer) {
+		temp, err := rValue.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return nil, err
+		}
+		return marshalValue(temp)
+	}
+	switch rValue.Kind() {
+	case reflect.Bool:
+		return rValue.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rValue.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rValue.Uint(), nil
+	case reflect.Float32:
+		return float32(rValue.Float()), nil
+	case reflect.Float64:
+		return rValue.Float(), nil
+	case reflect.String:
+		return rValue.String(), nil
+	case reflect.Slice:
+		if rValue.IsNil() {
+			return nil, nil
+		}
+		if rType.Elem().Kind() == reflect.Uint8 {
+			return rValue.Bytes(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		if rType.Elem().Kind() == reflect.Uint8 {
+			output := make([]byte, rValue.Len())
+			reflect.Copy(reflect.ValueOf(output), rValue)
+			return output, nil
+		}
+		output := make([]interface{}, rValue.Len())
+		var err error
+		for i := 0; i < rValue.Len(); i++ {
+			output[i], err = marshalReflect(rValue.Index(i))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return output, nil
+	case reflect.Map:
+		if rValue.IsNil() {
+			return nil, nil
+		}
+		output := make(map[string]interface{}, rValue.Len())
+		iter := rValue.MapRange()
+		for iter.Next() {
+			key, err := mapKeyString(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			output[key], err = marshalReflect(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+		}
+		return output, nil
+	case reflect.Struct:
+		output := make(map[string]interface{})
+		for _, field := range structFields(rType) {
+			fieldValue := fieldByIndex(rValue, field.index, false)
+			if !fieldValue.IsValid() {
+				continue
+			}
+			if field.omitEmpty && isEmptyValue(fieldValue) {
+				continue
+			}
+			temp, err := marshalReflect(fieldValue)
+			if err != nil {
+				return nil, err
+			}
+			if field.asString {
+				switch value := temp.(type) {
+				case bool:
+					temp = strconv.FormatBool(value)
+				case int64:
+					temp = strconv.FormatInt(value, 10)
+				case uint64:
+					temp = strconv.FormatUint(value, 10)
+				case float32:
+					temp = strconv.FormatFloat(float64(value), 'g', -1, 32)
+				case float64:
+					temp = strconv.FormatFloat(value, 'g', -1, 64)
+				}
+			}
+			output[field.name] = temp
+		}
+		return output, nil
+	}
+	return nil, fmt.Errorf("unsupported type: %s", rType)
+}
+
+func mapKeyString(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(tyTextMarshaler) {
+		data, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(data), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type: %s", key.Type())
+}
@@ -4,10 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"reflect"
-
-	"github.com/sijms/go-ora/v3/types"
-	"github.com/sijms/go-ora/v3/utils"
 )
 
 type objectField struct {
@@ -26,62 +22,9 @@ func NewobjectField(objData map[string]interface{}, header *header) (*objectFiel
 	var err error
 	for keyName, value := range objData {
 		var field Field
-		if value == nil {
-			field = &nullField{}
-		} else {
-			rValue := reflect.ValueOf(value)
-			//rType := reflect.TypeOf(value)
-			switch rValue.Kind() {
-			case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				fallthrough
-			case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				fallthrough
-			case reflect.Float32, reflect.Float64:
-				temp, err := types.NewNumber(value)
-				if err != nil {
-					return nil, err
-				}
-				field = &numberField{value: *temp}
-			case reflect.String:
-				field = NewstringField(rValue.String())
-			case reflect.Bool:
-				field = &booleanField{value: rValue.Bool()}
-			case reflect.Slice, reflect.Array:
-				field, err = NewarrayField(value, header)
-				if err != nil {
-					return nil, err
-				}
-			case reflect.Map:
-				if temp, ok := value.(map[string]interface{}); ok {
-					field, err = NewobjectField(temp, header)
-					if err != nil {
-						return nil, err
-					}
-				} else {
-					return nil, fmt.Errorf("invalid JSON object at key: %s not decoded as Map[string]Any", keyName)
-				}
-			default:
-				switch rValue.Type() {
-				case utils.TyTime:
-					data := types.Date{}
-					data.SetDataType(types.TIMESTAMPTZ)
-					err = data.SetValue(value)
-					if err != nil {
-						return nil, err
-					}
-					field = &dateField{value: data}
-				case utils.TyNullTime:
-					data := types.Date{}
-					data.SetDataType(types.TIMESTAMPTZ)
-					err = data.SetValue(value)
-					if err != nil {
-						return nil, err
-					}
-					field = &dateField{value: data}
-				default:
-					return nil, fmt.Errorf("unsupported type: %s at key: %s", rValue.Type(), keyName)
-				}
-			}
+		field, err = newField(value, header)
+		if err != nil {
+			return nil, fmt.Errorf("%w at key: %s", err, keyName)
 		}
 		if field == nil {
 			return nil, fmt.Errorf("no value for key %s", keyName)
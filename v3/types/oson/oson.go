@@ -11,6 +11,10 @@ func (oson *Oson) DecodeJson(data []byte) (interface{}, error) {
 	return Decode(data)
 }
 
+func (oson *Oson) UnmarshalJson(data []byte, v interface{}) error {
+	return Unmarshal(data, v)
+}
+
 //type Json struct {
 //	Value  interface{}
 //	bValue []byte
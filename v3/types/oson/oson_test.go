@@ -1,8 +1,15 @@
 package oson
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
+	"math/big"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sijms/go-ora/v3/types"
 )
 
 func TestOson(t *testing.T) {
@@ -31,3 +38,148 @@ func TestOson(t *testing.T) {
 	}
 	t.Log(output)
 }
+
+type osonTag struct {
+	Value string
+}
+
+func (tag osonTag) MarshalJSON() ([]byte, error) {
+	return json.Marshal("tag:" + tag.Value)
+}
+
+func (tag *osonTag) UnmarshalJSON(data []byte) error {
+	var temp string
+	err := json.Unmarshal(data, &temp)
+	if err != nil {
+		return err
+	}
+	tag.Value = strings.TrimPrefix(temp, "tag:")
+	return nil
+}
+
+type osonBase struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type osonOrder struct {
+	osonBase
+	Name     string            `json:"name"`
+	Note     string            `json:"note,omitempty"`
+	Skip     string            `json:"-"`
+	Price    *big.Float        `json:"price"`
+	Quantity uint32            `json:"quantity,string"`
+	Ttl      time.Duration     `json:"ttl"`
+	Term     IntervalYM        `json:"term"`
+	Hash     []byte            `json:"hash"`
+	Tags     []osonTag         `json:"tags"`
+	Extra    map[string]string `json:"extra,omitempty"`
+	Parent   *osonOrder        `json:"parent,omitempty"`
+}
+
+func TestOsonStruct(t *testing.T) {
+	input := osonOrder{
+		osonBase: osonBase{
+			ID:      7,
+			Created: time.Date(2024, 3, 4, 5, 6, 7, 8000, time.FixedZone("+03:00", 3*60*60)),
+		},
+		Name:     "order",
+		Skip:     "skipped",
+		Price:    big.NewFloat(12.5),
+		Quantity: 3,
+		Ttl:      -(26*time.Hour + 3*time.Minute + 4*time.Second + 5),
+		Term:     IntervalYM{Years: 2, Months: 6},
+		Hash:     []byte{1, 2, 3},
+		Tags:     []osonTag{{"a"}, {"b"}},
+		Parent:   &osonOrder{Name: "parent"},
+	}
+	data, err := Encode(&input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := value.(map[string]interface{})
+	for _, key := range []string{"note", "Skip", "extra"} {
+		if _, found := object[key]; found {
+			t.Errorf("key %s should not be encoded", key)
+		}
+	}
+	if object["quantity"] != "3" {
+		t.Errorf("expected quantity encoded as string got: %v", object["quantity"])
+	}
+	if tags, ok := object["tags"].([]interface{}); !ok || len(tags) != 2 || tags[0] != "tag:a" {
+		t.Errorf("expected tags encoded by MarshalJSON got: %v", object["tags"])
+	}
+	var output osonOrder
+	err = Unmarshal(data, &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.ID != input.ID || output.Name != input.Name || output.Quantity != input.Quantity {
+		t.Errorf("expected: %+v got: %+v", input, output)
+	}
+	if !output.Created.Equal(input.Created) {
+		t.Errorf("expected created: %v got: %v", input.Created, output.Created)
+	}
+	if output.Price == nil || output.Price.Cmp(input.Price) != 0 {
+		t.Errorf("expected price: %v got: %v", input.Price, output.Price)
+	}
+	if output.Ttl != input.Ttl || output.Term != input.Term {
+		t.Errorf("expected interval: %v, %v got: %v, %v", input.Ttl, input.Term, output.Ttl, output.Term)
+	}
+	if !bytes.Equal(output.Hash, input.Hash) {
+		t.Errorf("expected hash: %v got: %v", input.Hash, output.Hash)
+	}
+	if len(output.Tags) != 2 || output.Tags[1].Value != "b" {
+		t.Errorf("expected tags: %v got: %v", input.Tags, output.Tags)
+	}
+	if output.Skip != "" || output.Parent == nil || output.Parent.Name != "parent" {
+		t.Errorf("unexpected output: %+v", output)
+	}
+}
+
+func TestOsonDate(t *testing.T) {
+	input := map[string]interface{}{"date": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	data, err := Encode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Date sql.NullTime `json:"date"`
+	}
+	err = Unmarshal(data, &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !output.Date.Valid || !output.Date.Time.Equal(input["date"].(time.Time)) {
+		t.Errorf("expected: %v got: %v", input["date"], output.Date)
+	}
+}
+
+func TestOsonBool(t *testing.T) {
+	var active types.Bool
+	_ = active.SetValue(true)
+	input := struct {
+		Active  types.Bool `json:"active"`
+		Done    bool       `json:"done"`
+		Missing types.Bool `json:"missing"`
+	}{Active: active}
+	data, err := Encode(&input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := value.(map[string]interface{})
+	if object["active"] != true || object["done"] != false {
+		t.Errorf("expected boolean values got: %v", object)
+	}
+	if missing, found := object["missing"]; !found || missing != nil {
+		t.Errorf("expected null for empty types.Bool got: %v", missing)
+	}
+}
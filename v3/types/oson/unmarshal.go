@@ -0,0 +1,442 @@
+package oson
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sijms/go-ora/v3/types"
+)
+
+// Unmarshal decode oson data into v following encoding/json rules. v should be
+// non-nil pointer. oracle extended scalars are mapped as following:
+//
+//	DATE, TIMESTAMP, TIMESTAMP WITH TIME ZONE: time.Time
+//	INTERVAL DAY TO SECOND: time.Duration
+//	INTERVAL YEAR TO MONTH: IntervalYM
+//	RAW: []byte
+//	NUMBER: any go number, string, json.Number, types.Number, big.Int or big.Float
+func Unmarshal(data []byte, v interface{}) error {
+	rValue := reflect.ValueOf(v)
+	if rValue.Kind() != reflect.Ptr || rValue.IsNil() {
+		return fmt.Errorf("oson: Unmarshal(non-pointer or nil %T)", v)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	value, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	return unmarshalValue(rValue.Elem(), value)
+}
+
+func unmarshalError(src interface{}, dst reflect.Value) error {
+	return fmt.Errorf("oson: cannot unmarshal %T into Go value of type %s", src, dst.Type())
+}
+
+func unmarshalValue(dst reflect.Value, src interface{}) error {
+	if src == nil {
+		switch dst.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		return nil
+	}
+	srcValue := reflect.ValueOf(src)
+	if srcValue.Type().AssignableTo(dst.Type()) {
+		dst.Set(srcValue)
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return unmarshalValue(dst.Elem(), src)
+	}
+	switch dst.Type() {
+	case tyBigInt, tyBigFloat, types.TyNumber, tyJsonNumber:
+		text, ok := numberText(src)
+		if !ok {
+			return unmarshalError(src, dst)
+		}
+		switch dst.Type() {
+		case tyBigInt:
+			temp, success := new(big.Int).SetString(text, 10)
+			if !success {
+				return unmarshalError(src, dst)
+			}
+			dst.Set(reflect.ValueOf(temp).Elem())
+		case tyBigFloat:
+			temp, success := new(big.Float).SetString(text)
+			if !success {
+				return unmarshalError(src, dst)
+			}
+			dst.Set(reflect.ValueOf(temp).Elem())
+		case types.TyNumber:
+			temp, err := types.NewNumber(text)
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(temp).Elem())
+		default:
+			dst.SetString(text)
+		}
+		return nil
+	case tyTime:
+		if text, ok := src.(string); ok {
+			temp, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(temp))
+			return nil
+		}
+		return unmarshalError(src, dst)
+	}
+	if dst.CanAddr() {
+		addr := dst.Addr()
+		if addr.Type().Implements(tyJsonUnmarshaler) {
+			data, err := json.Marshal(jsonCompatible(src))
+			if err != nil {
+				return err
+			}
+			return addr.Interface().(json.Unmarshaler).UnmarshalJSON(data)
+		}
+		if addr.Type().Implements(tyScanner) {
+			return addr.Interface().(interface{ Scan(interface{}) error }).Scan(scanValue(src))
+		}
+		if text, ok := src.(string); ok && addr.Type().Implements(tyTextUnmarshaler) {
+			return addr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+		}
+	}
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return unmarshalError(src, dst)
+		}
+		dst.Set(srcValue)
+	case reflect.Bool:
+		value, ok := src.(bool)
+		if !ok {
+			return unmarshalError(src, dst)
+		}
+		dst.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := toInt64(src)
+		if err != nil {
+			return unmarshalError(src, dst)
+		}
+		if dst.OverflowInt(value) {
+			return fmt.Errorf("oson: value %d overflows Go value of type %s", value, dst.Type())
+		}
+		dst.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		text, ok := numberText(src)
+		if !ok {
+			return unmarshalError(src, dst)
+		}
+		value, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return unmarshalError(src, dst)
+		}
+		if dst.OverflowUint(value) {
+			return fmt.Errorf("oson: value %d overflows Go value of type %s", value, dst.Type())
+		}
+		dst.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := toFloat64(src)
+		if err != nil {
+			return unmarshalError(src, dst)
+		}
+		dst.SetFloat(value)
+	case reflect.String:
+		switch value := src.(type) {
+		case string:
+			dst.SetString(value)
+		default:
+			// NUMBER mapped to string keep all digits
+			text, ok := numberText(src)
+			if !ok {
+				return unmarshalError(src, dst)
+			}
+			dst.SetString(text)
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			switch value := src.(type) {
+			case []byte:
+				dst.SetBytes(append([]byte(nil), value...))
+				return nil
+			case string:
+				temp, err := base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					return err
+				}
+				dst.SetBytes(temp)
+				return nil
+			}
+		}
+		items, ok := src.([]interface{})
+		if !ok {
+			return unmarshalError(src, dst)
+		}
+		output := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			err := unmarshalValue(output.Index(i), item)
+			if err != nil {
+				return err
+			}
+		}
+		dst.Set(output)
+	case reflect.Array:
+		var items []interface{}
+		switch value := src.(type) {
+		case []interface{}:
+			items = value
+		case []byte:
+			if dst.Type().Elem().Kind() != reflect.Uint8 {
+				return unmarshalError(src, dst)
+			}
+			reflect.Copy(dst, reflect.ValueOf(value))
+			return nil
+		default:
+			return unmarshalError(src, dst)
+		}
+		for i := 0; i < dst.Len(); i++ {
+			if i < len(items) {
+				err := unmarshalValue(dst.Index(i), items[i])
+				if err != nil {
+					return err
+				}
+			} else {
+				dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
+			}
+		}
+	case reflect.Map:
+		object, ok := src.(map[string]interface{})
+		if !ok {
+			return unmarshalError(src, dst)
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		keyType := dst.Type().Key()
+		for key, item := range object {
+			keyValue, err := mapKey(keyType, key)
+			if err != nil {
+				return err
+			}
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			err = unmarshalValue(elem, item)
+			if err != nil {
+				return err
+			}
+			dst.SetMapIndex(keyValue, elem)
+		}
+	case reflect.Struct:
+		object, ok := src.(map[string]interface{})
+		if !ok {
+			return unmarshalError(src, dst)
+		}
+		fields := structFields(dst.Type())
+		for key, item := range object {
+			field := findField(fields, key)
+			if field == nil {
+				continue
+			}
+			fieldValue := fieldByIndex(dst, field.index, true)
+			if !fieldValue.IsValid() {
+				continue
+			}
+			if field.asString {
+				if text, ok := item.(string); ok {
+					item = stringOption(text, fieldValue)
+				}
+			}
+			err := unmarshalValue(fieldValue, item)
+			if err != nil {
+				return fmt.Errorf("%w at key: %s", err, key)
+			}
+		}
+	default:
+		return unmarshalError(src, dst)
+	}
+	return nil
+}
+
+// findField return field with exact name or case-insensitive match like encoding/json
+func findField(fields []jsonField, name string) *jsonField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// stringOption convert quoted value of field tagged with ",string" back to its scalar
+func stringOption(text string, dst reflect.Value) interface{} {
+	switch dst.Kind() {
+	case reflect.Bool:
+		if temp, err := strconv.ParseBool(text); err == nil {
+			return temp
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return json.Number(text)
+	}
+	return text
+}
+
+func mapKey(keyType reflect.Type, key string) (reflect.Value, error) {
+	if keyType.Kind() == reflect.String {
+		return reflect.ValueOf(key).Convert(keyType), nil
+	}
+	if reflect.PtrTo(keyType).Implements(tyTextUnmarshaler) {
+		temp := reflect.New(keyType)
+		err := temp.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key))
+		return temp.Elem(), err
+	}
+	switch keyType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		temp, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(temp).Convert(keyType), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		temp, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(temp).Convert(keyType), nil
+	}
+	return reflect.Value{}, fmt.Errorf("oson: unsupported map key type: %s", keyType)
+}
+
+// numberText return decimal representation of number value
+func numberText(src interface{}) (string, bool) {
+	switch value := src.(type) {
+	case *big.Int:
+		return value.String(), true
+	case *big.Float:
+		return value.Text('f', -1), true
+	case json.Number:
+		return value.String(), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32), true
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case uint64:
+		return strconv.FormatUint(value, 10), true
+	}
+	return "", false
+}
+
+func toInt64(src interface{}) (int64, error) {
+	switch value := src.(type) {
+	case *big.Int:
+		if !value.IsInt64() {
+			return 0, errors.New("number out of range")
+		}
+		return value.Int64(), nil
+	case *big.Float:
+		if !value.IsInt() {
+			return 0, errors.New("number is not integer")
+		}
+		temp, accuracy := value.Int64()
+		if accuracy != big.Exact {
+			return 0, errors.New("number out of range")
+		}
+		return temp, nil
+	case float64:
+		if value != math.Trunc(value) {
+			return 0, errors.New("number is not integer")
+		}
+		return int64(value), nil
+	case float32:
+		return toInt64(float64(value))
+	case json.Number:
+		return value.Int64()
+	case time.Duration:
+		return int64(value), nil
+	}
+	return 0, errors.New("value is not number")
+}
+
+func toFloat64(src interface{}) (float64, error) {
+	switch value := src.(type) {
+	case *big.Int:
+		temp, _ := new(big.Float).SetInt(value).Float64()
+		return temp, nil
+	case *big.Float:
+		temp, _ := value.Float64()
+		return temp, nil
+	case float64:
+		return value, nil
+	case float32:
+		return float64(value), nil
+	case json.Number:
+		return value.Float64()
+	}
+	return 0, errors.New("value is not number")
+}
+
+// scanValue convert decoded value into one of driver.Value types before passing it to sql.Scanner
+func scanValue(src interface{}) interface{} {
+	switch value := src.(type) {
+	case *big.Int:
+		if value.IsInt64() {
+			return value.Int64()
+		}
+		return value.String()
+	case *big.Float:
+		temp, _ := value.Float64()
+		return temp
+	case float32:
+		return float64(value)
+	case time.Duration:
+		return int64(value)
+	}
+	return src
+}
+
+// jsonCompatible prepare decoded value to be marshaled by encoding/json without losing
+// number precision
+func jsonCompatible(src interface{}) interface{} {
+	switch value := src.(type) {
+	case map[string]interface{}:
+		output := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			output[key] = jsonCompatible(item)
+		}
+		return output
+	case []interface{}:
+		output := make([]interface{}, len(value))
+		for i, item := range value {
+			output[i] = jsonCompatible(item)
+		}
+		return output
+	case *big.Int, *big.Float:
+		text, _ := numberText(value)
+		return json.Number(text)
+	}
+	return src
+}
@@ -134,8 +134,11 @@ func Encode(mainObj interface{}) ([]byte, error) {
 		flags: 0x2106,
 		keys:  keyCollection{},
 	}
-	var err error
-	header.keys.extractKeys(mainObj)
+	value, err := marshalValue(mainObj)
+	if err != nil {
+		return nil, err
+	}
+	header.keys.extractKeys(value)
 	keyBuffer, err := header.keys.encode()
 	if err != nil {
 		return nil, err
@@ -153,15 +156,15 @@ func Encode(mainObj interface{}) ([]byte, error) {
 			return nil, err
 		}
 	}
-	switch mainObj := mainObj.(type) {
+	switch value := value.(type) {
 	case map[string]interface{}:
-		obj, err := NewobjectField(mainObj, header)
+		obj, err := NewobjectField(value, header)
 		if err != nil {
 			return nil, err
 		}
 		objectData, err = obj.Encode()
 	case []interface{}:
-		obj, err := NewarrayField(mainObj, header)
+		obj, err := NewarrayField(value, header)
 		if err != nil {
 			return nil, err
 		}
@@ -527,6 +530,65 @@ func decodeNode(buffer *bytes.Reader, header *header) (Field, error) {
 		}
 		field.value.SetBytes(numberBytes)
 		return field, nil
+	case 60, 57, 0x7D, 0x7C: // date, timestamp, timestamp without fraction, timestamp tz
+		length := 7
+		field := &dateField{basicField: basicField{opCode: opCode}}
+		switch opCode {
+		case 60:
+			field.value.SetDataType(types.DATE)
+		case 57:
+			length = 11
+			field.value.SetDataType(types.TIMESTAMP)
+		case 0x7D:
+			field.value.SetDataType(types.TIMESTAMP)
+		case 0x7C:
+			length = 13
+			field.value.SetDataType(types.TIMESTAMPTZ)
+		}
+		data, err := readBytes(buffer, length)
+		if err != nil {
+			return nil, err
+		}
+		field.value.SetBytes(data)
+		return field, nil
+	case 61: // interval year to month
+		data, err := readBytes(buffer, 5)
+		if err != nil {
+			return nil, err
+		}
+		field := &intervalYMField{basicField: basicField{opCode: opCode}}
+		return field, field.decode(data)
+	case 62: // interval day to second
+		data, err := readBytes(buffer, 11)
+		if err != nil {
+			return nil, err
+		}
+		field := &intervalDSField{basicField: basicField{opCode: opCode}}
+		return field, field.decode(data)
+	case 58, 59, 0x7E: // binary and id
+		var length int
+		switch opCode {
+		case 58:
+			var temp uint16
+			err = binary.Read(buffer, binary.BigEndian, &temp)
+			length = int(temp)
+		case 59:
+			var temp uint32
+			err = binary.Read(buffer, binary.BigEndian, &temp)
+			length = int(temp)
+		default:
+			var temp uint8
+			temp, err = buffer.ReadByte()
+			length = int(temp)
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := readBytes(buffer, length)
+		if err != nil {
+			return nil, err
+		}
+		return &binaryField{value: data, basicField: basicField{opCode: opCode}}, nil
 	case 0x7F:
 		var temp = make([]byte, 4)
 		_, err = buffer.Read(temp)
@@ -572,6 +634,21 @@ func decodeNode(buffer *bytes.Reader, header *header) (Field, error) {
 	return nil, fmt.Errorf("unsupported type code: %d", opCode)
 }
 
+func readBytes(buffer *bytes.Reader, length int) ([]byte, error) {
+	data := make([]byte, length)
+	if length == 0 {
+		return data, nil
+	}
+	read, err := buffer.Read(data)
+	if err != nil {
+		return nil, err
+	}
+	if read != length {
+		return nil, fmt.Errorf("invalid buffer read: expected to read %d, got %d", length, read)
+	}
+	return data, nil
+}
+
 func Decode(data []byte) (interface{}, error) {
 	buffer := bytes.NewReader(data)
 	header := &header{}
@@ -46,6 +46,10 @@ var (
 	TyBlob     = reflect.TypeOf((*Blob)(nil)).Elem()
 	TyBFile    = reflect.TypeOf((*BFile)(nil)).Elem()
 	TyObject   = reflect.TypeOf((*Object)(nil)).Elem()
+
+	TySparseVector = reflect.TypeOf((*SparseVector)(nil)).Elem()
+	TyBinaryVector = reflect.TypeOf((*BinaryVector)(nil)).Elem()
+	TyXmlType      = reflect.TypeOf((*XmlType)(nil)).Elem()
 )
 
 const (
@@ -58,6 +62,7 @@ const (
 	MaxLenIntervalYM  int64 = 0x5
 	MaxLenIntervalDS  int64 = 0xB
 	MaxLenBFile       int64 = 4000
+	MaxLenBool        int64 = 0x4
 )
 
 func xorBuffer(buffer []byte, length int) {
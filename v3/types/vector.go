@@ -25,6 +25,37 @@ const (
 	VECTOR_DENSE
 )
 
+// vector image header values
+const (
+	vectorMagicByte byte = 219
+
+	vectorVersionBase   byte = 0
+	vectorVersionBinary byte = 1
+	vectorVersionSparse byte = 2
+
+	vectorFormatFloat32 byte = 2
+	vectorFormatFloat64 byte = 3
+	vectorFormatInt8    byte = 4
+	vectorFormatBinary  byte = 5
+
+	vectorFlagNorm         uint16 = 0x2
+	vectorFlagNormReserved uint16 = 0x10
+	vectorFlagSparse       uint16 = 0x20
+)
+
+// SparseVector represent vector with only non-zero values stored.
+// Dimensions is the total number of dimensions, Indices are zero based
+// positions of the stored values and Values is []float32, []float64 or []int8
+type SparseVector struct {
+	Dimensions int
+	Indices    []uint32
+	Values     interface{}
+}
+
+// BinaryVector represent vector of BINARY format. each byte pack 8 dimensions
+// (the most significant bit is the first dimension)
+type BinaryVector []byte
+
 type Vector struct {
 	Basic
 	loc Locator
@@ -41,122 +72,69 @@ func (vector *Vector) Upload() error {
 	return vector.uploadData(vector.bValue, 0, 0)
 }
 
-func (vector *Vector) SetValue(input interface{}) error {
-	if input == nil {
-		vector.bValue = nil
-		return nil
-	}
-	var (
-		header        = []byte{219, 0}
-		flag   uint16 = 2 | 16
-		format byte
-		length uint32
-		err    error
-		buffer = &bytes.Buffer{}
-		data   interface{}
-	)
+// vectorValues return format and count of supported dense value arrays
+func vectorValues(input interface{}) (format byte, length int, data interface{}, err error) {
 	switch value := input.(type) {
-	case Vector:
-		*vector = value
-		return nil
-	case *Vector:
-		*vector = *value
-		return nil
 	case []uint8:
-		format = 4
-		length = uint32(len(value))
-		data = value
-		//v.Type = VECTOR_UINT8
-		//v.data = value
+		return vectorFormatInt8, len(value), value, nil
 	case *[]uint8:
-		format = 4
-		length = uint32(len(*value))
-		data = *value
-		//v.Type = VECTOR_UINT8
-		//v.data = *value
+		return vectorFormatInt8, len(*value), *value, nil
 	case []*uint8:
 		temp := make([]byte, 0, len(value))
 		for _, val := range value {
 			temp = append(temp, *val)
 		}
-		format = 4
-		length = uint32(len(value))
-		data = temp
-		//v.Type = VECTOR_UINT8
-		//v.data = temp
+		return vectorFormatInt8, len(value), temp, nil
+	case []int8:
+		return vectorFormatInt8, len(value), value, nil
+	case *[]int8:
+		return vectorFormatInt8, len(*value), *value, nil
+	case []*int8:
+		temp := make([]int8, 0, len(value))
+		for _, val := range value {
+			temp = append(temp, *val)
+		}
+		return vectorFormatInt8, len(value), temp, nil
 	case []float32:
-		format = 2
-		length = uint32(len(value))
-		data = value
-		//v.Type = VECTOR_FL32
-		//v.data = value
+		return vectorFormatFloat32, len(value), value, nil
 	case *[]float32:
-		format = 2
-		length = uint32(len(*value))
-		data = *value
-		//v.Type = VECTOR_FL32
-		//v.data = *value
+		return vectorFormatFloat32, len(*value), *value, nil
 	case []*float32:
 		temp := make([]float32, 0, len(value))
 		for _, val := range value {
 			temp = append(temp, *val)
 		}
-		format = 2
-		length = uint32(len(value))
-		data = temp
-		//v.Type = VECTOR_FL32
-		//v.data = temp
+		return vectorFormatFloat32, len(value), temp, nil
 	case []float64:
-		format = 3
-		length = uint32(len(value))
-		data = value
-		//v.Type = VECTOR_FL64
-		//v.data = value
+		return vectorFormatFloat64, len(value), value, nil
 	case *[]float64:
-		format = 3
-		length = uint32(len(*value))
-		data = *value
-		//v.Type = VECTOR_FL64
-		//v.data = *value
+		return vectorFormatFloat64, len(*value), *value, nil
 	case []*float64:
 		temp := make([]float64, 0, len(value))
 		for _, val := range value {
 			temp = append(temp, *val)
 		}
-		format = 3
-		length = uint32(len(value))
-		data = temp
-		//v.Type = VECTOR_FL64
-		//v.data = temp
+		return vectorFormatFloat64, len(value), temp, nil
+	case BinaryVector:
+		return vectorFormatBinary, len(value) * 8, []byte(value), nil
+	case *BinaryVector:
+		return vectorFormatBinary, len(*value) * 8, []byte(*value), nil
 	default:
-		return vectorTypeError
+		return 0, 0, nil, vectorTypeError
 	}
+}
 
-	_, err = buffer.Write(header)
-	if err != nil {
-		return err
-	}
-	err = binary.Write(buffer, binary.BigEndian, flag)
-	if err != nil {
-		return err
-	}
-	err = buffer.WriteByte(format)
-	if err != nil {
-		return err
-	}
-	err = binary.Write(buffer, binary.BigEndian, length)
-	if err != nil {
-		return err
-	}
-	_, err = buffer.Write(bytes.Repeat([]byte{0}, 8))
-	if err != nil {
-		return err
-	}
+func writeVectorValues(buffer *bytes.Buffer, data interface{}) error {
+	var err error
 	switch value := data.(type) {
 	case []uint8:
 		_, err = buffer.Write(value)
-		if err != nil {
-			return err
+	case []int8:
+		for _, val := range value {
+			err = buffer.WriteByte(byte(val))
+			if err != nil {
+				return err
+			}
 		}
 	case []float32:
 		for _, val := range value {
@@ -166,7 +144,6 @@ func (vector *Vector) SetValue(input interface{}) error {
 			if err != nil {
 				return err
 			}
-			//temp := NewDoubleCoderFromFloat(val)
 			_, err = buffer.Write(n.Bytes())
 			if err != nil {
 				return err
@@ -188,6 +165,104 @@ func (vector *Vector) SetValue(input interface{}) error {
 	default:
 		return vectorTypeError
 	}
+	return err
+}
+
+func (vector *Vector) SetValue(input interface{}) error {
+	if input == nil {
+		vector.bValue = nil
+		return nil
+	}
+	var (
+		version = vectorVersionBase
+		flag    = vectorFlagNorm | vectorFlagNormReserved
+		format  byte
+		length  int
+		err     error
+		buffer  = &bytes.Buffer{}
+		data    interface{}
+		sparse  *SparseVector
+	)
+	switch value := input.(type) {
+	case Vector:
+		*vector = value
+		return nil
+	case *Vector:
+		*vector = *value
+		return nil
+	case SparseVector:
+		sparse = &value
+	case *SparseVector:
+		sparse = value
+	default:
+		format, length, data, err = vectorValues(input)
+		if err != nil {
+			return err
+		}
+	}
+	if sparse != nil {
+		var count int
+		format, count, data, err = vectorValues(sparse.Values)
+		if err != nil {
+			return err
+		}
+		if format == vectorFormatBinary {
+			return errors.New("sparse vector of BINARY format is not supported")
+		}
+		err = sparse.validate(count)
+		if err != nil {
+			return err
+		}
+		version = vectorVersionSparse
+		flag |= vectorFlagSparse
+		length = sparse.Dimensions
+	}
+	if format == vectorFormatBinary {
+		version = vectorVersionBinary
+		flag = vectorFlagNormReserved
+	}
+
+	err = buffer.WriteByte(vectorMagicByte)
+	if err != nil {
+		return err
+	}
+	err = buffer.WriteByte(version)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(buffer, binary.BigEndian, flag)
+	if err != nil {
+		return err
+	}
+	err = buffer.WriteByte(format)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(buffer, binary.BigEndian, uint32(length))
+	if err != nil {
+		return err
+	}
+	// norm is written only with NORM flag (BINARY vectors have no norm)
+	if flag&vectorFlagNorm > 0 {
+		_, err = buffer.Write(bytes.Repeat([]byte{0}, 8))
+		if err != nil {
+			return err
+		}
+	}
+	if sparse != nil {
+		err = binary.Write(buffer, binary.BigEndian, uint16(len(sparse.Indices)))
+		if err != nil {
+			return err
+		}
+		err = binary.Write(buffer, binary.BigEndian, sparse.Indices)
+		if err != nil {
+			return err
+		}
+	}
+	err = writeVectorValues(buffer, data)
+	if err != nil {
+		return err
+	}
 	vector.bValue = buffer.Bytes()
 	dataLen := uint64(len(vector.bValue))
 	if dataLen > 0 {
@@ -198,6 +273,68 @@ func (vector *Vector) SetValue(input interface{}) error {
 	return nil
 }
 
+// readVectorValues read count values of the given format
+func readVectorValues(buffer *bytes.Buffer, format byte, count int) (interface{}, error) {
+	switch format {
+	case vectorFormatFloat32:
+		if buffer.Len() < count*4 {
+			return nil, vectorTypeError
+		}
+		vecData := make([]float32, count)
+		for i := 0; i < count; i++ {
+			n := Number{}
+			n.SetBytes(buffer.Next(4))
+			n.SetDataType(IBFLOAT)
+			output, err := n.Value()
+			if err != nil {
+				return nil, err
+			}
+			if temp, ok := output.(float32); ok {
+				vecData[i] = temp
+			}
+		}
+		return vecData, nil
+	case vectorFormatFloat64:
+		if buffer.Len() < count*8 {
+			return nil, vectorTypeError
+		}
+		vecData := make([]float64, count)
+		for i := 0; i < count; i++ {
+			n := Number{}
+			n.SetBytes(buffer.Next(8))
+			n.SetDataType(IBDOUBLE)
+			output, err := n.Value()
+			if err != nil {
+				return nil, err
+			}
+			if temp, ok := output.(float64); ok {
+				vecData[i] = temp
+			}
+		}
+		return vecData, nil
+	case vectorFormatInt8:
+		if buffer.Len() < count {
+			return nil, vectorTypeError
+		}
+		vecData := make([]uint8, count)
+		copy(vecData, buffer.Next(count))
+		return vecData, nil
+	case vectorFormatBinary:
+		count = (count + 7) / 8
+		if buffer.Len() < count {
+			return nil, vectorTypeError
+		}
+		vecData := make(BinaryVector, count)
+		copy(vecData, buffer.Next(count))
+		return vecData, nil
+	default:
+		return nil, fmt.Errorf("unsupported format (%d) for vector type", format)
+	}
+}
+
+// Value return the vector data as []uint8 (INT8), []float32, []float64,
+// BinaryVector or SparseVector. dense INT8 values are returned as []uint8 as
+// in previous versions use CopyTo with *[]int8 to get signed values
 func (vector *Vector) Value() (interface{}, error) {
 	if len(vector.bValue) == 0 {
 		return nil, nil
@@ -235,7 +372,7 @@ func (vector *Vector) Value() (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if magicNumber != 219 {
+	if magicNumber != int(vectorMagicByte) {
 		return nil, vectorTypeError
 	}
 	var version, flag, format, count int
@@ -243,7 +380,7 @@ func (vector *Vector) Value() (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if version != 0 {
+	if version > int(vectorVersionSparse) {
 		return nil, fmt.Errorf("vector version (%d) not supported", version)
 	}
 	flag, err = read(buffer, 2)
@@ -256,7 +393,7 @@ func (vector *Vector) Value() (interface{}, error) {
 	}
 	if flag&1 > 0 {
 		count, err = read(buffer, 1)
-	} else if flag&2 > 0 {
+	} else if uint16(flag)&(vectorFlagNorm|vectorFlagNormReserved|vectorFlagSparse) > 0 {
 		count, err = read(buffer, 4)
 	} else {
 		count, err = read(buffer, 2)
@@ -264,65 +401,42 @@ func (vector *Vector) Value() (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if flag&0x10 > 0 {
+	if uint16(flag)&vectorFlagNorm > 0 {
 		_ = buffer.Next(8)
 	}
-	switch format {
-	case 2:
-		// float32 array
-		vecData := make([]float32, count)
-		for i := 0; i < count; i++ {
-			n := Number{}
-			n.SetBytes(buffer.Next(4))
-			n.SetDataType(IBFLOAT)
-			output, err := n.Value()
-			if err != nil {
-				return nil, err
-			}
-			if temp, ok := output.(float32); ok {
-				vecData[i] = temp
-			}
-			//temp := &DoubleCoder{}
-			//vecData[i], err = temp.DecodeFloat(buffer.Next(4))
-			//if err != nil {
-			//	return nil, err
-			//}
+	if uint16(flag)&vectorFlagSparse > 0 {
+		var sparseCount int
+		sparseCount, err = read(buffer, 2)
+		if err != nil {
+			return nil, err
 		}
-		return vecData, nil
-		//return types.NewVector(vecData, coder.streamer, coder.copy())
-	case 3:
-		// float64 array
-		vecData := make([]float64, count)
-		for i := 0; i < count; i++ {
-			n := Number{}
-			n.SetBytes(buffer.Next(8))
-			n.SetDataType(IBDOUBLE)
-			output, err := n.Value()
+		ret := SparseVector{Dimensions: count, Indices: make([]uint32, sparseCount)}
+		for i := 0; i < sparseCount; i++ {
+			var index int
+			index, err = read(buffer, 4)
 			if err != nil {
 				return nil, err
 			}
-			if temp, ok := output.(float64); ok {
-				vecData[i] = temp
-			}
-			//temp := &DoubleCoder{}
-			//vecData[i], err = temp.DecodeDouble(buffer.Next(8))
-			//if err != nil {
-			//	return nil, err
-			//}
+			ret.Indices[i] = uint32(index)
 		}
-		return vecData, nil
-		//return types.NewVector(vecData, coder.streamer, coder.copy())
-	case 4:
-		// int8 array
-		vecData := make([]uint8, count)
-		for i := 0; i < count; i++ {
-			vecData[i], err = buffer.ReadByte()
+		ret.Values, err = readVectorValues(buffer, byte(format), sparseCount)
+		if err != nil {
+			return nil, err
 		}
-		return vecData, nil
-		//return types.NewVector(vecData, coder.streamer, coder.copy())
-	default:
-		return nil, fmt.Errorf("unsupported format (%d) for vector type", format)
+		if values, ok := ret.Values.([]uint8); ok {
+			ret.Values = toInt8(values)
+		}
+		return ret, nil
+	}
+	return readVectorValues(buffer, byte(format), count)
+}
+
+func toInt8(input []uint8) []int8 {
+	ret := make([]int8, len(input))
+	for i, val := range input {
+		ret[i] = int8(val)
 	}
+	return ret
 }
 
 //	type Vector interface {
@@ -347,7 +461,8 @@ func (vector *Vector) Value() (interface{}, error) {
 
 var vectorTypeError = errors.New("unexpected data for vector type")
 
-// CreateVector : create vector from supported array type: uint8, float32 and float64
+// CreateVector : create vector from supported array type: uint8, int8, float32, float64,
+// BinaryVector and SparseVector
 func CreateVector(array interface{}) (*Vector, error) {
 	v := new(Vector)
 	return v, v.SetValue(array)
@@ -593,6 +708,115 @@ func (vector *Vector) CopyTo(dest driver.Value) error {
 			*dst = v
 			return nil
 		}
+	case *[]int8:
+		if val == nil {
+			*dst = nil
+			return nil
+		}
+		if v, ok := val.([]uint8); ok {
+			*dst = toInt8(v)
+			return nil
+		}
+	case *BinaryVector:
+		if val == nil {
+			*dst = nil
+			return nil
+		}
+		if v, ok := val.(BinaryVector); ok {
+			*dst = v
+			return nil
+		}
+	case *SparseVector:
+		if val == nil {
+			*dst = SparseVector{}
+			return nil
+		}
+		if v, ok := val.(SparseVector); ok {
+			*dst = v
+			return nil
+		}
 	}
 	return fmt.Errorf("cannot copy Vector to variable of type %T", dest)
 }
+
+// Scan read sparse vector from Vector value
+func (sparse *SparseVector) Scan(input interface{}) error {
+	switch value := input.(type) {
+	case nil:
+		*sparse = SparseVector{}
+		return nil
+	case SparseVector:
+		*sparse = value
+		return nil
+	case *SparseVector:
+		*sparse = *value
+		return nil
+	case Vector:
+		return value.CopyTo(sparse)
+	case *Vector:
+		return value.CopyTo(sparse)
+	}
+	return fmt.Errorf("cannot scan type %T into SparseVector", input)
+}
+
+// Scan read binary vector from Vector value
+func (bv *BinaryVector) Scan(input interface{}) error {
+	switch value := input.(type) {
+	case nil:
+		*bv = nil
+		return nil
+	case BinaryVector:
+		*bv = value
+		return nil
+	case Vector:
+		return value.CopyTo(bv)
+	case *Vector:
+		return value.CopyTo(bv)
+	}
+	return fmt.Errorf("cannot scan type %T into BinaryVector", input)
+}
+
+func (sparse *SparseVector) validate(count int) error {
+	if count != len(sparse.Indices) {
+		return fmt.Errorf("sparse vector has %d indices and %d values", len(sparse.Indices), count)
+	}
+	for _, index := range sparse.Indices {
+		if int(index) >= sparse.Dimensions {
+			return fmt.Errorf("sparse vector index %d out of range for %d dimensions", index, sparse.Dimensions)
+		}
+	}
+	return nil
+}
+
+// Dense expand sparse vector into dense array of the same value type
+func (sparse SparseVector) Dense() (interface{}, error) {
+	_, count, _, err := vectorValues(sparse.Values)
+	if err != nil {
+		return nil, err
+	}
+	err = sparse.validate(count)
+	if err != nil {
+		return nil, err
+	}
+	switch values := sparse.Values.(type) {
+	case []float32:
+		ret := make([]float32, sparse.Dimensions)
+		for i, index := range sparse.Indices {
+			ret[index] = values[i]
+		}
+		return ret, nil
+	case []float64:
+		ret := make([]float64, sparse.Dimensions)
+		for i, index := range sparse.Indices {
+			ret[index] = values[i]
+		}
+		return ret, nil
+	case []int8:
+		ret := make([]int8, sparse.Dimensions)
+		for i, index := range sparse.Indices {
+			ret[index] = values[i]
+		}
+		return ret, nil
+	}
+	return nil, vectorTypeError
+}
@@ -0,0 +1,127 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestVectorDenseFormats(t *testing.T) {
+	var testScenarios = []struct {
+		name     string
+		input    interface{}
+		expected interface{}
+	}{
+		{"float32", []float32{1.5, -2.5, 3}, []float32{1.5, -2.5, 3}},
+		{"float64", []float64{1.25, -2.5, 1e10}, []float64{1.25, -2.5, 1e10}},
+		{"uint8", []uint8{1, 2, 250}, []uint8{1, 2, 250}},
+		{"int8", []int8{-128, 0, 127}, []uint8{0x80, 0, 0x7F}},
+	}
+	for _, tt := range testScenarios {
+		t.Run(tt.name, func(t *testing.T) {
+			vector, err := CreateVector(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// dense vectors have NORM flag and 8 bytes norm after the header
+			if vector.bValue[3]&byte(vectorFlagNorm) == 0 {
+				t.Errorf("expected NORM flag and got flags: %X", vector.bValue[2:4])
+			}
+			output, err := vector.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.expected, output) {
+				t.Errorf("expected: %v and got: %v", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestVectorInt8(t *testing.T) {
+	input := []int8{-128, -1, 0, 127}
+	vector, err := CreateVector(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vector.bValue[4] != vectorFormatInt8 {
+		t.Errorf("expected format %d and got: %d", vectorFormatInt8, vector.bValue[4])
+	}
+	var output []int8
+	err = vector.CopyTo(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(input, output) {
+		t.Errorf("expected: %v and got: %v", input, output)
+	}
+	var unsigned []uint8
+	err = vector.CopyTo(&unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint8{0x80, 0xFF, 0, 0x7F}; !bytes.Equal(expected, unsigned) {
+		t.Errorf("expected: %v and got: %v", expected, unsigned)
+	}
+}
+
+func TestVectorBinary(t *testing.T) {
+	input := BinaryVector{0xA5, 0x0F}
+	vector, err := CreateVector(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := []byte{vectorMagicByte, vectorVersionBinary, 0, byte(vectorFlagNormReserved), vectorFormatBinary, 0, 0, 0, 16}
+	if !bytes.HasPrefix(vector.bValue, header) {
+		t.Errorf("unexpected binary vector header: %v", vector.bValue[:len(header)])
+	}
+	// no norm bytes without NORM flag
+	if len(vector.bValue) != len(header)+len(input) {
+		t.Errorf("expected %d bytes and got: %d", len(header)+len(input), len(vector.bValue))
+	}
+	var output BinaryVector
+	err = output.Scan(vector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(input, output) {
+		t.Errorf("expected: %v and got: %v", input, output)
+	}
+}
+
+func TestVectorSparse(t *testing.T) {
+	inputs := []SparseVector{
+		{Dimensions: 10, Indices: []uint32{1, 5, 9}, Values: []float32{0.5, -1, 2}},
+		{Dimensions: 1000, Indices: []uint32{0, 999}, Values: []float64{3.25, -4}},
+		{Dimensions: 4, Indices: []uint32{3}, Values: []int8{-7}},
+	}
+	for _, input := range inputs {
+		vector, err := CreateVector(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vector.bValue[1] != vectorVersionSparse {
+			t.Errorf("expected version %d and got: %d", vectorVersionSparse, vector.bValue[1])
+		}
+		var output SparseVector
+		err = output.Scan(vector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(input, output) {
+			t.Errorf("expected: %#v and got: %#v", input, output)
+		}
+	}
+	dense, err := inputs[0].Dense()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []float32{0, 0.5, 0, 0, 0, -1, 0, 0, 0, 2}
+	if !reflect.DeepEqual(dense, expected) {
+		t.Errorf("expected: %v and got: %v", expected, dense)
+	}
+	_, err = CreateVector(SparseVector{Dimensions: 2, Indices: []uint32{2}, Values: []float32{1}})
+	if err == nil {
+		t.Error("expected error for sparse index out of range")
+	}
+}
@@ -0,0 +1,197 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// XmlType represent SYS.XMLTYPE value. small documents are returned inline by the
+// server while large documents are returned as CLOB locator and read on demand
+// with LOB streaming. binary XML (CSX) images are not decoded.
+// as input the document is sent as CLOB which is converted implicitly to XMLTYPE
+type XmlType struct {
+	Clob
+}
+
+// xmlReadChunk number of characters read by each round trip in XmlType reader
+const xmlReadChunk = 0x8000
+
+// CreateXmlType create XmlType from string, []byte or any value accepted by xml.Marshal
+func CreateXmlType(input interface{}) (*XmlType, error) {
+	ret := &XmlType{}
+	return ret, ret.SetValue(input)
+}
+
+func (doc *XmlType) SetValue(input interface{}) error {
+	switch value := input.(type) {
+	case nil:
+		doc.bValue = nil
+		return nil
+	case XmlType:
+		*doc = value
+		return nil
+	case *XmlType:
+		*doc = *value
+		return nil
+	case Clob, *Clob, String, *String, string, *string, sql.NullString, *sql.NullString:
+		return doc.Clob.SetValue(value)
+	case []byte:
+		if value == nil {
+			doc.bValue = nil
+			return nil
+		}
+		return doc.Clob.SetValue(string(value))
+	default:
+		temp, err := xml.Marshal(input)
+		if err != nil {
+			return err
+		}
+		return doc.Clob.SetValue(string(temp))
+	}
+}
+
+func (doc *XmlType) Scan(input interface{}) error {
+	return doc.SetValue(input)
+}
+
+// IsLob return true if the document is stored in a CLOB locator
+func (doc *XmlType) IsLob() bool {
+	return !doc.IsQuasi()
+}
+
+// Read load the document when it is stored in CLOB locator
+func (doc *XmlType) Read(ctx context.Context) error {
+	if !doc.IsLob() {
+		return nil
+	}
+	return doc.Clob.Read(ctx)
+}
+
+// Unmarshal decode the document into v using encoding/xml
+func (doc *XmlType) Unmarshal(v interface{}) error {
+	if doc.bValue == nil && doc.IsLob() {
+		reader := doc.NewReader(context.Background())
+		return xml.NewDecoder(reader).Decode(v)
+	}
+	value, err := doc.Value()
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+	return xml.Unmarshal([]byte(value.(string)), v)
+}
+
+// NewReader return reader for the document. CLOB documents are streamed
+// in chunks so large documents are not loaded into memory at once
+func (doc *XmlType) NewReader(ctx context.Context) io.Reader {
+	if doc.bValue != nil || !doc.IsLob() {
+		value, err := doc.Value()
+		if err != nil || value == nil {
+			return bytes.NewReader(nil)
+		}
+		return bytes.NewReader([]byte(value.(string)))
+	}
+	return &xmlReader{ctx: ctx, doc: doc}
+}
+
+func (doc *XmlType) CopyTo(dest driver.Value) error {
+	switch dst := dest.(type) {
+	case *XmlType:
+		*dst = *doc
+		return nil
+	case *Clob:
+		*dst = doc.Clob
+		return nil
+	case *string, *sql.NullString, *[]byte:
+		return doc.Clob.CopyTo(dest)
+	case nil:
+		return fmt.Errorf("cannot copy XmlType to variable of type %T", dest)
+	default:
+		return doc.Unmarshal(dest)
+	}
+}
+
+type xmlReader struct {
+	ctx    context.Context
+	doc    *XmlType
+	offset int64
+	buffer bytes.Buffer
+	carry  []byte
+	eof    bool
+}
+
+func (reader *xmlReader) Read(p []byte) (int, error) {
+	for reader.buffer.Len() == 0 && !reader.eof {
+		data, err := reader.doc.ReadBytesFromPos(reader.ctx, reader.offset, xmlReadChunk)
+		if err != nil {
+			return 0, err
+		}
+		if len(data) == 0 {
+			reader.eof = true
+			break
+		}
+		data = append(reader.carry, data...)
+		reader.carry = nil
+		conv := reader.doc.Conv
+		// don't split utf-16 surrogate pair between two chunks
+		if conv != nil && len(data) >= 2 {
+			switch conv.GetLangID() {
+			case 2000:
+				if data[len(data)-2]&0xFC == 0xD8 {
+					reader.carry = append(reader.carry, data[len(data)-2:]...)
+					data = data[:len(data)-2]
+				}
+			case 2002:
+				if data[len(data)-1]&0xFC == 0xD8 {
+					reader.carry = append(reader.carry, data[len(data)-2:]...)
+					data = data[:len(data)-2]
+				}
+			}
+		}
+		reader.offset += xmlReadChunk
+		if conv != nil {
+			reader.buffer.WriteString(conv.Decode(data))
+		} else {
+			reader.buffer.Write(data)
+		}
+	}
+	if reader.buffer.Len() == 0 {
+		return 0, io.EOF
+	}
+	return reader.buffer.Read(p)
+}
+
+type xmlTarget struct {
+	dest interface{}
+}
+
+// XmlTarget return sql.Scanner that unmarshal XmlType column into dest using encoding/xml
+//
+//	var order Order
+//	err := db.QueryRow("SELECT DOC FROM ORDERS").Scan(types.XmlTarget(&order))
+func XmlTarget(dest interface{}) sql.Scanner {
+	return &xmlTarget{dest: dest}
+}
+
+func (target *xmlTarget) Scan(input interface{}) error {
+	switch value := input.(type) {
+	case nil:
+		return nil
+	case string:
+		return xml.Unmarshal([]byte(value), target.dest)
+	case []byte:
+		return xml.Unmarshal(value, target.dest)
+	case *XmlType:
+		return value.Unmarshal(target.dest)
+	case XmlType:
+		return value.Unmarshal(target.dest)
+	}
+	return fmt.Errorf("cannot scan type %T into xml target", input)
+}
@@ -0,0 +1,138 @@
+package types
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/sijms/go-ora/v3/converters"
+)
+
+type xmlOrder struct {
+	XMLName xml.Name `xml:"order"`
+	ID      int      `xml:"id,attr"`
+	Items   []string `xml:"item"`
+}
+
+func TestXmlTypeValue(t *testing.T) {
+	input := xmlOrder{ID: 10, Items: []string{"book", "pen"}}
+	doc, err := CreateXmlType(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := `<order id="10"><item>book</item><item>pen</item></order>`
+	var str string
+	err = doc.CopyTo(&str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if str != text {
+		t.Errorf("expected: %s and got: %s", text, str)
+	}
+	var output xmlOrder
+	err = doc.CopyTo(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.ID != input.ID || len(output.Items) != 2 || output.Items[1] != "pen" {
+		t.Errorf("unexpected unmarshal result: %#v", output)
+	}
+	data, err := io.ReadAll(doc.NewReader(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != text {
+		t.Errorf("expected: %s and got: %s", text, string(data))
+	}
+}
+
+func TestXmlTarget(t *testing.T) {
+	var output xmlOrder
+	err := XmlTarget(&output).Scan(`<order id="5"><item>cup</item></order>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.ID != 5 || len(output.Items) != 1 || output.Items[0] != "cup" {
+		t.Errorf("unexpected unmarshal result: %#v", output)
+	}
+	// copy from XmlType as returned in output parameters
+	doc, err := CreateXmlType("<order id=\"7\"/>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var str string
+	err = Copy(&str, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if str != `<order id="7"/>` {
+		t.Errorf("unexpected copy result: %s", str)
+	}
+	var copied XmlType
+	err = Copy(&copied, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.IsLob() {
+		t.Error("inline document should not be reported as lob")
+	}
+}
+
+// lobStreamTest serve CLOB data encoded in AL16UTF16 and record read requests
+type lobStreamTest struct {
+	LobStreamer
+	units []uint16
+	reads [][2]int64
+}
+
+func (stream *lobStreamTest) StartContext(ctx context.Context) chan struct{} { return nil }
+func (stream *lobStreamTest) EndContext(done chan struct{})                  {}
+func (stream *lobStreamTest) GetLocator() Locator                            { return Locator{0, 0, 0, 0} }
+
+func (stream *lobStreamTest) Read(offset, count int64) ([]byte, error) {
+	stream.reads = append(stream.reads, [2]int64{offset, count})
+	var ret []byte
+	for i := offset; i < offset+count && i < int64(len(stream.units)); i++ {
+		ret = binary.BigEndian.AppendUint16(ret, stream.units[i])
+	}
+	return ret, nil
+}
+
+func TestXmlTypeReader(t *testing.T) {
+	// surrogate pair is split between the first and second chunk
+	text := "<a>" + strings.Repeat("x", xmlReadChunk-4) + "\U0001D11E</a>"
+	stream := &lobStreamTest{units: utf16.Encode([]rune(text))}
+	doc := &XmlType{}
+	doc.SetStreamer(stream)
+	doc.Conv = &converters.StringConverter{LangID: 2000}
+	if !doc.IsLob() {
+		t.Fatal("expected document stored in lob")
+	}
+	data, err := io.ReadAll(doc.NewReader(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != text {
+		t.Errorf("unexpected document of length %d: %q", len(data), data[len(data)-10:])
+	}
+	expected := [][2]int64{{0, xmlReadChunk}, {xmlReadChunk, xmlReadChunk}, {2 * xmlReadChunk, xmlReadChunk}}
+	if !reflect.DeepEqual(stream.reads, expected) {
+		t.Errorf("expected reads %v got: %v", expected, stream.reads)
+	}
+	var output struct {
+		XMLName xml.Name `xml:"a"`
+		Text    string   `xml:",chardata"`
+	}
+	stream.reads = nil
+	if err = doc.Unmarshal(&output); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(output.Text, "x\U0001D11E") || len(stream.reads) == 0 {
+		t.Errorf("unexpected unmarshal result of length %d with %d reads", len(output.Text), len(stream.reads))
+	}
+}